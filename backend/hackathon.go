@@ -5,7 +5,7 @@ import (
 	"flag"
 	"fmt"
 
-	"github.com/Z3Labs/Hackathon/backend/internal/clients/prom"
+	"github.com/Z3Labs/Hackathon/backend/internal/clients/diagnosis"
 	"github.com/Z3Labs/Hackathon/backend/internal/config"
 	"github.com/Z3Labs/Hackathon/backend/internal/handler"
 	"github.com/Z3Labs/Hackathon/backend/internal/logic/deployments"
@@ -34,6 +34,7 @@ func main() {
 
 	collector := metrics.NewDeploymentCollector(ctx.DeploymentModel)
 	prometheus.MustRegister(collector)
+	prometheus.MustRegister(metrics.NewDiagnosisUsageCollector(ctx.ReportModel, c.AI.Budget))
 	
 	server.AddRoute(rest.Route{
 		Method:  "GET",
@@ -45,15 +46,27 @@ func main() {
 	rollbackManager := deployments.NewRollbackManager(context.Background(), ctx)
 	
 	var alertMonitor *deployments.AlertMonitor
-	if c.AI.PrometheusURL != "" {
-		promClient := prom.NewVMClient(prom.NewDefaultConfig(c.AI.PrometheusURL))
+	if ctx.PromClient != nil {
+		promClient := ctx.PromClient
 		alertMonitor = deployments.NewAlertMonitor(ctx, promClient)
+		if err := alertMonitor.Restore(context.Background()); err != nil {
+			fmt.Printf("restore alert monitor error: %v\n", err)
+		}
 		deploymentManager.SetAlertMonitor(alertMonitor)
+		deploymentManager.SetHealthVerifier(deployments.NewHealthVerifier(promClient))
+		deploymentManager.SetCanaryAnalyzer(deployments.NewCanaryAnalyzer(promClient))
 		fmt.Println("Alert monitor initialized with Prometheus URL:", c.AI.PrometheusURL)
 	} else {
 		fmt.Println("Alert monitor disabled: no Prometheus URL configured")
 	}
 	
+	diagnosisQueue := diagnosis.NewQueue(ctx, c.AI)
+	diagnosisQueue.Start()
+	defer diagnosisQueue.Stop()
+
+	ctx.Webhooks.Start()
+	defer ctx.Webhooks.Stop()
+
 	deploymentCron := deployments.NewDeploymentCron(deploymentManager, rollbackManager, alertMonitor)
 	if err := deploymentCron.Start(); err != nil {
 		panic(fmt.Sprintf("failed to start deployment cron: %v", err))
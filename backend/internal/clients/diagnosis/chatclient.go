@@ -0,0 +1,455 @@
+package diagnosis
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/clients/prom"
+	"github.com/Z3Labs/Hackathon/backend/internal/config"
+)
+
+const (
+	ProviderAnthropic = "anthropic" // Anthropic Messages 接口及兼容服务
+	ProviderOpenAI    = "openai"    // OpenAI Chat Completions 接口及兼容服务
+	ProviderMCP       = "mcp"       // 旧版 docker 容器内 Python MCP 诊断脚本
+
+	anthropicVersion = "2023-06-01"
+	// maxToolResultLen 单次工具调用返回给模型的最大长度，超出部分截断
+	maxToolResultLen = 16000
+	// maxErrorBodyLen 接口报错时记录的响应体最大长度
+	maxErrorBodyLen = 2000
+)
+
+// chatMessage 与接口无关的对话消息，由 chatProvider 转换为各自的请求格式
+type chatMessage struct {
+	Role       string     // user、assistant 或 tool
+	Content    string     // 文本内容
+	ToolCalls  []toolCall // assistant 发起的工具调用
+	ToolCallId string     // tool 消息对应的工具调用ID
+	IsError    bool       // tool 消息是否为调用失败
+}
+
+type toolCall struct {
+	Id        string
+	Name      string
+	Arguments json.RawMessage
+}
+
+// chatResponse 一轮对话的结果，ToolCalls 为空时对话结束
+type chatResponse struct {
+	Text             string
+	ToolCalls        []toolCall
+	PromptTokens     int
+	CompletionTokens int
+}
+
+type chatProvider interface {
+	complete(ctx context.Context, messages []chatMessage, tools []Tool) (*chatResponse, error)
+}
+
+type chatClient struct {
+	provider  chatProvider
+	tools     []Tool
+	toolIndex map[string]Tool
+	maxRounds int
+	timeout   time.Duration
+	logger    logx.Logger
+}
+
+// NewAIClient 按配置的接口类型创建 AI 客户端，promClient 为空时不提供 Prometheus 工具
+func NewAIClient(cfg config.AIConfig, promClient prom.VMClient) AIClient {
+	if cfg.Provider == ProviderMCP {
+		return NewMCPClient(cfg)
+	}
+	return NewChatClient(cfg, promClient)
+}
+
+// NewChatClient 创建直接调用对话接口的 AI 客户端，在进程内执行 Prometheus 和 GitHub 工具调用，
+// API Key 和 GitHub Token 只放在请求头中
+func NewChatClient(cfg config.AIConfig, promClient prom.VMClient) AIClient {
+	httpClient := &http.Client{Timeout: time.Duration(cfg.Timeout) * time.Second}
+
+	var provider chatProvider
+	switch cfg.Provider {
+	case ProviderOpenAI:
+		provider = &openAIProvider{baseURL: cfg.BaseURL, apiKey: cfg.APIKey, model: cfg.Model, maxTokens: cfg.MaxTokens, httpClient: httpClient}
+	default:
+		provider = &anthropicProvider{baseURL: cfg.BaseURL, apiKey: cfg.APIKey, model: cfg.Model, maxTokens: cfg.MaxTokens, httpClient: httpClient}
+	}
+
+	tools := make([]Tool, 0)
+	if promClient != nil {
+		tools = append(tools, prometheusTools(promClient)...)
+	}
+	if cfg.GitHubToken != "" {
+		gh := &githubClient{baseURL: cfg.GitHubAPIURL, token: cfg.GitHubToken, httpClient: httpClient}
+		tools = append(tools, githubTools(gh, cfg.GitHubToolsets)...)
+	}
+
+	return newChatClient(provider, tools, cfg.MaxToolRounds, time.Duration(cfg.Timeout)*time.Second)
+}
+
+func newChatClient(provider chatProvider, tools []Tool, maxRounds int, timeout time.Duration) *chatClient {
+	toolIndex := make(map[string]Tool, len(tools))
+	for _, tool := range tools {
+		toolIndex[tool.Name] = tool
+	}
+	if maxRounds <= 0 {
+		maxRounds = 20
+	}
+	return &chatClient{
+		provider:  provider,
+		tools:     tools,
+		toolIndex: toolIndex,
+		maxRounds: maxRounds,
+		timeout:   timeout,
+		logger:    logx.WithContext(context.Background()),
+	}
+}
+
+// GenerateCompletion 多轮对话直到模型不再调用工具并输出通过校验的报告，返回规范化的 JSON 报告和累计用量
+func (c *chatClient) GenerateCompletion(ctx context.Context, prompt string) (string, Usage, error) {
+	// 多轮工具调用需要更长时间，与 MCP 模式保持一致
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout*2)
+		defer cancel()
+	}
+
+	messages := []chatMessage{{Role: "user", Content: prompt}}
+	var usage Usage
+	repairs := 0
+	for round := 1; round <= c.maxRounds; round++ {
+		resp, err := c.provider.complete(ctx, messages, c.tools)
+		if err != nil {
+			return "", usage, fmt.Errorf("第 %d 轮对话失败: %w", round, err)
+		}
+		usage.add(Usage{PromptTokens: resp.PromptTokens, CompletionTokens: resp.CompletionTokens, ToolCalls: len(resp.ToolCalls)})
+
+		if len(resp.ToolCalls) == 0 {
+			report, _, err := parseReport(resp.Text)
+			if err == nil {
+				return report, usage, nil
+			}
+			if repairs >= maxRepairRounds {
+				return "", usage, err
+			}
+			// 报告未通过校验，把错误反馈给模型要求修正
+			repairs++
+			c.logger.Infof("诊断报告校验失败，第 %d 次要求修正: %v", repairs, err)
+			messages = append(messages,
+				chatMessage{Role: "assistant", Content: resp.Text},
+				chatMessage{Role: "user", Content: repairPrompt(err)},
+			)
+			continue
+		}
+
+		messages = append(messages, chatMessage{Role: "assistant", Content: resp.Text, ToolCalls: resp.ToolCalls})
+		for _, call := range resp.ToolCalls {
+			result, err := c.callTool(ctx, call)
+			msg := chatMessage{Role: "tool", ToolCallId: call.Id, Content: result}
+			if err != nil {
+				msg.Content = fmt.Sprintf("工具调用失败: %v", err)
+				msg.IsError = true
+			}
+			messages = append(messages, msg)
+		}
+	}
+
+	return "", usage, fmt.Errorf("超过最大对话轮数 %d", c.maxRounds)
+}
+
+func (c *chatClient) callTool(ctx context.Context, call toolCall) (string, error) {
+	tool, ok := c.toolIndex[call.Name]
+	if !ok {
+		return "", fmt.Errorf("未找到工具 %s", call.Name)
+	}
+	c.logger.Infof("调用工具: %s, 参数: %s", call.Name, string(call.Arguments))
+
+	result, err := tool.Call(ctx, call.Arguments)
+	if err != nil {
+		c.logger.Errorf("工具 %s 调用失败: %v", call.Name, err)
+		return "", err
+	}
+	if len(result) > maxToolResultLen {
+		result = result[:maxToolResultLen] + "\n...（结果过长已截断）"
+	}
+	return result, nil
+}
+
+// extractReportJSON 从模型最终输出中取出 JSON 报告，兼容 markdown 代码块和前后附带的说明文字
+func extractReportJSON(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", fmt.Errorf("AI 返回空结果")
+	}
+	if json.Valid([]byte(text)) {
+		return text, nil
+	}
+
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start >= 0 && end > start && json.Valid([]byte(text[start:end+1])) {
+		return text[start : end+1], nil
+	}
+	return "", fmt.Errorf("AI 返回结果不是 JSON: %s", truncate(text, maxErrorBodyLen))
+}
+
+// postJSON 发送 JSON 请求并解析响应，非 2xx 时返回状态码和响应体
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body, out interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("status %d: %s", resp.StatusCode, truncate(string(respBody), maxErrorBodyLen))
+	}
+	return json.Unmarshal(respBody, out)
+}
+
+// joinURL 拼接接口地址，baseURL 已包含版本前缀时不重复添加
+func joinURL(baseURL, version, path string) string {
+	baseURL = strings.TrimRight(baseURL, "/")
+	if !strings.HasSuffix(baseURL, version) {
+		baseURL += version
+	}
+	return baseURL + path
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	// 不截断多字节字符
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "..."
+}
+
+// anthropicProvider Anthropic Messages 接口 POST /v1/messages
+type anthropicProvider struct {
+	baseURL    string
+	apiKey     string
+	model      string
+	maxTokens  int
+	httpClient *http.Client
+}
+
+type anthropicContent struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	Id        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	ToolUseId string          `json:"tool_use_id,omitempty"`
+	Content   string          `json:"content,omitempty"`
+	IsError   bool            `json:"is_error,omitempty"`
+}
+
+type anthropicMessage struct {
+	Role    string             `json:"role"`
+	Content []anthropicContent `json:"content"`
+}
+
+type anthropicTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"input_schema"`
+}
+
+type anthropicRequest struct {
+	Model     string             `json:"model"`
+	MaxTokens int                `json:"max_tokens"`
+	Messages  []anthropicMessage `json:"messages"`
+	Tools     []anthropicTool    `json:"tools,omitempty"`
+}
+
+type anthropicResponse struct {
+	Content    []anthropicContent `json:"content"`
+	StopReason string             `json:"stop_reason"`
+	Usage      struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+func (p *anthropicProvider) complete(ctx context.Context, messages []chatMessage, tools []Tool) (*chatResponse, error) {
+	req := anthropicRequest{Model: p.model, MaxTokens: p.maxTokens}
+	for _, tool := range tools {
+		req.Tools = append(req.Tools, anthropicTool{Name: tool.Name, Description: tool.Description, InputSchema: tool.InputSchema})
+	}
+	for _, msg := range messages {
+		switch msg.Role {
+		case "assistant":
+			content := make([]anthropicContent, 0, len(msg.ToolCalls)+1)
+			if msg.Content != "" {
+				content = append(content, anthropicContent{Type: "text", Text: msg.Content})
+			}
+			for _, call := range msg.ToolCalls {
+				content = append(content, anthropicContent{Type: "tool_use", Id: call.Id, Name: call.Name, Input: call.Arguments})
+			}
+			req.Messages = append(req.Messages, anthropicMessage{Role: "assistant", Content: content})
+		case "tool":
+			// 同一轮的多个工具结果合并到一条 user 消息中
+			result := anthropicContent{Type: "tool_result", ToolUseId: msg.ToolCallId, Content: msg.Content, IsError: msg.IsError}
+			if n := len(req.Messages); n > 0 && req.Messages[n-1].Role == "user" && req.Messages[n-1].Content[0].Type == "tool_result" {
+				req.Messages[n-1].Content = append(req.Messages[n-1].Content, result)
+			} else {
+				req.Messages = append(req.Messages, anthropicMessage{Role: "user", Content: []anthropicContent{result}})
+			}
+		default:
+			req.Messages = append(req.Messages, anthropicMessage{Role: "user", Content: []anthropicContent{{Type: "text", Text: msg.Content}}})
+		}
+	}
+
+	var resp anthropicResponse
+	headers := map[string]string{"x-api-key": p.apiKey, "anthropic-version": anthropicVersion}
+	if err := postJSON(ctx, p.httpClient, joinURL(p.baseURL, "/v1", "/messages"), headers, req, &resp); err != nil {
+		return nil, err
+	}
+
+	result := &chatResponse{PromptTokens: resp.Usage.InputTokens, CompletionTokens: resp.Usage.OutputTokens}
+	for _, block := range resp.Content {
+		switch block.Type {
+		case "text":
+			result.Text += block.Text
+		case "tool_use":
+			input := block.Input
+			if len(input) == 0 {
+				input = json.RawMessage("{}")
+			}
+			result.ToolCalls = append(result.ToolCalls, toolCall{Id: block.Id, Name: block.Name, Arguments: input})
+		}
+	}
+	return result, nil
+}
+
+// openAIProvider OpenAI Chat Completions 接口 POST /v1/chat/completions
+type openAIProvider struct {
+	baseURL    string
+	apiKey     string
+	model      string
+	maxTokens  int
+	httpClient *http.Client
+}
+
+type openAIToolCall struct {
+	Id       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type openAIMessage struct {
+	Role       string           `json:"role"`
+	Content    string           `json:"content"`
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallId string           `json:"tool_call_id,omitempty"`
+}
+
+type openAITool struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name        string                 `json:"name"`
+		Description string                 `json:"description"`
+		Parameters  map[string]interface{} `json:"parameters"`
+	} `json:"function"`
+}
+
+type openAIRequest struct {
+	Model     string          `json:"model"`
+	MaxTokens int             `json:"max_tokens,omitempty"`
+	Messages  []openAIMessage `json:"messages"`
+	Tools     []openAITool    `json:"tools,omitempty"`
+}
+
+type openAIResponse struct {
+	Choices []struct {
+		Message      openAIMessage `json:"message"`
+		FinishReason string        `json:"finish_reason"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+func (p *openAIProvider) complete(ctx context.Context, messages []chatMessage, tools []Tool) (*chatResponse, error) {
+	req := openAIRequest{Model: p.model, MaxTokens: p.maxTokens}
+	for _, tool := range tools {
+		var t openAITool
+		t.Type = "function"
+		t.Function.Name = tool.Name
+		t.Function.Description = tool.Description
+		t.Function.Parameters = tool.InputSchema
+		req.Tools = append(req.Tools, t)
+	}
+	for _, msg := range messages {
+		m := openAIMessage{Role: msg.Role, Content: msg.Content, ToolCallId: msg.ToolCallId}
+		for _, call := range msg.ToolCalls {
+			var c openAIToolCall
+			c.Id = call.Id
+			c.Type = "function"
+			c.Function.Name = call.Name
+			c.Function.Arguments = string(call.Arguments)
+			m.ToolCalls = append(m.ToolCalls, c)
+		}
+		req.Messages = append(req.Messages, m)
+	}
+
+	var resp openAIResponse
+	headers := map[string]string{"Authorization": "Bearer " + p.apiKey}
+	if err := postJSON(ctx, p.httpClient, joinURL(p.baseURL, "/v1", "/chat/completions"), headers, req, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("empty choices in response")
+	}
+
+	message := resp.Choices[0].Message
+	result := &chatResponse{Text: message.Content, PromptTokens: resp.Usage.PromptTokens, CompletionTokens: resp.Usage.CompletionTokens}
+	// 部分兼容服务只返回总数，按输入 token 计
+	if result.PromptTokens == 0 && result.CompletionTokens == 0 {
+		result.PromptTokens = resp.Usage.TotalTokens
+	}
+	for _, call := range message.ToolCalls {
+		args := json.RawMessage(call.Function.Arguments)
+		if !json.Valid(args) {
+			args = json.RawMessage("{}")
+		}
+		result.ToolCalls = append(result.ToolCalls, toolCall{Id: call.Id, Name: call.Function.Name, Arguments: args})
+	}
+	return result, nil
+}
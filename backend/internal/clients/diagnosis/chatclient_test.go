@@ -0,0 +1,194 @@
+package diagnosis
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/clients/prom"
+	"github.com/Z3Labs/Hackathon/backend/internal/config"
+)
+
+const testReport = `{"summary":"实例宕机","rootCause":"进程退出","impact":"host-1 不可用","remediation":["重启服务"],"suspects":[],"evidence":[{"promQL":"up == 0","observation":"host-1 为 0"}],"confidence":0.9}`
+
+// newTestVM 模拟 VictoriaMetrics 的即时查询接口
+func newTestVM(t *testing.T) (prom.VMClient, *[]string) {
+	queries := make([]string, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query().Get("query"))
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{"job":"node","instance":"host-1:9100"},"value":[1700000000,"0"]}]}}`))
+	}))
+	t.Cleanup(server.Close)
+	return prom.NewVMClient(prom.NewDefaultConfig(server.URL)), &queries
+}
+
+func TestChatClientAnthropic(t *testing.T) {
+	vm, queries := newTestVM(t)
+
+	round := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/messages", r.URL.Path)
+		assert.Equal(t, "test-key", r.Header.Get("x-api-key"))
+		assert.Equal(t, anthropicVersion, r.Header.Get("anthropic-version"))
+
+		var req anthropicRequest
+		body, _ := io.ReadAll(r.Body)
+		assert.NoError(t, json.Unmarshal(body, &req))
+		assert.Len(t, req.Tools, 4)
+
+		round++
+		if round == 1 {
+			w.Write([]byte(`{"content":[{"type":"text","text":"查询目标"},{"type":"tool_use","id":"call-1","name":"get_targets","input":{}},{"type":"tool_use","id":"call-2","name":"unknown","input":{}}],"usage":{"input_tokens":100,"output_tokens":20}}`))
+			return
+		}
+		// 工具结果合并在同一条 user 消息中返回
+		assert.Len(t, req.Messages, 3)
+		results := req.Messages[2].Content
+		assert.Len(t, results, 2)
+		assert.Equal(t, "call-1", results[0].ToolUseId)
+		assert.Contains(t, results[0].Content, `"up":false`)
+		assert.True(t, results[1].IsError)
+
+		reply, _ := json.Marshal(map[string]interface{}{
+			"content": []map[string]string{{"type": "text", "text": "```json\n" + testReport + "\n```"}},
+			"usage":   map[string]int{"input_tokens": 200, "output_tokens": 50},
+		})
+		w.Write(reply)
+	}))
+	defer server.Close()
+
+	client := NewChatClient(config.AIConfig{Provider: ProviderAnthropic, BaseURL: server.URL, APIKey: "test-key", Model: "test", Timeout: 10, MaxToolRounds: 5}, vm)
+	report, usage, err := client.GenerateCompletion(context.Background(), "诊断")
+	assert.NoError(t, err)
+	assert.Equal(t, testReport, report)
+	assert.Equal(t, Usage{PromptTokens: 300, CompletionTokens: 70, ToolCalls: 2}, usage)
+	assert.Equal(t, []string{"up"}, *queries)
+}
+
+func TestChatClientOpenAI(t *testing.T) {
+	vm, queries := newTestVM(t)
+
+	round := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+
+		var req openAIRequest
+		body, _ := io.ReadAll(r.Body)
+		assert.NoError(t, json.Unmarshal(body, &req))
+
+		round++
+		if round == 1 {
+			w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"","tool_calls":[{"id":"call-1","type":"function","function":{"name":"execute_query","arguments":"{\"query\":\"rate(x[5m])\"}"}}]}}],"usage":{"prompt_tokens":20,"completion_tokens":10,"total_tokens":30}}`))
+			return
+		}
+		assert.Len(t, req.Messages, 3)
+		assert.Equal(t, "tool", req.Messages[2].Role)
+		assert.Equal(t, "call-1", req.Messages[2].ToolCallId)
+
+		reply, _ := json.Marshal(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"role": "assistant", "content": "诊断完成：" + testReport}}},
+			"usage":   map[string]int{"total_tokens": 40},
+		})
+		w.Write(reply)
+	}))
+	defer server.Close()
+
+	// BaseURL 已带 /v1 时不重复拼接
+	client := NewChatClient(config.AIConfig{Provider: ProviderOpenAI, BaseURL: server.URL + "/v1", APIKey: "test-key", Model: "test", Timeout: 10, MaxToolRounds: 5}, vm)
+	report, usage, err := client.GenerateCompletion(context.Background(), "诊断")
+	assert.NoError(t, err)
+	assert.Equal(t, testReport, report)
+	// 只返回总数时按输入 token 计
+	assert.Equal(t, Usage{PromptTokens: 60, CompletionTokens: 10, ToolCalls: 1}, usage)
+	assert.Equal(t, []string{"rate(x[5m])"}, *queries)
+}
+
+func TestChatClientRepair(t *testing.T) {
+	replies := []string{`{"summary":"实例宕机"}`, testReport}
+	requests := make([]anthropicRequest, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req anthropicRequest
+		body, _ := io.ReadAll(r.Body)
+		assert.NoError(t, json.Unmarshal(body, &req))
+		requests = append(requests, req)
+
+		reply, _ := json.Marshal(map[string]interface{}{
+			"content": []map[string]string{{"type": "text", "text": replies[len(requests)-1]}},
+			"usage":   map[string]int{"input_tokens": 1, "output_tokens": 1},
+		})
+		w.Write(reply)
+	}))
+	defer server.Close()
+
+	client := NewChatClient(config.AIConfig{BaseURL: server.URL, Timeout: 10, MaxToolRounds: 5}, nil)
+	report, _, err := client.GenerateCompletion(context.Background(), "诊断")
+	assert.NoError(t, err)
+	assert.Equal(t, testReport, report)
+
+	// 第二轮请求带上校验错误要求修正
+	assert.Len(t, requests, 2)
+	assert.Len(t, requests[1].Messages, 3)
+	assert.Contains(t, requests[1].Messages[2].Content[0].Text, "rootCause 不能为空")
+}
+
+func TestChatClientMaxRounds(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"content":[{"type":"tool_use","id":"call","name":"get_targets","input":{}}],"usage":{"input_tokens":1,"output_tokens":1}}`))
+	}))
+	defer server.Close()
+
+	client := NewChatClient(config.AIConfig{BaseURL: server.URL, Timeout: 10, MaxToolRounds: 2}, nil)
+	_, usage, err := client.GenerateCompletion(context.Background(), "诊断")
+	assert.Error(t, err)
+	assert.Equal(t, 4, usage.TotalTokens())
+	assert.Equal(t, 2, usage.ToolCalls)
+}
+
+func TestGithubTools(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer gh-token", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/repos/Z3Labs/demo/releases/tags/v1.0.0":
+			w.Write([]byte(`{"tag_name":"v1.0.0","body":"#12"}`))
+		case "/repos/Z3Labs/demo/pulls/12":
+			if r.Header.Get("Accept") == "application/vnd.github.v3.diff" {
+				w.Write([]byte("diff --git a/main.go b/main.go"))
+				return
+			}
+			w.Write([]byte(`{"number":12}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tools := githubTools(&githubClient{baseURL: server.URL, token: "gh-token", httpClient: server.Client()}, "releases,pull_requests")
+	index := make(map[string]Tool)
+	for _, tool := range tools {
+		index[tool.Name] = tool
+	}
+	assert.Len(t, index, 3)
+
+	result, err := index["get_release_by_tag"].Call(context.Background(), json.RawMessage(`{"repo":"https://github.com/Z3Labs/demo.git","tag":"v1.0.0"}`))
+	assert.NoError(t, err)
+	assert.Contains(t, result, "v1.0.0")
+
+	result, err = index["pull_request_read"].Call(context.Background(), json.RawMessage(`{"owner":"Z3Labs","repo":"demo","method":"get_diff","pullNumber":"12"}`))
+	assert.NoError(t, err)
+	assert.Contains(t, result, "diff --git")
+
+	_, err = index["get_latest_release"].Call(context.Background(), json.RawMessage(`{"owner":"Z3Labs","repo":"demo"}`))
+	assert.Error(t, err)
+}
+
+func TestParseMCPUsage(t *testing.T) {
+	output := "[AI] 第 1 轮对话\n[USAGE] {\"input_tokens\": 100, \"output_tokens\": 10, \"tool_calls\": 1}\n[AI] 第 2 轮对话\n[USAGE] {\"input_tokens\": 250, \"output_tokens\": 40, \"tool_calls\": 1}\n\n#####\n{}"
+	assert.Equal(t, Usage{PromptTokens: 250, CompletionTokens: 40, ToolCalls: 1}, parseMCPUsage(output))
+	assert.Equal(t, Usage{}, parseMCPUsage("诊断失败"))
+}
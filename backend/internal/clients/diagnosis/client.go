@@ -7,6 +7,7 @@ import (
 
 	"github.com/zeromicro/go-zero/core/logx"
 
+	"github.com/Z3Labs/Hackathon/backend/internal/clients/prom"
 	"github.com/Z3Labs/Hackathon/backend/internal/config"
 	"github.com/Z3Labs/Hackathon/backend/internal/model"
 	"github.com/Z3Labs/Hackathon/backend/internal/svc"
@@ -17,6 +18,11 @@ type diagnosisClient struct {
 	ctx         context.Context
 	reportModel model.ReportModel
 	aiClient    AIClient
+	model       string
+	promClient  prom.VMClient
+	contexts    *contextCollector
+	prompts     *promptBuilder
+	prices      priceTable
 	logx.Logger
 }
 
@@ -25,8 +31,17 @@ func New(ctx context.Context, svcCtx *svc.ServiceContext, aiConfig config.AIConf
 	return &diagnosisClient{
 		ctx:         ctx,
 		reportModel: svcCtx.ReportModel,
-		aiClient:    NewMCPClient(aiConfig),
-		Logger:      logx.WithContext(ctx),
+		aiClient:    NewAIClient(aiConfig, svcCtx.PromClient),
+		model:       aiConfig.Model,
+		promClient:  svcCtx.PromClient,
+		contexts:    newContextCollector(svcCtx, aiConfig),
+		prompts: &promptBuilder{
+			deploymentModel:  svcCtx.DeploymentModel,
+			applicationModel: svcCtx.ApplicationModel,
+			templateModel:    svcCtx.PromptTemplateModel,
+		},
+		prices: newPriceTable(aiConfig.Prices),
+		Logger: logx.WithContext(ctx),
 	}
 }
 
@@ -41,21 +56,38 @@ func (c *diagnosisClient) GenerateReport(req *types.PostAlertCallbackReq) (strin
 		DeploymentId: deploymentId,
 		Content:      "",
 		Status:       model.ReportStatusGenerating,
+		Model:        c.model,
 		CreatedTime:  time.Now(),
 		UpdatedTime:  time.Now(),
 	}); err != nil {
 		return "", fmt.Errorf("创建报告记录失败: %w", err)
 	}
 
-	// 2. 构建提示词
-	prompt := buildPromptTemplate(req)
+	// 2. 收集补充上下文并构建提示词
+	alert := toDiagnosisAlert(req)
+	contexts := c.contexts.Collect(c.ctx, alert)
+	deployment, app := c.prompts.lookup(c.ctx, alert)
+	prompt, version, err := c.prompts.build(c.ctx, alert, deployment, app, "", contexts)
 
 	// 3. 调用 AI 接口（通过 MCP 查询指标并生成诊断报告）
-	report, err := c.reportModel.FindById(c.ctx, reportId)
-	if err != nil {
-		return "", fmt.Errorf("查询报告记录失败: %w", err)
+	report, findErr := c.reportModel.FindById(c.ctx, reportId)
+	if findErr != nil {
+		return "", fmt.Errorf("查询报告记录失败: %w", findErr)
+	}
+	report.PromptVersion = version
+	if app != nil {
+		report.AppId, report.AppName = app.Id, app.Name
+	}
+	var reportContent string
+	var usage Usage
+	if err == nil {
+		reportContent, usage, err = c.aiClient.GenerateCompletion(c.ctx, prompt)
+		c.prices.record(report, usage)
+	}
+	var result *model.DiagnosisResult
+	if err == nil {
+		reportContent, result, err = parseReport(reportContent)
 	}
-	reportContent, tokensUsed, err := c.aiClient.GenerateCompletion(c.ctx, prompt)
 	if err != nil {
 		// AI 调用失败，更新状态为失败
 		report.Status = model.ReportStatusFailed
@@ -68,7 +100,12 @@ func (c *diagnosisClient) GenerateReport(req *types.PostAlertCallbackReq) (strin
 	}
 
 	// 4. 更新报告内容和状态为完成
+	if c.promClient != nil {
+		captureEvidence(c.promClient, alert, result, time.Now())
+	}
 	report.Content = reportContent
+	report.Result = result
+	report.Contexts = contexts
 	report.Status = model.ReportStatusCompleted
 	report.UpdatedTime = time.Now()
 
@@ -76,7 +113,7 @@ func (c *diagnosisClient) GenerateReport(req *types.PostAlertCallbackReq) (strin
 		return "", fmt.Errorf("更新报告失败: %w", err)
 	}
 
-	c.Infof("部署 %s 诊断报告生成成功，Token 消耗: %d", deploymentId, tokensUsed)
+	c.Infof("部署 %s 诊断报告生成成功，Token 消耗: %d，工具调用: %d 次", deploymentId, usage.TotalTokens(), usage.ToolCalls)
 
 	return reportContent, nil
 }
@@ -15,7 +15,7 @@ import (
 	"github.com/Z3Labs/Hackathon/backend/internal/types"
 )
 
-// TestBuildPromptTemplate 测试 prompt 构建
+// TestBuildPromptTemplate 测试内置 prompt 渲染
 func TestBuildPromptTemplate(t *testing.T) {
 	req := &types.PostAlertCallbackReq{
 		Key:        "test-alert-001",
@@ -37,11 +37,14 @@ func TestBuildPromptTemplate(t *testing.T) {
 		},
 	}
 
-	prompt := buildPromptTemplate(req)
+	prompt, err := RenderPrompt(builtinPrompt, NewPromptData(toDiagnosisAlert(req), nil, nil, nil))
+	if err != nil {
+		t.Fatalf("RenderPrompt() failed: %v", err)
+	}
 
 	// 验证 prompt 不为空
 	if prompt == "" {
-		t.Error("buildPromptTemplate() returned empty prompt")
+		t.Error("RenderPrompt() returned empty prompt")
 	}
 
 	// 验证 prompt 包含关键信息
@@ -146,7 +149,10 @@ func TestMCPClient_GenerateCompletion(t *testing.T) {
 	}
 
 	// 4. 构建 prompt
-	prompt := buildPromptTemplate(req)
+	prompt, err := RenderPrompt(builtinPrompt, NewPromptData(toDiagnosisAlert(req), nil, nil, nil))
+	if err != nil {
+		t.Fatalf("RenderPrompt() failed: %v", err)
+	}
 	//t.Logf("Generated prompt:\n%s\n", prompt)
 
 	// 5. 调用 GenerateCompletion
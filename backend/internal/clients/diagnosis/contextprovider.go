@@ -0,0 +1,215 @@
+package diagnosis
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/config"
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+	"github.com/Z3Labs/Hackathon/backend/internal/svc"
+)
+
+const (
+	// contextTimeout 单个上下文收集的超时时间
+	contextTimeout = 30 * time.Second
+	// maxContextLen 单个上下文写入提示词的最大长度
+	maxContextLen = 8000
+	// maxContextNodes 需要登录机器收集的上下文最多涉及的机器数
+	maxContextNodes = 3
+	// defaultContextLogLines 日志类上下文默认读取的行数
+	defaultContextLogLines = 100
+)
+
+// ContextTarget 收集上下文时可用的发布信息
+type ContextTarget struct {
+	Alert      *model.DiagnosisAlert
+	Deployment *model.Deployment
+	App        *model.Application
+	Nodes      []model.NodeDeployment // 告警关联的机器，告警未带机器标签时为所有已开始发布的机器
+	StartsAt   time.Time              // 告警开始时间
+	LogLines   int                    // 日志类上下文读取的行数
+	Unit       string                 // systemd 单元名
+}
+
+// ContextProvider 在调用模型前收集一类额外的诊断上下文
+type ContextProvider interface {
+	Type() model.DiagnosisContextType
+	Collect(ctx context.Context, target *ContextTarget) (string, error)
+}
+
+// contextTitles 上下文在提示词中的标题
+var contextTitles = map[model.DiagnosisContextType]string{
+	model.DiagnosisContextDeployLog:          "发布日志",
+	model.DiagnosisContextJournal:            "systemd 日志",
+	model.DiagnosisContextVersionDiff:        "版本代码差异",
+	model.DiagnosisContextMachine:            "机器信息",
+	model.DiagnosisContextRelatedDeployments: "上下游应用近期发布",
+	model.DiagnosisContextSimilarIncidents:   "历史相似故障",
+}
+
+// ValidContextType 是否为应用可启用的上下文类型，历史相似故障默认附带，不需要启用
+func ValidContextType(t model.DiagnosisContextType) bool {
+	_, ok := contextTitles[t]
+	return ok && t != model.DiagnosisContextSimilarIncidents
+}
+
+// contextCollector 按应用配置依次调用启用的 ContextProvider
+type contextCollector struct {
+	deploymentModel  model.DeploymentModel
+	applicationModel model.ApplicationModel
+	providers        map[model.DiagnosisContextType]ContextProvider
+	logx.Logger
+}
+
+func newContextCollector(svcCtx *svc.ServiceContext, cfg config.AIConfig) *contextCollector {
+	var github *githubClient
+	if cfg.GitHubToken != "" {
+		github = &githubClient{baseURL: cfg.GitHubAPIURL, token: cfg.GitHubToken, httpClient: &http.Client{Timeout: contextTimeout}}
+	}
+	run := sshRunner
+	return newContextCollectorWithProviders(svcCtx.DeploymentModel, svcCtx.ApplicationModel,
+		&deployLogProvider{},
+		&journalProvider{machineModel: svcCtx.MachineModel, run: run},
+		&versionDiffProvider{github: github},
+		&machineProvider{machineModel: svcCtx.MachineModel, run: run},
+		&relatedDeploymentsProvider{deploymentModel: svcCtx.DeploymentModel},
+	)
+}
+
+func newContextCollectorWithProviders(deploymentModel model.DeploymentModel, applicationModel model.ApplicationModel,
+	providers ...ContextProvider) *contextCollector {
+
+	c := &contextCollector{
+		deploymentModel:  deploymentModel,
+		applicationModel: applicationModel,
+		providers:        make(map[model.DiagnosisContextType]ContextProvider, len(providers)),
+		Logger:           logx.WithContext(context.Background()),
+	}
+	for _, provider := range providers {
+		c.providers[provider.Type()] = provider
+	}
+	return c
+}
+
+// Collect 收集告警所属应用启用的上下文，单个上下文失败只记录错误。
+// 始终返回非 nil 的切片，表示已收集过，重试时不再重复收集
+func (c *contextCollector) Collect(ctx context.Context, alert *model.DiagnosisAlert) []model.DiagnosisContext {
+	contexts := make([]model.DiagnosisContext, 0)
+	target, err := c.resolveTarget(ctx, alert)
+	if err != nil {
+		c.Errorf("[DiagnosisContext] resolveTarget error:%v", err)
+		return contexts
+	}
+	if target == nil {
+		return contexts
+	}
+
+	for _, t := range target.App.DiagnosisContext.Providers {
+		provider, ok := c.providers[t]
+		if !ok {
+			continue
+		}
+		item := model.DiagnosisContext{Type: t}
+		providerCtx, cancel := context.WithTimeout(ctx, contextTimeout)
+		content, err := provider.Collect(providerCtx, target)
+		cancel()
+		if err != nil {
+			c.Infof("部署 %s 收集上下文 %s 失败: %v", target.Deployment.Id, t, err)
+			item.Error = err.Error()
+		}
+		item.Content = truncate(strings.TrimSpace(content), maxContextLen)
+		contexts = append(contexts, item)
+	}
+	return contexts
+}
+
+// resolveTarget 查询告警关联的发布单和应用，应用未启用上下文收集时返回 nil
+func (c *contextCollector) resolveTarget(ctx context.Context, alert *model.DiagnosisAlert) (*ContextTarget, error) {
+	deploymentId := alert.Labels["deploymentId"]
+	if deploymentId == "" {
+		return nil, nil
+	}
+	deployment, err := c.deploymentModel.FindById(ctx, deploymentId)
+	if err != nil {
+		return nil, fmt.Errorf("查询发布单 %s 失败: %w", deploymentId, err)
+	}
+	app, err := c.applicationModel.FindById(ctx, deployment.AppId)
+	if err != nil {
+		return nil, fmt.Errorf("查询应用 %s 失败: %w", deployment.AppId, err)
+	}
+	policy := app.DiagnosisContext
+	if policy == nil || len(policy.Providers) == 0 {
+		return nil, nil
+	}
+
+	target := &ContextTarget{
+		Alert:      alert,
+		Deployment: deployment,
+		App:        app,
+		Nodes:      alertNodes(alert, deployment),
+		StartsAt:   parseRFC3339(alert.StartsAt),
+		LogLines:   policy.LogLines,
+		Unit:       policy.SystemdUnit,
+	}
+	if target.StartsAt.IsZero() {
+		target.StartsAt = time.Now()
+	}
+	if target.LogLines <= 0 {
+		target.LogLines = defaultContextLogLines
+	}
+	if target.Unit == "" {
+		target.Unit = app.Name + ".service"
+	}
+	return target, nil
+}
+
+// alertNodes 告警 hostname 标签对应的机器（多台时以逗号分隔），没有匹配时返回所有已开始发布的机器
+func alertNodes(alert *model.DiagnosisAlert, deployment *model.Deployment) []model.NodeDeployment {
+	hosts := make(map[string]bool)
+	for _, host := range strings.Split(alert.Labels["hostname"], ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			hosts[host] = true
+		}
+	}
+
+	matched := make([]model.NodeDeployment, 0)
+	started := make([]model.NodeDeployment, 0)
+	for _, node := range deployment.NodeDeployments {
+		if hosts[node.Name] || (node.Ip != "" && hosts[node.Ip]) {
+			matched = append(matched, node)
+		}
+		if node.NodeDeployStatus != model.NodeDeploymentStatusPending {
+			started = append(started, node)
+		}
+	}
+	if len(matched) > 0 {
+		return matched
+	}
+	return started
+}
+
+// formatContexts 提示词中的补充上下文
+func formatContexts(contexts []model.DiagnosisContext) string {
+	if len(contexts) == 0 {
+		return "（无）"
+	}
+
+	sections := make([]string, 0, len(contexts))
+	for _, item := range contexts {
+		title := contextTitles[item.Type]
+		if title == "" {
+			title = string(item.Type)
+		}
+		content := item.Content
+		if item.Error != "" {
+			content = strings.TrimSpace(content + "\n（收集失败: " + item.Error + "）")
+		}
+		sections = append(sections, fmt.Sprintf("### %s\n%s", title, content))
+	}
+	return strings.Join(sections, "\n\n")
+}
@@ -0,0 +1,143 @@
+package diagnosis
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+)
+
+type fakeDeploymentModel struct {
+	model.DeploymentModel
+	deployment *model.Deployment
+	related    []*model.Deployment
+	cond       *model.DeploymentCond
+}
+
+func (m *fakeDeploymentModel) FindById(ctx context.Context, id string) (*model.Deployment, error) {
+	return m.deployment, nil
+}
+
+func (m *fakeDeploymentModel) Search(ctx context.Context, cond *model.DeploymentCond) ([]*model.Deployment, error) {
+	m.cond = cond
+	return m.related, nil
+}
+
+type fakeApplicationModel struct {
+	model.ApplicationModel
+	app *model.Application
+}
+
+func (m *fakeApplicationModel) FindById(ctx context.Context, id string) (*model.Application, error) {
+	return m.app, nil
+}
+
+type fakeMachineModel struct {
+	model.MachineModel
+}
+
+func (m *fakeMachineModel) FindById(ctx context.Context, id string) (*model.Machine, error) {
+	if id == "" {
+		return nil, errors.New("not found")
+	}
+	return &model.Machine{Id: id, Ip: "10.0.0." + id}, nil
+}
+
+func (m *fakeMachineModel) Search(ctx context.Context, cond *model.MachineCond) ([]*model.Machine, error) {
+	return nil, nil
+}
+
+func TestContextCollector(t *testing.T) {
+	github := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/Z3Labs/demo/compare/v1.0.0...v1.1.0", r.URL.Path)
+		w.Write([]byte(`{"total_commits":1,"commits":[{"sha":"abcdef123456","commit":{"message":"fix: 关闭连接\n\n详细说明"}}],"files":[{"filename":"main.go","status":"modified","additions":3,"deletions":1,"patch":"@@ -1 +1 @@"}]}`))
+	}))
+	defer github.Close()
+
+	deployments := &fakeDeploymentModel{
+		deployment: &model.Deployment{
+			Id:             "d1",
+			AppId:          "a1",
+			PackageVersion: "v1.1.0",
+			NodeDeployments: []model.NodeDeployment{
+				{Id: "1", Name: "host-1", Ip: "10.0.0.1", NodeDeployStatus: model.NodeDeploymentStatusFailed, ReleaseLog: "line1\nline2\nline3\n", PrevVersion: "v1.0.0"},
+				{Id: "2", Name: "host-2", Ip: "10.0.0.2", NodeDeployStatus: model.NodeDeploymentStatusSuccess},
+			},
+		},
+		related: []*model.Deployment{{AppId: "up", AppName: "gateway", PackageVersion: "v2", Status: model.DeploymentStatusSuccess, CreatedTime: 1736949000}},
+	}
+	apps := &fakeApplicationModel{app: &model.Application{
+		Name:           "demo",
+		Repo:           "git@github.com:Z3Labs/demo.git",
+		UpStreamAppIds: []string{"up"},
+		DiagnosisContext: &model.DiagnosisContextPolicy{
+			LogLines: 2,
+			Providers: []model.DiagnosisContextType{
+				model.DiagnosisContextDeployLog,
+				model.DiagnosisContextJournal,
+				model.DiagnosisContextVersionDiff,
+				model.DiagnosisContextMachine,
+				model.DiagnosisContextRelatedDeployments,
+			},
+		},
+	}}
+
+	commands := make([]string, 0)
+	run := func(machine *model.Machine, command string) (string, error) {
+		commands = append(commands, machine.Ip+" "+command)
+		if strings.HasPrefix(command, "uptime") {
+			return "", errors.New("connection refused")
+		}
+		return "started demo", nil
+	}
+	collector := newContextCollectorWithProviders(deployments, apps,
+		&deployLogProvider{},
+		&journalProvider{machineModel: &fakeMachineModel{}, run: run},
+		&versionDiffProvider{github: &githubClient{baseURL: github.URL, token: "gh-token", httpClient: github.Client()}},
+		&machineProvider{machineModel: &fakeMachineModel{}, run: run},
+		&relatedDeploymentsProvider{deploymentModel: deployments},
+	)
+
+	alert := &model.DiagnosisAlert{StartsAt: "2025-01-15T14:00:00Z", Labels: map[string]string{"deploymentId": "d1", "hostname": "host-1"}}
+	contexts := collector.Collect(context.Background(), alert)
+	assert.Len(t, contexts, 5)
+
+	// 只取告警机器的发布日志末尾
+	assert.Equal(t, model.DiagnosisContextDeployLog, contexts[0].Type)
+	assert.Contains(t, contexts[0].Content, "line2\nline3")
+	assert.NotContains(t, contexts[0].Content, "line1")
+	assert.NotContains(t, contexts[0].Content, "host-2")
+
+	assert.Empty(t, contexts[1].Error)
+	assert.Contains(t, contexts[1].Content, "started demo")
+	since := time.Date(2025, 1, 15, 13, 30, 0, 0, time.UTC).Unix()
+	assert.Contains(t, commands[0], "journalctl -u demo.service --no-pager -n 2 --since @"+strconv.FormatInt(since, 10))
+
+	assert.Contains(t, contexts[2].Content, "abcdef1 fix: 关闭连接")
+	assert.Contains(t, contexts[2].Content, "main.go (modified +3 -1)")
+
+	// 所有机器都执行失败时记录错误
+	assert.NotEmpty(t, contexts[3].Error)
+	assert.Contains(t, contexts[3].Content, "connection refused")
+
+	assert.Contains(t, contexts[4].Content, "[上游] gateway 版本 v2")
+	assert.Equal(t, []string{"up"}, deployments.cond.AppIds)
+
+	// 未启用上下文的应用不收集
+	apps.app.DiagnosisContext = nil
+	contexts = collector.Collect(context.Background(), alert)
+	assert.NotNil(t, contexts)
+	assert.Empty(t, contexts)
+
+	// 不合法的 systemd 单元名不会拼接到命令中
+	_, err := (&journalProvider{machineModel: &fakeMachineModel{}, run: run}).Collect(context.Background(), &ContextTarget{Unit: "demo; rm -rf /"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,290 @@
+package diagnosis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+	"github.com/Z3Labs/Hackathon/backend/internal/utils"
+)
+
+const (
+	// sshCommandTimeout 登录机器执行命令的超时时间
+	sshCommandTimeout = 15 * time.Second
+	// relatedDeploymentsLookback 上下游应用发布记录的回看时间
+	relatedDeploymentsLookback = 24 * time.Hour
+	maxRelatedDeployments      = 10
+	// maxDiffPatchLen 版本差异中每个文件保留的 patch 长度
+	maxDiffPatchLen = 1500
+)
+
+// systemdUnitRegex systemd 单元名只允许安全字符，避免拼接到远程命令中
+var systemdUnitRegex = regexp.MustCompile(`^[A-Za-z0-9@._:-]+$`)
+
+// ValidSystemdUnit systemd 单元名是否合法
+func ValidSystemdUnit(unit string) bool {
+	return systemdUnitRegex.MatchString(unit)
+}
+
+// remoteRunner 在机器上执行命令，测试时替换
+type remoteRunner func(machine *model.Machine, command string) (string, error)
+
+func sshRunner(machine *model.Machine, command string) (string, error) {
+	return utils.RunSSHCommand(machine.Ip, machine.Port, machine.Username, machine.Password, command, sshCommandTimeout)
+}
+
+// findMachine 发布节点对应的机器记录，优先按机器 ID 查询，查不到时按 IP 查询
+func findMachine(ctx context.Context, machineModel model.MachineModel, node model.NodeDeployment) (*model.Machine, error) {
+	machine, err := machineModel.FindById(ctx, node.Id)
+	if err == nil {
+		return machine, nil
+	}
+	if node.Ip == "" {
+		return nil, err
+	}
+	machines, searchErr := machineModel.Search(ctx, &model.MachineCond{Ip: node.Ip})
+	if searchErr != nil || len(machines) == 0 {
+		return nil, fmt.Errorf("未找到机器 %s: %v", node.Name, err)
+	}
+	return machines[0], nil
+}
+
+// runOnNodes 在告警关联的机器上执行命令，单台失败时记录错误，全部失败时返回错误
+func runOnNodes(ctx context.Context, machineModel model.MachineModel, run remoteRunner, nodes []model.NodeDeployment,
+	command func(machine *model.Machine) string) (string, error) {
+
+	if len(nodes) == 0 {
+		return "", fmt.Errorf("没有关联的发布机器")
+	}
+	if len(nodes) > maxContextNodes {
+		nodes = nodes[:maxContextNodes]
+	}
+
+	sections := make([]string, 0, len(nodes))
+	failed := 0
+	for _, node := range nodes {
+		if ctx.Err() != nil {
+			return strings.Join(sections, "\n\n"), ctx.Err()
+		}
+		header := fmt.Sprintf("#### %s (%s)", node.Name, node.Ip)
+		machine, err := findMachine(ctx, machineModel, node)
+		if err != nil {
+			failed++
+			sections = append(sections, header+"\n查询机器失败: "+err.Error())
+			continue
+		}
+		output, err := run(machine, command(machine))
+		if err != nil {
+			failed++
+			sections = append(sections, strings.TrimSpace(header+"\n"+output+"\n执行失败: "+err.Error()))
+			continue
+		}
+		sections = append(sections, header+"\n"+strings.TrimSpace(output))
+	}
+
+	content := strings.Join(sections, "\n\n")
+	if failed == len(nodes) {
+		return content, fmt.Errorf("所有机器均执行失败")
+	}
+	return content, nil
+}
+
+// tailLines 保留最后 n 行
+func tailLines(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// deployLogProvider 告警关联机器的发布日志末尾
+type deployLogProvider struct{}
+
+func (p *deployLogProvider) Type() model.DiagnosisContextType {
+	return model.DiagnosisContextDeployLog
+}
+
+func (p *deployLogProvider) Collect(_ context.Context, target *ContextTarget) (string, error) {
+	if len(target.Nodes) == 0 {
+		return "", fmt.Errorf("没有关联的发布机器")
+	}
+
+	sections := make([]string, 0, len(target.Nodes))
+	for _, node := range target.Nodes {
+		log := tailLines(node.ReleaseLog, target.LogLines)
+		if strings.TrimSpace(log) == "" {
+			log = "（无发布日志）"
+		}
+		sections = append(sections, fmt.Sprintf("#### %s (%s) 状态: %s\n%s", node.Name, node.Ip, node.NodeDeployStatus, log))
+	}
+	return strings.Join(sections, "\n\n"), nil
+}
+
+// journalProvider 登录机器读取应用 systemd 单元在告警前后的日志
+type journalProvider struct {
+	machineModel model.MachineModel
+	run          remoteRunner
+}
+
+func (p *journalProvider) Type() model.DiagnosisContextType {
+	return model.DiagnosisContextJournal
+}
+
+func (p *journalProvider) Collect(ctx context.Context, target *ContextTarget) (string, error) {
+	if !ValidSystemdUnit(target.Unit) {
+		return "", fmt.Errorf("systemd 单元名不合法: %s", target.Unit)
+	}
+	since := target.StartsAt.Add(-snapshotLookback).Unix()
+	command := fmt.Sprintf("journalctl -u %s --no-pager -n %d --since @%d", target.Unit, target.LogLines, since)
+	return runOnNodes(ctx, p.machineModel, p.run, target.Nodes, func(*model.Machine) string {
+		return command
+	})
+}
+
+// machineProvider 机器记录和负载、内存、磁盘等运行状态
+type machineProvider struct {
+	machineModel model.MachineModel
+	run          remoteRunner
+}
+
+func (p *machineProvider) Type() model.DiagnosisContextType {
+	return model.DiagnosisContextMachine
+}
+
+func (p *machineProvider) Collect(ctx context.Context, target *ContextTarget) (string, error) {
+	return runOnNodes(ctx, p.machineModel, p.run, target.Nodes, func(*model.Machine) string {
+		return "uptime; nproc; free -m; df -h"
+	})
+}
+
+// versionDiffProvider 上一个版本与发布版本之间的提交和文件变更
+type versionDiffProvider struct {
+	github *githubClient
+}
+
+type githubCompare struct {
+	TotalCommits int `json:"total_commits"`
+	Commits      []struct {
+		Sha    string `json:"sha"`
+		Commit struct {
+			Message string `json:"message"`
+		} `json:"commit"`
+	} `json:"commits"`
+	Files []struct {
+		Filename  string `json:"filename"`
+		Status    string `json:"status"`
+		Additions int    `json:"additions"`
+		Deletions int    `json:"deletions"`
+		Patch     string `json:"patch"`
+	} `json:"files"`
+}
+
+func (p *versionDiffProvider) Type() model.DiagnosisContextType {
+	return model.DiagnosisContextVersionDiff
+}
+
+func (p *versionDiffProvider) Collect(ctx context.Context, target *ContextTarget) (string, error) {
+	if p.github == nil {
+		return "", fmt.Errorf("未配置 GitHubToken")
+	}
+	owner, repo := splitRepo("", target.App.Repo)
+	if owner == "" || repo == "" {
+		return "", fmt.Errorf("应用未配置代码仓库")
+	}
+	base := target.App.PrevVersion
+	for _, node := range target.Nodes {
+		if node.PrevVersion != "" {
+			base = node.PrevVersion
+			break
+		}
+	}
+	head := target.Deployment.PackageVersion
+	if base == "" || head == "" || base == head {
+		return "", fmt.Errorf("没有可对比的上一个版本")
+	}
+
+	body, err := p.github.get(ctx, fmt.Sprintf("/repos/%s/%s/compare/%s...%s", owner, repo, base, head), "")
+	if err != nil {
+		return "", err
+	}
+	var compare githubCompare
+	if err := json.Unmarshal([]byte(body), &compare); err != nil {
+		return "", fmt.Errorf("解析版本对比结果失败: %w", err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s...%s 共 %d 个提交，%d 个文件变更\n\n", base, head, compare.TotalCommits, len(compare.Files))
+	b.WriteString("提交:\n")
+	for _, commit := range compare.Commits {
+		sha := commit.Sha
+		if len(sha) > 7 {
+			sha = sha[:7]
+		}
+		message, _, _ := strings.Cut(commit.Commit.Message, "\n")
+		fmt.Fprintf(&b, "- %s %s\n", sha, message)
+	}
+	b.WriteString("\n文件:\n")
+	for _, file := range compare.Files {
+		fmt.Fprintf(&b, "- %s (%s +%d -%d)\n", file.Filename, file.Status, file.Additions, file.Deletions)
+		if file.Patch != "" {
+			fmt.Fprintf(&b, "```diff\n%s\n```\n", truncate(file.Patch, maxDiffPatchLen))
+		}
+	}
+	return b.String(), nil
+}
+
+// relatedDeploymentsProvider 上下游应用最近的发布记录
+type relatedDeploymentsProvider struct {
+	deploymentModel model.DeploymentModel
+}
+
+func (p *relatedDeploymentsProvider) Type() model.DiagnosisContextType {
+	return model.DiagnosisContextRelatedDeployments
+}
+
+func (p *relatedDeploymentsProvider) Collect(ctx context.Context, target *ContextTarget) (string, error) {
+	relations := make(map[string]string)
+	for _, id := range target.App.UpStreamAppIds {
+		relations[id] = "上游"
+	}
+	for _, id := range target.App.DownstreamAppIds {
+		relations[id] = "下游"
+	}
+	if len(relations) == 0 {
+		return "应用未配置上下游依赖", nil
+	}
+	ids := make([]string, 0, len(relations))
+	for id := range relations {
+		ids = append(ids, id)
+	}
+
+	deployments, err := p.deploymentModel.Search(ctx, &model.DeploymentCond{
+		AppIds:       ids,
+		CreatedAfter: target.StartsAt.Add(-relatedDeploymentsLookback).Unix(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("查询上下游发布记录失败: %w", err)
+	}
+	if len(deployments) == 0 {
+		return fmt.Sprintf("上下游应用最近 %s 内没有发布", relatedDeploymentsLookback), nil
+	}
+
+	sort.Slice(deployments, func(i, j int) bool {
+		return deployments[i].CreatedTime > deployments[j].CreatedTime
+	})
+	if len(deployments) > maxRelatedDeployments {
+		deployments = deployments[:maxRelatedDeployments]
+	}
+	lines := make([]string, 0, len(deployments))
+	for _, d := range deployments {
+		lines = append(lines, fmt.Sprintf("- [%s] %s 版本 %s 状态 %s 创建于 %s",
+			relations[d.AppId], d.AppName, d.PackageVersion, d.Status, time.Unix(d.CreatedTime, 0).Format(time.RFC3339)))
+	}
+	return strings.Join(lines, "\n"), nil
+}
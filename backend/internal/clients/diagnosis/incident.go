@@ -0,0 +1,304 @@
+package diagnosis
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+)
+
+const (
+	// maxIndexedReports 检索相似故障时索引的最近报告数，每次检索时从报告记录重建索引
+	maxIndexedReports = 2000
+	// maxIncidentTextLen 相似故障写入提示词时根因的最大长度
+	maxIncidentTextLen = 600
+
+	bm25K1 = 1.2
+	bm25B  = 0.75
+
+	// 应用和告警名称匹配比描述文本更能说明是同类故障，按权重重复计入词频
+	appTermWeight       = 3
+	alertnameTermWeight = 3
+)
+
+// ignoredIncidentLabels 每次发布都不同的标签，不参与检索
+var ignoredIncidentLabels = map[string]bool{
+	"deploymentId": true,
+	"appId":        true,
+	"alertname":    true,
+	"appName":      true,
+}
+
+// IncidentQuery 相似故障检索条件，至少需要一个条件
+type IncidentQuery struct {
+	Text                string            // 自由文本，如告警描述、根因关键词
+	AppName             string            // 应用名称
+	Alertname           string            // 告警名称
+	Labels              map[string]string // 告警标签
+	ExcludeDeploymentId string            // 排除该发布单的报告，避免重新生成时检索到自己
+	Limit               int               // 返回的最大数量
+}
+
+// SearchIncidents 在最近的诊断报告中按 BM25 检索相似故障，索引应用、告警名称、标签、问题概述和根因，
+// 按得分从高到低返回，同一发布单只保留得分最高的报告
+func SearchIncidents(ctx context.Context, reportModel model.ReportModel, query IncidentQuery) ([]model.SimilarIncident, error) {
+	incidents := make([]model.SimilarIncident, 0)
+	if query.Limit <= 0 {
+		return incidents, nil
+	}
+	terms := queryTerms(query)
+	if len(terms) == 0 {
+		return incidents, nil
+	}
+
+	reports, err := reportModel.FindIncidents(ctx, maxIndexedReports)
+	if err != nil {
+		return nil, err
+	}
+	index := newIncidentIndex(reports)
+
+	seen := make(map[string]bool)
+	for _, hit := range index.search(terms) {
+		report := hit.report
+		if report.DeploymentId != "" {
+			if report.DeploymentId == query.ExcludeDeploymentId || seen[report.DeploymentId] {
+				continue
+			}
+			seen[report.DeploymentId] = true
+		}
+		incidents = append(incidents, toSimilarIncident(report, hit.score))
+		if len(incidents) >= query.Limit {
+			break
+		}
+	}
+	return incidents, nil
+}
+
+// incidentQueryFor 按报告的告警组装检索条件
+func incidentQueryFor(report *model.Report, limit int) IncidentQuery {
+	alert := report.Alert
+	text := alert.Desc
+	if desc := alert.Annotations["description"]; desc != "" {
+		text = desc
+	}
+	return IncidentQuery{
+		Text:                text,
+		AppName:             report.AppName,
+		Alertname:           alert.Alertname,
+		Labels:              alert.Labels,
+		ExcludeDeploymentId: report.DeploymentId,
+		Limit:               limit,
+	}
+}
+
+// formatIncidents 格式化相似故障，作为补充上下文写入提示词
+func formatIncidents(incidents []model.SimilarIncident) string {
+	sections := make([]string, 0, len(incidents))
+	for i, incident := range incidents {
+		title := fmt.Sprintf("%d. %s 应用 %s 告警 %s", i+1, incident.CreatedTime.Format("2006-01-02"), incident.AppName, incident.Alertname)
+		if incident.Verified {
+			title += "（已确认诊断准确）"
+		}
+		lines := []string{title}
+		if incident.Summary != "" {
+			lines = append(lines, "   概述: "+incident.Summary)
+		}
+		if incident.RootCause != "" {
+			lines = append(lines, "   根因: "+truncate(incident.RootCause, maxIncidentTextLen))
+		}
+		if len(incident.Remediation) > 0 {
+			lines = append(lines, "   处理: "+strings.Join(incident.Remediation, "；"))
+		}
+		sections = append(sections, strings.Join(lines, "\n"))
+	}
+	return "以下历史故障与本次告警相似，仅供参考，请以本次查询到的数据为准。\n\n" + strings.Join(sections, "\n\n")
+}
+
+func toSimilarIncident(report *model.Report, score float64) model.SimilarIncident {
+	incident := model.SimilarIncident{
+		ReportId:     report.Id,
+		DeploymentId: report.DeploymentId,
+		AppName:      report.AppName,
+		Verified:     report.Feedback != nil && report.Feedback.Accurate,
+		Score:        math.Round(score*1000) / 1000,
+		CreatedTime:  report.CreatedTime,
+	}
+	if report.Alert != nil {
+		incident.Alertname = report.Alert.Alertname
+		if incident.AppName == "" {
+			// 记录应用之前生成的报告从告警标签中取应用名称
+			incident.AppName = report.Alert.Labels["appName"]
+		}
+	}
+	if report.Result != nil {
+		incident.Summary = report.Result.Summary
+		incident.RootCause = report.Result.RootCause
+		incident.Remediation = report.Result.Remediation
+	}
+	return incident
+}
+
+// incidentDoc 一份报告的词频
+type incidentDoc struct {
+	report *model.Report
+	terms  map[string]float64
+	length float64
+}
+
+// incidentIndex 内存中的 BM25 倒排统计
+type incidentIndex struct {
+	docs      []*incidentDoc
+	docFreq   map[string]int
+	avgLength float64
+}
+
+type incidentHit struct {
+	report *model.Report
+	score  float64
+}
+
+func newIncidentIndex(reports []*model.Report) *incidentIndex {
+	index := &incidentIndex{
+		docs:    make([]*incidentDoc, 0, len(reports)),
+		docFreq: make(map[string]int),
+	}
+	var total float64
+	for _, report := range reports {
+		doc := &incidentDoc{report: report, terms: reportTerms(report)}
+		for term, weight := range doc.terms {
+			doc.length += weight
+			index.docFreq[term]++
+		}
+		total += doc.length
+		index.docs = append(index.docs, doc)
+	}
+	if len(index.docs) > 0 {
+		index.avgLength = total / float64(len(index.docs))
+	}
+	return index
+}
+
+// search 返回得分大于 0 的报告，得分相同时较新的报告在前
+func (idx *incidentIndex) search(query map[string]float64) []incidentHit {
+	hits := make([]incidentHit, 0)
+	n := float64(len(idx.docs))
+	for _, doc := range idx.docs {
+		var score float64
+		for term, queryWeight := range query {
+			tf := doc.terms[term]
+			if tf == 0 {
+				continue
+			}
+			df := float64(idx.docFreq[term])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			norm := bm25K1 * (1 - bm25B + bm25B*doc.length/idx.avgLength)
+			score += queryWeight * idf * tf * (bm25K1 + 1) / (tf + norm)
+		}
+		if score > 0 {
+			hits = append(hits, incidentHit{report: doc.report, score: score})
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].score != hits[j].score {
+			return hits[i].score > hits[j].score
+		}
+		return hits[i].report.CreatedTime.After(hits[j].report.CreatedTime)
+	})
+	return hits
+}
+
+// reportTerms 报告的检索词：应用、告警名称、标签、问题概述和根因
+func reportTerms(report *model.Report) map[string]float64 {
+	terms := make(map[string]float64)
+	appName := report.AppName
+	if report.Alert != nil {
+		if appName == "" {
+			appName = report.Alert.Labels["appName"]
+		}
+		addNameTerms(terms, "alertname", report.Alert.Alertname, alertnameTermWeight)
+		addLabelTerms(terms, report.Alert.Labels)
+	}
+	addNameTerms(terms, "app", appName, appTermWeight)
+	if report.Result != nil {
+		addTextTerms(terms, report.Result.Summary, 1)
+		addTextTerms(terms, report.Result.RootCause, 1)
+	}
+	return terms
+}
+
+// queryTerms 检索条件的检索词，与 reportTerms 使用相同的分词和权重
+func queryTerms(query IncidentQuery) map[string]float64 {
+	terms := make(map[string]float64)
+	addNameTerms(terms, "app", query.AppName, appTermWeight)
+	addNameTerms(terms, "alertname", query.Alertname, alertnameTermWeight)
+	addLabelTerms(terms, query.Labels)
+	addTextTerms(terms, query.Text, 1)
+	return terms
+}
+
+// addNameTerms 名称按完整名称匹配，同时拆词以便部分匹配
+func addNameTerms(terms map[string]float64, field, name string, weight float64) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return
+	}
+	terms[field+"="+strings.ToLower(name)] += weight
+	addTextTerms(terms, name, 1)
+}
+
+func addLabelTerms(terms map[string]float64, labels map[string]string) {
+	for key, value := range labels {
+		if ignoredIncidentLabels[key] || value == "" {
+			continue
+		}
+		terms[strings.ToLower(key)+"="+strings.ToLower(value)]++
+	}
+}
+
+func addTextTerms(terms map[string]float64, text string, weight float64) {
+	for _, token := range tokenize(text) {
+		terms[token] += weight
+	}
+}
+
+// tokenize 英文和数字按单词切分并转为小写，中文按相邻两字切分，不依赖分词词典
+func tokenize(text string) []string {
+	tokens := make([]string, 0)
+	var word []rune
+	var han []rune
+	flushWord := func() {
+		if len(word) > 1 {
+			tokens = append(tokens, strings.ToLower(string(word)))
+		}
+		word = word[:0]
+	}
+	flushHan := func() {
+		if len(han) == 1 {
+			tokens = append(tokens, string(han))
+		}
+		for i := 0; i+1 < len(han); i++ {
+			tokens = append(tokens, string(han[i:i+2]))
+		}
+		han = han[:0]
+	}
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flushWord()
+			han = append(han, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			flushHan()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushHan()
+		}
+	}
+	flushWord()
+	flushHan()
+	return tokens
+}
@@ -0,0 +1,84 @@
+package diagnosis
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+)
+
+func TestTokenize(t *testing.T) {
+	got := tokenize("HighErrorRate: 连接池耗尽, db_pool 99%")
+	want := []string{"higherrorrate", "连接", "接池", "池耗", "耗尽", "db_pool", "99"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tokenize = %v, want %v", got, want)
+	}
+}
+
+func incidentReport(id, deploymentId, app, alertname, rootCause string, created time.Time) *model.Report {
+	return &model.Report{
+		Id:           id,
+		DeploymentId: deploymentId,
+		AppName:      app,
+		Alert:        &model.DiagnosisAlert{Alertname: alertname, Labels: map[string]string{"deploymentId": deploymentId}},
+		Result:       &model.DiagnosisResult{Summary: alertname, RootCause: rootCause, Remediation: []string{"回滚"}},
+		CreatedTime:  created,
+	}
+}
+
+func TestSearchIncidents(t *testing.T) {
+	now := time.Now()
+	reports := &fakeReportModel{incidents: []*model.Report{
+		incidentReport("r1", "d1", "order", "HighErrorRate", "数据库连接池耗尽导致请求失败", now.Add(-time.Hour)),
+		incidentReport("r2", "d2", "order", "HighLatency", "GC 停顿过长", now.Add(-2*time.Hour)),
+		incidentReport("r3", "d3", "payment", "HighErrorRate", "下游超时", now.Add(-3*time.Hour)),
+		// 同一发布单的重新生成只保留得分最高的一份
+		incidentReport("r4", "d1", "order", "HighErrorRate", "未知", now.Add(-30*time.Minute)),
+		incidentReport("r5", "d5", "user", "DiskFull", "日志写满磁盘", now.Add(-4*time.Hour)),
+	}}
+
+	got, err := SearchIncidents(context.Background(), reports, IncidentQuery{
+		AppName:   "order",
+		Alertname: "HighErrorRate",
+		Text:      "连接池耗尽",
+		Limit:     3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]string, 0, len(got))
+	for _, incident := range got {
+		ids = append(ids, incident.ReportId)
+	}
+	if want := []string{"r1", "r3", "r2"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("ids = %v, want %v", ids, want)
+	}
+
+	// 排除当前发布单，未匹配任何词的报告不返回
+	got, _ = SearchIncidents(context.Background(), reports, IncidentQuery{Alertname: "HighErrorRate", ExcludeDeploymentId: "d1", Limit: 10})
+	if len(got) != 1 || got[0].ReportId != "r3" {
+		t.Errorf("incidents = %+v, want r3 only", got)
+	}
+}
+
+func TestQueueAttachIncidents(t *testing.T) {
+	reports := &fakeReportModel{incidents: []*model.Report{
+		incidentReport("r1", "d1", "", "down", "进程崩溃", time.Now()),
+	}}
+	queue := newTestQueue(reports, &fakeAIClient{})
+	queue.incidents = 3
+	alert := &model.DiagnosisAlert{Alertname: "down", Labels: map[string]string{"deploymentId": "d2"}}
+	queue.run(&model.Report{Id: "r2", DeploymentId: "d2", Alert: alert, Attempts: 1})
+
+	got := reports.updated[0]
+	if len(got.Similar) != 1 || got.Similar[0].ReportId != "r1" {
+		t.Fatalf("similar = %+v, want r1", got.Similar)
+	}
+	if len(got.Contexts) != 1 || got.Contexts[0].Type != model.DiagnosisContextSimilarIncidents ||
+		!strings.Contains(got.Contexts[0].Content, "进程崩溃") {
+		t.Errorf("contexts = %+v, want similar incidents", got.Contexts)
+	}
+}
@@ -13,5 +13,6 @@ type DiagnosisClient interface {
 }
 
 type AIClient interface {
-	GenerateCompletion(ctx context.Context, prompt string) (response string, tokensUsed int, err error)
+	// GenerateCompletion 生成诊断报告，失败时也返回已消耗的用量
+	GenerateCompletion(ctx context.Context, prompt string) (response string, usage Usage, err error)
 }
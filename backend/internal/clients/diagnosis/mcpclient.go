@@ -3,9 +3,10 @@ package diagnosis
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"os"
 	"os/exec"
-	"regexp"
 	"strings"
 	"time"
 
@@ -20,10 +21,13 @@ const (
 	diagnosisImageName     = "diagnosis-service:latest"
 
 	pyReturnSplit = "#####"
+	// 传给 Python 脚本的密钥环境变量，通过 docker exec -e 转发，避免出现在进程命令行中
+	envDiagnosisAPIKey      = "DIAGNOSIS_API_KEY"
+	envDiagnosisGitHubToken = "DIAGNOSIS_GITHUB_TOKEN"
+	// pyUsagePrefix Python 脚本在日志中输出累计用量的行前缀，后接 JSON
+	pyUsagePrefix = "[USAGE] "
 )
 
-var jsonRegex = regexp.MustCompile(`\{[^{}]*(?:\{[^{}]*\}[^{}]*)*\}`)
-
 type mcpClient struct {
 	containerName  string        // Docker 容器名称
 	scriptPath     string        // 容器内 Python 脚本路径
@@ -63,23 +67,23 @@ func NewMCPClient(cfg config.AIConfig) AIClient {
 	return client
 }
 
-func (c *mcpClient) GenerateCompletion(ctx context.Context, prompt string) (string, int, error) {
+func (c *mcpClient) GenerateCompletion(ctx context.Context, prompt string) (string, Usage, error) {
 	// 设置超时（MCP 调用需要更长时间，因为涉及多轮工具调用）
 	ctx, cancel := context.WithTimeout(ctx, c.timeout*2)
 	defer cancel()
 
 	// 确保容器运行
 	if err := c.ensureContainer(ctx); err != nil {
-		return "", 0, fmt.Errorf("确保容器运行失败: %w", err)
+		return "", Usage{}, fmt.Errorf("确保容器运行失败: %w", err)
 	}
 
-	// 使用 docker exec 调用容器内的 Python 脚本
-	args := []string{
-		"exec", "-i",
-		c.containerName,
+	// 使用 docker exec 调用容器内的 Python 脚本。密钥只写入 docker 客户端的环境变量，
+	// -e 只带变量名，由 docker 从客户端环境中取值转发到容器内
+	env := append(os.Environ(), envDiagnosisAPIKey+"="+c.apiKey)
+	execArgs := []string{"exec", "-i", "-e", envDiagnosisAPIKey}
+	scriptArgs := []string{
 		"python", c.scriptPath,
 		"--prompt", prompt,
-		"--api-key", c.apiKey,
 		"--base-url", c.baseURL,
 		"--model", c.model,
 		"--prometheus-url", c.prometheusURL,
@@ -87,13 +91,16 @@ func (c *mcpClient) GenerateCompletion(ctx context.Context, prompt string) (stri
 
 	// 添加 GitHub MCP 参数（如果提供了 token，自动启用）
 	if c.githubToken != "" {
-		args = append(args, "--github-token", c.githubToken)
+		env = append(env, envDiagnosisGitHubToken+"="+c.githubToken)
+		execArgs = append(execArgs, "-e", envDiagnosisGitHubToken)
 		if c.githubToolsets != "" {
-			args = append(args, "--github-toolsets", c.githubToolsets)
+			scriptArgs = append(scriptArgs, "--github-toolsets", c.githubToolsets)
 		}
 	}
 
+	args := append(append(execArgs, c.containerName), scriptArgs...)
 	cmd := exec.CommandContext(ctx, "docker", args...)
+	cmd.Env = env
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
@@ -101,30 +108,50 @@ func (c *mcpClient) GenerateCompletion(ctx context.Context, prompt string) (stri
 
 	// 执行命令
 	err := cmd.Run()
+	usage := parseMCPUsage(stdout.String())
 	if err != nil {
-		return "", 0, fmt.Errorf("生成分析报告失败: %w\n, stdout: %s\n stderr: %s", err, stdout.String(), stderr.String())
+		return "", usage, fmt.Errorf("生成分析报告失败: %w\n, stdout: %s\n stderr: %s", err, stdout.String(), stderr.String())
 	}
 
 	// 直接返回文本结果
 	result := strings.TrimSpace(stdout.String())
 
 	if result == "" {
-		return "", 0, fmt.Errorf("python 脚本返回空结果")
+		return "", usage, fmt.Errorf("python 脚本返回空结果")
 	}
 	split := strings.Split(result, pyReturnSplit)
 
-	// MCP 模式下无法获取准确的 token 使用量，返回 0
 	if len(split) > 1 {
 		c.logger.Infof("Python 脚本执行成功，执行日志: \n%s", split[0])
 		c.logger.Infof("report: \n%s", split[1])
 		returnValue := strings.Trim(strings.TrimSpace(strings.Join(split[1:], pyReturnSplit)), "\n")
-		findString := jsonRegex.FindString(returnValue)
-		if findString != "" {
-			return findString, 0, nil
+		report, _, err := parseReport(returnValue)
+		if err != nil {
+			return "", usage, err
+		}
+		return report, usage, nil
+	}
+	return "", usage, fmt.Errorf(result)
+}
+
+// parseMCPUsage 从脚本输出中取最后一行用量记录，没有时返回空用量
+func parseMCPUsage(output string) Usage {
+	var usage Usage
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, pyUsagePrefix) {
+			continue
+		}
+		var record struct {
+			InputTokens  int `json:"input_tokens"`
+			OutputTokens int `json:"output_tokens"`
+			ToolCalls    int `json:"tool_calls"`
+		}
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, pyUsagePrefix)), &record); err == nil {
+			usage = Usage{PromptTokens: record.InputTokens, CompletionTokens: record.OutputTokens, ToolCalls: record.ToolCalls}
 		}
-		return "", 0, fmt.Errorf(returnValue)
 	}
-	return "", 0, fmt.Errorf(result)
+	return usage
 }
 
 // ensureContainer 确保诊断服务容器正在运行
@@ -1,56 +1,316 @@
 package diagnosis
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"text/template"
+	"time"
 
-	"github.com/Z3Labs/Hackathon/backend/internal/types"
+	"github.com/zeromicro/go-zero/core/stores/mon"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+)
+
+const (
+	// BuiltinPromptName 内置提示词模板名称，不保存在数据库中
+	BuiltinPromptName = "builtin"
+	// DefaultPromptVersion 内置提示词的版本，应用未选择模板时使用
+	DefaultPromptVersion = "builtin@v2"
+	// LegacyPromptVersion 记录提示词版本之前生成的报告所用的内置提示词版本
+	LegacyPromptVersion = "builtin@v1"
 )
 
-// buildPromptTemplate 基于告警信息构建完整的 AI prompt
-func buildPromptTemplate(req *types.PostAlertCallbackReq) string {
-	// 构建标签信息
-	labelsStr := formatMap(req.Labels)
+// ErrPromptTemplateNotFound 指定的提示词模板或版本不存在
+var ErrPromptTemplateNotFound = errors.New("提示词模板不存在")
 
-	// 构建注解信息
-	annotationsStr := formatMap(req.Annotations)
+// PromptData 提示词模板中可用的变量，Deployment 和 App 在告警未关联发布单时为空
+type PromptData struct {
+	Alert       *model.DiagnosisAlert
+	Description string // 告警描述，优先取 annotations 中的 description
+	Deployment  *model.Deployment
+	App         *model.Application
+	Contexts    []model.DiagnosisContext
+	Metrics     []PromptMetric // 应用配置的 RED 指标和回滚告警规则，可作为查询起点
+}
 
-	// 提取关键的描述信息（通常在 annotations 的 description 字段）
-	description := req.Desc
-	if desc, ok := req.Annotations["description"]; ok && desc != "" {
-		description = desc
+// PromptMetric 应用已配置的指标查询
+type PromptMetric struct {
+	Name  string
+	Query string
+}
+
+// NewPromptData 组装提示词模板变量
+func NewPromptData(alert *model.DiagnosisAlert, deployment *model.Deployment, app *model.Application, contexts []model.DiagnosisContext) *PromptData {
+	data := &PromptData{
+		Alert:       alert,
+		Description: alert.Desc,
+		Deployment:  deployment,
+		App:         app,
+		Contexts:    contexts,
+		Metrics:     make([]PromptMetric, 0),
+	}
+	if desc := alert.Annotations["description"]; desc != "" {
+		data.Description = desc
 	}
+	if app == nil {
+		return data
+	}
+
+	if red := app.REDMetricsConfig; red != nil && red.Enabled {
+		for _, metric := range []struct {
+			name       string
+			definition *model.MetricDefinition
+		}{
+			{"请求速率", red.RateMetric},
+			{"错误率", red.ErrorMetric},
+			{"响应时长", red.DurationMetric},
+		} {
+			if metric.definition == nil {
+				continue
+			}
+			query := metric.definition.PromQL
+			if query == "" {
+				query = metric.definition.MetricName
+			}
+			if query != "" {
+				data.Metrics = append(data.Metrics, PromptMetric{Name: metric.name, Query: query})
+			}
+		}
+	}
+	if policy := app.RollbackPolicy; policy != nil {
+		for _, rule := range policy.AlertRules {
+			data.Metrics = append(data.Metrics, PromptMetric{Name: "告警规则 " + rule.Name, Query: rule.AlertExpr})
+		}
+	}
+	return data
+}
+
+var promptFuncs = template.FuncMap{
+	"formatMap":      formatMap,
+	"formatContexts": formatContexts,
+	"join":           strings.Join,
+}
+
+// RenderPrompt 渲染提示词模板并追加输出格式说明，模板只描述诊断任务，报告格式由系统统一要求
+func RenderPrompt(content string, data *PromptData) (string, error) {
+	tmpl, err := template.New("prompt").Funcs(promptFuncs).Option("missingkey=zero").Parse(content)
+	if err != nil {
+		return "", fmt.Errorf("模板解析失败: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("模板渲染失败: %w", err)
+	}
+	return strings.TrimSpace(buf.String()) + "\n\n" + fmt.Sprintf(outputInstructions, reportSchema), nil
+}
+
+// ValidatePromptTemplate 使用示例数据渲染模板，检查语法和变量引用；
+// 发布单和应用可能为空，引用其字段时需用 {{if .Deployment}} 判断
+func ValidatePromptTemplate(content string) error {
+	if strings.TrimSpace(content) == "" {
+		return errors.New("模板内容不能为空")
+	}
+	for _, data := range []*PromptData{
+		NewPromptData(sampleAlert(), nil, nil, nil),
+		NewPromptData(sampleAlert(), &model.Deployment{}, &model.Application{}, []model.DiagnosisContext{}),
+	} {
+		if _, err := RenderPrompt(content, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sampleAlert() *model.DiagnosisAlert {
+	return &model.DiagnosisAlert{
+		Alertname:   "HighErrorRate",
+		Status:      "firing",
+		StartsAt:    time.Now().Format(time.RFC3339),
+		Labels:      map[string]string{"hostname": "host-1"},
+		Annotations: map[string]string{"description": "错误率超过阈值"},
+	}
+}
 
-	prompt := fmt.Sprintf(`你是一个专业的 DevOps 运维诊断专家，擅长分析系统告警并定位问题根因。
+// BuiltinPromptTemplate 内置提示词模板
+func BuiltinPromptTemplate() *model.PromptTemplate {
+	return &model.PromptTemplate{
+		Id:          DefaultPromptVersion,
+		Name:        BuiltinPromptName,
+		Version:     2,
+		Content:     builtinPrompt,
+		Description: "内置提示词",
+	}
+}
+
+// ResolvePromptTemplate 查找提示词模板。version 为 <name>@v<version> 时使用指定版本，为模板名称时使用最新版本；
+// 为空时使用应用选择的模板，应用未选择时使用内置提示词
+func ResolvePromptTemplate(ctx context.Context, templateModel model.PromptTemplateModel, version string, app *model.Application) (*model.PromptTemplate, error) {
+	if version == "" && app != nil && app.PromptTemplate != nil && app.PromptTemplate.Name != "" {
+		version = app.PromptTemplate.Name
+		if app.PromptTemplate.Version > 0 {
+			version = model.PromptTemplateId(app.PromptTemplate.Name, app.PromptTemplate.Version)
+		}
+	}
+	if version == "" || version == BuiltinPromptName || version == DefaultPromptVersion {
+		return BuiltinPromptTemplate(), nil
+	}
+	if strings.HasPrefix(version, BuiltinPromptName+"@") || templateModel == nil {
+		return nil, fmt.Errorf("%w: %s", ErrPromptTemplateNotFound, version)
+	}
+
+	var (
+		tmpl *model.PromptTemplate
+		err  error
+	)
+	if strings.Contains(version, "@v") {
+		tmpl, err = templateModel.FindById(ctx, version)
+	} else {
+		tmpl, err = templateModel.FindLatest(ctx, version)
+	}
+	if err == mon.ErrNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrPromptTemplateNotFound, version)
+	}
+	return tmpl, err
+}
+
+// promptBuilder 查询告警关联的发布单和应用，按应用选择的模板渲染提示词
+type promptBuilder struct {
+	deploymentModel  model.DeploymentModel
+	applicationModel model.ApplicationModel
+	templateModel    model.PromptTemplateModel
+}
+
+// lookup 告警关联的发布单和应用，查询不到时为空
+func (b *promptBuilder) lookup(ctx context.Context, alert *model.DiagnosisAlert) (*model.Deployment, *model.Application) {
+	deploymentId := alert.Labels["deploymentId"]
+	if b == nil || deploymentId == "" {
+		return nil, nil
+	}
+	deployment, err := b.deploymentModel.FindById(ctx, deploymentId)
+	if err != nil {
+		return nil, nil
+	}
+	app, err := b.applicationModel.FindById(ctx, deployment.AppId)
+	if err != nil {
+		return deployment, nil
+	}
+	return deployment, app
+}
+
+// build 返回渲染后的提示词和使用的模板版本。模板不存在或渲染失败时返回 promptConfigError，重试无法恢复
+func (b *promptBuilder) build(ctx context.Context, alert *model.DiagnosisAlert, deployment *model.Deployment, app *model.Application,
+	version string, contexts []model.DiagnosisContext) (string, string, error) {
+
+	var templateModel model.PromptTemplateModel
+	if b != nil {
+		templateModel = b.templateModel
+	}
+
+	tmpl, err := ResolvePromptTemplate(ctx, templateModel, version, app)
+	if errors.Is(err, ErrPromptTemplateNotFound) {
+		return "", "", &promptConfigError{err}
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	prompt, err := RenderPrompt(tmpl.Content, NewPromptData(alert, deployment, app, contexts))
+	if err != nil {
+		return "", tmpl.Id, &promptConfigError{fmt.Errorf("提示词 %s %w", tmpl.Id, err)}
+	}
+	return prompt, tmpl.Id, nil
+}
+
+// promptConfigError 提示词模板配置错误
+type promptConfigError struct {
+	err error
+}
+
+func (e *promptConfigError) Error() string { return e.err.Error() }
+
+func (e *promptConfigError) Unwrap() error { return e.err }
+
+// formatMap 格式化 map 为易读的字符串
+func formatMap(m map[string]string) string {
+	if len(m) == 0 {
+		return "（无）"
+	}
+
+	var lines []string
+	for key, value := range m {
+		lines = append(lines, fmt.Sprintf("  - %s: %s", key, value))
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+// outputInstructions 追加在每个提示词末尾的输出格式说明
+const outputInstructions = `**输出格式**
+重要：请严格按照以下JSON格式输出!!!，你的输出只有一个json，不要用 markdown代码块 标记或任何额外的文本说明
+
+%s
+
+其中：
+- summary: 问题概述，简要描述告警反映的问题
+- rootCause: 根因分析，详细说明问题的根本原因，引用具体的指标数据和分析过程
+- impact: 影响范围，说明问题影响的系统范围和严重程度
+- remediation: 字符串数组，按执行顺序给出具体的解决步骤，至少一条
+- suspects: 可疑的代码变更，每项至少填写 pr（PR 编号）或 file（文件路径）之一，以及 description；没有时为空数组[]
+- evidence: 支撑结论的异常指标，promQL 为合法的 Prometheus 查询语句，observation 为查询到的关键现象；没有时为空数组[]
+- confidence: 0 到 1 之间的数字，表示你对根因结论的把握
+- 不要输出以上以外的字段
+
+现在请开始诊断分析：`
+
+// builtinPrompt 内置提示词模板
+const builtinPrompt = `你是一个专业的 DevOps 运维诊断专家，擅长分析系统告警并定位问题根因。
 
 **收到以下告警信息**：
 
-告警类型：%s
-告警名称: %s
-告警状态: %s
-严重程度: %s
-描述信息: %s
-触发值: %.2f
-开始时间: %s
-接收时间: %s
-结束时间: %s
-告警源: %s
-需要处理: %t
-紧急程度: %t
+告警类型：{{.Alert.Key}}
+告警名称: {{.Alert.Alertname}}
+告警状态: {{.Alert.Status}}
+严重程度: {{.Alert.Severity}}
+描述信息: {{.Description}}
+触发值: {{printf "%.2f" .Alert.Values}}
+开始时间: {{.Alert.StartsAt}}
+接收时间: {{.Alert.ReceiveAt}}
+结束时间: {{.Alert.EndsAt}}
+告警源: {{.Alert.GeneratorURL}}
+{{- if .Deployment}}
+
+**发布信息**:
+应用: {{.Deployment.AppName}}
+发布版本: {{.Deployment.PackageVersion}}
+发布状态: {{.Deployment.Status}}
+{{- end}}
 
 **标签信息**:
-%s
+{{formatMap .Alert.Labels}}
 
 **注解信息**:
-%s
+{{formatMap .Alert.Annotations}}
+{{- if .Metrics}}
+
+**应用已配置的指标**（可作为查询起点）:
+{{- range .Metrics}}
+  - {{.Name}}: {{.Query}}
+{{- end}}
+{{- end}}
+
+**补充上下文**（发布平台在诊断前收集，可直接作为分析依据）:
+{{formatContexts .Contexts}}
 
 **你的任务**：
 
-1. **使用 Prometheus MCP 工具查询相关指标**
+1. **使用 Prometheus 工具查询相关指标**
    - 使用 get_targets() 检查 Prometheus 抓取目标状态
    - 使用 execute_query() 查询关键指标（CPU、内存、网络、应用指标等）
    - 使用 execute_range_query() 获取时间范围内的趋势数据
-   - 使用 get_metric_metadata(metric: "metric_name") - 获取指标元数据（类型、说明等）
    - 若不知道有哪些指标，可以使用 list_metrics() 列出所有可用指标名称，确保指标输入正确
    - 根据告警信息中的标签 hostname 精准查询相关实例的指标，hostname可能为多个用 ',' 连接，需要拆分后分别查询
 
@@ -61,64 +321,16 @@ func buildPromptTemplate(req *types.PostAlertCallbackReq) string {
    - 定位问题的根本原因
 
 **重要提示**：
-- 请使用 MCP 工具主动查询所需的指标数据，不要等待提供
-- 重点查询 描述信息 提及到的指标，以及 go-runtime 相关的指标（因为是go服务发布）
+- 请使用工具主动查询所需的指标数据，不要等待提供
+- 重点查询 描述信息 提及到的指标，以及应用运行时（进程、线程/协程、GC、连接池等）相关的指标，可先用 list_metrics() 确认应用暴露了哪些运行时指标
 - 在诊断报告中，只输出分析结果和建议，不需要列出查询到的原始指标数据
 - 报告应该简洁明了，便于运维人员快速理解和处理
 - 如果某些指标查询失败，请说明并基于现有信息进行分析
+{{- if .Alert.RepoAddress}}
 
-3. %s
-
-4. **输出格式**
-   重要：请严格按照以下JSON格式输出!!!，你的输出只有一个json，不要用 markdown代码块 标记或任何额外的文本说明
-   
-   {
-     "promQL": ["查询语句1", "查询语句2"],
-     "content": "报告内容"
-   }
-   
-   其中：
-   - promQL: 字符串数组，包含你在诊断分析过程中识别出的异常指标的Prometheus查询语句（每个查询语句一个元素）。如果没有发现异常指标，则为空数组[]
-   - content: 字符串，包含详细的诊断报告，格式如下：
-     
-     【问题概述】
-     简要描述告警反映的问题
-     
-     【根因分析】
-     详细说明问题的根本原因，引用具体的指标数据和分析过程
-     
-     【影响范围】
-     说明问题影响的系统范围和严重程度
-     
-     【解决方案】
-     提供具体的解决步骤和建议
-
-现在请开始诊断分析：`,
-		req.Key,
-		req.Alertname,
-		req.Status,
-		req.Severity,
-		description,
-		req.Values,
-		req.StartsAt,
-		req.ReceiveAt,
-		req.EndsAt,
-		req.GeneratorURL,
-		req.NeedHandle,
-		req.IsEmergent,
-		labelsStr,
-		annotationsStr,
-		fmt.Sprintf(github_search_prompt, req.RepoAddress, req.Tag),
-	)
-
-	return prompt
-}
-
-// 若问题不存在则输出分析结果
-var github_search_prompt = `根据以上排查信息，
-若确定问题的存在，则进一步分析 GitHub 仓库 "%s" 发布 release 中的潜在 bug：
+3. 根据以上排查信息，若确定问题的存在，则进一步分析 GitHub 仓库 "{{.Alert.RepoAddress}}" 发布 release 中的潜在 bug：
 
-  1. 用 "get_release_by_tag" 获取指定 tag %s 的release，若没有查到相关信息，则使用 "get_latest_release"获取最新一个release，
+  1. 用 "get_release_by_tag" 获取指定 tag {{.Alert.Tag}} 的release，若没有查到相关信息，则使用 "get_latest_release"获取最新一个release，
  然后从 body 中提取该次发布的 PR 编号，若该次发布存在pr，则继续，否则结束分析。
 
   2. 逐个分析 PR，对每个 PR 编号，依次调用以下工具：
@@ -140,19 +352,7 @@ var github_search_prompt = `根据以上排查信息，
      - 资源泄漏（未关闭连接、文件句柄）
      - 并发安全
      - 逻辑错误
-	 - ...
-
-  4. 若查找到可能的错误，则输出：PR编号 + 文件路径 + 问题描述 + 建议修复`
+     - ...
 
-// formatMap 格式化 map 为易读的字符串
-func formatMap(m map[string]string) string {
-	if len(m) == 0 {
-		return "（无）"
-	}
-
-	var lines []string
-	for key, value := range m {
-		lines = append(lines, fmt.Sprintf("  - %s: %s", key, value))
-	}
-	return strings.Join(lines, "\n")
-}
+  4. 若查找到可能的错误，将 PR编号 + 文件路径 + 问题描述 + 建议修复 写入报告的 suspects
+{{- end}}`
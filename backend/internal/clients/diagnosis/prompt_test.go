@@ -0,0 +1,110 @@
+package diagnosis
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/core/stores/mon"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+)
+
+type fakePromptTemplateModel struct {
+	model.PromptTemplateModel
+	templates []*model.PromptTemplate
+}
+
+func (m *fakePromptTemplateModel) FindById(ctx context.Context, id string) (*model.PromptTemplate, error) {
+	for _, tmpl := range m.templates {
+		if tmpl.Id == id {
+			return tmpl, nil
+		}
+	}
+	return nil, mon.ErrNotFound
+}
+
+func (m *fakePromptTemplateModel) FindLatest(ctx context.Context, name string) (*model.PromptTemplate, error) {
+	var latest *model.PromptTemplate
+	for _, tmpl := range m.templates {
+		if tmpl.Name == name && (latest == nil || tmpl.Version > latest.Version) {
+			latest = tmpl
+		}
+	}
+	if latest == nil {
+		return nil, mon.ErrNotFound
+	}
+	return latest, nil
+}
+
+func TestRenderPrompt(t *testing.T) {
+	alert := &model.DiagnosisAlert{Alertname: "HighErrorRate", Desc: "desc", Labels: map[string]string{"b": "2", "a": "1"}}
+	app := &model.Application{
+		Name: "demo",
+		REDMetricsConfig: &model.REDMetrics{
+			Enabled:     true,
+			ErrorMetric: &model.MetricDefinition{PromQL: `sum(rate(http_requests_total{code=~"5.."}[1m]))`},
+		},
+	}
+	content := `{{.App.Name}} {{.Alert.Alertname}} {{.Description}}
+{{formatMap .Alert.Labels}}
+{{range .Metrics}}{{.Name}}={{.Query}}{{end}}`
+
+	prompt, err := RenderPrompt(content, NewPromptData(alert, &model.Deployment{}, app, nil))
+	assert.NoError(t, err)
+	assert.Contains(t, prompt, "demo HighErrorRate desc")
+	assert.Contains(t, prompt, "  - a: 1\n  - b: 2")
+	assert.Contains(t, prompt, `错误率=sum(rate(http_requests_total{code=~"5.."}[1m]))`)
+	// 输出格式由系统统一追加
+	assert.Contains(t, prompt, reportSchema)
+
+	// 内置模板在没有发布单和应用时也能渲染
+	prompt, err = RenderPrompt(builtinPrompt, NewPromptData(alert, nil, nil, nil))
+	assert.NoError(t, err)
+	assert.NotContains(t, prompt, "**发布信息**")
+	assert.NotContains(t, prompt, "go-runtime")
+}
+
+func TestValidatePromptTemplate(t *testing.T) {
+	assert.NoError(t, ValidatePromptTemplate(builtinPrompt))
+	assert.NoError(t, ValidatePromptTemplate(`{{if .Deployment}}{{.Deployment.AppName}}{{end}}`))
+	assert.Error(t, ValidatePromptTemplate(" "))
+	assert.Error(t, ValidatePromptTemplate(`{{.Alert.Alertname`))
+	assert.Error(t, ValidatePromptTemplate(`{{.Unknown}}`))
+	// 发布单可能为空
+	assert.Error(t, ValidatePromptTemplate(`{{.Deployment.AppName}}`))
+}
+
+func TestResolvePromptTemplate(t *testing.T) {
+	ctx := context.Background()
+	templates := &fakePromptTemplateModel{templates: []*model.PromptTemplate{
+		{Id: "java@v1", Name: "java", Version: 1},
+		{Id: "java@v2", Name: "java", Version: 2},
+	}}
+
+	tmpl, err := ResolvePromptTemplate(ctx, templates, "", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultPromptVersion, tmpl.Id)
+
+	// 应用未指定版本时使用最新版本
+	app := &model.Application{PromptTemplate: &model.PromptTemplateRef{Name: "java"}}
+	tmpl, err = ResolvePromptTemplate(ctx, templates, "", app)
+	assert.NoError(t, err)
+	assert.Equal(t, "java@v2", tmpl.Id)
+
+	app.PromptTemplate.Version = 1
+	tmpl, err = ResolvePromptTemplate(ctx, templates, "", app)
+	assert.NoError(t, err)
+	assert.Equal(t, "java@v1", tmpl.Id)
+
+	// 显式指定的版本优先于应用配置
+	tmpl, err = ResolvePromptTemplate(ctx, templates, "builtin", app)
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultPromptVersion, tmpl.Id)
+
+	for _, version := range []string{"java@v3", "python", LegacyPromptVersion} {
+		_, err = ResolvePromptTemplate(ctx, templates, version, nil)
+		assert.True(t, errors.Is(err, ErrPromptTemplateNotFound), version)
+	}
+}
@@ -19,16 +19,19 @@ async def main():
     """主函数"""
     parser = argparse.ArgumentParser(description='智能诊断系统 - MCP 版本')
     parser.add_argument('--prompt', required=True, help='完整的 AI prompt')
-    parser.add_argument('--api-key', required=True, help='AI API Key')
+    # API Key 和 GitHub Token 通过环境变量传入，避免出现在进程命令行中
+    parser.add_argument('--api-key', default=os.environ.get('DIAGNOSIS_API_KEY'), help='AI API Key，默认读取环境变量 DIAGNOSIS_API_KEY')
     parser.add_argument('--base-url', required=True, help='AI Base URL')
     parser.add_argument('--model', required=True, help='模型名称')
     parser.add_argument('--prometheus-url', required=True, help='Prometheus URL')
 
     # GitHub MCP 参数（可选）
-    parser.add_argument('--github-token', help='GitHub Personal Access Token')
+    parser.add_argument('--github-token', default=os.environ.get('DIAGNOSIS_GITHUB_TOKEN'), help='GitHub Personal Access Token，默认读取环境变量 DIAGNOSIS_GITHUB_TOKEN')
     parser.add_argument('--github-toolsets', default='repos,issues,pull_requests,releases', help='GitHub MCP 工具集')
 
     args = parser.parse_args()
+    if not args.api_key:
+        parser.error('缺少 AI API Key，请设置环境变量 DIAGNOSIS_API_KEY')
 
     try:
         # 调用诊断函数（如果提供了 github_token，自动启用 GitHub MCP）
@@ -148,6 +148,12 @@ async def simple_diagnosis(
 
         import traceback
 
+        # 累计用量，每轮结束后输出，Go 端取最后一行计算成本
+        usage = {"input_tokens": 0, "output_tokens": 0, "tool_calls": 0}
+
+        def print_usage():
+            print(f"[USAGE] {json.dumps(usage)}")
+
         for iteration in range(20):  # 最多 20 轮
             print(f"[AI] 第 {iteration + 1} 轮对话")
 
@@ -162,9 +168,13 @@ async def simple_diagnosis(
                 error_detail = traceback.format_exc()
                 print(f"\n[AI] ❌ API 调用失败:")
                 print(error_detail)
+                print_usage()
                 return f"API 调用失败: {str(e)}\n\n详细错误:\n{error_detail}"
 
             print(f"[AI] Stop reason: {response.stop_reason}")
+            if getattr(response, "usage", None):
+                usage["input_tokens"] += response.usage.input_tokens or 0
+                usage["output_tokens"] += response.usage.output_tokens or 0
 
             if response.stop_reason == "end_turn":
                 # 完成，提取最终响应文本
@@ -174,6 +184,7 @@ async def simple_diagnosis(
                         final_text += block.text
 
                 print(f"\n[AI] ✅ 分析完成")
+                print_usage()
                 print(f"\n#####") # 切割日志和ai结果
 
                 # 直接返回纯文本
@@ -186,6 +197,7 @@ async def simple_diagnosis(
             tool_results = []
             for block in response.content:
                 if block.type == "tool_use":
+                    usage["tool_calls"] += 1
                     # 使用工具路由器找到对应的 session
                     session = tool_router.get(block.name)
 
@@ -239,6 +251,7 @@ async def simple_diagnosis(
 
             # 添加工具结果
             messages.append({"role": "user", "content": tool_results})
+            print_usage()
             print()
 
         return "诊断分析达到最大迭代次数，请检查配置或联系技术支持。"
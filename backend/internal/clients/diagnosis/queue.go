@@ -0,0 +1,370 @@
+package diagnosis
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/clients/notify"
+	"github.com/Z3Labs/Hackathon/backend/internal/clients/prom"
+	"github.com/Z3Labs/Hackathon/backend/internal/config"
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+	"github.com/Z3Labs/Hackathon/backend/internal/svc"
+	"github.com/Z3Labs/Hackathon/backend/internal/types"
+)
+
+const (
+	// pollInterval worker 空闲时轮询队列的间隔，用于执行到期的重试任务和其他实例入队的任务
+	pollInterval = 5 * time.Second
+	// maxRetryBackoff 重试间隔上限
+	maxRetryBackoff = 10 * time.Minute
+)
+
+// wakeup 有新任务入队时唤醒空闲的 worker
+var wakeup = make(chan struct{}, 1)
+
+func wakeWorkers() {
+	select {
+	case wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// Enqueue 将告警加入诊断队列并立即返回报告ID，报告状态为排队中，由 Queue 的 worker 异步生成。
+// 同一发布单已有排队中或生成中的报告时不重复入队，返回已有报告的ID和 false
+func Enqueue(ctx context.Context, reportModel model.ReportModel, req *types.PostAlertCallbackReq) (string, bool, error) {
+	report := &model.Report{
+		DeploymentId: req.Labels["deploymentId"],
+		Alert:        toDiagnosisAlert(req),
+	}
+	created, err := reportModel.Enqueue(ctx, report)
+	if err != nil {
+		return "", false, err
+	}
+	if created {
+		wakeWorkers()
+	}
+	return report.Id, created, nil
+}
+
+// Regenerate 基于已有报告的告警重新生成诊断报告，modelName、promptVersion 为空时使用默认配置。
+// 同一发布单已有排队中或生成中的报告时不重复入队，返回已有报告的ID和 false
+func Regenerate(ctx context.Context, reportModel model.ReportModel, source *model.Report, modelName, promptVersion string) (string, bool, error) {
+	report := &model.Report{
+		DeploymentId:  source.DeploymentId,
+		Alert:         source.Alert,
+		Model:         modelName,
+		PromptVersion: promptVersion,
+	}
+	created, err := reportModel.Enqueue(ctx, report)
+	if err != nil {
+		return "", false, err
+	}
+	if created {
+		wakeWorkers()
+	}
+	return report.Id, created, nil
+}
+
+// Queue 诊断任务队列的 worker 池。任务持久化在报告中，多个后端实例可共享同一队列，
+// 领取任务时加租约，执行中断的任务在租约到期后被重新领取
+type Queue struct {
+	reportModel  model.ReportModel
+	aiClient     AIClient
+	aiConfig     config.AIConfig
+	clients      map[string]AIClient // 报告指定了其他模型时按模型创建的客户端
+	clientsMu    sync.Mutex
+	promClient   prom.VMClient // 为空时不保存指标快照
+	contexts     *contextCollector
+	prompts      *promptBuilder
+	prices       priceTable
+	budget       *budgetChecker // 为空时不限制预算
+	incidents    int            // 诊断时附带的历史相似故障数，0 表示不检索
+	notifier     *notify.Notifier
+	workers      int
+	maxAttempts  int
+	retryBackoff time.Duration
+	lease        time.Duration
+	ctx          context.Context
+	cancel       context.CancelFunc
+	wg           sync.WaitGroup
+	logx.Logger
+}
+
+// NewQueue 创建诊断任务队列，AI 客户端只在创建时初始化一次
+func NewQueue(svcCtx *svc.ServiceContext, aiConfig config.AIConfig) *Queue {
+	ctx, cancel := context.WithCancel(context.Background())
+	workers := aiConfig.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+	maxAttempts := aiConfig.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+	return &Queue{
+		reportModel: svcCtx.ReportModel,
+		aiClient:    NewAIClient(aiConfig, svcCtx.PromClient),
+		aiConfig:    aiConfig,
+		clients:     make(map[string]AIClient),
+		promClient:  svcCtx.PromClient,
+		contexts:    newContextCollector(svcCtx, aiConfig),
+		prompts: &promptBuilder{
+			deploymentModel:  svcCtx.DeploymentModel,
+			applicationModel: svcCtx.ApplicationModel,
+			templateModel:    svcCtx.PromptTemplateModel,
+		},
+		prices:       newPriceTable(aiConfig.Prices),
+		budget:       &budgetChecker{reportModel: svcCtx.ReportModel, cfg: aiConfig.Budget},
+		incidents:    aiConfig.Incidents,
+		notifier:     svcCtx.Notifier,
+		workers:      workers,
+		maxAttempts:  maxAttempts,
+		retryBackoff: time.Duration(aiConfig.RetryBackoff) * time.Second,
+		// AI 调用的超时为配置超时的两倍，租约再留出余量
+		lease:  2*time.Duration(aiConfig.Timeout)*time.Second + time.Minute,
+		ctx:    ctx,
+		cancel: cancel,
+		Logger: logx.WithContext(ctx),
+	}
+}
+
+// Start 启动 worker，同时执行的诊断任务数不超过配置的 Workers
+func (q *Queue) Start() {
+	for i := 0; i < q.workers; i++ {
+		q.wg.Add(1)
+		go q.work()
+	}
+	q.Infof("Diagnosis queue started with %d workers", q.workers)
+}
+
+// Stop 停止 worker 并等待执行中的任务结束，被中断的任务按失败重试
+func (q *Queue) Stop() {
+	q.cancel()
+	q.wg.Wait()
+}
+
+func (q *Queue) work() {
+	defer q.wg.Done()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		for q.ctx.Err() == nil && q.runOnce() {
+		}
+		select {
+		case <-q.ctx.Done():
+			return
+		case <-wakeup:
+		case <-ticker.C:
+		}
+	}
+}
+
+// runOnce 领取并执行一个任务，没有可执行的任务时返回 false
+func (q *Queue) runOnce() bool {
+	report, err := q.reportModel.Claim(q.ctx, time.Now(), q.lease)
+	if err != nil {
+		q.Errorf("[DiagnosisQueue] ReportModel.Claim error:%v", err)
+		return false
+	}
+	if report == nil {
+		return false
+	}
+	// 队列中可能还有任务，唤醒其他空闲的 worker
+	wakeWorkers()
+
+	q.run(report)
+	return true
+}
+
+func (q *Queue) run(report *model.Report) {
+	var err error
+	switch {
+	case report.Alert == nil:
+		err = errors.New("缺少告警信息")
+		report.Attempts = q.maxAttempts
+	case report.Attempts > q.maxAttempts:
+		// 多次执行中断后租约过期被重新领取
+		err = errors.New("超过最大尝试次数")
+	default:
+		if report.Model == "" {
+			report.Model = q.aiConfig.Model
+		}
+		deployment, app := q.prompts.lookup(q.ctx, report.Alert)
+		if app != nil {
+			report.AppId, report.AppName = app.Id, app.Name
+		} else if deployment != nil {
+			report.AppId, report.AppName = deployment.AppId, deployment.AppName
+		}
+		// 每次尝试前检查预算，汇总失败时不阻塞诊断
+		reason, budgetErr := q.budget.exceeded(q.ctx, report.AppId, time.Now())
+		if budgetErr != nil {
+			q.Errorf("[DiagnosisQueue] ReportModel.UsageStats error:%v", budgetErr)
+		}
+		if reason != "" {
+			downgrade := q.budget.downgradeModel()
+			if downgrade == "" {
+				q.skip(report, reason)
+				return
+			}
+			if report.Model != downgrade {
+				q.Infof("部署 %s %s，诊断模型从 %s 降级为 %s", report.DeploymentId, reason, report.Model, downgrade)
+				report.Model = downgrade
+			}
+		}
+		// 补充上下文和相似故障只在首次执行时收集并随报告保存，重试时复用
+		if report.Contexts == nil {
+			if q.contexts != nil {
+				report.Contexts = q.contexts.Collect(q.ctx, report.Alert)
+			}
+			q.attachIncidents(report)
+		}
+		// 未指定提示词版本时按应用选择的模板渲染，并记录实际使用的版本
+		var prompt, version string
+		prompt, version, err = q.prompts.build(q.ctx, report.Alert, deployment, app, report.PromptVersion, report.Contexts)
+		if version != "" {
+			report.PromptVersion = version
+		}
+		var configErr *promptConfigError
+		if errors.As(err, &configErr) {
+			// 模板不存在或渲染失败，重试无法恢复
+			report.Attempts = q.maxAttempts
+		}
+		var content string
+		var usage Usage
+		if err == nil {
+			content, usage, err = q.clientFor(report.Model).GenerateCompletion(q.ctx, prompt)
+			// 失败的尝试同样消耗 token，计入报告用量
+			q.prices.record(report, usage)
+		}
+		var result *model.DiagnosisResult
+		if err == nil {
+			// 未通过校验的报告按失败重试
+			content, result, err = parseReport(content)
+		}
+		if err == nil {
+			if q.promClient != nil {
+				captureEvidence(q.promClient, report.Alert, result, time.Now())
+			}
+			report.Content = content
+			report.Result = result
+			report.Status = model.ReportStatusCompleted
+			report.LastError = ""
+			q.save(report)
+			q.Infof("部署 %s 诊断报告生成成功，第 %d 次尝试，Token 消耗: %d，工具调用: %d 次", report.DeploymentId, report.Attempts, usage.TotalTokens(), usage.ToolCalls)
+			q.notifyReport(report, deployment)
+			return
+		}
+	}
+
+	report.LastError = err.Error()
+	if report.Attempts >= q.maxAttempts {
+		report.Status = model.ReportStatusFailed
+		report.Content = err.Error()
+		q.Errorf("部署 %s 诊断报告生成失败，已尝试 %d 次: %v", report.DeploymentId, report.Attempts, err)
+	} else {
+		report.Status = model.ReportStatusQueued
+		report.NextRunAt = time.Now().Add(q.backoff(report.Attempts))
+		q.Infof("部署 %s 诊断报告生成失败，%s 后重试: %v", report.DeploymentId, report.NextRunAt.Format(time.RFC3339), err)
+	}
+	q.save(report)
+}
+
+// skip 预算已用完时不再诊断，报告标记为已跳过
+func (q *Queue) skip(report *model.Report, reason string) {
+	report.Status = model.ReportStatusSkipped
+	report.Content = "诊断预算已用完，跳过诊断: " + reason
+	report.LastError = reason
+	q.save(report)
+	q.Infof("部署 %s 跳过诊断: %s", report.DeploymentId, reason)
+}
+
+// notifyReport 通知应用诊断报告已生成，告警未关联发布单时不通知
+func (q *Queue) notifyReport(report *model.Report, deployment *model.Deployment) {
+	if deployment == nil {
+		return
+	}
+	event := notify.DeploymentEvent(model.NotifyEventReportCompleted, deployment, truncate(report.Result.RootCause, maxIncidentTextLen))
+	event.ReportId = report.Id
+	event.Summary = report.Result.Summary
+	q.notifier.Notify(event)
+}
+
+// attachIncidents 检索历史相似故障保存到报告，并作为补充上下文写入提示词，检索失败不影响诊断
+func (q *Queue) attachIncidents(report *model.Report) {
+	if q.incidents <= 0 {
+		return
+	}
+	incidents, err := SearchIncidents(q.ctx, q.reportModel, incidentQueryFor(report, q.incidents))
+	if err != nil {
+		q.Errorf("[DiagnosisQueue] SearchIncidents error:%v", err)
+		return
+	}
+	report.Similar = incidents
+	if len(incidents) > 0 {
+		report.Contexts = append(report.Contexts, model.DiagnosisContext{
+			Type:    model.DiagnosisContextSimilarIncidents,
+			Content: formatIncidents(incidents),
+		})
+	}
+}
+
+// clientFor 报告使用的模型对应的 AI 客户端，与配置的默认模型相同时复用默认客户端
+func (q *Queue) clientFor(modelName string) AIClient {
+	if modelName == "" || modelName == q.aiConfig.Model {
+		return q.aiClient
+	}
+
+	q.clientsMu.Lock()
+	defer q.clientsMu.Unlock()
+	client, ok := q.clients[modelName]
+	if !ok {
+		cfg := q.aiConfig
+		cfg.Model = modelName
+		client = NewAIClient(cfg, q.promClient)
+		q.clients[modelName] = client
+	}
+	return client
+}
+
+func (q *Queue) save(report *model.Report) {
+	// 停止时 q.ctx 已取消，仍需写回任务状态
+	if err := q.reportModel.Update(context.Background(), report); err != nil {
+		q.Errorf("[DiagnosisQueue] ReportModel.Update error:%v, reportId:%s", err, report.Id)
+	}
+}
+
+// backoff 第 n 次失败后的重试间隔，从 RetryBackoff 开始每次翻倍
+func (q *Queue) backoff(attempts int) time.Duration {
+	d := q.retryBackoff
+	for i := 1; i < attempts && d < maxRetryBackoff; i++ {
+		d *= 2
+	}
+	if d > maxRetryBackoff {
+		d = maxRetryBackoff
+	}
+	return d
+}
+
+func toDiagnosisAlert(req *types.PostAlertCallbackReq) *model.DiagnosisAlert {
+	return &model.DiagnosisAlert{
+		Key:          req.Key,
+		Status:       req.Status,
+		Desc:         req.Desc,
+		StartsAt:     req.StartsAt,
+		ReceiveAt:    req.ReceiveAt,
+		EndsAt:       req.EndsAt,
+		Severity:     req.Severity,
+		Alertname:    req.Alertname,
+		GeneratorURL: req.GeneratorURL,
+		RepoAddress:  req.RepoAddress,
+		Tag:          req.Tag,
+		Labels:       req.Labels,
+		Annotations:  req.Annotations,
+		Values:       req.Values,
+	}
+}
@@ -0,0 +1,180 @@
+package diagnosis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/config"
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+)
+
+type fakeReportModel struct {
+	model.ReportModel
+	updated   []model.Report
+	usage     []*model.ReportUsageStat
+	incidents []*model.Report
+}
+
+func (m *fakeReportModel) FindIncidents(ctx context.Context, limit int64) ([]*model.Report, error) {
+	return m.incidents, nil
+}
+
+func (m *fakeReportModel) UsageStats(ctx context.Context, since time.Time) ([]*model.ReportUsageStat, error) {
+	return m.usage, nil
+}
+
+func (m *fakeReportModel) Update(ctx context.Context, report *model.Report) error {
+	m.updated = append(m.updated, *report)
+	return nil
+}
+
+type fakeAIClient struct {
+	content string
+	err     error
+}
+
+func (c *fakeAIClient) GenerateCompletion(ctx context.Context, prompt string) (string, Usage, error) {
+	usage := Usage{PromptTokens: 8, CompletionTokens: 2, ToolCalls: 1}
+	if c.err != nil {
+		return "", usage, c.err
+	}
+	if c.content != "" {
+		return c.content, usage, nil
+	}
+	return testReport, usage, nil
+}
+
+func newTestQueue(reportModel model.ReportModel, aiClient AIClient) *Queue {
+	return &Queue{
+		reportModel:  reportModel,
+		aiClient:     aiClient,
+		workers:      1,
+		maxAttempts:  3,
+		retryBackoff: 30 * time.Second,
+		lease:        time.Minute,
+		aiConfig:     config.AIConfig{Model: "default-model"},
+		clients:      make(map[string]AIClient),
+		ctx:          context.Background(),
+		Logger:       logx.WithContext(context.Background()),
+	}
+}
+
+func TestQueueRun(t *testing.T) {
+	alert := &model.DiagnosisAlert{Alertname: "down", Labels: map[string]string{"deploymentId": "d1"}}
+
+	reports := &fakeReportModel{}
+	newTestQueue(reports, &fakeAIClient{}).run(&model.Report{Id: "r1", Alert: alert, Attempts: 1})
+	if got := reports.updated[0]; got.Status != model.ReportStatusCompleted || got.Content != testReport || got.Result == nil {
+		t.Errorf("report = %+v, want completed", got)
+	}
+	// 未指定时记录默认模型和提示词版本
+	if got := reports.updated[0]; got.Model != "default-model" || got.PromptVersion != DefaultPromptVersion {
+		t.Errorf("model = %s, promptVersion = %s, want defaults", got.Model, got.PromptVersion)
+	}
+
+	// 提示词模板不存在时直接失败，不再重试
+	reports = &fakeReportModel{}
+	newTestQueue(reports, &fakeAIClient{}).run(&model.Report{Id: "r1", Alert: alert, Attempts: 1, PromptVersion: "builtin@v9"})
+	if got := reports.updated[0]; got.Status != model.ReportStatusFailed {
+		t.Errorf("report = %+v, want failed", got)
+	}
+
+	// 报告未通过校验时按失败重试
+	reports = &fakeReportModel{}
+	newTestQueue(reports, &fakeAIClient{content: `{"promQL":[],"content":"report"}`}).run(&model.Report{Id: "r1", Alert: alert, Attempts: 1})
+	if got := reports.updated[0]; got.Status != model.ReportStatusQueued || got.Result != nil {
+		t.Errorf("report = %+v, want queued for retry", got)
+	}
+
+	// 未超过最大尝试次数时重新排队
+	reports = &fakeReportModel{}
+	before := time.Now()
+	newTestQueue(reports, &fakeAIClient{err: errors.New("timeout")}).run(&model.Report{Id: "r2", Alert: alert, Attempts: 2})
+	got := reports.updated[0]
+	if got.Status != model.ReportStatusQueued || got.LastError != "timeout" {
+		t.Errorf("report = %+v, want queued for retry", got)
+	}
+	if got.NextRunAt.Before(before.Add(time.Minute)) {
+		t.Errorf("NextRunAt = %v, want backoff of 60s", got.NextRunAt)
+	}
+
+	// 达到最大尝试次数时失败
+	reports = &fakeReportModel{}
+	newTestQueue(reports, &fakeAIClient{err: errors.New("timeout")}).run(&model.Report{Id: "r3", Alert: alert, Attempts: 3})
+	if got := reports.updated[0]; got.Status != model.ReportStatusFailed || got.Content != "timeout" {
+		t.Errorf("report = %+v, want failed", got)
+	}
+}
+
+func TestQueueUsageAndBudget(t *testing.T) {
+	alert := &model.DiagnosisAlert{Alertname: "down"}
+	prices := []config.ModelPrice{
+		{Model: "default-model", PromptPrice: 10, CompletionPrice: 30},
+		{Model: "cheap", PromptPrice: 1, CompletionPrice: 1},
+	}
+
+	// 失败的尝试也累计用量和成本
+	reports := &fakeReportModel{}
+	q := newTestQueue(reports, &fakeAIClient{})
+	q.prices = newPriceTable(prices)
+	q.run(&model.Report{Id: "r1", Alert: alert, Attempts: 2, Usage: &model.ReportUsage{PromptTokens: 100, Cost: 0.001}})
+	got := reports.updated[0]
+	if got.Usage == nil || got.Usage.PromptTokens != 108 || got.Usage.CompletionTokens != 2 || got.Usage.ToolCalls != 1 {
+		t.Fatalf("usage = %+v, want accumulated", got.Usage)
+	}
+	if want := 0.001 + (8*10+2*30)/1e6; got.Usage.Cost < want-1e-12 || got.Usage.Cost > want+1e-12 {
+		t.Errorf("cost = %v, want %v", got.Usage.Cost, want)
+	}
+
+	// 超出预算时跳过
+	reports = &fakeReportModel{usage: []*model.ReportUsageStat{{AppId: "a1", Cost: 6}, {AppId: "a2", Cost: 5}}}
+	q = newTestQueue(reports, &fakeAIClient{})
+	q.budget = &budgetChecker{reportModel: reports, cfg: config.BudgetConfig{MonthlyLimit: 10, Action: BudgetActionSkip}}
+	q.run(&model.Report{Id: "r2", Alert: alert, Attempts: 1})
+	if got := reports.updated[0]; got.Status != model.ReportStatusSkipped || got.Usage != nil {
+		t.Errorf("report = %+v, want skipped", got)
+	}
+
+	// 超出预算时降级到配置的模型
+	reports = &fakeReportModel{usage: []*model.ReportUsageStat{{AppId: "a1", Cost: 11}}}
+	q = newTestQueue(reports, &fakeAIClient{err: errors.New("default model should not be used")})
+	q.prices = newPriceTable(prices)
+	q.clients["cheap"] = &fakeAIClient{}
+	q.budget = &budgetChecker{reportModel: reports, cfg: config.BudgetConfig{MonthlyLimit: 10, Action: BudgetActionDowngrade, DowngradeModel: "cheap"}}
+	q.run(&model.Report{Id: "r3", Alert: alert, Attempts: 1})
+	if got := reports.updated[0]; got.Status != model.ReportStatusCompleted || got.Model != "cheap" || got.Usage.Cost != 10/1e6 {
+		t.Errorf("report = %+v, want completed with downgraded model", got)
+	}
+}
+
+func TestBudgetChecker(t *testing.T) {
+	reports := &fakeReportModel{usage: []*model.ReportUsageStat{{AppId: "a1", Cost: 3}, {AppId: "a1", Cost: 2}, {AppId: "a2", Cost: 1}}}
+	budget := &budgetChecker{reportModel: reports, cfg: config.BudgetConfig{MonthlyLimit: 10, AppMonthlyLimit: 5}}
+	now := time.Now()
+
+	if reason, _ := budget.exceeded(context.Background(), "a1", now); reason == "" {
+		t.Error("app a1 should exceed its monthly budget")
+	}
+	if reason, _ := budget.exceeded(context.Background(), "a2", now); reason != "" {
+		t.Errorf("app a2 should be within budget, got %s", reason)
+	}
+	// 未配置预算时不查询用量
+	var empty *budgetChecker
+	if reason, err := empty.exceeded(context.Background(), "a1", now); reason != "" || err != nil {
+		t.Errorf("nil budget = %s, %v", reason, err)
+	}
+}
+
+func TestQueueBackoff(t *testing.T) {
+	q := newTestQueue(nil, nil)
+	tests := map[int]time.Duration{1: 30 * time.Second, 2: time.Minute, 3: 2 * time.Minute, 10: maxRetryBackoff}
+	for attempts, want := range tests {
+		if got := q.backoff(attempts); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
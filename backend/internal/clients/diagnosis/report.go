@@ -0,0 +1,118 @@
+package diagnosis
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/clients/prom"
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+)
+
+// maxRepairRounds 模型输出未通过校验时，要求其修正的最大次数
+const maxRepairRounds = 2
+
+// reportSchema 提示词中给出的输出格式
+const reportSchema = `{
+  "summary": "问题概述，一两句话说明告警反映的问题",
+  "rootCause": "根因分析，引用具体的指标数据和分析过程",
+  "impact": "影响范围，说明影响的系统范围和严重程度",
+  "remediation": ["解决步骤1", "解决步骤2"],
+  "suspects": [{"pr": 12, "file": "path/to/file.go", "description": "问题描述", "suggestion": "修复建议"}],
+  "evidence": [{"promQL": "支撑结论的查询语句", "observation": "查询到的关键现象，如峰值、变化时间点"}],
+  "confidence": 0.8
+}`
+
+// parseReport 从模型输出中取出 JSON 报告并严格校验，返回规范化后的 JSON 原文和结构化报告
+func parseReport(text string) (string, *model.DiagnosisResult, error) {
+	raw, err := extractReportJSON(text)
+	if err != nil {
+		return "", nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader([]byte(raw)))
+	decoder.DisallowUnknownFields()
+	var result model.DiagnosisResult
+	if err := decoder.Decode(&result); err != nil {
+		return "", nil, fmt.Errorf("报告格式错误: %w", err)
+	}
+	if err := validateReport(&result); err != nil {
+		return "", nil, err
+	}
+
+	data, err := json.Marshal(&result)
+	if err != nil {
+		return "", nil, err
+	}
+	return string(data), &result, nil
+}
+
+// validateReport 校验报告必填字段和取值范围，去掉空白项
+func validateReport(result *model.DiagnosisResult) error {
+	result.Summary = strings.TrimSpace(result.Summary)
+	result.RootCause = strings.TrimSpace(result.RootCause)
+	result.Impact = strings.TrimSpace(result.Impact)
+	if result.Summary == "" {
+		return fmt.Errorf("summary 不能为空")
+	}
+	if result.RootCause == "" {
+		return fmt.Errorf("rootCause 不能为空")
+	}
+	if result.Impact == "" {
+		return fmt.Errorf("impact 不能为空")
+	}
+
+	remediation := make([]string, 0, len(result.Remediation))
+	for _, step := range result.Remediation {
+		if step = strings.TrimSpace(step); step != "" {
+			remediation = append(remediation, step)
+		}
+	}
+	if len(remediation) == 0 {
+		return fmt.Errorf("remediation 至少包含一个解决步骤")
+	}
+	result.Remediation = remediation
+
+	if result.Suspects == nil {
+		result.Suspects = []model.SuspectChange{}
+	}
+	for i, suspect := range result.Suspects {
+		if suspect.PR < 0 {
+			return fmt.Errorf("suspects[%d].pr 不能为负数", i)
+		}
+		if suspect.PR == 0 && strings.TrimSpace(suspect.File) == "" {
+			return fmt.Errorf("suspects[%d] 需要 pr 或 file", i)
+		}
+		if strings.TrimSpace(suspect.Description) == "" {
+			return fmt.Errorf("suspects[%d].description 不能为空", i)
+		}
+	}
+
+	if result.Evidence == nil {
+		result.Evidence = []model.DiagnosisEvidence{}
+	}
+	for i := range result.Evidence {
+		evidence := &result.Evidence[i]
+		evidence.PromQL = strings.TrimSpace(evidence.PromQL)
+		if evidence.PromQL == "" {
+			return fmt.Errorf("evidence[%d].promQL 不能为空", i)
+		}
+		if err := prom.ValidateExpr(evidence.PromQL); err != nil {
+			return fmt.Errorf("evidence[%d].promQL 语法错误: %v", i, err)
+		}
+	}
+
+	if result.Confidence < 0 || result.Confidence > 1 {
+		return fmt.Errorf("confidence 应在 0 到 1 之间，当前为 %v", result.Confidence)
+	}
+	return nil
+}
+
+// repairPrompt 报告未通过校验时发给模型的修正要求
+func repairPrompt(err error) string {
+	return fmt.Sprintf(`你输出的报告未通过校验：%v
+
+请修正后重新输出，只输出一个 JSON 对象，不要使用 markdown 代码块或附加说明，字段必须与以下格式完全一致：
+%s`, err, reportSchema)
+}
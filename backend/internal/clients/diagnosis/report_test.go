@@ -0,0 +1,59 @@
+package diagnosis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseReport(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		wantErr string
+	}{
+		{
+			name: "valid with code fence",
+			text: "```json\n" + testReport + "\n```",
+		},
+		{
+			name:    "legacy format",
+			text:    `{"promQL":["up"],"content":"报告内容"}`,
+			wantErr: "unknown field",
+		},
+		{
+			name:    "missing remediation",
+			text:    `{"summary":"a","rootCause":"b","impact":"c","remediation":[" "],"confidence":0.5}`,
+			wantErr: "remediation",
+		},
+		{
+			name:    "invalid promQL",
+			text:    `{"summary":"a","rootCause":"b","impact":"c","remediation":["d"],"evidence":[{"promQL":"rate(x[5m]"}],"confidence":0.5}`,
+			wantErr: "evidence[0].promQL",
+		},
+		{
+			name:    "suspect without pr or file",
+			text:    `{"summary":"a","rootCause":"b","impact":"c","remediation":["d"],"suspects":[{"description":"e"}],"confidence":0.5}`,
+			wantErr: "suspects[0]",
+		},
+		{
+			name:    "confidence out of range",
+			text:    `{"summary":"a","rootCause":"b","impact":"c","remediation":["d"],"confidence":80}`,
+			wantErr: "confidence",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, result, err := parseReport(tt.text)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, testReport, raw)
+			assert.Equal(t, "实例宕机", result.Summary)
+			assert.Equal(t, []string{"重启服务"}, result.Remediation)
+		})
+	}
+}
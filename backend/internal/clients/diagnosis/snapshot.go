@@ -0,0 +1,100 @@
+package diagnosis
+
+import (
+	"time"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/clients/prom"
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+)
+
+const (
+	// snapshotLookback 快照从告警开始前多久开始，便于对比告警前后的变化
+	snapshotLookback = 30 * time.Minute
+	// maxSnapshotWindow 快照最长时间范围，告警持续更久时只保留结束前的部分
+	maxSnapshotWindow = 6 * time.Hour
+	// maxSnapshotPoints 每条时序最多保存的数据点数，据此计算采样间隔
+	maxSnapshotPoints = 240
+	minSnapshotStep   = 15 * time.Second
+	// maxSnapshotSeries 每条查询最多保存的时序数，避免报告文档过大
+	maxSnapshotSeries = 20
+)
+
+// captureEvidence 按告警时间窗口查询报告中引用的每条 PromQL 并保存到报告中，单条查询失败只记录错误
+func captureEvidence(promClient prom.VMClient, alert *model.DiagnosisAlert, result *model.DiagnosisResult, now time.Time) {
+	start, end, step := snapshotWindow(alert, now)
+	for i := range result.Evidence {
+		evidence := &result.Evidence[i]
+		snapshot := &model.EvidenceSnapshot{
+			Start:  start,
+			End:    end,
+			Step:   int(step / time.Second),
+			Series: []model.EvidenceSeries{},
+		}
+		evidence.Snapshot = snapshot
+
+		series, err := promClient.QueryRange(evidence.PromQL, start, end, step)
+		if err != nil {
+			snapshot.Error = err.Error()
+			continue
+		}
+		if len(series) > maxSnapshotSeries {
+			series = series[:maxSnapshotSeries]
+		}
+		for _, s := range series {
+			points := make([]model.EvidencePoint, 0, len(s.Values))
+			for _, sample := range s.Values {
+				points = append(points, model.EvidencePoint{Timestamp: sample.Timestamp, Value: sample.Value})
+			}
+			snapshot.Series = append(snapshot.Series, model.EvidenceSeries{
+				Instance: seriesInstance(s.Metric),
+				Labels:   s.Metric,
+				Points:   points,
+			})
+		}
+	}
+}
+
+// snapshotWindow 快照的时间范围：告警开始前 snapshotLookback 到告警结束，告警未结束时到当前时间
+func snapshotWindow(alert *model.DiagnosisAlert, now time.Time) (time.Time, time.Time, time.Duration) {
+	startsAt := parseRFC3339(alert.StartsAt)
+	if startsAt.IsZero() {
+		startsAt = parseRFC3339(alert.ReceiveAt)
+	}
+	if startsAt.IsZero() || startsAt.After(now) {
+		startsAt = now
+	}
+
+	end := parseRFC3339(alert.EndsAt)
+	// Alertmanager 对未结束的告警 endsAt 为零值或预计的过期时间
+	if end.IsZero() || end.Before(startsAt) || end.After(now) {
+		end = now
+	}
+	start := startsAt.Add(-snapshotLookback)
+	if end.Sub(start) > maxSnapshotWindow {
+		start = end.Add(-maxSnapshotWindow)
+	}
+
+	step := (end.Sub(start) / maxSnapshotPoints).Truncate(time.Second)
+	if step < minSnapshotStep {
+		step = minSnapshotStep
+	}
+	return start, end, step
+}
+
+func parseRFC3339(value string) time.Time {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil || t.Year() <= 1 {
+		return time.Time{}
+	}
+	return t
+}
+
+// seriesInstance 时序所属的机器，与监控查询接口的取值方式一致
+func seriesInstance(metric map[string]string) string {
+	for _, label := range []string{"hostname", "instance", "host"} {
+		if value := metric[label]; value != "" {
+			return value
+		}
+	}
+	return "unknown"
+}
@@ -0,0 +1,57 @@
+package diagnosis
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/clients/prom"
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+)
+
+func TestSnapshotWindow(t *testing.T) {
+	now := time.Date(2025, 1, 15, 15, 0, 0, 0, time.UTC)
+
+	// 告警已结束：告警开始前 30 分钟到结束
+	start, end, step := snapshotWindow(&model.DiagnosisAlert{StartsAt: "2025-01-15T14:00:00Z", EndsAt: "2025-01-15T14:30:00Z"}, now)
+	assert.Equal(t, time.Date(2025, 1, 15, 13, 30, 0, 0, time.UTC), start)
+	assert.Equal(t, time.Date(2025, 1, 15, 14, 30, 0, 0, time.UTC), end)
+	assert.Equal(t, 15*time.Second, step)
+
+	// 告警未结束：Alertmanager 的零值 endsAt 取当前时间
+	_, end, _ = snapshotWindow(&model.DiagnosisAlert{StartsAt: "2025-01-15T14:00:00Z", EndsAt: "0001-01-01T00:00:00Z"}, now)
+	assert.Equal(t, now, end)
+
+	// 超过最长时间范围时只保留结束前的部分
+	start, end, step = snapshotWindow(&model.DiagnosisAlert{StartsAt: "2025-01-14T00:00:00Z"}, now)
+	assert.Equal(t, maxSnapshotWindow, end.Sub(start))
+	assert.Equal(t, 90*time.Second, step)
+}
+
+func TestCaptureEvidence(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/query_range", r.URL.Path)
+		if r.URL.Query().Get("query") == "bad" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"hostname":"host-1"},"values":[[1736949600,"0.5"],[1736949615,"0.9"]]}]}}`))
+	}))
+	defer server.Close()
+
+	result := &model.DiagnosisResult{Evidence: []model.DiagnosisEvidence{{PromQL: "cpu"}, {PromQL: "bad"}}}
+	alert := &model.DiagnosisAlert{StartsAt: "2025-01-15T14:00:00Z"}
+	captureEvidence(prom.NewVMClient(prom.NewDefaultConfig(server.URL)), alert, result, time.Date(2025, 1, 15, 14, 10, 0, 0, time.UTC))
+
+	snapshot := result.Evidence[0].Snapshot
+	assert.Empty(t, snapshot.Error)
+	assert.Len(t, snapshot.Series, 1)
+	assert.Equal(t, "host-1", snapshot.Series[0].Instance)
+	assert.Equal(t, []model.EvidencePoint{{Timestamp: 1736949600, Value: 0.5}, {Timestamp: 1736949615, Value: 0.9}}, snapshot.Series[0].Points)
+
+	assert.NotEmpty(t, result.Evidence[1].Snapshot.Error)
+	assert.Empty(t, result.Evidence[1].Snapshot.Series)
+}
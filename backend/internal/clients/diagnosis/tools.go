@@ -0,0 +1,329 @@
+package diagnosis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/clients/prom"
+)
+
+// Tool 提供给模型调用的工具，InputSchema 为参数的 JSON Schema
+type Tool struct {
+	Name        string
+	Description string
+	InputSchema map[string]interface{}
+	Call        func(ctx context.Context, args json.RawMessage) (string, error)
+}
+
+// objectSchema 构建 object 类型的参数 Schema，properties 的值为参数说明，均为字符串类型
+func objectSchema(properties map[string]string, required ...string) map[string]interface{} {
+	props := make(map[string]interface{}, len(properties))
+	for name, desc := range properties {
+		props[name] = map[string]interface{}{"type": "string", "description": desc}
+	}
+	if required == nil {
+		required = []string{}
+	}
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": props,
+		"required":   required,
+	}
+}
+
+func toJSON(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// prometheusTools 基于 VMClient 的指标查询工具
+func prometheusTools(client prom.VMClient) []Tool {
+	return []Tool{
+		{
+			Name:        "execute_query",
+			Description: "执行 PromQL 即时查询，返回每条时间序列的标签和当前值",
+			InputSchema: objectSchema(map[string]string{"query": "PromQL 查询语句"}, "query"),
+			Call: func(ctx context.Context, args json.RawMessage) (string, error) {
+				var in struct {
+					Query string `json:"query"`
+				}
+				if err := json.Unmarshal(args, &in); err != nil {
+					return "", fmt.Errorf("参数解析失败: %w", err)
+				}
+				if in.Query == "" {
+					return "", fmt.Errorf("query 不能为空")
+				}
+				results, err := client.QueryInstant(in.Query)
+				if err != nil {
+					return "", err
+				}
+				return toJSON(results)
+			},
+		},
+		{
+			Name:        "execute_range_query",
+			Description: "执行 PromQL 范围查询，获取一段时间内的趋势数据。start、end 为 RFC3339 时间或 Unix 秒，默认最近一小时；step 如 30s、1m，默认 1m",
+			InputSchema: objectSchema(map[string]string{
+				"query": "PromQL 查询语句",
+				"start": "开始时间",
+				"end":   "结束时间",
+				"step":  "查询步长",
+			}, "query"),
+			Call: func(ctx context.Context, args json.RawMessage) (string, error) {
+				var in struct {
+					Query string `json:"query"`
+					Start string `json:"start"`
+					End   string `json:"end"`
+					Step  string `json:"step"`
+				}
+				if err := json.Unmarshal(args, &in); err != nil {
+					return "", fmt.Errorf("参数解析失败: %w", err)
+				}
+				if in.Query == "" {
+					return "", fmt.Errorf("query 不能为空")
+				}
+				now := time.Now()
+				end, err := parseToolTime(in.End, now)
+				if err != nil {
+					return "", fmt.Errorf("end 格式错误: %w", err)
+				}
+				start, err := parseToolTime(in.Start, end.Add(-time.Hour))
+				if err != nil {
+					return "", fmt.Errorf("start 格式错误: %w", err)
+				}
+				step := time.Minute
+				if in.Step != "" {
+					if step, err = time.ParseDuration(in.Step); err != nil || step <= 0 {
+						return "", fmt.Errorf("step 格式错误: %s", in.Step)
+					}
+				}
+				results, err := client.QueryRange(in.Query, start, end, step)
+				if err != nil {
+					return "", err
+				}
+				return toJSON(results)
+			},
+		},
+		{
+			Name:        "list_metrics",
+			Description: "列出可用的指标名称，match 为指标名称的正则表达式，不传时列出全部",
+			InputSchema: objectSchema(map[string]string{"match": "指标名称正则，如 go_.*"}),
+			Call: func(ctx context.Context, args json.RawMessage) (string, error) {
+				var in struct {
+					Match string `json:"match"`
+				}
+				if err := json.Unmarshal(args, &in); err != nil {
+					return "", fmt.Errorf("参数解析失败: %w", err)
+				}
+				if in.Match == "" {
+					in.Match = ".+"
+				}
+				results, err := client.QueryInstant(fmt.Sprintf("group by (__name__) ({__name__=~%s})", strconv.Quote(in.Match)))
+				if err != nil {
+					return "", err
+				}
+				names := make([]string, 0, len(results))
+				for _, result := range results {
+					names = append(names, result.Metric["__name__"])
+				}
+				return toJSON(names)
+			},
+		},
+		{
+			Name:        "get_targets",
+			Description: "查看抓取目标状态，返回每个 job 和 instance 是否在线",
+			InputSchema: objectSchema(map[string]string{}),
+			Call: func(ctx context.Context, args json.RawMessage) (string, error) {
+				results, err := client.QueryInstant("up")
+				if err != nil {
+					return "", err
+				}
+				type target struct {
+					Job      string `json:"job"`
+					Instance string `json:"instance"`
+					Up       bool   `json:"up"`
+				}
+				targets := make([]target, 0, len(results))
+				for _, result := range results {
+					targets = append(targets, target{Job: result.Metric["job"], Instance: result.Metric["instance"], Up: result.Value.Value == 1})
+				}
+				return toJSON(targets)
+			},
+		},
+	}
+}
+
+// parseToolTime 解析 RFC3339 时间或 Unix 秒，为空时返回默认值
+func parseToolTime(value string, def time.Time) (time.Time, error) {
+	if value == "" {
+		return def, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	sec, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, int64(sec*float64(time.Second))), nil
+}
+
+// githubClient GitHub REST API 客户端，Token 只放在请求头中
+type githubClient struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+func (c *githubClient) get(ctx context.Context, path, accept string) (string, error) {
+	baseURL := c.baseURL
+	if baseURL == "" {
+		baseURL = "https://api.github.com"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(baseURL, "/")+path, nil)
+	if err != nil {
+		return "", err
+	}
+	if accept == "" {
+		accept = "application/vnd.github+json"
+	}
+	req.Header.Set("Accept", accept)
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("status %d: %s", resp.StatusCode, truncate(string(body), maxErrorBodyLen))
+	}
+	return string(body), nil
+}
+
+type githubRepoArgs struct {
+	Owner      string      `json:"owner"`
+	Repo       string      `json:"repo"`
+	Tag        string      `json:"tag"`
+	Method     string      `json:"method"`
+	PullNumber json.Number `json:"pullNumber"`
+}
+
+// parseRepoArgs 解析仓库参数，repo 为完整地址（如 https://github.com/owner/repo）时从中拆出 owner
+func parseRepoArgs(args json.RawMessage) (*githubRepoArgs, error) {
+	var in githubRepoArgs
+	if err := json.Unmarshal(args, &in); err != nil {
+		return nil, fmt.Errorf("参数解析失败: %w", err)
+	}
+	in.Owner, in.Repo = splitRepo(in.Owner, in.Repo)
+	if in.Owner == "" || in.Repo == "" {
+		return nil, fmt.Errorf("owner 和 repo 不能为空")
+	}
+	return &in, nil
+}
+
+// splitRepo repo 可以是仓库名、owner/repo 或完整的仓库地址（包括 git@github.com:owner/repo.git）
+func splitRepo(owner, repo string) (string, string) {
+	repo = strings.TrimSuffix(strings.TrimSuffix(strings.TrimSpace(repo), "/"), ".git")
+	repo = strings.ReplaceAll(repo, ":", "/")
+	if strings.Contains(repo, "/") {
+		parts := strings.Split(repo, "/")
+		owner, repo = parts[len(parts)-2], parts[len(parts)-1]
+	}
+	return owner, repo
+}
+
+var githubRepoSchema = map[string]string{
+	"owner": "仓库所有者",
+	"repo":  "仓库名称",
+}
+
+// githubTools 按工具集启用 GitHub 工具，目前支持 releases 和 pull_requests
+func githubTools(client *githubClient, toolsets string) []Tool {
+	enabled := make(map[string]bool)
+	for _, name := range strings.Split(toolsets, ",") {
+		enabled[strings.TrimSpace(name)] = true
+	}
+
+	tools := make([]Tool, 0)
+	if enabled["releases"] {
+		tools = append(tools,
+			Tool{
+				Name:        "get_release_by_tag",
+				Description: "按 tag 获取 GitHub 仓库的 release，返回内容中的 body 包含发布说明",
+				InputSchema: objectSchema(map[string]string{"owner": githubRepoSchema["owner"], "repo": githubRepoSchema["repo"], "tag": "release 的 tag"}, "owner", "repo", "tag"),
+				Call: func(ctx context.Context, args json.RawMessage) (string, error) {
+					in, err := parseRepoArgs(args)
+					if err != nil {
+						return "", err
+					}
+					if in.Tag == "" {
+						return "", fmt.Errorf("tag 不能为空")
+					}
+					return client.get(ctx, fmt.Sprintf("/repos/%s/%s/releases/tags/%s", in.Owner, in.Repo, in.Tag), "")
+				},
+			},
+			Tool{
+				Name:        "get_latest_release",
+				Description: "获取 GitHub 仓库最新的 release",
+				InputSchema: objectSchema(githubRepoSchema, "owner", "repo"),
+				Call: func(ctx context.Context, args json.RawMessage) (string, error) {
+					in, err := parseRepoArgs(args)
+					if err != nil {
+						return "", err
+					}
+					return client.get(ctx, fmt.Sprintf("/repos/%s/%s/releases/latest", in.Owner, in.Repo), "")
+				},
+			},
+		)
+	}
+	if enabled["pull_requests"] {
+		tools = append(tools, Tool{
+			Name:        "pull_request_read",
+			Description: "读取 GitHub PR：method 为 get 时返回 PR 信息，get_files 返回变更文件列表，get_diff 返回代码 diff",
+			InputSchema: objectSchema(map[string]string{
+				"owner":      githubRepoSchema["owner"],
+				"repo":       githubRepoSchema["repo"],
+				"method":     "get、get_files 或 get_diff",
+				"pullNumber": "PR 编号",
+			}, "owner", "repo", "method", "pullNumber"),
+			Call: func(ctx context.Context, args json.RawMessage) (string, error) {
+				in, err := parseRepoArgs(args)
+				if err != nil {
+					return "", err
+				}
+				number, err := strconv.Atoi(in.PullNumber.String())
+				if err != nil || number <= 0 {
+					return "", fmt.Errorf("pullNumber 格式错误: %s", in.PullNumber)
+				}
+				path := fmt.Sprintf("/repos/%s/%s/pulls/%d", in.Owner, in.Repo, number)
+				switch in.Method {
+				case "get", "":
+					return client.get(ctx, path, "")
+				case "get_files":
+					return client.get(ctx, path+"/files", "")
+				case "get_diff":
+					return client.get(ctx, path, "application/vnd.github.v3.diff")
+				default:
+					return "", fmt.Errorf("不支持的 method: %s", in.Method)
+				}
+			},
+		})
+	}
+	return tools
+}
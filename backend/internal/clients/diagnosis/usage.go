@@ -0,0 +1,113 @@
+package diagnosis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/config"
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+)
+
+const (
+	BudgetActionSkip      = "skip"      // 超出预算后跳过诊断
+	BudgetActionDowngrade = "downgrade" // 超出预算后使用降级模型
+)
+
+// Usage 一次诊断的模型用量，失败时也返回已消耗的部分
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+	ToolCalls        int
+}
+
+func (u *Usage) add(other Usage) {
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.ToolCalls += other.ToolCalls
+}
+
+// TotalTokens 输入和输出 token 总数
+func (u Usage) TotalTokens() int {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// priceTable 按模型名称查找价格
+type priceTable map[string]config.ModelPrice
+
+func newPriceTable(prices []config.ModelPrice) priceTable {
+	table := make(priceTable, len(prices))
+	for _, price := range prices {
+		table[price.Model] = price
+	}
+	return table
+}
+
+// cost 按每百万 token 价格计算成本，模型不在价格表中时为 0
+func (t priceTable) cost(modelName string, usage Usage) float64 {
+	price, ok := t[modelName]
+	if !ok {
+		return 0
+	}
+	return (float64(usage.PromptTokens)*price.PromptPrice + float64(usage.CompletionTokens)*price.CompletionPrice) / 1e6
+}
+
+// record 把一次调用的用量和成本累加到报告上，重试的消耗同样计入
+func (t priceTable) record(report *model.Report, usage Usage) {
+	if usage == (Usage{}) {
+		return
+	}
+	if report.Usage == nil {
+		report.Usage = &model.ReportUsage{}
+	}
+	report.Usage.PromptTokens += usage.PromptTokens
+	report.Usage.CompletionTokens += usage.CompletionTokens
+	report.Usage.ToolCalls += usage.ToolCalls
+	report.Usage.Cost += t.cost(report.Model, usage)
+}
+
+// MonthStart 当前自然月的开始时间，预算和用量指标按自然月统计
+func MonthStart(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+}
+
+// budgetChecker 检查本月诊断成本是否超出预算，成本从报告记录中汇总，多个实例共享
+type budgetChecker struct {
+	reportModel model.ReportModel
+	cfg         config.BudgetConfig
+}
+
+// exceeded 返回超出的预算说明，未超出时为空
+func (b *budgetChecker) exceeded(ctx context.Context, appId string, now time.Time) (string, error) {
+	if b == nil || (b.cfg.MonthlyLimit <= 0 && b.cfg.AppMonthlyLimit <= 0) {
+		return "", nil
+	}
+
+	stats, err := b.reportModel.UsageStats(ctx, MonthStart(now))
+	if err != nil {
+		return "", err
+	}
+	var total, app float64
+	for _, stat := range stats {
+		total += stat.Cost
+		if appId != "" && stat.AppId == appId {
+			app += stat.Cost
+		}
+	}
+
+	if b.cfg.MonthlyLimit > 0 && total >= b.cfg.MonthlyLimit {
+		return fmt.Sprintf("本月诊断成本 %.4f 已达到预算 %.4f", total, b.cfg.MonthlyLimit), nil
+	}
+	if b.cfg.AppMonthlyLimit > 0 && appId != "" && app >= b.cfg.AppMonthlyLimit {
+		return fmt.Sprintf("应用本月诊断成本 %.4f 已达到预算 %.4f", app, b.cfg.AppMonthlyLimit), nil
+	}
+	return "", nil
+}
+
+// downgradeModel 超出预算时使用的模型，为空表示跳过诊断
+func (b *budgetChecker) downgradeModel() string {
+	if b.cfg.Action != BudgetActionDowngrade {
+		return ""
+	}
+	return b.cfg.DowngradeModel
+}
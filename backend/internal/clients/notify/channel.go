@@ -0,0 +1,258 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/config"
+)
+
+// maxResponseLen 读取的机器人响应最大长度
+const maxResponseLen = 4096
+
+// postJSON 发送 JSON 请求，非 2xx 响应视为失败，返回响应体
+func postJSON(ctx context.Context, client *http.Client, target string, payload interface{}) ([]byte, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseLen))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return respBody, nil
+}
+
+// botResult 钉钉、企业微信、飞书机器人的响应，HTTP 200 时仍可能返回错误码
+type botResult struct {
+	ErrCode *int   `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+	Code    *int   `json:"code"`
+	Msg     string `json:"msg"`
+}
+
+func checkBotResult(body []byte) error {
+	var result botResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("解析机器人响应失败: %w", err)
+	}
+	if result.ErrCode != nil && *result.ErrCode != 0 {
+		return fmt.Errorf("机器人返回错误 %d: %s", *result.ErrCode, result.ErrMsg)
+	}
+	if result.Code != nil && *result.Code != 0 {
+		return fmt.Errorf("机器人返回错误 %d: %s", *result.Code, result.Msg)
+	}
+	return nil
+}
+
+func hmacBase64(key, data string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(data))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// webhookSender 通用 webhook，请求体包含渲染后的标题、正文和原始事件
+type webhookSender struct {
+	url    string
+	client *http.Client
+}
+
+func (s *webhookSender) send(ctx context.Context, msg *Message) error {
+	_, err := postJSON(ctx, s.client, s.url, map[string]interface{}{
+		"title": msg.Title,
+		"text":  msg.Text,
+		"event": msg.Event,
+	})
+	return err
+}
+
+// dingTalkSender 钉钉群机器人 markdown 消息，配置密钥时按加签方式在地址上附加 timestamp 和 sign
+type dingTalkSender struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+func (s *dingTalkSender) send(ctx context.Context, msg *Message) error {
+	target := s.url
+	if s.secret != "" {
+		timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+		sign := hmacBase64(s.secret, timestamp+"\n"+s.secret)
+		separator := "?"
+		if strings.Contains(target, "?") {
+			separator = "&"
+		}
+		target += separator + "timestamp=" + timestamp + "&sign=" + url.QueryEscape(sign)
+	}
+	body, err := postJSON(ctx, s.client, target, map[string]interface{}{
+		"msgtype": "markdown",
+		"markdown": map[string]string{
+			"title": msg.Title,
+			"text":  "### " + msg.Title + "\n\n" + markdownLines(msg.Text),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return checkBotResult(body)
+}
+
+// weComSender 企业微信群机器人 markdown 消息
+type weComSender struct {
+	url    string
+	client *http.Client
+}
+
+func (s *weComSender) send(ctx context.Context, msg *Message) error {
+	body, err := postJSON(ctx, s.client, s.url, map[string]interface{}{
+		"msgtype": "markdown",
+		"markdown": map[string]string{
+			"content": "### " + msg.Title + "\n" + msg.Text,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return checkBotResult(body)
+}
+
+// feishuSender 飞书群机器人文本消息，配置密钥时在请求体中附加 timestamp 和 sign
+type feishuSender struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+func (s *feishuSender) send(ctx context.Context, msg *Message) error {
+	payload := map[string]interface{}{
+		"msg_type": "text",
+		"content": map[string]string{
+			"text": msg.Title + "\n" + msg.Text,
+		},
+	}
+	if s.secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		// 飞书以 timestamp + "\n" + secret 为密钥对空串签名
+		payload["timestamp"] = timestamp
+		payload["sign"] = hmacBase64(timestamp+"\n"+s.secret, "")
+	}
+	body, err := postJSON(ctx, s.client, s.url, payload)
+	if err != nil {
+		return err
+	}
+	return checkBotResult(body)
+}
+
+// slackSender Slack 兼容的 incoming webhook，Mattermost、Rocket.Chat 等使用相同格式
+type slackSender struct {
+	url    string
+	client *http.Client
+}
+
+func (s *slackSender) send(ctx context.Context, msg *Message) error {
+	_, err := postJSON(ctx, s.client, s.url, map[string]string{
+		"text": "*" + msg.Title + "*\n" + msg.Text,
+	})
+	return err
+}
+
+// emailSender 通过 SMTP 发送纯文本邮件
+type emailSender struct {
+	cfg     config.SMTPConfig
+	timeout time.Duration
+}
+
+func (s *emailSender) send(ctx context.Context, msg *Message) error {
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+	dialer := &net.Dialer{Timeout: s.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(s.timeout))
+
+	client, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.cfg.Host}); err != nil {
+			return err
+		}
+	}
+	if s.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(s.cfg.From); err != nil {
+		return err
+	}
+	for _, to := range s.cfg.To {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(buildMail(s.cfg.From, s.cfg.To, msg)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func buildMail(from string, to []string, msg *Message) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("From: " + from + "\r\n")
+	buf.WriteString("To: " + strings.Join(to, ", ") + "\r\n")
+	buf.WriteString("Subject: " + mime.BEncoding.Encode("UTF-8", msg.Title) + "\r\n")
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+	encoded := base64.StdEncoding.EncodeToString([]byte(msg.Text))
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded + "\r\n")
+	return buf.Bytes()
+}
+
+// markdownLines 钉钉 markdown 需要空行才能换行
+func markdownLines(text string) string {
+	return strings.ReplaceAll(text, "\n", "\n\n")
+}
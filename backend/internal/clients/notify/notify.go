@@ -0,0 +1,354 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/config"
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+)
+
+const (
+	ChannelWebhook  = "webhook"  // 通用 webhook，POST JSON
+	ChannelDingTalk = "dingtalk" // 钉钉群机器人
+	ChannelWeCom    = "wecom"    // 企业微信群机器人
+	ChannelFeishu   = "feishu"   // 飞书群机器人
+	ChannelSlack    = "slack"    // Slack 兼容的 incoming webhook
+	ChannelEmail    = "email"    // SMTP 邮件
+
+	// maxRetryBackoff 重试间隔上限
+	maxRetryBackoff = 5 * time.Minute
+)
+
+// eventNames 事件类型的中文名称，用于默认标题
+var eventNames = map[model.NotifyEventType]string{
+	model.NotifyEventDeploymentCreated:    "创建发布单",
+	model.NotifyEventDeploymentStarted:    "开始发布",
+	model.NotifyEventBatchCompleted:       "批次完成",
+	model.NotifyEventDeploymentSucceeded:  "发布成功",
+	model.NotifyEventDeploymentFailed:     "发布失败",
+	model.NotifyEventRollbackTriggered:    "自动回滚",
+	model.NotifyEventCanaryFailed:         "灰度不通过",
+	model.NotifyEventDeploymentRolledBack: "已回滚",
+	model.NotifyEventReportCompleted:      "诊断报告",
+}
+
+// ValidEventType 是否为支持的通知事件类型
+func ValidEventType(t model.NotifyEventType) bool {
+	_, ok := eventNames[t]
+	return ok
+}
+
+// Event 一条通知事件，也是消息模板中可用的变量
+type Event struct {
+	Type         model.NotifyEventType `json:"type"`                // 事件类型
+	AppId        string                `json:"app_id"`              // 应用ID，按应用的通知路由选择渠道
+	AppName      string                `json:"app_name"`            // 应用名称
+	DeploymentId string                `json:"deployment_id"`       // 发布单ID
+	Version      string                `json:"version"`             // 发布版本
+	Status       string                `json:"status"`              // 发布单状态
+	Message      string                `json:"message"`             // 事件说明，如失败原因、触发回滚的告警
+	ReportId     string                `json:"report_id,omitempty"` // 诊断报告ID
+	Summary      string                `json:"summary,omitempty"`   // 诊断报告的问题概述
+	Nodes        []string              `json:"nodes,omitempty"`     // 批次完成事件中本批发布的机器
+	Time         time.Time             `json:"time"`                // 事件时间
+}
+
+// Name 事件类型的中文名称
+func (e *Event) Name() string {
+	if name, ok := eventNames[e.Type]; ok {
+		return name
+	}
+	return string(e.Type)
+}
+
+// DeploymentEvent 按发布单组装通知事件
+func DeploymentEvent(eventType model.NotifyEventType, deployment *model.Deployment, message string) Event {
+	return Event{
+		Type:         eventType,
+		AppId:        deployment.AppId,
+		AppName:      deployment.AppName,
+		DeploymentId: deployment.Id,
+		Version:      deployment.PackageVersion,
+		Status:       string(deployment.Status),
+		Message:      message,
+		Time:         time.Now(),
+	}
+}
+
+// Message 渲染后的通知消息
+type Message struct {
+	Title string
+	Text  string
+	Event *Event
+}
+
+const defaultTitle = `【{{.Name}}】{{.AppName}} {{.Version}}`
+
+const defaultTemplate = `应用: {{.AppName}}
+版本: {{.Version}}
+发布单: {{.DeploymentId}}
+{{- if .Status}}
+状态: {{.Status}}
+{{- end}}
+{{- if .Message}}
+说明: {{.Message}}
+{{- end}}
+{{- if .Summary}}
+诊断结论: {{.Summary}}
+{{- end}}
+时间: {{.Time.Format "2006-01-02 15:04:05"}}`
+
+// sender 一类渠道的发送实现
+type sender interface {
+	send(ctx context.Context, msg *Message) error
+}
+
+type channel struct {
+	name   string
+	sender sender
+	title  *template.Template
+	text   *template.Template
+}
+
+func (c *channel) render(event *Event) (*Message, error) {
+	var title, text bytes.Buffer
+	if err := c.title.Execute(&title, event); err != nil {
+		return nil, fmt.Errorf("标题模板渲染失败: %w", err)
+	}
+	if err := c.text.Execute(&text, event); err != nil {
+		return nil, fmt.Errorf("正文模板渲染失败: %w", err)
+	}
+	return &Message{Title: title.String(), Text: text.String(), Event: event}, nil
+}
+
+// Subscriber 接收所有事件的订阅方，如外部系统的 webhook 订阅，不经过应用的通知路由。Publish 不能阻塞
+type Subscriber interface {
+	Publish(event Event)
+}
+
+// Notifier 按应用的通知路由把发布事件发送到配置的渠道，发送异步进行，失败按退避重试
+type Notifier struct {
+	applicationModel model.ApplicationModel
+	channels         map[string]*channel
+	subscribers      []Subscriber
+	maxAttempts      int
+	retryBackoff     time.Duration
+	wg               sync.WaitGroup
+	logx.Logger
+}
+
+// NewNotifier 创建通知器，渠道类型或模板配置错误时返回错误
+func NewNotifier(cfg config.NotifyConfig, applicationModel model.ApplicationModel) (*Notifier, error) {
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	n := &Notifier{
+		applicationModel: applicationModel,
+		channels:         make(map[string]*channel, len(cfg.Channels)),
+		maxAttempts:      maxAttempts,
+		retryBackoff:     time.Duration(cfg.RetryBackoff) * time.Second,
+		Logger:           logx.WithContext(context.Background()),
+	}
+
+	httpClient := &http.Client{Timeout: timeout}
+	for _, item := range cfg.Channels {
+		if item.Name == "" {
+			return nil, fmt.Errorf("通知渠道名称不能为空")
+		}
+		if _, ok := n.channels[item.Name]; ok {
+			return nil, fmt.Errorf("通知渠道 %s 重复", item.Name)
+		}
+		s, err := newSender(item, httpClient, timeout)
+		if err != nil {
+			return nil, fmt.Errorf("通知渠道 %s: %w", item.Name, err)
+		}
+		c := &channel{name: item.Name, sender: s}
+		if c.title, err = parseTemplate(item.Title, defaultTitle); err != nil {
+			return nil, fmt.Errorf("通知渠道 %s 标题模板解析失败: %w", item.Name, err)
+		}
+		if c.text, err = parseTemplate(item.Template, defaultTemplate); err != nil {
+			return nil, fmt.Errorf("通知渠道 %s 正文模板解析失败: %w", item.Name, err)
+		}
+		n.channels[item.Name] = c
+	}
+	return n, nil
+}
+
+// MustNewNotifier 创建通知器，配置错误时退出
+func MustNewNotifier(cfg config.NotifyConfig, applicationModel model.ApplicationModel) *Notifier {
+	n, err := NewNotifier(cfg, applicationModel)
+	logx.Must(err)
+	return n
+}
+
+func parseTemplate(content, fallback string) (*template.Template, error) {
+	if content == "" {
+		content = fallback
+	}
+	return template.New("notify").Option("missingkey=zero").Parse(content)
+}
+
+func newSender(cfg config.NotifyChannelConfig, httpClient *http.Client, timeout time.Duration) (sender, error) {
+	if cfg.Type != ChannelEmail && cfg.URL == "" {
+		return nil, fmt.Errorf("%s 渠道需要配置 URL", cfg.Type)
+	}
+	switch cfg.Type {
+	case ChannelWebhook:
+		return &webhookSender{url: cfg.URL, client: httpClient}, nil
+	case ChannelDingTalk:
+		return &dingTalkSender{url: cfg.URL, secret: cfg.Secret, client: httpClient}, nil
+	case ChannelWeCom:
+		return &weComSender{url: cfg.URL, client: httpClient}, nil
+	case ChannelFeishu:
+		return &feishuSender{url: cfg.URL, secret: cfg.Secret, client: httpClient}, nil
+	case ChannelSlack:
+		return &slackSender{url: cfg.URL, client: httpClient}, nil
+	case ChannelEmail:
+		if cfg.SMTP.Host == "" || cfg.SMTP.From == "" || len(cfg.SMTP.To) == 0 {
+			return nil, fmt.Errorf("email 渠道需要配置 SMTP 的 Host、From 和 To")
+		}
+		return &emailSender{cfg: cfg.SMTP, timeout: timeout}, nil
+	default:
+		return nil, fmt.Errorf("不支持的渠道类型 %s", cfg.Type)
+	}
+}
+
+// HasChannel 是否配置了该名称的渠道
+func (n *Notifier) HasChannel(name string) bool {
+	if n == nil {
+		return false
+	}
+	_, ok := n.channels[name]
+	return ok
+}
+
+// Routes 应用接收该事件的渠道：回滚策略的 NotifyChannel 接收所有事件，通知路由按事件类型匹配，同一渠道只发送一次
+func Routes(app *model.Application, eventType model.NotifyEventType) []string {
+	names := make([]string, 0)
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	if app.RollbackPolicy != nil {
+		add(app.RollbackPolicy.NotifyChannel)
+	}
+	if app.Notification != nil {
+		for _, route := range app.Notification.Routes {
+			if len(route.Events) == 0 {
+				add(route.Channel)
+				continue
+			}
+			for _, t := range route.Events {
+				if t == eventType {
+					add(route.Channel)
+					break
+				}
+			}
+		}
+	}
+	return names
+}
+
+// Subscribe 添加订阅方，需在开始发送事件前调用
+func (n *Notifier) Subscribe(s Subscriber) {
+	n.subscribers = append(n.subscribers, s)
+}
+
+// Notify 异步发送事件，事件先交给所有订阅方，未配置渠道或应用未选择渠道时不发送到渠道。通知器为空时不做任何事
+func (n *Notifier) Notify(event Event) {
+	if n == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	for _, s := range n.subscribers {
+		s.Publish(event)
+	}
+	if len(n.channels) == 0 || event.AppId == "" {
+		return
+	}
+
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		n.dispatch(context.Background(), &event)
+	}()
+}
+
+// Wait 等待已提交的通知发送完成，包括重试
+func (n *Notifier) Wait() {
+	if n != nil {
+		n.wg.Wait()
+	}
+}
+
+func (n *Notifier) dispatch(ctx context.Context, event *Event) {
+	app, err := n.applicationModel.FindById(ctx, event.AppId)
+	if err != nil {
+		n.Errorf("[Notifier] ApplicationModel.FindById error:%v, appId:%s", err, event.AppId)
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, name := range Routes(app, event.Type) {
+		c, ok := n.channels[name]
+		if !ok {
+			n.Errorf("[Notifier] 应用 %s 引用的通知渠道 %s 未配置", app.Name, name)
+			continue
+		}
+		wg.Add(1)
+		go func(c *channel) {
+			defer wg.Done()
+			n.deliver(ctx, c, event)
+		}(c)
+	}
+	wg.Wait()
+}
+
+// deliver 渲染并发送到一个渠道，失败时按退避重试
+func (n *Notifier) deliver(ctx context.Context, c *channel, event *Event) error {
+	msg, err := c.render(event)
+	if err != nil {
+		n.Errorf("[Notifier] 渠道 %s 渲染 %s 通知失败: %v", c.name, event.Type, err)
+		return err
+	}
+
+	backoff := n.retryBackoff
+	for attempt := 1; ; attempt++ {
+		err = c.sender.send(ctx, msg)
+		if err == nil {
+			n.Infof("发布单 %s 的 %s 通知已发送到 %s", event.DeploymentId, event.Type, c.name)
+			return nil
+		}
+		if attempt >= n.maxAttempts {
+			n.Errorf("[Notifier] 渠道 %s 发送 %s 通知失败，已尝试 %d 次: %v", c.name, event.Type, attempt, err)
+			return err
+		}
+		n.Infof("渠道 %s 发送 %s 通知失败，%s 后重试: %v", c.name, event.Type, backoff, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/config"
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+)
+
+type fakeApplicationModel struct {
+	model.ApplicationModel
+	app *model.Application
+}
+
+func (m *fakeApplicationModel) FindById(ctx context.Context, id string) (*model.Application, error) {
+	return m.app, nil
+}
+
+// recorder 记录收到的请求体，前 failures 次返回 500
+type recorder struct {
+	mu       sync.Mutex
+	failures int
+	response string
+	requests []*http.Request
+	bodies   []map[string]interface{}
+}
+
+func (r *recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	body, _ := io.ReadAll(req.Body)
+	var payload map[string]interface{}
+	json.Unmarshal(body, &payload)
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, payload)
+	if len(r.bodies) <= r.failures {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if r.response != "" {
+		w.Write([]byte(r.response))
+	}
+}
+
+func testEvent(eventType model.NotifyEventType) Event {
+	return Event{
+		Type:         eventType,
+		AppId:        "app1",
+		AppName:      "order",
+		DeploymentId: "d1",
+		Version:      "v1.2.0",
+		Status:       "failed",
+		Message:      "节点 host-1 发布失败",
+		Time:         time.Date(2025, 1, 2, 3, 4, 5, 0, time.Local),
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	app := &model.Application{
+		RollbackPolicy: &model.RollbackPolicy{NotifyChannel: "ops"},
+		Notification: &model.NotificationPolicy{Routes: []model.NotifyRoute{
+			{Channel: "team", Events: []model.NotifyEventType{model.NotifyEventDeploymentFailed, model.NotifyEventRollbackTriggered}},
+			{Channel: "ops"},
+			{Channel: "mail", Events: []model.NotifyEventType{model.NotifyEventReportCompleted}},
+		}},
+	}
+	if got, want := Routes(app, model.NotifyEventDeploymentFailed), []string{"ops", "team"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("failed routes = %v, want %v", got, want)
+	}
+	if got, want := Routes(app, model.NotifyEventReportCompleted), []string{"ops", "mail"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("report routes = %v, want %v", got, want)
+	}
+	if got := Routes(&model.Application{}, model.NotifyEventDeploymentFailed); len(got) != 0 {
+		t.Errorf("routes = %v, want none", got)
+	}
+}
+
+func TestNotifierChannels(t *testing.T) {
+	webhook := &recorder{failures: 1}
+	dingtalk := &recorder{response: `{"errcode":0,"errmsg":"ok"}`}
+	wecom := &recorder{response: `{"errcode":0,"errmsg":"ok"}`}
+	feishu := &recorder{response: `{"code":0,"msg":"success"}`}
+	slack := &recorder{response: "ok"}
+	servers := make(map[string]*httptest.Server)
+	for name, handler := range map[string]*recorder{"webhook": webhook, "dingtalk": dingtalk, "wecom": wecom, "feishu": feishu, "slack": slack} {
+		servers[name] = httptest.NewServer(handler)
+		defer servers[name].Close()
+	}
+
+	cfg := config.NotifyConfig{
+		MaxAttempts: 3,
+		Timeout:     5,
+		Channels: []config.NotifyChannelConfig{
+			{Name: "webhook", Type: ChannelWebhook, URL: servers["webhook"].URL},
+			{Name: "dingtalk", Type: ChannelDingTalk, URL: servers["dingtalk"].URL + "/robot/send?access_token=t", Secret: "SEC"},
+			{Name: "wecom", Type: ChannelWeCom, URL: servers["wecom"].URL},
+			{Name: "feishu", Type: ChannelFeishu, URL: servers["feishu"].URL, Secret: "key"},
+			{Name: "slack", Type: ChannelSlack, URL: servers["slack"].URL, Title: "[{{.Type}}] {{.AppName}}", Template: "{{.Message}}"},
+		},
+	}
+	app := &model.Application{Id: "app1", Name: "order", Notification: &model.NotificationPolicy{Routes: []model.NotifyRoute{
+		{Channel: "webhook"},
+		{Channel: "dingtalk"},
+		{Channel: "wecom"},
+		{Channel: "feishu"},
+		{Channel: "slack", Events: []model.NotifyEventType{model.NotifyEventDeploymentFailed}},
+	}}}
+	notifier, err := NewNotifier(cfg, &fakeApplicationModel{app: app})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notifier.Notify(testEvent(model.NotifyEventDeploymentFailed))
+	notifier.Notify(testEvent(model.NotifyEventDeploymentSucceeded))
+	notifier.Wait()
+
+	// webhook 第一次失败后重试
+	if len(webhook.bodies) != 3 {
+		t.Fatalf("webhook requests = %d, want 3", len(webhook.bodies))
+	}
+	if event := webhook.bodies[1]["event"].(map[string]interface{}); event["deployment_id"] != "d1" {
+		t.Errorf("webhook event = %v", event)
+	}
+
+	if len(dingtalk.requests) != 2 {
+		t.Fatalf("dingtalk requests = %d, want 2", len(dingtalk.requests))
+	}
+	query := dingtalk.requests[0].URL.Query()
+	if query.Get("access_token") != "t" || query.Get("timestamp") == "" ||
+		query.Get("sign") != hmacBase64("SEC", query.Get("timestamp")+"\nSEC") {
+		t.Errorf("dingtalk query = %v, want signed", query)
+	}
+	if markdown := dingtalk.bodies[0]["markdown"].(map[string]interface{}); !strings.Contains(markdown["title"].(string), "order v1.2.0") {
+		t.Errorf("dingtalk markdown = %v", markdown)
+	}
+
+	if content := wecom.bodies[0]["markdown"].(map[string]interface{})["content"].(string); !strings.Contains(content, "节点 host-1 发布失败") {
+		t.Errorf("wecom content = %s", content)
+	}
+
+	timestamp, _ := feishu.bodies[0]["timestamp"].(string)
+	if feishu.bodies[0]["sign"] != hmacBase64(timestamp+"\nkey", "") {
+		t.Errorf("feishu body = %v, want signed", feishu.bodies[0])
+	}
+
+	// slack 只订阅了发布失败，使用自定义模板
+	if len(slack.bodies) != 1 || slack.bodies[0]["text"] != "*[deployment_failed] order*\n节点 host-1 发布失败" {
+		t.Errorf("slack bodies = %v", slack.bodies)
+	}
+}
+
+func TestNotifierBotError(t *testing.T) {
+	bot := &recorder{response: `{"errcode":310000,"errmsg":"sign not match"}`}
+	server := httptest.NewServer(bot)
+	defer server.Close()
+
+	notifier, err := NewNotifier(config.NotifyConfig{
+		MaxAttempts: 2,
+		Channels:    []config.NotifyChannelConfig{{Name: "dingtalk", Type: ChannelDingTalk, URL: server.URL}},
+	}, &fakeApplicationModel{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := testEvent(model.NotifyEventDeploymentFailed)
+	err = notifier.deliver(context.Background(), notifier.channels["dingtalk"], &event)
+	if err == nil || !strings.Contains(err.Error(), "sign not match") {
+		t.Errorf("err = %v, want bot error", err)
+	}
+	if len(bot.bodies) != 2 {
+		t.Errorf("requests = %d, want 2 attempts", len(bot.bodies))
+	}
+}
+
+func TestNewNotifierInvalid(t *testing.T) {
+	for _, channels := range [][]config.NotifyChannelConfig{
+		{{Name: "a", Type: "sms", URL: "http://x"}},
+		{{Name: "a", Type: ChannelWebhook}},
+		{{Name: "a", Type: ChannelEmail}},
+		{{Name: "a", Type: ChannelWebhook, URL: "http://x", Template: "{{.Message"}},
+		{{Name: "a", Type: ChannelWebhook, URL: "http://x"}, {Name: "a", Type: ChannelSlack, URL: "http://y"}},
+	} {
+		if _, err := NewNotifier(config.NotifyConfig{Channels: channels}, nil); err == nil {
+			t.Errorf("channels %+v, want error", channels)
+		}
+	}
+}
+
+// smtpStub 只实现发送所需命令的 SMTP 服务器，记录收到的邮件
+func smtpStub(t *testing.T) (string, <-chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mails := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		listener.Close()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		io.WriteString(conn, "220 localhost ESMTP\r\n")
+		var envelope []string
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			command := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(command, "EHLO"):
+				io.WriteString(conn, "250-localhost\r\n250 8BITMIME\r\n")
+			case strings.HasPrefix(command, "MAIL"), strings.HasPrefix(command, "RCPT"):
+				envelope = append(envelope, strings.TrimSpace(line))
+				io.WriteString(conn, "250 OK\r\n")
+			case command == "DATA":
+				io.WriteString(conn, "354 End data with <CR><LF>.<CR><LF>\r\n")
+				var data strings.Builder
+				for {
+					dataLine, err := reader.ReadString('\n')
+					if err != nil || dataLine == ".\r\n" {
+						break
+					}
+					data.WriteString(dataLine)
+				}
+				mails <- strings.Join(envelope, "\n") + "\n" + data.String()
+				io.WriteString(conn, "250 OK\r\n")
+			case command == "QUIT":
+				io.WriteString(conn, "221 Bye\r\n")
+				return
+			default:
+				io.WriteString(conn, "250 OK\r\n")
+			}
+		}
+	}()
+	return listener.Addr().String(), mails
+}
+
+func TestEmailChannel(t *testing.T) {
+	addr, mails := smtpStub(t)
+	host, port, _ := net.SplitHostPort(addr)
+	portNum, _ := strconv.Atoi(port)
+
+	notifier, err := NewNotifier(config.NotifyConfig{
+		MaxAttempts: 1,
+		Timeout:     5,
+		Channels: []config.NotifyChannelConfig{{Name: "mail", Type: ChannelEmail, SMTP: config.SMTPConfig{
+			Host: host, Port: portNum, From: "deploy@example.com", To: []string{"ops@example.com", "dev@example.com"},
+		}}},
+	}, &fakeApplicationModel{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := testEvent(model.NotifyEventDeploymentRolledBack)
+	if err := notifier.deliver(context.Background(), notifier.channels["mail"], &event); err != nil {
+		t.Fatal(err)
+	}
+
+	mail := <-mails
+	for _, want := range []string{"MAIL FROM:<deploy@example.com>", "RCPT TO:<ops@example.com>", "RCPT TO:<dev@example.com>", "Subject: =?UTF-8?b?"} {
+		if !strings.Contains(mail, want) {
+			t.Errorf("mail missing %q:\n%s", want, mail)
+		}
+	}
+	body := mail[strings.Index(mail, "\r\n\r\n")+4:]
+	decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(strings.TrimSpace(body), "\r\n", ""))
+	if err != nil || !strings.Contains(string(decoded), "发布单: d1") {
+		t.Errorf("body = %q, err = %v", decoded, err)
+	}
+}
+
+type subscriberFunc func(event Event)
+
+func (f subscriberFunc) Publish(event Event) { f(event) }
+
+func TestNotifierSubscribers(t *testing.T) {
+	notifier, err := NewNotifier(config.NotifyConfig{}, &fakeApplicationModel{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []Event
+	notifier.Subscribe(subscriberFunc(func(event Event) { got = append(got, event) }))
+
+	// 未配置渠道时订阅方仍收到事件
+	notifier.Notify(Event{Type: model.NotifyEventDeploymentCreated, DeploymentId: "d1"})
+	if len(got) != 1 || got[0].Type != model.NotifyEventDeploymentCreated || got[0].Time.IsZero() {
+		t.Errorf("events = %+v", got)
+	}
+}
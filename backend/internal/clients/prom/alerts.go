@@ -2,159 +2,165 @@ package prom
 
 import (
 	"fmt"
-	"os"
-	"sync"
+	"io"
+	"net/http"
+	"strings"
 
 	"gopkg.in/yaml.v2"
 )
 
-type alertManager struct {
-	rulesFile string
-	mu        sync.RWMutex
+// AddAlert 向分组追加一条规则，分组不存在时创建，同名规则已存在时报错
+func (c *vmClient) AddAlert(group string, rule AlertRule) error {
+	am := &alertManager{rulesFile: c.getAlertsFilePath()}
+	return am.update(group, func(config *alertRulesConfig) error {
+		i := config.findGroup(group)
+		if i < 0 {
+			config.Groups = append(config.Groups, alertGroup{Name: group, Rules: []AlertRule{rule}})
+			return nil
+		}
+		for _, existing := range config.Groups[i].Rules {
+			if existing.Alert == rule.Alert {
+				return fmt.Errorf("alert %s already exists in group %s", rule.Alert, group)
+			}
+		}
+		config.Groups[i].Rules = append(config.Groups[i].Rules, rule)
+		return nil
+	})
 }
 
-type alertRulesConfig struct {
-	Groups []alertGroup `yaml:"groups"`
-}
+// DeleteAlert 删除分组中的规则，分组删空后一并删除
+func (c *vmClient) DeleteAlert(group, alertName string) error {
+	am := &alertManager{rulesFile: c.getAlertsFilePath()}
+	return am.update(group, func(config *alertRulesConfig) error {
+		i := config.findGroup(group)
+		if i < 0 {
+			return fmt.Errorf("alert group %s not found", group)
+		}
 
-type alertGroup struct {
-	Name  string      `yaml:"name"`
-	Rules []AlertRule `yaml:"rules"`
+		rules := make([]AlertRule, 0, len(config.Groups[i].Rules))
+		for _, rule := range config.Groups[i].Rules {
+			if rule.Alert != alertName {
+				rules = append(rules, rule)
+			}
+		}
+		if len(rules) == len(config.Groups[i].Rules) {
+			return fmt.Errorf("alert %s not found in group %s", alertName, group)
+		}
+		config.replaceGroup(group, rules)
+		return nil
+	})
 }
 
-func (c *vmClient) AddAlert(rule AlertRule) error {
-	if c.config.BaseURL == "" {
-		return fmt.Errorf("alerts file path not configured")
-	}
-
+// UpdateAlert 替换分组中的同名规则
+func (c *vmClient) UpdateAlert(group string, rule AlertRule) error {
 	am := &alertManager{rulesFile: c.getAlertsFilePath()}
-	am.mu.Lock()
-	defer am.mu.Unlock()
+	return am.update(group, func(config *alertRulesConfig) error {
+		i := config.findGroup(group)
+		if i < 0 {
+			return fmt.Errorf("alert group %s not found", group)
+		}
+		for j := range config.Groups[i].Rules {
+			if config.Groups[i].Rules[j].Alert == rule.Alert {
+				config.Groups[i].Rules[j] = rule
+				return nil
+			}
+		}
+		return fmt.Errorf("alert %s not found in group %s", rule.Alert, group)
+	})
+}
 
-	rules, err := am.loadRules()
+// GetAlertGroup 获取分组中的规则，分组不存在时返回空
+func (c *vmClient) GetAlertGroup(group string) ([]AlertRule, error) {
+	am := &alertManager{rulesFile: c.getAlertsFilePath()}
+	config, err := am.read()
 	if err != nil {
-		return fmt.Errorf("failed to load rules: %w", err)
-	}
-
-	if len(rules.Groups) == 0 {
-		rules.Groups = append(rules.Groups, alertGroup{
-			Name:  "default",
-			Rules: []AlertRule{},
-		})
+		return nil, err
 	}
-
-	rules.Groups[0].Rules = append(rules.Groups[0].Rules, rule)
-
-	if err := am.saveRules(rules); err != nil {
-		return fmt.Errorf("failed to save rules: %w", err)
+	if i := config.findGroup(group); i >= 0 {
+		return config.Groups[i].Rules, nil
 	}
-
-	return nil
+	return []AlertRule{}, nil
 }
 
-func (c *vmClient) DeleteAlert(alertName string) error {
-	if c.config.BaseURL == "" {
-		return fmt.Errorf("alerts file path not configured")
-	}
-
+// SyncAlertGroup 用给定规则整体替换规则文件中的同名分组，分组不存在时追加，规则为空时删除分组
+func (c *vmClient) SyncAlertGroup(group string, rules []AlertRule) error {
 	am := &alertManager{rulesFile: c.getAlertsFilePath()}
-	am.mu.Lock()
-	defer am.mu.Unlock()
+	return am.update(group, func(config *alertRulesConfig) error {
+		config.replaceGroup(group, rules)
+		return nil
+	})
+}
 
-	rules, err := am.loadRules()
-	if err != nil {
-		return fmt.Errorf("failed to load rules: %w", err)
-	}
+// DeleteAlertGroup 删除规则文件中的分组，分组不存在时不报错
+func (c *vmClient) DeleteAlertGroup(group string) error {
+	am := &alertManager{rulesFile: c.getAlertsFilePath()}
+	return am.update(group, func(config *alertRulesConfig) error {
+		config.replaceGroup(group, nil)
+		return nil
+	})
+}
 
-	found := false
-	for i := range rules.Groups {
-		newRules := make([]AlertRule, 0)
-		for _, rule := range rules.Groups[i].Rules {
-			if rule.Alert != alertName {
-				newRules = append(newRules, rule)
-			} else {
-				found = true
-			}
+// RenameAlertGroup 删除旧分组并用给定规则整体替换新分组，两者在同一次写入中完成，
+// 避免中途失败时旧分组已删除而新分组未写入
+func (c *vmClient) RenameAlertGroup(oldGroup, group string, rules []AlertRule) error {
+	am := &alertManager{rulesFile: c.getAlertsFilePath()}
+	return am.update(group, func(config *alertRulesConfig) error {
+		if oldGroup != group {
+			config.replaceGroup(oldGroup, nil)
 		}
-		rules.Groups[i].Rules = newRules
-	}
-
-	if !found {
-		return fmt.Errorf("alert %s not found", alertName)
-	}
-
-	if err := am.saveRules(rules); err != nil {
-		return fmt.Errorf("failed to save rules: %w", err)
-	}
-
-	return nil
+		config.replaceGroup(group, rules)
+		return nil
+	})
 }
 
-func (c *vmClient) UpdateAlert(rule AlertRule) error {
-	if c.config.BaseURL == "" {
-		return fmt.Errorf("alerts file path not configured")
-	}
-
+// DiffAlertGroup 预览 SyncAlertGroup 对规则文件的修改，不写入文件，返回按行比较的差异，无变化时为空。
+// 新规则无法通过校验时返回错误
+func (c *vmClient) DiffAlertGroup(group string, rules []AlertRule) (string, error) {
 	am := &alertManager{rulesFile: c.getAlertsFilePath()}
-	am.mu.Lock()
-	defer am.mu.Unlock()
-
-	rules, err := am.loadRules()
+	config, err := am.read()
 	if err != nil {
-		return fmt.Errorf("failed to load rules: %w", err)
+		return "", err
 	}
 
-	found := false
-	for i := range rules.Groups {
-		for j := range rules.Groups[i].Rules {
-			if rules.Groups[i].Rules[j].Alert == rule.Alert {
-				rules.Groups[i].Rules[j] = rule
-				found = true
-				break
-			}
-		}
-		if found {
-			break
-		}
+	before, err := yaml.Marshal(config)
+	if err != nil {
+		return "", err
 	}
-
-	if !found {
-		return fmt.Errorf("alert %s not found", rule.Alert)
+	config.replaceGroup(group, rules)
+	if err := validateRulesGroup(config, group); err != nil {
+		return "", err
 	}
-
-	if err := am.saveRules(rules); err != nil {
-		return fmt.Errorf("failed to save rules: %w", err)
+	after, err := yaml.Marshal(config)
+	if err != nil {
+		return "", err
 	}
 
-	return nil
+	return diffLines(string(before), string(after)), nil
 }
 
-func (am *alertManager) loadRules() (*alertRulesConfig, error) {
-	data, err := os.ReadFile(am.rulesFile)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return &alertRulesConfig{Groups: []alertGroup{}}, nil
-		}
-		return nil, err
+// ReloadAlerts 通知 vmalert 重新加载规则文件，未配置 vmalert 地址时不处理
+func (c *vmClient) ReloadAlerts() error {
+	if c.config.VMAlertURL == "" {
+		return nil
 	}
 
-	var config alertRulesConfig
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, err
+	resp, err := c.config.HTTPClient.Post(strings.TrimRight(c.config.VMAlertURL, "/")+"/-/reload", "", nil)
+	if err != nil {
+		return fmt.Errorf("failed to reload vmalert: %w", err)
 	}
+	defer resp.Body.Close()
 
-	return &config, nil
-}
-
-func (am *alertManager) saveRules(config *alertRulesConfig) error {
-	data, err := yaml.Marshal(config)
-	if err != nil {
-		return err
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("reload vmalert failed with status %d: %s", resp.StatusCode, string(body))
 	}
 
-	return os.WriteFile(am.rulesFile, data, 0644)
+	return nil
 }
 
 func (c *vmClient) getAlertsFilePath() string {
-	return "/etc/victoriametrics/alerts.yml"
+	if c.config.AlertRulesPath != "" {
+		return c.config.AlertRulesPath
+	}
+	return defaultAlertRulesPath
 }
@@ -0,0 +1,154 @@
+package prom
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func newTestRulesClient(t *testing.T) (VMClient, string) {
+	path := filepath.Join(t.TempDir(), "alerts.yml")
+	return NewVMClient(NewDefaultConfig("").WithAlertRules(path, "")), path
+}
+
+func TestAlertGroupCRUD(t *testing.T) {
+	client, _ := newTestRulesClient(t)
+
+	if err := client.AddAlert("app-web", AlertRule{Alert: "down", Expr: `up == 0`}); err != nil {
+		t.Fatalf("AddAlert() error = %v", err)
+	}
+	if err := client.AddAlert("app-api", AlertRule{Alert: "down", Expr: `up == 0`}); err != nil {
+		t.Fatalf("AddAlert() error = %v", err)
+	}
+	if err := client.AddAlert("app-web", AlertRule{Alert: "down", Expr: `up == 0`}); err == nil {
+		t.Fatalf("AddAlert() should reject duplicate alert in group")
+	}
+	if err := client.AddAlert("app-web", AlertRule{Alert: "bad", Expr: `up ==`}); err == nil {
+		t.Fatalf("AddAlert() should reject invalid expr")
+	}
+
+	if err := client.UpdateAlert("app-web", AlertRule{Alert: "down", Expr: `up < 1`}); err != nil {
+		t.Fatalf("UpdateAlert() error = %v", err)
+	}
+	rules, err := client.GetAlertGroup("app-web")
+	if err != nil {
+		t.Fatalf("GetAlertGroup() error = %v", err)
+	}
+	if len(rules) != 1 || rules[0].Expr != `up < 1` {
+		t.Fatalf("GetAlertGroup() = %v, want updated rule", rules)
+	}
+	rules, _ = client.GetAlertGroup("app-api")
+	if len(rules) != 1 || rules[0].Expr != `up == 0` {
+		t.Fatalf("other group should not be changed, got %v", rules)
+	}
+
+	if err := client.DeleteAlert("app-web", "down"); err != nil {
+		t.Fatalf("DeleteAlert() error = %v", err)
+	}
+	if err := client.DeleteAlert("app-web", "down"); err == nil {
+		t.Fatalf("DeleteAlert() should fail for deleted group")
+	}
+	rules, _ = client.GetAlertGroup("app-api")
+	if len(rules) != 1 {
+		t.Fatalf("other group should be kept, got %v", rules)
+	}
+
+	if err := client.RenameAlertGroup("app-api", "app-gateway", []AlertRule{{Alert: "down", Expr: `up < 1`}}); err != nil {
+		t.Fatalf("RenameAlertGroup() error = %v", err)
+	}
+	if rules, _ = client.GetAlertGroup("app-api"); len(rules) != 0 {
+		t.Fatalf("old group should be removed, got %v", rules)
+	}
+	if rules, _ = client.GetAlertGroup("app-gateway"); len(rules) != 1 || rules[0].Expr != `up < 1` {
+		t.Fatalf("GetAlertGroup() = %v, want renamed group", rules)
+	}
+}
+
+func TestSyncAlertGroupConcurrent(t *testing.T) {
+	client, path := newTestRulesClient(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			group := "app-" + string(rune('a'+i))
+			if err := client.SyncAlertGroup(group, []AlertRule{{Alert: "down", Expr: `up == 0`}}); err != nil {
+				t.Errorf("SyncAlertGroup() error = %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	am := &alertManager{rulesFile: path}
+	config, err := am.loadRules()
+	if err != nil {
+		t.Fatalf("loadRules() error = %v", err)
+	}
+	if len(config.Groups) != 20 {
+		t.Fatalf("groups = %d, want 20", len(config.Groups))
+	}
+
+	// 临时文件应在重命名后清理
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), ".tmp-") {
+			t.Errorf("temp file %s left behind", entry.Name())
+		}
+	}
+}
+
+func TestDiffAlertGroup(t *testing.T) {
+	client, _ := newTestRulesClient(t)
+	if err := client.SyncAlertGroup("app-web", []AlertRule{{Alert: "down", Expr: `up == 0`}}); err != nil {
+		t.Fatalf("SyncAlertGroup() error = %v", err)
+	}
+
+	diff, err := client.DiffAlertGroup("app-web", []AlertRule{{Alert: "down", Expr: `up == 0`}})
+	if err != nil || diff != "" {
+		t.Fatalf("DiffAlertGroup() = %q, %v, want no change", diff, err)
+	}
+
+	diff, err = client.DiffAlertGroup("app-web", []AlertRule{{Alert: "down", Expr: `up < 1`}})
+	if err != nil {
+		t.Fatalf("DiffAlertGroup() error = %v", err)
+	}
+	if !strings.Contains(diff, "- ") || !strings.Contains(diff, "+ ") || !strings.Contains(diff, "up < 1") {
+		t.Errorf("DiffAlertGroup() = %q, want changed expr", diff)
+	}
+
+	// 预览不写入文件
+	rules, _ := client.GetAlertGroup("app-web")
+	if rules[0].Expr != `up == 0` {
+		t.Errorf("DiffAlertGroup() should not modify rules file")
+	}
+
+	if _, err := client.DiffAlertGroup("app-web", []AlertRule{{Alert: "down", Expr: `up ==`}}); err == nil {
+		t.Errorf("DiffAlertGroup() should reject invalid expr")
+	}
+}
+
+func TestSyncAlertGroupKeepsUnmanagedGroups(t *testing.T) {
+	client, path := newTestRulesClient(t)
+	// 手工维护的分组使用 MetricsQL 扩展语法，PromQL 解析器无法解析
+	unmanaged := "groups:\n- name: manual\n  rules:\n  - alert: high_load\n    expr: sum(node_load1) keep_metric_names > 1\n"
+	if err := os.WriteFile(path, []byte(unmanaged), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.SyncAlertGroup("app-web", []AlertRule{{Alert: "down", Expr: `up == 0`}}); err != nil {
+		t.Fatalf("SyncAlertGroup() error = %v", err)
+	}
+	if _, err := client.DiffAlertGroup("app-web", []AlertRule{{Alert: "down", Expr: `up < 1`}}); err != nil {
+		t.Fatalf("DiffAlertGroup() error = %v", err)
+	}
+	if err := client.SyncAlertGroup("app-web", []AlertRule{{Alert: "down", Expr: `up ==`}}); err == nil {
+		t.Errorf("SyncAlertGroup() should reject invalid expr")
+	}
+	rules, _ := client.GetAlertGroup("manual")
+	if len(rules) != 1 || rules[0].Alert != "high_load" {
+		t.Errorf("unmanaged group should be kept, got %v", rules)
+	}
+}
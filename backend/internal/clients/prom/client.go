@@ -13,9 +13,15 @@ import (
 type VMClient interface {
 	QueryInstant(query string) ([]InstantQueryResult, error)
 	QueryRange(query string, start, end time.Time, step time.Duration) ([]RangeQueryResult, error)
-	AddAlert(rule AlertRule) error
-	DeleteAlert(alertName string) error
-	UpdateAlert(rule AlertRule) error
+	AddAlert(group string, rule AlertRule) error
+	DeleteAlert(group, alertName string) error
+	UpdateAlert(group string, rule AlertRule) error
+	GetAlertGroup(group string) ([]AlertRule, error)
+	SyncAlertGroup(group string, rules []AlertRule) error
+	DeleteAlertGroup(group string) error
+	RenameAlertGroup(oldGroup, group string, rules []AlertRule) error
+	DiffAlertGroup(group string, rules []AlertRule) (string, error)
+	ReloadAlerts() error
 }
 
 type vmClient struct {
@@ -5,9 +5,14 @@ import (
 	"time"
 )
 
+// defaultAlertRulesPath vmalert 加载的告警规则文件
+const defaultAlertRulesPath = "/etc/victoriametrics/alerts.yml"
+
 type VMClientConfig struct {
-	BaseURL    string
-	HTTPClient *http.Client
+	BaseURL        string
+	HTTPClient     *http.Client
+	AlertRulesPath string // 告警规则文件路径
+	VMAlertURL     string // vmalert 地址，用于规则变更后触发重新加载
 }
 
 func NewDefaultConfig(baseURL string) *VMClientConfig {
@@ -16,9 +21,18 @@ func NewDefaultConfig(baseURL string) *VMClientConfig {
 		HTTPClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		AlertRulesPath: defaultAlertRulesPath,
 	}
 }
 
+func (c *VMClientConfig) WithAlertRules(rulesPath, vmalertURL string) *VMClientConfig {
+	if rulesPath != "" {
+		c.AlertRulesPath = rulesPath
+	}
+	c.VMAlertURL = vmalertURL
+	return c
+}
+
 func (c *VMClientConfig) WithHTTPClient(client *http.Client) *VMClientConfig {
 	c.HTTPClient = client
 	return c
@@ -0,0 +1,24 @@
+//go:build !windows
+
+package prom
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile 对锁文件加排他 flock，多个后端进程共享同一规则文件时串行读改写
+func lockFile(path string) (func(), error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
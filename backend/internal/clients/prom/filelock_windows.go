@@ -0,0 +1,8 @@
+//go:build windows
+
+package prom
+
+// lockFile Windows 下不支持 flock，仅依赖进程内锁
+func lockFile(path string) (func(), error) {
+	return func() {}, nil
+}
@@ -0,0 +1,234 @@
+package prom
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+)
+
+// rulesFileMu 进程内所有对规则文件的读改写串行执行；跨进程由规则文件旁的锁文件保证
+var rulesFileMu sync.Mutex
+
+type alertManager struct {
+	rulesFile string
+}
+
+type alertRulesConfig struct {
+	Groups []alertGroup `yaml:"groups"`
+}
+
+type alertGroup struct {
+	Name  string      `yaml:"name"`
+	Rules []AlertRule `yaml:"rules"`
+}
+
+// update 在进程锁和文件锁内加载规则文件，由 fn 修改 group 分组后校验该分组并原子写回。
+// 只校验本次写入的分组，其他分组（包括不由本服务管理的分组）原样保留
+func (am *alertManager) update(group string, fn func(config *alertRulesConfig) error) error {
+	rulesFileMu.Lock()
+	defer rulesFileMu.Unlock()
+
+	unlock, err := lockFile(am.rulesFile + ".lock")
+	if err != nil {
+		return fmt.Errorf("failed to lock rules file: %w", err)
+	}
+	defer unlock()
+
+	config, err := am.loadRules()
+	if err != nil {
+		return fmt.Errorf("failed to load rules: %w", err)
+	}
+
+	if err := fn(config); err != nil {
+		return err
+	}
+
+	if err := validateRulesGroup(config, group); err != nil {
+		return err
+	}
+
+	if err := am.saveRules(config); err != nil {
+		return fmt.Errorf("failed to save rules: %w", err)
+	}
+
+	return nil
+}
+
+// read 在进程锁和文件锁内加载规则文件，避免读到其他进程写了一半的内容
+func (am *alertManager) read() (*alertRulesConfig, error) {
+	rulesFileMu.Lock()
+	defer rulesFileMu.Unlock()
+
+	unlock, err := lockFile(am.rulesFile + ".lock")
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock rules file: %w", err)
+	}
+	defer unlock()
+
+	config, err := am.loadRules()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load rules: %w", err)
+	}
+	return config, nil
+}
+
+func (am *alertManager) loadRules() (*alertRulesConfig, error) {
+	data, err := os.ReadFile(am.rulesFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &alertRulesConfig{Groups: []alertGroup{}}, nil
+		}
+		return nil, err
+	}
+
+	var config alertRulesConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// saveRules 先写入同目录下的临时文件并落盘，再重命名覆盖规则文件，vmalert 不会读到写了一半的文件
+func (am *alertManager) saveRules(config *alertRulesConfig) error {
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(am.rulesFile), filepath.Base(am.rulesFile)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpName, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpName, am.rulesFile)
+}
+
+// findGroup 返回分组下标，不存在时返回 -1
+func (config *alertRulesConfig) findGroup(group string) int {
+	for i := range config.Groups {
+		if config.Groups[i].Name == group {
+			return i
+		}
+	}
+	return -1
+}
+
+// replaceGroup 用给定规则整体替换分组，规则为空时删除分组，分组不存在时追加
+func (config *alertRulesConfig) replaceGroup(group string, rules []AlertRule) {
+	i := config.findGroup(group)
+	switch {
+	case i < 0 && len(rules) == 0:
+	case i < 0:
+		config.Groups = append(config.Groups, alertGroup{Name: group, Rules: rules})
+	case len(rules) == 0:
+		config.Groups = append(config.Groups[:i], config.Groups[i+1:]...)
+	default:
+		config.Groups[i].Rules = rules
+	}
+}
+
+// validateRulesGroup 写入前校验要写入的分组：分组名不重复、规则名不重复、表达式语法正确，避免 vmalert 加载失败。
+// 分组已删除时没有需要校验的内容
+func validateRulesGroup(config *alertRulesConfig, name string) error {
+	if name == "" {
+		return fmt.Errorf("alert group name is empty")
+	}
+	found := false
+	for _, group := range config.Groups {
+		if group.Name != name {
+			continue
+		}
+		if found {
+			return fmt.Errorf("duplicate alert group %s", group.Name)
+		}
+		found = true
+
+		alerts := make(map[string]bool, len(group.Rules))
+		for _, rule := range group.Rules {
+			if rule.Alert == "" {
+				return fmt.Errorf("alert name is empty in group %s", group.Name)
+			}
+			if alerts[rule.Alert] {
+				return fmt.Errorf("duplicate alert %s in group %s", rule.Alert, group.Name)
+			}
+			alerts[rule.Alert] = true
+
+			if err := ValidateExpr(rule.Expr); err != nil {
+				return fmt.Errorf("invalid expr of alert %s in group %s: %w", rule.Alert, group.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// diffLines 按行比较两段文本，返回带 "+ "、"- "、"  " 前缀的差异；内容相同时返回空字符串
+func diffLines(before, after string) string {
+	if before == after {
+		return ""
+	}
+	a := strings.Split(strings.TrimSuffix(before, "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(after, "\n"), "\n")
+	if before == "" {
+		a = nil
+	}
+	if after == "" {
+		b = nil
+	}
+
+	// lcs[i][j] 为 a[i:] 与 b[j:] 的最长公共子序列长度
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var sb strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			sb.WriteString("  " + a[i] + "\n")
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] >= lcs[i+1][j]):
+			sb.WriteString("+ " + b[j] + "\n")
+			j++
+		default:
+			sb.WriteString("- " + a[i] + "\n")
+			i++
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,27 @@
+package prom
+
+import (
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+// InjectMatcher 为 PromQL 表达式中的每个向量选择器追加 label=~"regex" 匹配条件，
+// 用于把全局的告警表达式限定到指定机器上。
+// 表达式用 Prometheus 的 PromQL 解析器解析后改写，返回格式化后的表达式；没有向量选择器的表达式（如 vector(1)）原样格式化返回
+func InjectMatcher(expr, label, regex string) (string, error) {
+	parsed, err := parser.ParseExpr(expr)
+	if err != nil {
+		return "", err
+	}
+	matcher, err := labels.NewMatcher(labels.MatchRegexp, label, regex)
+	if err != nil {
+		return "", err
+	}
+	parser.Inspect(parsed, func(node parser.Node, _ []parser.Node) error {
+		if vs, ok := node.(*parser.VectorSelector); ok {
+			vs.LabelMatchers = append(vs.LabelMatchers, matcher)
+		}
+		return nil
+	})
+	return parsed.String(), nil
+}
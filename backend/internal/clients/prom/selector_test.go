@@ -0,0 +1,72 @@
+package prom
+
+import (
+	"testing"
+)
+
+func TestInjectMatcher(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "裸指标名",
+			expr: `up == 0`,
+			want: `up{hostname=~"web-1"} == 0`,
+		},
+		{
+			name: "已有标签条件",
+			expr: `http_requests_total{code=~"5.."}`,
+			want: `http_requests_total{code=~"5..",hostname=~"web-1"}`,
+		},
+		{
+			name: "空标签条件",
+			expr: `node_load1{}`,
+			want: `node_load1{hostname=~"web-1"}`,
+		},
+		{
+			name: "函数、区间和聚合分组",
+			expr: `sum by (instance) (rate(http_requests_total{code="500"}[5m])) / sum by (instance) (rate(http_requests_total[5m])) > 0.05`,
+			want: `sum by (instance) (rate(http_requests_total{code="500",hostname=~"web-1"}[5m])) / sum by (instance) (rate(http_requests_total{hostname=~"web-1"}[5m])) > 0.05`,
+		},
+		{
+			name: "向量匹配和 offset",
+			expr: `a / on(job) group_left(team) b offset 5m`,
+			want: `a{hostname=~"web-1"} / on (job) group_left (team) b{hostname=~"web-1"} offset 5m`,
+		},
+		{
+			name: "字符串参数和无指标名选择器",
+			expr: `label_replace({__name__="up"}, "host", "$1", "instance", "(.*):.*")`,
+			want: `label_replace({__name__="up",hostname=~"web-1"}, "host", "$1", "instance", "(.*):.*")`,
+		},
+		{
+			name: "子查询和科学计数法",
+			expr: `max_over_time(node_load1[10m:1m]) > 1e+3`,
+			want: `max_over_time(node_load1{hostname=~"web-1"}[10m:1m]) > 1000`,
+		},
+		{
+			name:    "括号不匹配",
+			expr:    `sum(rate(up[5m])`,
+			wantErr: true,
+		},
+		{
+			name: "没有向量选择器时原样返回",
+			expr: `vector(1)`,
+			want: `vector(1)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := InjectMatcher(tt.expr, "hostname", "web-1")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("InjectMatcher() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("InjectMatcher() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package prom
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+// ValidateExpr 用 Prometheus 的 PromQL 解析器校验表达式语法、函数名和参数，
+// 写入 vmalert 规则文件前调用，避免错误的表达式导致 vmalert 加载规则失败
+func ValidateExpr(expr string) error {
+	if strings.TrimSpace(expr) == "" {
+		return fmt.Errorf("empty expression")
+	}
+	_, err := parser.ParseExpr(expr)
+	return err
+}
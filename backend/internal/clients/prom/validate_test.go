@@ -0,0 +1,50 @@
+package prom
+
+import (
+	"testing"
+)
+
+func TestValidateExpr(t *testing.T) {
+	valid := []string{
+		`up == 0`,
+		`rate(http_requests_total{code=~"5..", job!="test"}[5m]) > 0.1`,
+		`sum by (job) (rate(errors_total[1m])) / sum(rate(requests_total[1m])) by (job)`,
+		`histogram_quantile(0.99, sum(rate(latency_bucket[5m])) by (le)) > 0.5`,
+		`max_over_time(rate(errors_total[1m])[10m:1m])`,
+		`errors_total offset 5m / on (instance) group_left up`,
+		`errors_total unless on (instance) up`,
+		`-up + 1 >= bool 0`,
+		`time() - process_start_time_seconds < 60`,
+		`absent(up{job="api"})`,
+		`topk(3, node_load1)`,
+	}
+	for _, expr := range valid {
+		if err := ValidateExpr(expr); err != nil {
+			t.Errorf("ValidateExpr(%s) error = %v", expr, err)
+		}
+	}
+
+	invalid := []string{
+		``,
+		`up ==`,
+		`rate(errors_total[5m]`,
+		`up{job="api"`,
+		`up{job=api}`,
+		`up{job=~"("}`,
+		`rate(errors_total[5x])`,
+		`up up`,
+		`sum by job (up)`,
+		`up > > 1`,
+		`(up))`,
+		`up, 1`,
+		`rate()`,
+		`x[5m][5m]`,
+		`unknown_func(up)`,
+		`errors_total unless on (instance) group_left up`,
+	}
+	for _, expr := range invalid {
+		if err := ValidateExpr(expr); err == nil {
+			t.Errorf("ValidateExpr(%s) should fail", expr)
+		}
+	}
+}
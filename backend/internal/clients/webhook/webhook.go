@@ -0,0 +1,333 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/core/stores/mon"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/clients/notify"
+	"github.com/Z3Labs/Hackathon/backend/internal/config"
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+)
+
+const (
+	HeaderEvent     = "X-Hackathon-Event"     // 事件类型
+	HeaderEventId   = "X-Hackathon-Event-Id"  // 事件ID，重试和重新投递时不变，接收方可据此去重
+	HeaderDelivery  = "X-Hackathon-Delivery"  // 投递记录ID
+	HeaderTimestamp = "X-Hackathon-Timestamp" // 发送时的 Unix 时间戳（秒）
+	HeaderSignature = "X-Hackathon-Signature" // sha256=<HMAC-SHA256(secret, timestamp + "." + body) 的十六进制>
+
+	// pollInterval worker 空闲时轮询投递记录的间隔，用于执行到期的重试和其他实例产生的投递
+	pollInterval = 5 * time.Second
+	// maxRetryBackoff 重试间隔上限
+	maxRetryBackoff = time.Hour
+	// maxResponseLen 保存的响应体最大长度
+	maxResponseLen = 2048
+	// publishTimeout 为一个事件创建投递记录的超时
+	publishTimeout = 10 * time.Second
+)
+
+// Payload 投递的请求体
+type Payload struct {
+	Id   string                `json:"id"`   // 事件ID
+	Type model.NotifyEventType `json:"type"` // 事件类型
+	Time time.Time             `json:"time"` // 事件时间
+	Data *notify.Event         `json:"data"` // 事件内容
+}
+
+// Sign 计算请求签名。接收方用订阅密钥按相同方式计算并与 X-Hackathon-Signature 比较，
+// 同时校验时间戳与当前时间的差距以防止重放
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewSecret 生成随机签名密钥
+func NewSecret() string {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return "whsec_" + hex.EncodeToString(buf)
+}
+
+// Dispatcher 把发布事件投递到订阅了该事件的 webhook。每个订阅的投递持久化为一条记录，多个后端实例共享，
+// 领取时加租约，失败后按退避重试，超过最大尝试次数进入死信列表
+type Dispatcher struct {
+	webhookModel  model.WebhookModel
+	deliveryModel model.WebhookDeliveryModel
+	client        *http.Client
+	workers       int
+	maxAttempts   int
+	retryBackoff  time.Duration
+	lease         time.Duration
+	wakeup        chan struct{}
+	publishing    sync.WaitGroup
+	ctx           context.Context
+	cancel        context.CancelFunc
+	wg            sync.WaitGroup
+	logx.Logger
+}
+
+// NewDispatcher 创建 webhook 投递器
+func NewDispatcher(cfg config.WebhookConfig, webhookModel model.WebhookModel, deliveryModel model.WebhookDeliveryModel) *Dispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	// 未配置 Webhook 时 go-zero 不填充字段默认值，按配置的默认值处理
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = 2
+	}
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 8
+	}
+	retryBackoff := time.Duration(cfg.RetryBackoff) * time.Second
+	if retryBackoff <= 0 {
+		retryBackoff = 10 * time.Second
+	}
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &Dispatcher{
+		webhookModel:  webhookModel,
+		deliveryModel: deliveryModel,
+		client:        &http.Client{Timeout: timeout},
+		workers:       workers,
+		maxAttempts:   maxAttempts,
+		retryBackoff:  retryBackoff,
+		lease:         timeout + time.Minute,
+		wakeup:        make(chan struct{}, 1),
+		ctx:           ctx,
+		cancel:        cancel,
+		Logger:        logx.WithContext(ctx),
+	}
+}
+
+// Start 启动投递 worker
+func (d *Dispatcher) Start() {
+	for i := 0; i < d.workers; i++ {
+		d.wg.Add(1)
+		go d.work()
+	}
+	d.Infof("Webhook dispatcher started with %d workers", d.workers)
+}
+
+// Stop 等待正在创建的投递记录写入，停止 worker，被中断的投递在租约到期后重新领取
+func (d *Dispatcher) Stop() {
+	d.publishing.Wait()
+	d.cancel()
+	d.wg.Wait()
+}
+
+// Wake 有新的待投递记录时唤醒空闲的 worker。投递器为空时不做任何事
+func (d *Dispatcher) Wake() {
+	if d == nil {
+		return
+	}
+	select {
+	case d.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// Publish 实现 notify.Subscriber，异步为订阅了该事件的 webhook 创建投递记录
+func (d *Dispatcher) Publish(event notify.Event) {
+	d.publishing.Add(1)
+	go func() {
+		defer d.publishing.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+		defer cancel()
+		if _, err := d.enqueue(ctx, &event); err != nil {
+			d.Errorf("[WebhookDispatcher] enqueue %s error:%v", event.Type, err)
+		}
+	}()
+}
+
+// enqueue 为订阅了该事件且接收该应用事件的已启用 webhook 各创建一条投递记录，返回创建的记录数
+func (d *Dispatcher) enqueue(ctx context.Context, event *notify.Event) (int, error) {
+	enabled := true
+	webhooks, err := d.webhookModel.Search(ctx, &model.WebhookCond{
+		Enabled:   &enabled,
+		EventType: event.Type,
+		AppId:     event.AppId,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if len(webhooks) == 0 {
+		return 0, nil
+	}
+
+	payload := Payload{
+		Id:   primitive.NewObjectID().Hex(),
+		Type: event.Type,
+		Time: event.Time,
+		Data: event,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	for _, webhook := range webhooks {
+		delivery := &model.WebhookDelivery{
+			WebhookId:    webhook.Id,
+			EventId:      payload.Id,
+			EventType:    event.Type,
+			AppId:        event.AppId,
+			DeploymentId: event.DeploymentId,
+			Payload:      string(body),
+		}
+		if err := d.deliveryModel.Insert(ctx, delivery); err != nil {
+			d.Errorf("[WebhookDispatcher] WebhookDeliveryModel.Insert error:%v, webhookId:%s", err, webhook.Id)
+			continue
+		}
+		created++
+	}
+	if created > 0 {
+		d.Wake()
+	}
+	return created, nil
+}
+
+func (d *Dispatcher) work() {
+	defer d.wg.Done()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		for d.ctx.Err() == nil && d.runOnce() {
+		}
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-d.wakeup:
+		case <-ticker.C:
+		}
+	}
+}
+
+// runOnce 领取并投递一条记录，没有可投递的记录时返回 false
+func (d *Dispatcher) runOnce() bool {
+	delivery, err := d.deliveryModel.Claim(d.ctx, time.Now(), d.lease)
+	if err != nil {
+		d.Errorf("[WebhookDispatcher] WebhookDeliveryModel.Claim error:%v", err)
+		return false
+	}
+	if delivery == nil {
+		return false
+	}
+	// 可能还有待投递的记录，唤醒其他空闲的 worker
+	d.Wake()
+
+	d.run(delivery)
+	return true
+}
+
+// run 投递一条记录并保存结果，失败时按退避安排重试或进入死信列表
+func (d *Dispatcher) run(delivery *model.WebhookDelivery) {
+	// 订阅已删除或已停用时不再重试，直接进入死信列表，重新启用后可从死信列表重新投递
+	dead := false
+	webhook, err := d.webhookModel.FindById(d.ctx, delivery.WebhookId)
+	switch {
+	case err == mon.ErrNotFound:
+		dead, err = true, errors.New("订阅已删除")
+	case err != nil:
+		err = fmt.Errorf("查询订阅失败: %w", err)
+	case !webhook.Enabled:
+		dead, err = true, errors.New("订阅已停用")
+	default:
+		err = d.send(d.ctx, webhook, delivery)
+	}
+
+	now := time.Now()
+	if err == nil {
+		delivery.Status = model.WebhookDeliveryStatusSucceeded
+		delivery.LastError = ""
+		delivery.DeliveredTime = now
+		d.Infof("webhook %s 的 %s 事件已投递: %s", delivery.WebhookId, delivery.EventType, delivery.Id)
+	} else {
+		delivery.LastError = err.Error()
+		if dead || delivery.Attempts >= d.maxAttempts {
+			delivery.Status = model.WebhookDeliveryStatusDead
+			d.Errorf("[WebhookDispatcher] webhook %s 投递 %s 失败，已尝试 %d 次，进入死信列表: %v",
+				delivery.WebhookId, delivery.Id, delivery.Attempts, err)
+		} else {
+			delivery.Status = model.WebhookDeliveryStatusPending
+			delivery.NextRunAt = now.Add(d.backoff(delivery.Attempts))
+			d.Infof("webhook %s 投递 %s 失败，%s 重试: %v",
+				delivery.WebhookId, delivery.Id, delivery.NextRunAt.Format(time.RFC3339), err)
+		}
+	}
+	delivery.LeaseUntil = time.Time{}
+	// 停止时 worker 的 ctx 已取消，结果仍需保存
+	if err := d.deliveryModel.Update(context.Background(), delivery); err != nil {
+		d.Errorf("[WebhookDispatcher] WebhookDeliveryModel.Update error:%v, id:%s", err, delivery.Id)
+	}
+}
+
+// backoff 第 attempts 次失败后的重试间隔，从配置的间隔开始每次翻倍
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	backoff := d.retryBackoff
+	for i := 1; i < attempts && backoff < maxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxRetryBackoff {
+		backoff = maxRetryBackoff
+	}
+	return backoff
+}
+
+// send 发送签名的请求，记录响应状态码和响应体，非 2xx 响应视为失败
+func (d *Dispatcher) send(ctx context.Context, webhook *model.Webhook, delivery *model.WebhookDelivery) error {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Hackathon-Webhook/1.0")
+	req.Header.Set(HeaderEvent, string(delivery.EventType))
+	req.Header.Set(HeaderEventId, delivery.EventId)
+	req.Header.Set(HeaderDelivery, delivery.Id)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, timestamp, body))
+
+	start := time.Now()
+	resp, err := d.client.Do(req)
+	delivery.Duration = time.Since(start).Milliseconds()
+	if err != nil {
+		delivery.ResponseStatus, delivery.ResponseBody = 0, ""
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseLen))
+	delivery.ResponseStatus = resp.StatusCode
+	delivery.ResponseBody = string(respBody)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
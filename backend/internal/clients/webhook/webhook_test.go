@@ -0,0 +1,191 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/mon"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/clients/notify"
+	"github.com/Z3Labs/Hackathon/backend/internal/config"
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+)
+
+type fakeWebhookModel struct {
+	model.WebhookModel
+	webhooks []*model.Webhook
+}
+
+func (m *fakeWebhookModel) Search(ctx context.Context, cond *model.WebhookCond) ([]*model.Webhook, error) {
+	return m.webhooks, nil
+}
+
+func (m *fakeWebhookModel) FindById(ctx context.Context, id string) (*model.Webhook, error) {
+	for _, webhook := range m.webhooks {
+		if webhook.Id == id {
+			return webhook, nil
+		}
+	}
+	return nil, mon.ErrNotFound
+}
+
+type fakeDeliveryModel struct {
+	model.WebhookDeliveryModel
+	mu         sync.Mutex
+	deliveries []*model.WebhookDelivery
+}
+
+func (m *fakeDeliveryModel) Insert(ctx context.Context, delivery *model.WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delivery.Id = delivery.WebhookId + "-delivery"
+	delivery.Status = model.WebhookDeliveryStatusPending
+	m.deliveries = append(m.deliveries, delivery)
+	return nil
+}
+
+func (m *fakeDeliveryModel) Update(ctx context.Context, delivery *model.WebhookDelivery) error {
+	return nil
+}
+
+// receiver 记录收到的请求，前 failures 次返回 500
+type receiver struct {
+	mu       sync.Mutex
+	failures int
+	headers  []http.Header
+	bodies   [][]byte
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	body, _ := io.ReadAll(req.Body)
+	r.headers = append(r.headers, req.Header)
+	r.bodies = append(r.bodies, body)
+	if len(r.bodies) <= r.failures {
+		http.Error(w, "unavailable", http.StatusInternalServerError)
+		return
+	}
+	w.Write([]byte("ok"))
+}
+
+func testDispatcher(webhooks []*model.Webhook, maxAttempts int) (*Dispatcher, *fakeDeliveryModel) {
+	deliveries := &fakeDeliveryModel{}
+	d := NewDispatcher(config.WebhookConfig{MaxAttempts: maxAttempts, RetryBackoff: 10, Timeout: 5},
+		&fakeWebhookModel{webhooks: webhooks}, deliveries)
+	return d, deliveries
+}
+
+func testEvent() *notify.Event {
+	return &notify.Event{
+		Type:         model.NotifyEventBatchCompleted,
+		AppId:        "app1",
+		AppName:      "order",
+		DeploymentId: "d1",
+		Version:      "v1.2.0",
+		Status:       "deploying",
+		Nodes:        []string{"host-1", "host-2"},
+		Time:         time.Now(),
+	}
+}
+
+// claim 模拟 Claim：置为投递中并累加尝试次数
+func claim(delivery *model.WebhookDelivery) *model.WebhookDelivery {
+	delivery.Status = model.WebhookDeliveryStatusDelivering
+	delivery.Attempts++
+	return delivery
+}
+
+func TestDispatcherDeliver(t *testing.T) {
+	recv := &receiver{failures: 1}
+	server := httptest.NewServer(recv)
+	defer server.Close()
+
+	d, deliveries := testDispatcher([]*model.Webhook{{Id: "w1", URL: server.URL, Secret: "s3cret", Enabled: true}}, 3)
+	created, err := d.enqueue(context.Background(), testEvent())
+	if err != nil || created != 1 {
+		t.Fatalf("created = %d, err = %v", created, err)
+	}
+	delivery := deliveries.deliveries[0]
+
+	// 第一次投递失败，按退避重新排队
+	d.run(claim(delivery))
+	if delivery.Status != model.WebhookDeliveryStatusPending || delivery.ResponseStatus != http.StatusInternalServerError ||
+		delivery.LastError == "" || time.Until(delivery.NextRunAt) < 9*time.Second {
+		t.Fatalf("delivery = %+v, want pending retry", delivery)
+	}
+
+	d.run(claim(delivery))
+	if delivery.Status != model.WebhookDeliveryStatusSucceeded || delivery.Attempts != 2 || delivery.DeliveredTime.IsZero() {
+		t.Fatalf("delivery = %+v, want succeeded", delivery)
+	}
+
+	// 重试时请求体和事件ID不变，签名可由接收方校验
+	if len(recv.bodies) != 2 || string(recv.bodies[0]) != string(recv.bodies[1]) {
+		t.Fatalf("bodies = %q", recv.bodies)
+	}
+	header := recv.headers[1]
+	if got, want := header.Get(HeaderSignature), Sign("s3cret", header.Get(HeaderTimestamp), recv.bodies[1]); got != want {
+		t.Errorf("signature = %s, want %s", got, want)
+	}
+	if header.Get(HeaderEvent) != string(model.NotifyEventBatchCompleted) || header.Get(HeaderDelivery) != delivery.Id ||
+		header.Get(HeaderEventId) != delivery.EventId {
+		t.Errorf("headers = %v", header)
+	}
+
+	var payload Payload
+	if err := json.Unmarshal(recv.bodies[1], &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Id != delivery.EventId || payload.Data.DeploymentId != "d1" || len(payload.Data.Nodes) != 2 {
+		t.Errorf("payload = %+v", payload)
+	}
+}
+
+func TestDispatcherDeadLetter(t *testing.T) {
+	recv := &receiver{failures: 10}
+	server := httptest.NewServer(recv)
+	defer server.Close()
+
+	d, deliveries := testDispatcher([]*model.Webhook{
+		{Id: "w1", URL: server.URL, Enabled: true},
+		{Id: "w2", URL: server.URL, Enabled: false},
+	}, 2)
+	if _, err := d.enqueue(context.Background(), testEvent()); err != nil {
+		t.Fatal(err)
+	}
+	failing, disabled := deliveries.deliveries[0], deliveries.deliveries[1]
+	if failing.EventId == "" || failing.EventId != disabled.EventId {
+		t.Errorf("event ids = %s, %s, want shared", failing.EventId, disabled.EventId)
+	}
+
+	d.run(claim(failing))
+	d.run(claim(failing))
+	if failing.Status != model.WebhookDeliveryStatusDead || failing.Attempts != 2 {
+		t.Errorf("delivery = %+v, want dead after 2 attempts", failing)
+	}
+
+	// 停用的订阅不发送请求，直接进入死信列表
+	d.run(claim(disabled))
+	if disabled.Status != model.WebhookDeliveryStatusDead || disabled.LastError != "订阅已停用" {
+		t.Errorf("delivery = %+v, want dead", disabled)
+	}
+	if len(recv.bodies) != 2 {
+		t.Errorf("requests = %d, want 2", len(recv.bodies))
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	d, _ := testDispatcher(nil, 10)
+	for attempts, want := range map[int]time.Duration{1: 10 * time.Second, 2: 20 * time.Second, 4: 80 * time.Second, 20: time.Hour} {
+		if got := d.backoff(attempts); got != want {
+			t.Errorf("backoff(%d) = %s, want %s", attempts, got, want)
+		}
+	}
+}
@@ -9,6 +9,12 @@ type Config struct {
 	AI    AIConfig
 	Qiniu QiniuConfig // 七牛云配置
 	VM    VMConfig    // VictoriaMetrics 配置
+	// 发布管控配置
+	Deploy DeployConfig `json:",optional"`
+	// 发布事件通知配置
+	Notify NotifyConfig `json:",optional"`
+	// 外部系统 webhook 订阅的投递配置
+	Webhook WebhookConfig `json:",optional"`
 }
 
 type MongoDBConfig struct {
@@ -17,13 +23,86 @@ type MongoDBConfig struct {
 }
 
 type AIConfig struct {
-	BaseURL        string `json:",optional"` // API 基础 URL，从环境变量读取
-	APIKey         string // API 密钥，从环境变量读取
-	Model          string `json:",default=gpt-4"`                               // 模型名称
-	Timeout        int    `json:",default=30"`                                  // 超时时间（秒）
-	PrometheusURL  string `json:",optional"`                                    // Prometheus URL（MCP 模式需要）
-	GitHubToken    string `json:",optional"`                                    // GitHub Personal Access Token（提供后自动启用 GitHub MCP）
-	GitHubToolsets string `json:",default=repos,issues,pull_requests,releases"` // GitHub MCP 工具集
+	Provider       string       `json:",default=anthropic,options=anthropic|openai|mcp"` // 对话接口类型：anthropic、openai 兼容接口，mcp 为旧版 docker 诊断容器
+	BaseURL        string       `json:",optional"`                                       // API 基础 URL，从环境变量读取
+	APIKey         string       // API 密钥，从环境变量读取
+	Model          string       `json:",default=gpt-4"`                               // 模型名称
+	Timeout        int          `json:",default=30"`                                  // 超时时间（秒）
+	PrometheusURL  string       `json:",optional"`                                    // Prometheus URL（诊断时查询指标）
+	GitHubToken    string       `json:",optional"`                                    // GitHub Personal Access Token（提供后自动启用 GitHub 工具）
+	GitHubToolsets string       `json:",default=repos,issues,pull_requests,releases"` // GitHub 工具集
+	GitHubAPIURL   string       `json:",default=https://api.github.com"`              // GitHub API 地址
+	MaxTokens      int          `json:",default=4096"`                                // 单轮对话最大输出 token 数
+	MaxToolRounds  int          `json:",default=20"`                                  // 单次诊断最多对话轮数（含工具调用）
+	Workers        int          `json:",default=2"`                                   // 并发生成诊断报告的任务数
+	MaxAttempts    int          `json:",default=3"`                                   // 诊断任务最多尝试次数
+	RetryBackoff   int          `json:",default=30"`                                  // 诊断任务失败后首次重试间隔（秒），之后每次翻倍
+	Prices         []ModelPrice `json:",optional"`                                    // 模型价格表，用于计算诊断成本
+	Budget         BudgetConfig `json:",optional"`                                    // 诊断成本预算
+	Incidents      int          `json:",default=3"`                                   // 诊断时附带的历史相似故障数，0 表示不检索
+}
+
+// ModelPrice 模型每百万 token 的价格，单位自定，预算使用相同单位
+type ModelPrice struct {
+	Model           string  // 模型名称
+	PromptPrice     float64 `json:",optional"` // 每百万输入 token 价格
+	CompletionPrice float64 `json:",optional"` // 每百万输出 token 价格
+}
+
+// BudgetConfig 按自然月统计的诊断成本预算，超出后跳过诊断或降级到其他模型
+type BudgetConfig struct {
+	MonthlyLimit    float64 `json:",optional"`                            // 每月所有应用诊断成本上限，0 表示不限制
+	AppMonthlyLimit float64 `json:",optional"`                            // 每个应用每月诊断成本上限，0 表示不限制
+	Action          string  `json:",default=skip,options=skip|downgrade"` // 超出预算后的处理：skip-跳过诊断，downgrade-使用 DowngradeModel
+	DowngradeModel  string  `json:",optional"`                            // 降级使用的模型，未配置时跳过诊断
+}
+
+// NotifyConfig 发布事件通知渠道，应用通过渠道名称选择接收的渠道和事件
+type NotifyConfig struct {
+	Channels     []NotifyChannelConfig `json:",optional"`   // 通知渠道
+	MaxAttempts  int                   `json:",default=3"`  // 单条通知最多发送次数
+	RetryBackoff int                   `json:",default=5"`  // 发送失败后首次重试间隔（秒），之后每次翻倍
+	Timeout      int                   `json:",default=10"` // 单次发送超时（秒）
+}
+
+// NotifyChannelConfig 一个通知渠道
+type NotifyChannelConfig struct {
+	Name     string     // 渠道名称，应用的通知路由和回滚策略的 NotifyChannel 通过名称引用
+	Type     string     `json:",options=webhook|dingtalk|wecom|feishu|slack|email"` // 渠道类型
+	URL      string     `json:",optional"`                                          // 机器人或 webhook 地址，email 渠道不需要
+	Secret   string     `json:",optional"`                                          // 钉钉、飞书机器人的加签密钥
+	Title    string     `json:",optional"`                                          // 标题模板（Go text/template），为空时按事件类型使用默认标题
+	Template string     `json:",optional"`                                          // 正文模板，为空时使用默认正文
+	SMTP     SMTPConfig `json:",optional"`                                          // email 渠道的 SMTP 配置
+}
+
+// SMTPConfig 邮件通知的 SMTP 服务器，服务器支持 STARTTLS 时自动启用
+type SMTPConfig struct {
+	Host     string   `json:",optional"`   // SMTP 服务器地址
+	Port     int      `json:",default=25"` // SMTP 端口
+	Username string   `json:",optional"`   // 用户名，为空时不认证
+	Password string   `json:",optional"`   // 密码
+	From     string   `json:",optional"`   // 发件人
+	To       []string `json:",optional"`   // 收件人
+}
+
+// DeployConfig 发布管控配置
+type DeployConfig struct {
+	Admins []DeployAdmin `json:",optional"` // 发布管理员，只有管理员可以在封版期、发布窗口外或计划时间前强制发布
+}
+
+// DeployAdmin 发布管理员，强制发布时需同时提供名称和令牌
+type DeployAdmin struct {
+	Name  string // 管理员名称，记录为强制发布的操作人
+	Token string // 管理员令牌，用于校验强制发布请求的操作人身份
+}
+
+// WebhookConfig webhook 订阅的投递配置，订阅本身通过接口管理
+type WebhookConfig struct {
+	Workers      int `json:",default=2"`  // 并发投递数
+	MaxAttempts  int `json:",default=8"`  // 单条投递最多尝试次数，超过后进入死信列表
+	RetryBackoff int `json:",default=10"` // 投递失败后首次重试间隔（秒），之后每次翻倍，最长 1 小时
+	Timeout      int `json:",default=10"` // 单次投递超时（秒）
 }
 
 type QiniuConfig struct {
@@ -34,5 +113,7 @@ type QiniuConfig struct {
 }
 
 type VMConfig struct {
-	VMUIURL string `json:",optional"` // VictoriaMetrics UI URL
+	VMUIURL        string `json:",optional"`                                // VictoriaMetrics UI URL
+	AlertRulesPath string `json:",default=/etc/victoriametrics/alerts.yml"` // vmalert 告警规则文件路径
+	VMAlertURL     string `json:",optional"`                                // vmalert 地址，规则同步后调用 /-/reload 重新加载
 }
@@ -0,0 +1,29 @@
+package apps
+
+import (
+	"net/http"
+
+	"github.com/Z3Labs/Hackathon/backend/common/errorx"
+	"github.com/Z3Labs/Hackathon/backend/common/httpresp"
+	"github.com/Z3Labs/Hackathon/backend/internal/logic/apps"
+	"github.com/Z3Labs/Hackathon/backend/internal/svc"
+	"github.com/Z3Labs/Hackathon/backend/internal/types"
+
+	"github.com/zeromicro/go-zero/rest/httpx"
+)
+
+func PreviewAlertRulesHandler(svcCtx *svc.ServiceContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req types.PreviewAlertRulesReq
+		if err := httpx.Parse(r, &req); err != nil {
+			httpresp.HttpErr(w, r, errorx.NewStatCodeError(http.StatusBadRequest, 2, err.Error()))
+			return
+		}
+
+		l := apps.NewPreviewAlertRulesLogic(r.Context(), svcCtx)
+		resp, err := l.PreviewAlertRules(&req)
+
+		httpresp.Http(w, r, resp, err)
+
+	}
+}
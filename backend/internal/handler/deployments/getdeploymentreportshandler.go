@@ -0,0 +1,29 @@
+package deployments
+
+import (
+	"net/http"
+
+	"github.com/Z3Labs/Hackathon/backend/common/errorx"
+	"github.com/Z3Labs/Hackathon/backend/common/httpresp"
+	"github.com/Z3Labs/Hackathon/backend/internal/logic/deployments"
+	"github.com/Z3Labs/Hackathon/backend/internal/svc"
+	"github.com/Z3Labs/Hackathon/backend/internal/types"
+
+	"github.com/zeromicro/go-zero/rest/httpx"
+)
+
+func GetDeploymentReportsHandler(svcCtx *svc.ServiceContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req types.GetDeploymentReportsReq
+		if err := httpx.Parse(r, &req); err != nil {
+			httpresp.HttpErr(w, r, errorx.NewStatCodeError(http.StatusBadRequest, 2, err.Error()))
+			return
+		}
+
+		l := deployments.NewGetDeploymentReportsLogic(r.Context(), svcCtx)
+		resp, err := l.GetDeploymentReports(&req)
+
+		httpresp.Http(w, r, resp, err)
+
+	}
+}
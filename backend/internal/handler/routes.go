@@ -7,8 +7,11 @@ import (
 	alert "github.com/Z3Labs/Hackathon/backend/internal/handler/alert"
 	apps "github.com/Z3Labs/Hackathon/backend/internal/handler/apps"
 	deployments "github.com/Z3Labs/Hackathon/backend/internal/handler/deployments"
+	freezes "github.com/Z3Labs/Hackathon/backend/internal/handler/freezes"
 	machines "github.com/Z3Labs/Hackathon/backend/internal/handler/machines"
 	monitoring "github.com/Z3Labs/Hackathon/backend/internal/handler/monitoring"
+	prompts "github.com/Z3Labs/Hackathon/backend/internal/handler/prompts"
+	webhooks "github.com/Z3Labs/Hackathon/backend/internal/handler/webhooks"
 	"github.com/Z3Labs/Hackathon/backend/internal/svc"
 
 	"github.com/zeromicro/go-zero/rest"
@@ -47,6 +50,11 @@ func RegisterHandlers(server *rest.Server, serverCtx *svc.ServiceContext) {
 				Path:    "/api/v1/apps/:id",
 				Handler: apps.GetAppDetailHandler(serverCtx),
 			},
+			{
+				Method:  http.MethodPost,
+				Path:    "/api/v1/apps/:id/alert-rules/preview",
+				Handler: apps.PreviewAlertRulesHandler(serverCtx),
+			},
 			{
 				Method:  http.MethodGet,
 				Path:    "/api/v1/apps/versions",
@@ -87,6 +95,21 @@ func RegisterHandlers(server *rest.Server, serverCtx *svc.ServiceContext) {
 				Path:    "/api/v1/deployments/:id/rollback",
 				Handler: deployments.RollbackDeploymentHandler(serverCtx),
 			},
+			{
+				Method:  http.MethodPost,
+				Path:    "/api/v1/deployments/rollback-to-version",
+				Handler: deployments.RollbackToVersionHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodPost,
+				Path:    "/api/v1/deployments/:id/approve",
+				Handler: deployments.ApproveDeploymentHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodPost,
+				Path:    "/api/v1/deployments/:id/reject",
+				Handler: deployments.RejectDeploymentHandler(serverCtx),
+			},
 			{
 				Method:  http.MethodPost,
 				Path:    "/api/v1/deployments/:id/node-deployments/rollback",
@@ -112,6 +135,121 @@ func RegisterHandlers(server *rest.Server, serverCtx *svc.ServiceContext) {
 				Path:    "/api/v1/deployments/:id/node-deployments/cancel",
 				Handler: deployments.CancelNodeDeploymentHandler(serverCtx),
 			},
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/v1/deployments/:id/alert-evaluations",
+				Handler: deployments.GetAlertEvaluationsHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/v1/deployments/:id/reports",
+				Handler: deployments.GetDeploymentReportsHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodPost,
+				Path:    "/api/v1/deployments/:id/reports/regenerate",
+				Handler: deployments.RegenerateReportHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodPost,
+				Path:    "/api/v1/reports/:id/feedback",
+				Handler: deployments.SubmitReportFeedbackHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/v1/reports/feedback-stats",
+				Handler: deployments.GetReportFeedbackStatsHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/v1/incidents",
+				Handler: deployments.SearchIncidentsHandler(serverCtx),
+			},
+		},
+	)
+
+	server.AddRoutes(
+		[]rest.Route{
+			{
+				Method:  http.MethodPost,
+				Path:    "/api/v1/prompt-templates",
+				Handler: prompts.CreatePromptTemplateHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/v1/prompt-templates",
+				Handler: prompts.GetPromptTemplateListHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodPost,
+				Path:    "/api/v1/prompt-templates/preview",
+				Handler: prompts.PreviewPromptTemplateHandler(serverCtx),
+			},
+		},
+	)
+
+	server.AddRoutes(
+		[]rest.Route{
+			{
+				Method:  http.MethodPost,
+				Path:    "/api/v1/freeze-periods",
+				Handler: freezes.CreateFreezePeriodHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/v1/freeze-periods",
+				Handler: freezes.GetFreezePeriodListHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodDelete,
+				Path:    "/api/v1/freeze-periods/:id",
+				Handler: freezes.DeleteFreezePeriodHandler(serverCtx),
+			},
+		},
+	)
+
+	server.AddRoutes(
+		[]rest.Route{
+			{
+				Method:  http.MethodPost,
+				Path:    "/api/v1/webhooks",
+				Handler: webhooks.CreateWebhookHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/v1/webhooks",
+				Handler: webhooks.GetWebhookListHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/v1/webhooks/:id",
+				Handler: webhooks.GetWebhookDetailHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodPut,
+				Path:    "/api/v1/webhooks/:id",
+				Handler: webhooks.UpdateWebhookHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodDelete,
+				Path:    "/api/v1/webhooks/:id",
+				Handler: webhooks.DeleteWebhookHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/v1/webhooks/:id/deliveries",
+				Handler: webhooks.GetWebhookDeliveriesHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/v1/webhooks/dead-letters",
+				Handler: webhooks.GetWebhookDeadLettersHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodPost,
+				Path:    "/api/v1/webhooks/deliveries/:id/redeliver",
+				Handler: webhooks.RedeliverWebhookHandler(serverCtx),
+			},
 		},
 	)
 
@@ -162,6 +300,16 @@ func RegisterHandlers(server *rest.Server, serverCtx *svc.ServiceContext) {
 				Path:    "/api/v1/machines/hostname",
 				Handler: machines.GetMachineHostnameHandler(serverCtx),
 			},
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/v1/machines/:id/versions",
+				Handler: machines.GetMachineVersionsHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodPost,
+				Path:    "/api/v1/machines/:id/prune-releases",
+				Handler: machines.PruneMachineReleasesHandler(serverCtx),
+			},
 		},
 	)
 
@@ -172,6 +320,11 @@ func RegisterHandlers(server *rest.Server, serverCtx *svc.ServiceContext) {
 				Path:    "/v1/alerts",
 				Handler: alert.AlertCallBackHandler(serverCtx),
 			},
+			{
+				Method:  http.MethodPost,
+				Path:    "/api/v1/alerts/webhook",
+				Handler: alert.AlertmanagerWebhookHandler(serverCtx),
+			},
 		},
 	)
 }
@@ -4,6 +4,8 @@ import (
 	"context"
 
 	"github.com/Z3Labs/Hackathon/backend/internal/clients/diagnosis"
+	"github.com/Z3Labs/Hackathon/backend/internal/clients/notify"
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
 	"github.com/Z3Labs/Hackathon/backend/internal/svc"
 	"github.com/Z3Labs/Hackathon/backend/internal/types"
 
@@ -46,19 +48,68 @@ func (l *AlertCallBackLogic) AlertCallBack(req *types.PostAlertCallbackReq) erro
 		l.Errorf("DeploymentModel.FindById error: %v", err)
 		return err
 	}
-	req.Tag = deploy.PackageVersion
 	application, err := l.svcCtx.ApplicationModel.FindById(l.ctx, deploy.AppId)
 	if err != nil {
 		l.Errorf("ApplicationModel.FindById error: %v", err)
 		return err
 	}
-	req.RepoAddress = application.Repo
-	// 耗时操作，约需要30～60s
-	_, err = diagnosis.New(context.Background(), l.svcCtx, l.svcCtx.Config.AI).GenerateReport(req)
+
+	return l.Diagnose(deploy, application, req)
+}
+
+// HandleDeploymentAlert 发布单告警的统一处理流程，发布监控和告警回调共用：
+// 先按回滚策略决定是否自动回滚，再将诊断任务加入队列。返回是否触发了自动回滚
+func (l *AlertCallBackLogic) HandleDeploymentAlert(deployment *model.Deployment, app *model.Application, req *types.PostAlertCallbackReq) bool {
+	rollback := l.AutoRollback(deployment, app, req.Alertname)
+	if err := l.Diagnose(deployment, app, req); err != nil {
+		l.Errorf("[HandleDeploymentAlert] Diagnose error:%v, deploymentId:%s", err, deployment.Id)
+	}
+	return rollback
+}
+
+// ShouldAutoRollback 应用开启自动回滚时，只有发布中的发布单因告警转为回滚中，回滚中的不再重复触发
+func ShouldAutoRollback(deployment *model.Deployment, app *model.Application) bool {
+	return app.RollbackPolicy != nil && app.RollbackPolicy.AutoRollback &&
+		deployment.Status == model.DeploymentStatusDeploying
+}
+
+// AutoRollback 满足自动回滚条件时将发布单置为回滚中，由定时任务执行整单回滚。返回是否触发了回滚
+func (l *AlertCallBackLogic) AutoRollback(deployment *model.Deployment, app *model.Application, alertname string) bool {
+	if !ShouldAutoRollback(deployment, app) {
+		if deployment.Status == model.DeploymentStatusRollingBack {
+			l.Infof("Deployment %s is already rolling back, skipping auto rollback for alert %s", deployment.Id, alertname)
+		}
+		return false
+	}
+
+	l.Infof("Auto rollback triggered for deployment %s (status: %s) due to alert %s", deployment.Id, deployment.Status, alertname)
+	if err := l.svcCtx.DeploymentModel.UpdateStatus(l.ctx, deployment.Id, model.DeploymentStatusRollingBack); err != nil {
+		l.Errorf("[AutoRollback] DeploymentModel.UpdateStatus error:%v, deploymentId:%s", err, deployment.Id)
+		return false
+	}
+	deployment.Status = model.DeploymentStatusRollingBack
+	l.svcCtx.Notifier.Notify(notify.DeploymentEvent(model.NotifyEventRollbackTriggered, deployment, "告警 "+alertname+" 触发自动回滚"))
+	return true
+}
+
+// Diagnose 补充发布版本和仓库地址后将诊断任务加入队列，报告由诊断队列异步生成，
+// 同一发布单已有排队中或生成中的报告时不重复生成
+func (l *AlertCallBackLogic) Diagnose(deployment *model.Deployment, app *model.Application, req *types.PostAlertCallbackReq) error {
+	req.Tag = deployment.PackageVersion
+	req.RepoAddress = app.Repo
+	if req.Labels == nil {
+		req.Labels = make(map[string]string)
+	}
+	req.Labels["deploymentId"] = deployment.Id
+
+	reportId, created, err := diagnosis.Enqueue(l.ctx, l.svcCtx.ReportModel, req)
 	if err != nil {
-		l.Errorf("GenerateReport error: %v", err)
+		l.Errorf("[Diagnose] diagnosis.Enqueue error:%v, deploymentId:%s", err, deployment.Id)
 		return err
 	}
+	if !created {
+		l.Infof("Diagnosis for deployment %s is already queued as report %s, skipping alert %s", deployment.Id, reportId, req.Alertname)
+	}
 
 	return nil
 }
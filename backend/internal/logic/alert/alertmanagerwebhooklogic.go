@@ -0,0 +1,202 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+	"github.com/Z3Labs/Hackathon/backend/internal/svc"
+	"github.com/Z3Labs/Hackathon/backend/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type AlertmanagerWebhookLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewAlertmanagerWebhookLogic(ctx context.Context, svcCtx *svc.ServiceContext) AlertmanagerWebhookLogic {
+	return AlertmanagerWebhookLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *AlertmanagerWebhookLogic) AlertmanagerWebhook(req *types.AlertmanagerWebhookReq) (resp *types.AlertmanagerWebhookResp, err error) {
+	// 只关联发布中和回滚中的发布单
+	deployments := make([]*model.Deployment, 0)
+	for _, status := range []model.DeploymentStatus{model.DeploymentStatusDeploying, model.DeploymentStatusRollingBack} {
+		list, err := l.svcCtx.DeploymentModel.Search(l.ctx, &model.DeploymentCond{Status: string(status)})
+		if err != nil {
+			l.Errorf("[AlertmanagerWebhook] DeploymentModel.Search error:%v", err)
+			return nil, errors.New("查询发布单失败")
+		}
+		deployments = append(deployments, list...)
+	}
+
+	resp = &types.AlertmanagerWebhookResp{
+		Received: len(req.Alerts),
+		Results:  make([]types.AlertHandleResult, 0, len(req.Alerts)),
+	}
+	for _, item := range req.Alerts {
+		result, err := l.handleAlert(req, item, deployments)
+		if err != nil {
+			return nil, err
+		}
+		switch model.AlertHandleAction(result.Action) {
+		case model.AlertHandleActionDuplicate:
+			resp.Duplicated++
+		case model.AlertHandleActionRollback, model.AlertHandleActionDiagnose, model.AlertHandleActionResolved:
+			resp.Matched++
+		}
+		resp.Results = append(resp.Results, result)
+	}
+
+	return resp, nil
+}
+
+// handleAlert 关联发布单并按指纹去重，新的告警走与发布监控相同的回滚和诊断流程
+func (l *AlertmanagerWebhookLogic) handleAlert(req *types.AlertmanagerWebhookReq, item types.AlertmanagerAlert,
+	deployments []*model.Deployment) (types.AlertHandleResult, error) {
+
+	labels := make(map[string]string, len(req.CommonLabels)+len(item.Labels))
+	for k, v := range req.CommonLabels {
+		labels[k] = v
+	}
+	for k, v := range item.Labels {
+		labels[k] = v
+	}
+	annotations := make(map[string]string, len(req.CommonAnnotations)+len(item.Annotations))
+	for k, v := range req.CommonAnnotations {
+		annotations[k] = v
+	}
+	for k, v := range item.Annotations {
+		annotations[k] = v
+	}
+
+	status := model.ReceivedAlertStatus(item.Status)
+	if status == "" {
+		status = model.ReceivedAlertStatus(req.Status)
+	}
+	fingerprint := item.Fingerprint
+	if fingerprint == "" {
+		fingerprint = alertFingerprint(labels)
+	}
+
+	received := &model.ReceivedAlert{
+		Fingerprint:  fingerprint,
+		Alertname:    labels["alertname"],
+		Status:       status,
+		Labels:       labels,
+		Annotations:  annotations,
+		StartsAt:     parseAlertTime(item.StartsAt),
+		EndsAt:       parseAlertTime(item.EndsAt),
+		GeneratorURL: item.GeneratorURL,
+		Action:       model.AlertHandleActionUnmatched,
+	}
+
+	deployment, hosts := correlateAlert(labels, deployments)
+	var app *model.Application
+	if deployment != nil {
+		received.DeploymentId = deployment.Id
+		received.AppName = deployment.AppName
+		received.Hosts = hosts
+
+		switch {
+		case status == model.ReceivedAlertStatusResolved:
+			received.Action = model.AlertHandleActionResolved
+		default:
+			var err error
+			app, err = l.svcCtx.ApplicationModel.FindById(l.ctx, deployment.AppId)
+			if err != nil {
+				l.Errorf("[AlertmanagerWebhook] ApplicationModel.FindById error:%v, appId:%s", err, deployment.AppId)
+				return types.AlertHandleResult{}, errors.New("查询应用失败")
+			}
+			received.Action = model.AlertHandleActionDiagnose
+			if ShouldAutoRollback(deployment, app) {
+				received.Action = model.AlertHandleActionRollback
+			}
+		}
+	}
+
+	result := types.AlertHandleResult{
+		Fingerprint:  fingerprint,
+		Alertname:    received.Alertname,
+		Status:       string(status),
+		DeploymentId: received.DeploymentId,
+		Hosts:        hosts,
+		Action:       string(received.Action),
+	}
+	if result.Hosts == nil {
+		result.Hosts = []string{}
+	}
+
+	fresh, err := l.svcCtx.ReceivedAlertModel.Record(l.ctx, received)
+	if err != nil {
+		l.Errorf("[AlertmanagerWebhook] ReceivedAlertModel.Record error:%v", err)
+		return types.AlertHandleResult{}, errors.New("记录告警失败")
+	}
+	if !fresh {
+		result.Action = string(model.AlertHandleActionDuplicate)
+		return result, nil
+	}
+	if app == nil {
+		return result, nil
+	}
+
+	l.recordFiringAlert(deployment, hosts, received.Alertname)
+
+	alertReq := &types.PostAlertCallbackReq{
+		Key:          fingerprint,
+		Status:       string(status),
+		Desc:         annotations["description"],
+		StartsAt:     item.StartsAt,
+		ReceiveAt:    time.Now().Format(time.RFC3339),
+		EndsAt:       item.EndsAt,
+		Severity:     labels["severity"],
+		Alertname:    received.Alertname,
+		GeneratorURL: item.GeneratorURL,
+		Labels:       make(map[string]string, len(labels)+3),
+		Annotations:  annotations,
+	}
+	if alertReq.Desc == "" {
+		alertReq.Desc = annotations["summary"]
+	}
+	for k, v := range labels {
+		alertReq.Labels[k] = v
+	}
+	alertReq.Labels["deploymentId"] = deployment.Id
+	alertReq.Labels["appName"] = deployment.AppName
+	if len(hosts) > 0 {
+		alertReq.Labels["hostname"] = strings.Join(hosts, ",")
+	}
+
+	pipeline := NewAlertCallBackLogic(l.ctx, l.svcCtx)
+	pipeline.HandleDeploymentAlert(deployment, app, alertReq)
+
+	return result, nil
+}
+
+// recordFiringAlert 在关联到的机器上记录告警名称
+func (l *AlertmanagerWebhookLogic) recordFiringAlert(deployment *model.Deployment, hosts []string, alertname string) {
+	if len(hosts) == 0 {
+		return
+	}
+	if err := l.svcCtx.DeploymentModel.AddFiringAlert(l.ctx, deployment.Id, hosts, alertname); err != nil {
+		l.Errorf("[AlertmanagerWebhook] DeploymentModel.AddFiringAlert error:%v, deploymentId:%s", err, deployment.Id)
+	}
+}
+
+// parseAlertTime 解析 RFC3339 时间，Alertmanager 对未结束的告警 endsAt 可能为零值或空
+func parseAlertTime(value string) time.Time {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
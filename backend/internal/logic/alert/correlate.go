@@ -0,0 +1,100 @@
+package alert
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+)
+
+// hostLabels 告警中表示机器的标签，按顺序取第一个非空的值
+var hostLabels = []string{"hostname", "host", "nodename", "instance"}
+
+// alertFingerprint 告警未携带指纹时按标签计算，与 Alertmanager 一样对排序后的标签做 FNV-1a 哈希
+func alertFingerprint(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := fnv.New64a()
+	for _, name := range names {
+		h.Write([]byte(name))
+		h.Write([]byte{0xff})
+		h.Write([]byte(labels[name]))
+		h.Write([]byte{0xff})
+	}
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// correlateAlert 按标签将告警关联到发布中的发布单：
+// 带 deploymentId 标签时直接关联；带应用标签时只在该应用的发布单中查找；带机器标签时只匹配已开始发布的机器。
+// 只有应用标签时关联该应用的发布单并归属到所有已开始发布的机器，应用和机器标签都没有时不关联
+func correlateAlert(labels map[string]string, deployments []*model.Deployment) (*model.Deployment, []string) {
+	if id := labels["deploymentId"]; id != "" {
+		for _, deployment := range deployments {
+			if deployment.Id == id {
+				return deployment, matchAlertHosts(labels, deployment)
+			}
+		}
+		return nil, nil
+	}
+
+	appName, appId := labels["appName"], labels["appId"]
+	if appName == "" {
+		appName = labels["app"]
+	}
+	host := alertHost(labels)
+	if appName == "" && appId == "" && host == "" {
+		return nil, nil
+	}
+
+	for _, deployment := range deployments {
+		if appId != "" && deployment.AppId != appId {
+			continue
+		}
+		if appName != "" && deployment.AppName != appName {
+			continue
+		}
+		hosts := matchAlertHosts(labels, deployment)
+		if host != "" && len(hosts) == 0 {
+			continue
+		}
+		return deployment, hosts
+	}
+	return nil, nil
+}
+
+// matchAlertHosts 告警标签对应的已开始发布的机器，告警不带机器标签时返回所有已开始发布的机器
+func matchAlertHosts(labels map[string]string, deployment *model.Deployment) []string {
+	host := alertHost(labels)
+	hosts := make([]string, 0)
+	for _, node := range deployment.NodeDeployments {
+		if model.NodeStatus(node.NodeDeployStatus) == model.NodeStatusPending {
+			continue
+		}
+		if host == "" || host == node.Name || (node.Ip != "" && host == node.Ip) || strings.HasPrefix(host, node.Name+".") {
+			hosts = append(hosts, node.Name)
+		}
+	}
+	return hosts
+}
+
+// alertHost 告警标签中的机器，instance 为 host:port 时去掉端口
+func alertHost(labels map[string]string) string {
+	for _, label := range hostLabels {
+		value := labels[label]
+		if value == "" {
+			continue
+		}
+		if host, _, err := net.SplitHostPort(value); err == nil {
+			return host
+		}
+		return value
+	}
+	return ""
+}
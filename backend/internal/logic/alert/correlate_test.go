@@ -0,0 +1,72 @@
+package alert
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+)
+
+func TestCorrelateAlert(t *testing.T) {
+	deployments := []*model.Deployment{
+		{
+			Id:      "d1",
+			AppId:   "a1",
+			AppName: "web",
+			NodeDeployments: []model.NodeDeployment{
+				{Name: "web-1", Ip: "10.0.0.1", NodeDeployStatus: model.NodeDeploymentStatusSuccess},
+				{Name: "web-2", Ip: "10.0.0.2", NodeDeployStatus: model.NodeDeploymentStatusPending},
+			},
+		},
+		{
+			Id:      "d2",
+			AppId:   "a2",
+			AppName: "api",
+			NodeDeployments: []model.NodeDeployment{
+				{Name: "api-1", Ip: "10.0.1.1", NodeDeployStatus: model.NodeDeploymentStatusDeploying},
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		labels     map[string]string
+		deployment string
+		hosts      []string
+	}{
+		{name: "发布单ID", labels: map[string]string{"deploymentId": "d2"}, deployment: "d2", hosts: []string{"api-1"}},
+		{name: "应用和机器", labels: map[string]string{"appName": "web", "hostname": "web-1"}, deployment: "d1", hosts: []string{"web-1"}},
+		{name: "instance 带端口", labels: map[string]string{"instance": "10.0.1.1:9100"}, deployment: "d2", hosts: []string{"api-1"}},
+		{name: "只有应用标签", labels: map[string]string{"appId": "a1"}, deployment: "d1", hosts: []string{"web-1"}},
+		{name: "机器未开始发布", labels: map[string]string{"hostname": "web-2"}},
+		{name: "应用与机器不符", labels: map[string]string{"appName": "api", "hostname": "web-1"}},
+		{name: "无关联标签", labels: map[string]string{"alertname": "HighLoad"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deployment, hosts := correlateAlert(tt.labels, deployments)
+			if tt.deployment == "" {
+				if deployment != nil {
+					t.Fatalf("correlateAlert() = %s, want nil", deployment.Id)
+				}
+				return
+			}
+			if deployment == nil || deployment.Id != tt.deployment {
+				t.Fatalf("correlateAlert() = %v, want %s", deployment, tt.deployment)
+			}
+			if !reflect.DeepEqual(hosts, tt.hosts) {
+				t.Errorf("hosts = %v, want %v", hosts, tt.hosts)
+			}
+		})
+	}
+}
+
+func TestAlertFingerprint(t *testing.T) {
+	a := alertFingerprint(map[string]string{"alertname": "down", "hostname": "web-1"})
+	b := alertFingerprint(map[string]string{"hostname": "web-1", "alertname": "down"})
+	c := alertFingerprint(map[string]string{"alertname": "down", "hostname": "web-2"})
+	if a != b || a == c || len(a) != 16 {
+		t.Errorf("alertFingerprint() = %s, %s, %s", a, b, c)
+	}
+}
@@ -0,0 +1,123 @@
+package apps
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/clients/prom"
+	"github.com/Z3Labs/Hackathon/backend/internal/logic/deployments"
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+	"github.com/Z3Labs/Hackathon/backend/internal/types"
+)
+
+// alertGroupName 应用的告警规则在 vmalert 规则文件中的专属分组
+func alertGroupName(appName string) string {
+	return "app-" + appName
+}
+
+// syncAlertRules 将应用回滚策略中的告警规则整体同步到该应用专属的 vmalert 规则分组并触发重新加载，
+// 使后端服务不可用时告警仍由 vmalert 独立计算。回滚策略未启用或没有可写入的规则时删除分组。
+// oldName 为更新前的应用名称，应用改名时在同一次写入中删除旧分组
+func syncAlertRules(client prom.VMClient, app *model.Application, oldName string) ([]model.AlertRuleSyncStatus, error) {
+	rules, statuses := buildAlertGroup(app, time.Now())
+	if oldName == "" {
+		oldName = app.Name
+	}
+	if err := client.RenameAlertGroup(alertGroupName(oldName), alertGroupName(app.Name), rules); err != nil {
+		return nil, err
+	}
+	if err := client.ReloadAlerts(); err != nil {
+		for i := range statuses {
+			if statuses[i].Synced {
+				statuses[i].Message = fmt.Sprintf("已写入规则文件，vmalert 重新加载失败: %v", err)
+			}
+		}
+	}
+	return statuses, nil
+}
+
+// buildAlertGroup 生成应用专属分组的 vmalert 规则及每条规则的同步状态，无法转换的规则不写入分组；
+// 回滚策略未启用时分组为空
+func buildAlertGroup(app *model.Application, now time.Time) ([]prom.AlertRule, []model.AlertRuleSyncStatus) {
+	if app.RollbackPolicy == nil || !app.RollbackPolicy.Enabled {
+		return []prom.AlertRule{}, []model.AlertRuleSyncStatus{}
+	}
+
+	hostnames := make([]string, 0, len(app.Machines))
+	for _, machine := range app.Machines {
+		hostnames = append(hostnames, machine.Name)
+	}
+
+	statuses := make([]model.AlertRuleSyncStatus, 0, len(app.RollbackPolicy.AlertRules))
+	rules := make([]prom.AlertRule, 0, len(app.RollbackPolicy.AlertRules))
+	for _, rule := range app.RollbackPolicy.AlertRules {
+		status := model.AlertRuleSyncStatus{Name: rule.Name, SyncedAt: now}
+		vmRule, err := buildVMAlertRule(app, rule, hostnames)
+		if err != nil {
+			status.Message = err.Error()
+		} else {
+			status.Synced = true
+			rules = append(rules, vmRule)
+		}
+		statuses = append(statuses, status)
+	}
+	return rules, statuses
+}
+
+// buildVMAlertRule 将应用告警规则转换为 vmalert 规则：表达式限定到应用的机器，并按比较条件和无数据策略改写，
+// 标签中带上应用信息，告警回调据此关联到发布单
+func buildVMAlertRule(app *model.Application, rule model.PrometheusAlert, hostnames []string) (prom.AlertRule, error) {
+	expr := rule.AlertExpr
+	if len(hostnames) > 0 {
+		scoped, err := deployments.ScopeAlertExpr(rule, hostnames)
+		if err != nil {
+			return prom.AlertRule{}, fmt.Errorf("表达式无法限定到应用机器: %v", err)
+		}
+		expr = scoped
+	}
+
+	operator := rule.Operator
+	if operator == "" {
+		operator = ">"
+	}
+	vmExpr := fmt.Sprintf("(%s) %s %s", expr, operator, strconv.FormatFloat(rule.Threshold, 'g', -1, 64))
+	if rule.AbsentPolicy == model.AlertAbsentPolicyFire {
+		vmExpr = fmt.Sprintf("(%s) or absent(%s)", vmExpr, expr)
+	}
+	if err := prom.ValidateExpr(vmExpr); err != nil {
+		return prom.AlertRule{}, fmt.Errorf("表达式语法错误: %v", err)
+	}
+
+	labels := map[string]string{}
+	for k, v := range rule.Labels {
+		labels[k] = v
+	}
+	labels["appName"] = app.Name
+	labels["appId"] = app.Id
+	if rule.Severity != "" {
+		labels["severity"] = rule.Severity
+	}
+
+	return prom.AlertRule{
+		Alert:         rule.Name,
+		Expr:          vmExpr,
+		For:           rule.Duration,
+		KeepFiringFor: rule.KeepFiringFor,
+		Labels:        labels,
+		Annotations:   rule.Annotations,
+	}, nil
+}
+
+func convertAlertRuleSync(statuses []model.AlertRuleSyncStatus) []types.AlertRuleSyncStatus {
+	result := make([]types.AlertRuleSyncStatus, 0, len(statuses))
+	for _, status := range statuses {
+		result = append(result, types.AlertRuleSyncStatus{
+			Name:     status.Name,
+			Synced:   status.Synced,
+			Message:  status.Message,
+			SyncedAt: status.SyncedAt.Unix(),
+		})
+	}
+	return result
+}
@@ -0,0 +1,48 @@
+package apps
+
+import (
+	"testing"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+)
+
+func TestBuildVMAlertRule(t *testing.T) {
+	app := &model.Application{Id: "app-1", Name: "web"}
+
+	tests := []struct {
+		name string
+		rule model.PrometheusAlert
+		want string
+	}{
+		{
+			name: "未配置比较条件时值大于 0 告警",
+			rule: model.PrometheusAlert{Name: "down", AlertExpr: `up == 0`},
+			want: `(up{hostname=~"(web-1)(:[0-9]+)?"} == 0) > 0`,
+		},
+		{
+			name: "阈值比较",
+			rule: model.PrometheusAlert{Name: "errors", AlertExpr: `rate(errors_total[1m])`, Operator: ">=", Threshold: 0.5},
+			want: `(rate(errors_total{hostname=~"(web-1)(:[0-9]+)?"}[1m])) >= 0.5`,
+		},
+		{
+			name: "无数据视为告警",
+			rule: model.PrometheusAlert{Name: "qps", AlertExpr: `rate(requests_total[1m])`, Operator: "<", Threshold: 1, AbsentPolicy: model.AlertAbsentPolicyFire},
+			want: `((rate(requests_total{hostname=~"(web-1)(:[0-9]+)?"}[1m])) < 1) or absent(rate(requests_total{hostname=~"(web-1)(:[0-9]+)?"}[1m]))`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := buildVMAlertRule(app, tt.rule, []string{"web-1"})
+			if err != nil {
+				t.Fatalf("buildVMAlertRule() error = %v", err)
+			}
+			if rule.Expr != tt.want {
+				t.Errorf("Expr = %s, want %s", rule.Expr, tt.want)
+			}
+			if rule.Labels["appName"] != "web" || rule.Labels["appId"] != "app-1" {
+				t.Errorf("Labels = %v, want app labels", rule.Labels)
+			}
+		})
+	}
+}
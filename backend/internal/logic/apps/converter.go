@@ -13,12 +13,17 @@ func convertRollbackPolicy(policy *model.RollbackPolicy) *types.RollbackPolicy {
 	var alertRules []types.PrometheusAlert
 	for _, rule := range policy.AlertRules {
 		alertRules = append(alertRules, types.PrometheusAlert{
-			Name:        rule.Name,
-			AlertExpr:   rule.AlertExpr,
-			Duration:    rule.Duration,
-			Severity:    rule.Severity,
-			Labels:      rule.Labels,
-			Annotations: rule.Annotations,
+			Name:          rule.Name,
+			AlertExpr:     rule.AlertExpr,
+			Duration:      rule.Duration,
+			Severity:      rule.Severity,
+			Labels:        rule.Labels,
+			Annotations:   rule.Annotations,
+			HostLabel:     rule.HostLabel,
+			Operator:      rule.Operator,
+			Threshold:     rule.Threshold,
+			KeepFiringFor: rule.KeepFiringFor,
+			AbsentPolicy:  string(rule.AbsentPolicy),
 		})
 	}
 
@@ -41,6 +46,7 @@ func convertREDMetrics(metrics *model.REDMetrics) *types.REDMetrics {
 		ErrorMetric:     convertMetricDefinition(metrics.ErrorMetric),
 		DurationMetric:  convertMetricDefinition(metrics.DurationMetric),
 		HealthThreshold: convertHealthThreshold(metrics.HealthThreshold),
+		VerifySeconds:   metrics.VerifySeconds,
 	}
 }
 
@@ -78,12 +84,17 @@ func convertTypesToModelRollbackPolicy(policy *types.RollbackPolicy) *model.Roll
 	var alertRules []model.PrometheusAlert
 	for _, rule := range policy.AlertRules {
 		alertRules = append(alertRules, model.PrometheusAlert{
-			Name:        rule.Name,
-			AlertExpr:   rule.AlertExpr,
-			Duration:    rule.Duration,
-			Severity:    rule.Severity,
-			Labels:      rule.Labels,
-			Annotations: rule.Annotations,
+			Name:          rule.Name,
+			AlertExpr:     rule.AlertExpr,
+			Duration:      rule.Duration,
+			Severity:      rule.Severity,
+			Labels:        rule.Labels,
+			Annotations:   rule.Annotations,
+			HostLabel:     rule.HostLabel,
+			Operator:      rule.Operator,
+			Threshold:     rule.Threshold,
+			KeepFiringFor: rule.KeepFiringFor,
+			AbsentPolicy:  model.AlertAbsentPolicy(rule.AbsentPolicy),
 		})
 	}
 
@@ -106,6 +117,7 @@ func convertTypesToModelREDMetrics(metrics *types.REDMetrics) *model.REDMetrics
 		ErrorMetric:     convertTypesToModelMetricDefinition(metrics.ErrorMetric),
 		DurationMetric:  convertTypesToModelMetricDefinition(metrics.DurationMetric),
 		HealthThreshold: convertTypesToModelHealthThreshold(metrics.HealthThreshold),
+		VerifySeconds:   metrics.VerifySeconds,
 	}
 }
 
@@ -133,3 +145,235 @@ func convertTypesToModelHealthThreshold(threshold *types.HealthThreshold) *model
 		DurationP95Max: threshold.DurationP95Max,
 	}
 }
+
+func convertDeployWindows(windows []model.DeployWindow) []types.DeployWindow {
+	var result []types.DeployWindow
+	for _, window := range windows {
+		result = append(result, types.DeployWindow{
+			Weekdays:  window.Weekdays,
+			StartTime: window.StartTime,
+			EndTime:   window.EndTime,
+		})
+	}
+	return result
+}
+
+func convertTypesToModelDeployWindows(windows []types.DeployWindow) []model.DeployWindow {
+	result := make([]model.DeployWindow, 0, len(windows))
+	for _, window := range windows {
+		result = append(result, model.DeployWindow{
+			Weekdays:  window.Weekdays,
+			StartTime: window.StartTime,
+			EndTime:   window.EndTime,
+		})
+	}
+	return result
+}
+
+func convertApprovalPolicy(policy *model.ApprovalPolicy) *types.ApprovalPolicy {
+	if policy == nil {
+		return nil
+	}
+
+	return &types.ApprovalPolicy{
+		Enabled:           policy.Enabled,
+		Approvers:         policy.Approvers,
+		RequiredApprovals: policy.RequiredApprovals,
+		ExpireHours:       policy.ExpireHours,
+	}
+}
+
+func convertRetentionPolicy(policy *model.RetentionPolicy) *types.RetentionPolicy {
+	if policy == nil {
+		return nil
+	}
+
+	return &types.RetentionPolicy{
+		KeepReleases: policy.KeepReleases,
+	}
+}
+
+func convertTypesToModelRetentionPolicy(policy *types.RetentionPolicy) *model.RetentionPolicy {
+	if policy == nil {
+		return nil
+	}
+
+	return &model.RetentionPolicy{
+		KeepReleases: policy.KeepReleases,
+	}
+}
+
+func convertCanaryPolicy(policy *model.CanaryPolicy) *types.CanaryPolicy {
+	if policy == nil {
+		return nil
+	}
+
+	return &types.CanaryPolicy{
+		Enabled:       policy.Enabled,
+		WindowSeconds: policy.WindowSeconds,
+		PassScore:     policy.PassScore,
+		MarginalScore: policy.MarginalScore,
+		AutoPromote:   policy.AutoPromote,
+	}
+}
+
+func convertTypesToModelCanaryPolicy(policy *types.CanaryPolicy) *model.CanaryPolicy {
+	if policy == nil {
+		return nil
+	}
+
+	return &model.CanaryPolicy{
+		Enabled:       policy.Enabled,
+		WindowSeconds: policy.WindowSeconds,
+		PassScore:     policy.PassScore,
+		MarginalScore: policy.MarginalScore,
+		AutoPromote:   policy.AutoPromote,
+	}
+}
+
+func convertReadinessProbe(probe *model.ReadinessProbe) *types.ReadinessProbe {
+	if probe == nil {
+		return nil
+	}
+
+	return &types.ReadinessProbe{
+		Type:                string(probe.Type),
+		Port:                probe.Port,
+		HTTPPath:            probe.HTTPPath,
+		ExpectStatus:        probe.ExpectStatus,
+		ExpectBody:          probe.ExpectBody,
+		Command:             probe.Command,
+		TimeoutSeconds:      probe.TimeoutSeconds,
+		Retries:             probe.Retries,
+		IntervalSeconds:     probe.IntervalSeconds,
+		InitialDelaySeconds: probe.InitialDelaySeconds,
+	}
+}
+
+func convertTypesToModelReadinessProbe(probe *types.ReadinessProbe) *model.ReadinessProbe {
+	if probe == nil {
+		return nil
+	}
+
+	return &model.ReadinessProbe{
+		Type:                model.ProbeType(probe.Type),
+		Port:                probe.Port,
+		HTTPPath:            probe.HTTPPath,
+		ExpectStatus:        probe.ExpectStatus,
+		ExpectBody:          probe.ExpectBody,
+		Command:             probe.Command,
+		TimeoutSeconds:      probe.TimeoutSeconds,
+		Retries:             probe.Retries,
+		IntervalSeconds:     probe.IntervalSeconds,
+		InitialDelaySeconds: probe.InitialDelaySeconds,
+	}
+}
+
+func convertDiagnosisContextPolicy(policy *model.DiagnosisContextPolicy) *types.DiagnosisContextPolicy {
+	if policy == nil {
+		return nil
+	}
+
+	providers := make([]string, 0, len(policy.Providers))
+	for _, provider := range policy.Providers {
+		providers = append(providers, string(provider))
+	}
+	return &types.DiagnosisContextPolicy{
+		Providers:   providers,
+		LogLines:    policy.LogLines,
+		SystemdUnit: policy.SystemdUnit,
+	}
+}
+
+func convertTypesToModelDiagnosisContextPolicy(policy *types.DiagnosisContextPolicy) *model.DiagnosisContextPolicy {
+	if policy == nil {
+		return nil
+	}
+
+	providers := make([]model.DiagnosisContextType, 0, len(policy.Providers))
+	for _, provider := range policy.Providers {
+		providers = append(providers, model.DiagnosisContextType(provider))
+	}
+	return &model.DiagnosisContextPolicy{
+		Providers:   providers,
+		LogLines:    policy.LogLines,
+		SystemdUnit: policy.SystemdUnit,
+	}
+}
+
+func convertPromptTemplateRef(ref *model.PromptTemplateRef) *types.PromptTemplateRef {
+	if ref == nil {
+		return nil
+	}
+
+	return &types.PromptTemplateRef{
+		Name:    ref.Name,
+		Version: ref.Version,
+	}
+}
+
+func convertTypesToModelPromptTemplateRef(ref *types.PromptTemplateRef) *model.PromptTemplateRef {
+	if ref == nil {
+		return nil
+	}
+
+	return &model.PromptTemplateRef{
+		Name:    ref.Name,
+		Version: ref.Version,
+	}
+}
+
+func convertNotificationPolicy(policy *model.NotificationPolicy) *types.NotificationPolicy {
+	if policy == nil {
+		return nil
+	}
+
+	routes := make([]types.NotifyRoute, 0, len(policy.Routes))
+	for _, route := range policy.Routes {
+		events := make([]string, 0, len(route.Events))
+		for _, event := range route.Events {
+			events = append(events, string(event))
+		}
+		routes = append(routes, types.NotifyRoute{
+			Channel: route.Channel,
+			Events:  events,
+		})
+	}
+	return &types.NotificationPolicy{
+		Routes: routes,
+	}
+}
+
+func convertTypesToModelNotificationPolicy(policy *types.NotificationPolicy) *model.NotificationPolicy {
+	if policy == nil {
+		return nil
+	}
+
+	routes := make([]model.NotifyRoute, 0, len(policy.Routes))
+	for _, route := range policy.Routes {
+		events := make([]model.NotifyEventType, 0, len(route.Events))
+		for _, event := range route.Events {
+			events = append(events, model.NotifyEventType(event))
+		}
+		routes = append(routes, model.NotifyRoute{
+			Channel: route.Channel,
+			Events:  events,
+		})
+	}
+	return &model.NotificationPolicy{
+		Routes: routes,
+	}
+}
+
+func convertTypesToModelApprovalPolicy(policy *types.ApprovalPolicy) *model.ApprovalPolicy {
+	if policy == nil {
+		return nil
+	}
+
+	return &model.ApprovalPolicy{
+		Enabled:           policy.Enabled,
+		Approvers:         policy.Approvers,
+		RequiredApprovals: policy.RequiredApprovals,
+		ExpireHours:       policy.ExpireHours,
+	}
+}
@@ -68,6 +68,15 @@ func (l *GetAppDetailLogic) GetAppDetail(req *types.GetAppDetailReq) (resp *type
 		Machines:         machines,
 		RollbackPolicy:   convertRollbackPolicy(application.RollbackPolicy),
 		REDMetricsConfig: convertREDMetrics(application.REDMetricsConfig),
+		DeployWindows:    convertDeployWindows(application.DeployWindows),
+		ApprovalPolicy:   convertApprovalPolicy(application.ApprovalPolicy),
+		RetentionPolicy:  convertRetentionPolicy(application.RetentionPolicy),
+		CanaryPolicy:     convertCanaryPolicy(application.CanaryPolicy),
+		ReadinessProbe:   convertReadinessProbe(application.ReadinessProbe),
+		DiagnosisContext: convertDiagnosisContextPolicy(application.DiagnosisContext),
+		PromptTemplate:   convertPromptTemplateRef(application.PromptTemplate),
+		Notification:     convertNotificationPolicy(application.Notification),
+		AlertRuleSync:    convertAlertRuleSync(application.AlertRuleSync),
 		CreatedAt:        application.CreatedTime.Unix(),
 		UpdatedAt:        application.UpdatedTime.Unix(),
 	}
@@ -83,6 +83,15 @@ func (l *GetAppListLogic) GetAppList(req *types.GetAppListReq) (resp *types.GetA
 			Machines:         machines,
 			RollbackPolicy:   convertRollbackPolicy(app.RollbackPolicy),
 			REDMetricsConfig: convertREDMetrics(app.REDMetricsConfig),
+			DeployWindows:    convertDeployWindows(app.DeployWindows),
+			ApprovalPolicy:   convertApprovalPolicy(app.ApprovalPolicy),
+			RetentionPolicy:  convertRetentionPolicy(app.RetentionPolicy),
+			CanaryPolicy:     convertCanaryPolicy(app.CanaryPolicy),
+			ReadinessProbe:   convertReadinessProbe(app.ReadinessProbe),
+			DiagnosisContext: convertDiagnosisContextPolicy(app.DiagnosisContext),
+			PromptTemplate:   convertPromptTemplateRef(app.PromptTemplate),
+			Notification:     convertNotificationPolicy(app.Notification),
+			AlertRuleSync:    convertAlertRuleSync(app.AlertRuleSync),
 			CreatedAt:        app.CreatedTime.Unix(),
 			UpdatedAt:        app.UpdatedTime.Unix(),
 		})
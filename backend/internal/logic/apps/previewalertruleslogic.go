@@ -0,0 +1,74 @@
+package apps
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+	"github.com/Z3Labs/Hackathon/backend/internal/svc"
+	"github.com/Z3Labs/Hackathon/backend/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type PreviewAlertRulesLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewPreviewAlertRulesLogic(ctx context.Context, svcCtx *svc.ServiceContext) PreviewAlertRulesLogic {
+	return PreviewAlertRulesLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *PreviewAlertRulesLogic) PreviewAlertRules(req *types.PreviewAlertRulesReq) (resp *types.PreviewAlertRulesResp, err error) {
+	if l.svcCtx.PromClient == nil {
+		return nil, errors.New("未配置 Prometheus，无法同步告警规则")
+	}
+
+	app, err := l.svcCtx.ApplicationModel.FindById(l.ctx, req.Id)
+	if err != nil {
+		l.Errorf("[PreviewAlertRules] ApplicationModel.FindById error:%v", err)
+		return nil, errors.New("应用不存在")
+	}
+
+	// 按待保存的配置生成规则，不修改应用
+	if req.RollbackPolicy != nil {
+		if err := validateAlertRules(req.RollbackPolicy.AlertRules); err != nil {
+			return nil, err
+		}
+		app.RollbackPolicy = convertTypesToModelRollbackPolicy(req.RollbackPolicy)
+	}
+	if req.MachineIds != nil {
+		machines := make([]model.Machine, 0, len(req.MachineIds))
+		for _, machineId := range req.MachineIds {
+			machine, err := l.svcCtx.MachineModel.FindById(l.ctx, machineId)
+			if err != nil {
+				l.Errorf("[PreviewAlertRules] MachineModel.FindById error:%v, machineId:%s", err, machineId)
+				continue
+			}
+			machines = append(machines, *machine)
+		}
+		app.Machines = machines
+	}
+
+	group := alertGroupName(app.Name)
+	rules, statuses := buildAlertGroup(app, time.Now())
+	diff, err := l.svcCtx.PromClient.DiffAlertGroup(group, rules)
+	if err != nil {
+		l.Errorf("[PreviewAlertRules] PromClient.DiffAlertGroup error:%v", err)
+		return nil, errors.New("预览告警规则变更失败")
+	}
+
+	return &types.PreviewAlertRulesResp{
+		Group:         group,
+		Changed:       diff != "",
+		Diff:          diff,
+		AlertRuleSync: convertAlertRuleSync(statuses),
+	}, nil
+}
@@ -3,8 +3,14 @@ package apps
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/Z3Labs/Hackathon/backend/internal/clients/diagnosis"
+	"github.com/Z3Labs/Hackathon/backend/internal/clients/notify"
+	"github.com/Z3Labs/Hackathon/backend/internal/clients/prom"
+	"github.com/Z3Labs/Hackathon/backend/internal/logic/deployments"
 	"github.com/Z3Labs/Hackathon/backend/internal/model"
 	"github.com/Z3Labs/Hackathon/backend/internal/svc"
 	"github.com/Z3Labs/Hackathon/backend/internal/types"
@@ -35,6 +41,7 @@ func (l *UpdateAppLogic) UpdateApp(req *types.UpdateAppReq) (resp *types.UpdateA
 	}
 
 	// 更新应用信息
+	oldName := existingApp.Name
 	existingApp.Name = req.Name
 	if req.Repo != "" {
 		existingApp.Repo = req.Repo
@@ -49,14 +56,142 @@ func (l *UpdateAppLogic) UpdateApp(req *types.UpdateAppReq) (resp *types.UpdateA
 
 	// 更新回滚策略配置
 	if req.RollbackPolicy != nil {
+		// 监控时需要将表达式限定到发布中的机器，无法解析的表达式直接拒绝
+		if err := validateAlertRules(req.RollbackPolicy.AlertRules); err != nil {
+			return nil, err
+		}
+		// 默认通知渠道变更时才校验，兼容早期填写的渠道说明
+		channel := strings.TrimSpace(req.RollbackPolicy.NotifyChannel)
+		if channel != "" && (existingApp.RollbackPolicy == nil || existingApp.RollbackPolicy.NotifyChannel != channel) &&
+			!l.svcCtx.Notifier.HasChannel(channel) {
+			return nil, fmt.Errorf("通知渠道 %s 未配置", channel)
+		}
+		req.RollbackPolicy.NotifyChannel = channel
 		existingApp.RollbackPolicy = convertTypesToModelRollbackPolicy(req.RollbackPolicy)
 	}
 
+	// 更新通知路由，路由为空表示取消
+	if policy := req.Notification; policy != nil {
+		for _, route := range policy.Routes {
+			if !l.svcCtx.Notifier.HasChannel(route.Channel) {
+				return nil, fmt.Errorf("通知渠道 %s 未配置", route.Channel)
+			}
+			for _, event := range route.Events {
+				if !notify.ValidEventType(model.NotifyEventType(event)) {
+					return nil, fmt.Errorf("不支持的通知事件类型: %s", event)
+				}
+			}
+		}
+		existingApp.Notification = nil
+		if len(policy.Routes) > 0 {
+			existingApp.Notification = convertTypesToModelNotificationPolicy(policy)
+		}
+	}
+
 	// 更新RED指标配置
 	if req.REDMetricsConfig != nil {
 		existingApp.REDMetricsConfig = convertTypesToModelREDMetrics(req.REDMetricsConfig)
 	}
 
+	// 更新发布审批策略
+	if policy := req.ApprovalPolicy; policy != nil {
+		if len(policy.Approvers) > 0 && policy.RequiredApprovals > len(policy.Approvers) {
+			return nil, errors.New("审批通过人数不能超过审批人组人数")
+		}
+		existingApp.ApprovalPolicy = convertTypesToModelApprovalPolicy(policy)
+	}
+
+	// 更新版本目录保留策略
+	if policy := req.RetentionPolicy; policy != nil {
+		if policy.KeepReleases < 0 {
+			return nil, errors.New("保留版本数不能为负数")
+		}
+		existingApp.RetentionPolicy = convertTypesToModelRetentionPolicy(policy)
+	}
+
+	// 更新灰度自动分析策略
+	if policy := req.CanaryPolicy; policy != nil {
+		if policy.Enabled && policy.WindowSeconds <= 0 {
+			return nil, errors.New("灰度分析观察窗口必须大于0")
+		}
+		if policy.PassScore > 0 && policy.MarginalScore > policy.PassScore {
+			return nil, errors.New("灰度分析不通过分数不能高于通过分数")
+		}
+		existingApp.CanaryPolicy = convertTypesToModelCanaryPolicy(policy)
+	}
+
+	// 更新发布后就绪探针，类型为空表示删除探针
+	if probe := req.ReadinessProbe; probe != nil && probe.Type == "" {
+		existingApp.ReadinessProbe = nil
+	} else if probe != nil {
+		switch model.ProbeType(probe.Type) {
+		case model.ProbeTypeHTTP, model.ProbeTypeTCP:
+			if probe.Port <= 0 || probe.Port > 65535 {
+				return nil, errors.New("就绪探针端口无效")
+			}
+		case model.ProbeTypeCommand:
+			if probe.Command == "" {
+				return nil, errors.New("命令探针的命令不能为空")
+			}
+		default:
+			return nil, errors.New("不支持的就绪探针类型")
+		}
+		if probe.TimeoutSeconds < 0 || probe.IntervalSeconds < 0 || probe.InitialDelaySeconds < 0 {
+			return nil, errors.New("就绪探针的超时和间隔不能为负数")
+		}
+		if probe.Retries < -1 {
+			return nil, errors.New("就绪探针的重试次数无效，-1 表示不重试")
+		}
+		existingApp.ReadinessProbe = convertTypesToModelReadinessProbe(probe)
+	}
+
+	// 更新诊断补充上下文配置
+	if policy := req.DiagnosisContext; policy != nil {
+		for _, provider := range policy.Providers {
+			if !diagnosis.ValidContextType(model.DiagnosisContextType(provider)) {
+				return nil, fmt.Errorf("不支持的诊断上下文类型: %s", provider)
+			}
+		}
+		if policy.LogLines < 0 {
+			return nil, errors.New("日志行数不能为负数")
+		}
+		if policy.SystemdUnit != "" && !diagnosis.ValidSystemdUnit(policy.SystemdUnit) {
+			return nil, errors.New("systemd 单元名不合法")
+		}
+		existingApp.DiagnosisContext = convertTypesToModelDiagnosisContextPolicy(policy)
+	}
+
+	// 更新提示词模板，模板名称为空表示恢复内置提示词
+	if ref := req.PromptTemplate; ref != nil {
+		ref.Name = strings.TrimSpace(ref.Name)
+		if ref.Name == "" {
+			existingApp.PromptTemplate = nil
+		} else {
+			if ref.Version < 0 {
+				return nil, errors.New("提示词模板版本不能为负数")
+			}
+			promptTemplate := convertTypesToModelPromptTemplateRef(ref)
+			if _, err := diagnosis.ResolvePromptTemplate(l.ctx, l.svcCtx.PromptTemplateModel, "", &model.Application{PromptTemplate: promptTemplate}); err != nil {
+				if errors.Is(err, diagnosis.ErrPromptTemplateNotFound) {
+					return nil, errors.New("提示词模板不存在")
+				}
+				l.Errorf("[UpdateApp] diagnosis.ResolvePromptTemplate error:%v", err)
+				return nil, errors.New("获取提示词模板失败")
+			}
+			existingApp.PromptTemplate = promptTemplate
+		}
+	}
+
+	// 更新发布窗口，传空数组表示取消限制
+	if req.DeployWindows != nil {
+		for _, window := range req.DeployWindows {
+			if !validClock(window.StartTime) || !validClock(window.EndTime) {
+				return nil, errors.New("发布窗口时间格式错误，应为 HH:MM")
+			}
+		}
+		existingApp.DeployWindows = convertTypesToModelDeployWindows(req.DeployWindows)
+	}
+
 	// 如果提供了机器ID列表，更新机器关联
 	if req.MachineIds != nil {
 		machines := make([]model.Machine, 0)
@@ -98,9 +233,94 @@ func (l *UpdateAppLogic) UpdateApp(req *types.UpdateAppReq) (resp *types.UpdateA
 		return nil, errors.New("更新应用失败")
 	}
 
+	// 告警规则、机器或应用名称变化时同步 vmalert 规则文件。保存成功后才同步，避免规则文件与未保存的配置不一致
+	if l.svcCtx.PromClient != nil && (req.RollbackPolicy != nil || req.MachineIds != nil || oldName != existingApp.Name) {
+		existingApp.AlertRuleSync = l.syncAlertRules(existingApp, oldName)
+		if err := l.svcCtx.ApplicationModel.UpdateAlertRuleSync(l.ctx, existingApp.Id, existingApp.AlertRuleSync); err != nil {
+			l.Errorf("[UpdateApp] ApplicationModel.UpdateAlertRuleSync error:%v", err)
+		}
+	}
+
 	l.Infof("[UpdateApp] Successfully updated app: %s, ID: %s", req.Name, req.Id)
 
 	return &types.UpdateAppResp{
-		Success: true,
+		Success:       true,
+		AlertRuleSync: convertAlertRuleSync(existingApp.AlertRuleSync),
 	}, nil
 }
+
+// syncAlertRules 同步告警规则，写入规则文件失败时所有规则标记为未同步
+func (l *UpdateAppLogic) syncAlertRules(app *model.Application, oldName string) []model.AlertRuleSyncStatus {
+	statuses, err := syncAlertRules(l.svcCtx.PromClient, app, oldName)
+	if err == nil {
+		return statuses
+	}
+
+	l.Errorf("[UpdateApp] syncAlertRules error:%v", err)
+	statuses = []model.AlertRuleSyncStatus{}
+	if app.RollbackPolicy != nil {
+		for _, rule := range app.RollbackPolicy.AlertRules {
+			statuses = append(statuses, model.AlertRuleSyncStatus{
+				Name:     rule.Name,
+				Message:  fmt.Sprintf("写入规则文件失败: %v", err),
+				SyncedAt: time.Now(),
+			})
+		}
+	}
+	return statuses
+}
+
+func validClock(clock string) bool {
+	_, err := time.Parse("15:04", clock)
+	return err == nil
+}
+
+// validateAlertRules 校验应用的全部告警规则。规则名称在发布监控和 vmalert 规则分组中标识规则，不能重复
+func validateAlertRules(rules []types.PrometheusAlert) error {
+	names := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		if strings.TrimSpace(rule.Name) == "" {
+			return errors.New("告警规则名称不能为空")
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("告警规则名称 %s 重复", rule.Name)
+		}
+		names[rule.Name] = true
+		if err := validateAlertRule(rule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateAlertRule 校验告警规则：表达式需能限定到发布中的机器，比较条件、时长和无数据策略需合法
+func validateAlertRule(rule types.PrometheusAlert) error {
+	// 按发布监控的方式用规则配置的机器标签限定到示例机器，校验限定后的表达式
+	expr, err := deployments.ScopeAlertExpr(model.PrometheusAlert{
+		AlertExpr: rule.AlertExpr,
+		HostLabel: rule.HostLabel,
+	}, []string{"host"})
+	if err != nil {
+		return fmt.Errorf("告警规则 %s 的表达式无法解析: %v", rule.Name, err)
+	}
+	if err := prom.ValidateExpr(expr); err != nil {
+		return fmt.Errorf("告警规则 %s 的表达式语法错误: %v", rule.Name, err)
+	}
+	if !deployments.ValidAlertOperator(rule.Operator) {
+		return fmt.Errorf("告警规则 %s 的比较运算符 %s 不支持", rule.Name, rule.Operator)
+	}
+	for _, duration := range []string{rule.Duration, rule.KeepFiringFor} {
+		if duration == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(duration); err != nil || d < 0 {
+			return fmt.Errorf("告警规则 %s 的时长 %s 格式错误", rule.Name, duration)
+		}
+	}
+	switch model.AlertAbsentPolicy(rule.AbsentPolicy) {
+	case "", model.AlertAbsentPolicyOK, model.AlertAbsentPolicyFire, model.AlertAbsentPolicyIgnore:
+	default:
+		return fmt.Errorf("告警规则 %s 的无数据策略 %s 不支持", rule.Name, rule.AbsentPolicy)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package apps
+
+import (
+	"testing"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/types"
+)
+
+func TestValidateAlertRules(t *testing.T) {
+	down := types.PrometheusAlert{Name: "down", AlertExpr: `up == 0`}
+	errors := types.PrometheusAlert{Name: "errors", AlertExpr: `rate(errors_total[1m]) > 1`}
+
+	if err := validateAlertRules([]types.PrometheusAlert{down, errors}); err != nil {
+		t.Errorf("validateAlertRules() error = %v", err)
+	}
+	// 规则名称标识发布监控状态和 vmalert 规则，重复时拒绝
+	if err := validateAlertRules([]types.PrometheusAlert{down, errors, down}); err == nil {
+		t.Errorf("validateAlertRules() should reject duplicate names")
+	}
+	if err := validateAlertRules([]types.PrometheusAlert{{AlertExpr: `up == 0`}}); err == nil {
+		t.Errorf("validateAlertRules() should reject empty name")
+	}
+}
+
+func TestValidateAlertRuleHostLabel(t *testing.T) {
+	rule := types.PrometheusAlert{Name: "down", AlertExpr: `up == 0`, HostLabel: "instance"}
+	if err := validateAlertRule(rule); err != nil {
+		t.Errorf("validateAlertRule() error = %v", err)
+	}
+	// 按规则配置的机器标签限定表达式，非法的标签名无法通过校验
+	rule.HostLabel = "host-name"
+	if err := validateAlertRule(rule); err == nil {
+		t.Errorf("validateAlertRule() should reject invalid host label")
+	}
+}
@@ -3,6 +3,9 @@ package deployments
 import (
 	"context"
 	"fmt"
+	"math"
+	"net"
+	"regexp"
 	"strings"
 	"sync"
 	"time"
@@ -15,14 +18,20 @@ import (
 	"github.com/zeromicro/go-zero/core/logx"
 )
 
+// defaultAlertHostLabel 告警规则未指定机器标签时使用的标签名
+const defaultAlertHostLabel = "hostname"
+
 type DeploymentAlert struct {
 	DeploymentID  string
 	AppName       string
 	AlertRule     model.PrometheusAlert
 	StartTime     time.Time
 	LastCheckTime time.Time
-	FiringStart   *time.Time
-	IsFiring      bool
+	FiringStart   *time.Time // 开始满足告警条件的时间
+	IsFiring      bool       // 是否满足告警条件，包括等待持续时长和 keep_firing_for 保持期间
+	LastActiveAt  *time.Time // 最近一次满足告警条件的时间
+	FiringHosts   []string   // 满足告警条件的机器名称
+	LastReason    string     // 最近一次评估的说明
 }
 
 type AlertMonitor struct {
@@ -77,10 +86,86 @@ func (am *AlertMonitor) StartMonitoring(ctx context.Context, deployment *model.D
 	}
 
 	am.activeAlerts[deployment.Id] = alerts
+	for _, alert := range alerts {
+		am.saveState(ctx, alert)
+	}
 	logx.Infof("Started monitoring deployment %s (status: %s) with %d alert rules", deployment.Id, deployment.Status, len(alerts))
 	return nil
 }
 
+// Restore 服务启动时从数据库恢复告警监控状态，已开始的告警持续时长计时不会因重启丢失
+func (am *AlertMonitor) Restore(ctx context.Context) error {
+	states, err := am.svcCtx.AlertStateModel.Search(ctx, &model.AlertStateCond{})
+	if err != nil {
+		return fmt.Errorf("failed to load alert states: %w", err)
+	}
+
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	for _, state := range states {
+		am.activeAlerts[state.DeploymentId] = append(am.activeAlerts[state.DeploymentId], &DeploymentAlert{
+			DeploymentID:  state.DeploymentId,
+			AppName:       state.AppName,
+			AlertRule:     state.Rule,
+			StartTime:     state.StartTime,
+			LastCheckTime: state.LastCheckTime,
+			FiringStart:   state.FiringStart,
+			IsFiring:      state.IsFiring,
+			LastActiveAt:  state.LastActiveAt,
+			FiringHosts:   state.FiringHosts,
+			LastReason:    state.LastReason,
+		})
+	}
+	logx.Infof("Restored alert monitoring for %d deployments", len(am.activeAlerts))
+	return nil
+}
+
+// monitorActiveDeployments 为所有发布中和回滚中但尚未监控的发布单启动监控
+func (am *AlertMonitor) monitorActiveDeployments(ctx context.Context) {
+	for _, status := range []model.DeploymentStatus{model.DeploymentStatusDeploying, model.DeploymentStatusRollingBack} {
+		deployments, err := am.svcCtx.DeploymentModel.Search(ctx, &model.DeploymentCond{
+			Status: string(status),
+		})
+		if err != nil {
+			logx.Errorf("Failed to search deployments with status %s: %v", status, err)
+			continue
+		}
+
+		for _, deployment := range deployments {
+			if am.GetMonitoringStatus(deployment.Id) {
+				continue
+			}
+			app, err := am.svcCtx.ApplicationModel.FindById(ctx, deployment.AppId)
+			if err != nil {
+				logx.Errorf("Failed to find application %s: %v", deployment.AppId, err)
+				continue
+			}
+			if err := am.StartMonitoring(ctx, deployment, app); err != nil {
+				logx.Errorf("Failed to start monitoring deployment %s: %v", deployment.Id, err)
+			}
+		}
+	}
+}
+
+func (am *AlertMonitor) saveState(ctx context.Context, alert *DeploymentAlert) {
+	err := am.svcCtx.AlertStateModel.Upsert(ctx, &model.AlertState{
+		DeploymentId:  alert.DeploymentID,
+		AppName:       alert.AppName,
+		Rule:          alert.AlertRule,
+		StartTime:     alert.StartTime,
+		LastCheckTime: alert.LastCheckTime,
+		FiringStart:   alert.FiringStart,
+		IsFiring:      alert.IsFiring,
+		LastActiveAt:  alert.LastActiveAt,
+		FiringHosts:   alert.FiringHosts,
+		LastReason:    alert.LastReason,
+	})
+	if err != nil {
+		logx.Errorf("Failed to save state of alert %s for deployment %s: %v", alert.AlertRule.Name, alert.DeploymentID, err)
+	}
+}
+
 func (am *AlertMonitor) StopMonitoring(deploymentID string) {
 	am.mu.Lock()
 	defer am.mu.Unlock()
@@ -97,9 +182,15 @@ func (am *AlertMonitor) StopMonitoring(deploymentID string) {
 	} else {
 		logx.Infof("Deployment %s was not being monitored", deploymentID)
 	}
+
+	if err := am.svcCtx.AlertStateModel.DeleteByDeployment(context.Background(), deploymentID); err != nil {
+		logx.Errorf("Failed to delete alert states of deployment %s: %v", deploymentID, err)
+	}
 }
 
 func (am *AlertMonitor) CheckAlerts(ctx context.Context) error {
+	am.monitorActiveDeployments(ctx)
+
 	am.mu.RLock()
 	alertsToCheck := make(map[string][]*DeploymentAlert)
 	for k, v := range am.activeAlerts {
@@ -123,7 +214,7 @@ func (am *AlertMonitor) CheckAlerts(ctx context.Context) error {
 			continue
 		}
 
-		// 检查发布单状态是否还需要监控
+		// 发布结束后继续监控 30 分钟，之后停止
 		if !am.IsDeploymentInMonitoringStatus(deployment) {
 			if am.shouldStopMonitoring(deployment, now) {
 				am.StopMonitoring(deploymentID)
@@ -131,32 +222,18 @@ func (am *AlertMonitor) CheckAlerts(ctx context.Context) error {
 			}
 		}
 
-		// 检查单个告警规则
+		// 检查单个告警规则，每次评估后保存状态以便重启后恢复
 		for _, alert := range alerts {
 			if err := am.checkSingleAlert(ctx, deployment, alert, now); err != nil {
 				logx.Errorf("Failed to check alert %s for deployment %s: %v",
 					alert.AlertRule.Name, deploymentID, err)
 			}
+			am.saveState(ctx, alert)
 		}
 	}
-	am.cleanAlert(ctx)
 	return nil
 }
 
-func (am *AlertMonitor) cleanAlert(ctx context.Context) {
-	am.mu.Lock()
-	defer am.mu.Unlock()
-
-	logx.Info("Cleaning alerts for deployments no longer in monitoring status")
-	for deploymentID, _ := range am.activeAlerts {
-		logx.Infof("Checking deployment %s", deploymentID)
-		if deploy, err := am.svcCtx.DeploymentModel.FindById(ctx, deploymentID); err == nil && !am.IsDeploymentInMonitoringStatus(deploy) {
-			logx.Infof("Stopping monitoring for deployment %s", deploymentID)
-			delete(am.activeAlerts, deploymentID)
-		}
-	}
-
-}
 func (am *AlertMonitor) shouldStopMonitoring(deployment *model.Deployment, now time.Time) bool {
 	// 对于发布中和回滚中的状态，需要持续监控，不应该停止
 	switch deployment.Status {
@@ -180,60 +257,277 @@ func (am *AlertMonitor) checkSingleAlert(ctx context.Context, deployment *model.
 
 	alert.LastCheckTime = now
 
-	// todo 只查询发布中的机器的指标
-	queryExpr := alert.AlertRule.AlertExpr
+	// 只查询已开始发布的机器的指标，避免无关机器的告警触发本次发布回滚
+	hostnames := deployedHostnames(deployment)
+	if len(hostnames) == 0 {
+		return nil
+	}
+	queryExpr, err := ScopeAlertExpr(alert.AlertRule, hostnames)
+	if err != nil {
+		return fmt.Errorf("failed to scope alert expr: %w", err)
+	}
+	forDuration, err := parseAlertDuration(alert.AlertRule.Duration)
+	if err != nil {
+		return fmt.Errorf("invalid duration %s: %w", alert.AlertRule.Duration, err)
+	}
+	keepFiringFor, err := parseAlertDuration(alert.AlertRule.KeepFiringFor)
+	if err != nil {
+		return fmt.Errorf("invalid keep_firing_for %s: %w", alert.AlertRule.KeepFiringFor, err)
+	}
 	results, err := am.promClient.QueryInstant(queryExpr)
 	if err != nil {
 		return fmt.Errorf("failed to query prometheus: %w", err)
 	}
 
-	isFiring := am.isAlertFiring(results, alert.AlertRule)
-
-	if isFiring {
-		if !alert.IsFiring {
-			alert.IsFiring = true
-			firingStart := now
-			alert.FiringStart = &firingStart
-			logx.Infof("Alert %s started firing for deployment %s",
-				alert.AlertRule.Name, deployment.Id)
-		} else {
-			duration, err := time.ParseDuration(alert.AlertRule.Duration)
-			if err != nil {
-				return fmt.Errorf("invalid duration %s: %w", alert.AlertRule.Duration, err)
-			}
+	samples, firing := am.evaluateResults(results, alert.AlertRule)
+	evaluation := &model.AlertEvaluation{
+		DeploymentId: deployment.Id,
+		AppName:      deployment.AppName,
+		RuleName:     alert.AlertRule.Name,
+		Query:        queryExpr,
+		Operator:     alertOperator(alert.AlertRule),
+		Threshold:    alert.AlertRule.Threshold,
+		Samples:      samples,
+	}
 
-			if now.Sub(*alert.FiringStart) >= duration {
-				if err := am.triggerAlert(ctx, deployment, alert, results); err != nil {
-					return fmt.Errorf("failed to trigger alert: %w", err)
-				}
-			}
+	active := len(firing) > 0
+	if len(samples) == 0 {
+		switch alert.AlertRule.AbsentPolicy {
+		case model.AlertAbsentPolicyFire:
+			active = true
+			evaluation.Reason = "查询无数据，按策略视为告警"
+		case model.AlertAbsentPolicyIgnore:
+			// 保持之前的状态，不推进持续时长计时
+			evaluation.State = alert.state(now, forDuration)
+			evaluation.FiringHosts = alert.FiringHosts
+			evaluation.Reason = "查询无数据，按策略忽略本次评估"
+			am.recordEvaluation(ctx, evaluation)
+			return nil
+		default:
+			evaluation.Reason = "查询无数据，视为正常"
 		}
+	} else if active {
+		evaluation.Reason = describeBreaches(samples, alert.AlertRule)
 	} else {
-		if alert.IsFiring {
-			logx.Infof("Alert %s stopped firing for deployment %s",
-				alert.AlertRule.Name, deployment.Id)
-			alert.IsFiring = false
-			alert.FiringStart = nil
+		evaluation.Reason = "所有序列均未满足告警条件"
+	}
+
+	wasFiring := alert.IsFiring
+	if active {
+		alert.FiringHosts = attributeHosts(firing, deployment, alertHostLabel(alert.AlertRule))
+	}
+	kept := alert.advance(active, now, forDuration, keepFiringFor)
+	if kept {
+		evaluation.Reason += fmt.Sprintf("，在 keep_firing_for(%s) 内保持告警", alert.AlertRule.KeepFiringFor)
+	}
+	if alert.IsFiring && !wasFiring {
+		logx.Infof("Alert %s started firing for deployment %s on %v: %s",
+			alert.AlertRule.Name, deployment.Id, alert.FiringHosts, evaluation.Reason)
+	} else if !alert.IsFiring && wasFiring {
+		logx.Infof("Alert %s stopped firing for deployment %s",
+			alert.AlertRule.Name, deployment.Id)
+	}
+
+	evaluation.State = alert.state(now, forDuration)
+	evaluation.FiringHosts = alert.FiringHosts
+	alert.LastReason = evaluation.Reason
+	am.recordEvaluation(ctx, evaluation)
+
+	if active && evaluation.State == model.AlertEvaluationStateFiring {
+		if err := am.triggerAlert(ctx, deployment, alert, firing); err != nil {
+			return fmt.Errorf("failed to trigger alert: %w", err)
 		}
 	}
 
 	return nil
 }
 
-func (am *AlertMonitor) isAlertFiring(results []prom.InstantQueryResult, rule model.PrometheusAlert) bool {
-	if len(results) == 0 {
+// advance 根据本次评估是否满足告警条件推进规则状态，返回是否因 keep_firing_for 保持告警
+func (alert *DeploymentAlert) advance(active bool, now time.Time, forDuration, keepFiringFor time.Duration) bool {
+	if active {
+		if !alert.IsFiring {
+			alert.IsFiring = true
+			alert.FiringStart = &now
+		}
+		alert.LastActiveAt = &now
 		return false
 	}
 
+	// 已进入告警状态的规则在条件恢复后的 keep_firing_for 内保持告警，避免抖动
+	if alert.IsFiring && keepFiringFor > 0 && alert.LastActiveAt != nil &&
+		alert.LastActiveAt.Sub(*alert.FiringStart) >= forDuration && now.Sub(*alert.LastActiveAt) < keepFiringFor {
+		return true
+	}
+
+	alert.IsFiring = false
+	alert.FiringStart = nil
+	alert.LastActiveAt = nil
+	alert.FiringHosts = nil
+	return false
+}
+
+// state 规则当前的评估状态：满足条件的时长达到 for 后为告警中
+func (alert *DeploymentAlert) state(now time.Time, forDuration time.Duration) model.AlertEvaluationState {
+	if !alert.IsFiring || alert.FiringStart == nil {
+		return model.AlertEvaluationStateInactive
+	}
+	if now.Sub(*alert.FiringStart) >= forDuration {
+		return model.AlertEvaluationStateFiring
+	}
+	return model.AlertEvaluationStatePending
+}
+
+// evaluateResults 按规则的比较条件评估匹配规则标签的序列，返回所有序列的评估结果和满足告警条件的序列
+func (am *AlertMonitor) evaluateResults(results []prom.InstantQueryResult, rule model.PrometheusAlert) ([]model.AlertSample, []prom.InstantQueryResult) {
+	samples := make([]model.AlertSample, 0, len(results))
+	firing := make([]prom.InstantQueryResult, 0)
 	for _, result := range results {
-		if am.matchesLabels(result.Metric, rule.Labels) {
-			if result.Value.Value > 0 {
-				return true
-			}
+		if !am.matchesLabels(result.Metric, rule.Labels) {
+			continue
+		}
+		breached := compareAlertValue(alertOperator(rule), result.Value.Value, rule.Threshold)
+		samples = append(samples, model.AlertSample{
+			Labels:   result.Metric,
+			Value:    result.Value.Value,
+			Breached: breached,
+		})
+		if breached {
+			firing = append(firing, result)
 		}
 	}
 
-	return false
+	return samples, firing
+}
+
+func (am *AlertMonitor) recordEvaluation(ctx context.Context, evaluation *model.AlertEvaluation) {
+	if err := am.svcCtx.AlertEvaluationModel.Insert(ctx, evaluation); err != nil {
+		logx.Errorf("Failed to record evaluation of alert %s for deployment %s: %v",
+			evaluation.RuleName, evaluation.DeploymentId, err)
+	}
+}
+
+// alertOperator 规则的比较运算符，未配置时沿用表达式值大于 0 即告警的约定
+func alertOperator(rule model.PrometheusAlert) string {
+	if rule.Operator == "" {
+		return ">"
+	}
+	return rule.Operator
+}
+
+// compareAlertValue 按运算符比较序列值与阈值，NaN 不满足任何条件
+func compareAlertValue(operator string, value, threshold float64) bool {
+	if math.IsNaN(value) {
+		return false
+	}
+
+	switch operator {
+	case ">":
+		return value > threshold
+	case ">=":
+		return value >= threshold
+	case "<":
+		return value < threshold
+	case "<=":
+		return value <= threshold
+	case "==":
+		return value == threshold
+	case "!=":
+		return value != threshold
+	default:
+		return false
+	}
+}
+
+// ValidAlertOperator 判断告警规则的比较运算符是否支持，空表示沿用值大于 0 的约定
+func ValidAlertOperator(operator string) bool {
+	switch operator {
+	case "", ">", ">=", "<", "<=", "==", "!=":
+		return true
+	default:
+		return false
+	}
+}
+
+// parseAlertDuration 解析告警规则中的时长，空表示 0
+func parseAlertDuration(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// describeBreaches 描述满足告警条件的序列，如 "web-1: 0.12 > 0.05"
+func describeBreaches(samples []model.AlertSample, rule model.PrometheusAlert) string {
+	label := alertHostLabel(rule)
+	parts := make([]string, 0)
+	for _, sample := range samples {
+		if !sample.Breached {
+			continue
+		}
+		host := sample.Labels[label]
+		if host == "" {
+			host = sample.Labels["instance"]
+		}
+		parts = append(parts, fmt.Sprintf("%s: %g %s %g", host, sample.Value, alertOperator(rule), rule.Threshold))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// deployedHostnames 发布单中非 pending 状态的机器名称，即已开始发布的机器
+func deployedHostnames(deployment *model.Deployment) []string {
+	hostNames := make([]string, 0)
+	for _, node := range deployment.NodeDeployments {
+		if model.NodeStatus(node.NodeDeployStatus) != model.NodeStatusPending {
+			hostNames = append(hostNames, node.Name)
+		}
+	}
+	return hostNames
+}
+
+func alertHostLabel(rule model.PrometheusAlert) string {
+	if rule.HostLabel != "" {
+		return rule.HostLabel
+	}
+	return defaultAlertHostLabel
+}
+
+// ScopeAlertExpr 将告警表达式限定到指定机器：表达式含 {{hostname}} 占位符时按前端约定替换，
+// 否则为表达式中的每个向量选择器追加机器标签的匹配条件
+func ScopeAlertExpr(rule model.PrometheusAlert, hostnames []string) (string, error) {
+	if strings.Contains(rule.AlertExpr, hostnamePlaceholder) {
+		return renderHostname(rule.AlertExpr, hostnames), nil
+	}
+
+	// 精确匹配机器名，允许带端口以兼容 instance 为 host:port 的情况。标签正则匹配本身是全匹配
+	patterns := make([]string, 0, len(hostnames))
+	for _, hostname := range hostnames {
+		patterns = append(patterns, regexp.QuoteMeta(hostname))
+	}
+	return prom.InjectMatcher(rule.AlertExpr, alertHostLabel(rule), "("+strings.Join(patterns, "|")+")(:[0-9]+)?")
+}
+
+// attributeHosts 根据告警序列的机器标签找出触发告警的机器，标签缺失时同时尝试 instance 标签
+func attributeHosts(results []prom.InstantQueryResult, deployment *model.Deployment, hostLabel string) []string {
+	hosts := make([]string, 0)
+	for _, node := range deployment.NodeDeployments {
+		if model.NodeStatus(node.NodeDeployStatus) == model.NodeStatusPending {
+			continue
+		}
+		for _, result := range results {
+			value := result.Metric[hostLabel]
+			if value == "" {
+				value = result.Metric["instance"]
+			}
+			if host, _, err := net.SplitHostPort(value); err == nil {
+				value = host
+			}
+			if value != "" && (value == node.Name || (node.Ip != "" && value == node.Ip) || strings.HasPrefix(value, node.Name+".")) {
+				hosts = append(hosts, node.Name)
+				break
+			}
+		}
+	}
+	return hosts
 }
 
 func (am *AlertMonitor) matchesLabels(metric map[string]string, ruleLabels map[string]string) bool {
@@ -258,22 +552,21 @@ func (am *AlertMonitor) triggerAlert(ctx context.Context, deployment *model.Depl
 	logx.Infof("Triggering alert %s for deployment %s (status: %s) after firing for %v",
 		alert.AlertRule.Name, deployment.Id, deployment.Status, firingDuration)
 
-	// 构建告警描述，包含发布单状态信息
-	desc := fmt.Sprintf("Alert %s has been firing for %s (deployment status: %s)",
-		alert.AlertRule.Name, alert.AlertRule.Duration, deployment.Status)
+	// 构建告警描述，包含发布单状态信息和触发原因
+	desc := fmt.Sprintf("Alert %s has been firing for %s (deployment status: %s): %s",
+		alert.AlertRule.Name, firingDuration.Round(time.Second), deployment.Status, alert.LastReason)
 
 	if len(alert.AlertRule.Annotations) > 0 {
 		if d, ok := alert.AlertRule.Annotations["description"]; ok {
 			desc = d
 		}
 	}
-	// 带上非 pending 状态的节点列表
-	hostNames := make([]string, 0)
-	for _, node := range deployment.NodeDeployments {
-		if model.NodeStatus(node.NodeDeployStatus) != model.NodeStatusPending {
-			hostNames = append(hostNames, node.Name)
-		}
+	// 带上触发告警的机器，无法归属到具体机器时带上所有已开始发布的机器
+	hostNames := alert.FiringHosts
+	if len(hostNames) == 0 {
+		hostNames = deployedHostnames(deployment)
 	}
+	am.recordFiringAlert(ctx, deployment, alert)
 
 	alertReq := &types.PostAlertCallbackReq{
 		Key:       fmt.Sprintf("%s-%s-%d", deployment.Id, alert.AlertRule.Name, now.Unix()),
@@ -286,7 +579,6 @@ func (am *AlertMonitor) triggerAlert(ctx context.Context, deployment *model.Depl
 		Labels: map[string]string{
 			"deploymentId": deployment.Id,
 			"appName":      deployment.AppName,
-			"hostname":     strings.Join(hostNames, ","),
 		},
 		Annotations: alert.AlertRule.Annotations,
 	}
@@ -295,40 +587,31 @@ func (am *AlertMonitor) triggerAlert(ctx context.Context, deployment *model.Depl
 			alertReq.Labels[k] = v
 		}
 	}
+	alertReq.Labels["hostname"] = strings.Join(hostNames, ",")
 
 	if len(results) > 0 {
 		alertReq.Values = results[0].Value.Value
 	}
-	am.alert.AlertCallBack(alertReq)
 	app, err := am.svcCtx.ApplicationModel.FindById(ctx, deployment.AppId)
 	if err != nil {
 		return fmt.Errorf("failed to find application: %w", err)
 	}
-	// 告警告警回调
-	// 根据发布单状态决定是否触发回滚
-	shouldRollback := false
-	if app.RollbackPolicy != nil && app.RollbackPolicy.AutoRollback {
-		// 只有在发布中状态才自动回滚，回滚中的状态不再触发回滚
-		if deployment.Status == model.DeploymentStatusDeploying {
-			shouldRollback = true
-			logx.Infof("Auto rollback triggered for deployment %s (status: %s) due to alert %s",
-				deployment.Id, deployment.Status, alert.AlertRule.Name)
-		} else if deployment.Status == model.DeploymentStatusRollingBack {
-			logx.Infof("Deployment %s is already rolling back, skipping auto rollback for alert %s",
-				deployment.Id, alert.AlertRule.Name)
-		}
-	}
-
-	// 这里可以添加实际的回滚逻辑
-	if shouldRollback {
-		// TODO: 实现自动回滚逻辑
-		am.svcCtx.DeploymentModel.UpdateStatus(ctx, deployment.Id, model.DeploymentStatusRollingBack)
-		logx.Errorf("Auto rollback logic needs to be implemented for deployment %s", deployment.Id)
-	}
+	// 与告警回调共用处理流程：按回滚策略自动回滚并生成诊断报告
+	am.alert.HandleDeploymentAlert(deployment, app, alertReq)
 
 	return nil
 }
 
+// recordFiringAlert 在触发告警的机器上记录告警规则名称
+func (am *AlertMonitor) recordFiringAlert(ctx context.Context, deployment *model.Deployment, alert *DeploymentAlert) {
+	if len(alert.FiringHosts) == 0 {
+		return
+	}
+	if err := am.svcCtx.DeploymentModel.AddFiringAlert(ctx, deployment.Id, alert.FiringHosts, alert.AlertRule.Name); err != nil {
+		logx.Errorf("Failed to record firing alert %s for deployment %s: %v", alert.AlertRule.Name, deployment.Id, err)
+	}
+}
+
 func (am *AlertMonitor) GetActiveAlertsCount() int {
 	am.mu.RLock()
 	defer am.mu.RUnlock()
@@ -0,0 +1,120 @@
+package deployments
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/clients/prom"
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+)
+
+func TestScopeAlertExpr(t *testing.T) {
+	hostnames := []string{"web-1", "web.2"}
+
+	got, err := ScopeAlertExpr(model.PrometheusAlert{AlertExpr: `rate(errors_total[1m]) > 1`}, hostnames)
+	if err != nil {
+		t.Fatalf("ScopeAlertExpr() error = %v", err)
+	}
+	want := `rate(errors_total{hostname=~"(web-1|web\\.2)(:[0-9]+)?"}[1m]) > 1`
+	if got != want {
+		t.Errorf("ScopeAlertExpr() = %s, want %s", got, want)
+	}
+
+	got, err = ScopeAlertExpr(model.PrometheusAlert{AlertExpr: `up{instance=~{{hostname}}} == 0`}, hostnames)
+	if err != nil {
+		t.Fatalf("ScopeAlertExpr() error = %v", err)
+	}
+	want = `up{instance=~"^(web-1|web\\.2)$"} == 0`
+	if got != want {
+		t.Errorf("ScopeAlertExpr() = %s, want %s", got, want)
+	}
+}
+
+func TestAttributeHosts(t *testing.T) {
+	deployment := &model.Deployment{
+		NodeDeployments: []model.NodeDeployment{
+			{Name: "web-1", Ip: "10.0.0.1", NodeDeployStatus: model.NodeDeploymentStatusSuccess},
+			{Name: "web-2", Ip: "10.0.0.2", NodeDeployStatus: model.NodeDeploymentStatusDeploying},
+			{Name: "web-3", Ip: "10.0.0.3", NodeDeployStatus: model.NodeDeploymentStatusPending},
+			{Name: "web-4", Ip: "10.0.0.4", NodeDeployStatus: model.NodeDeploymentStatusSuccess},
+		},
+	}
+	results := []prom.InstantQueryResult{
+		{Metric: map[string]string{"hostname": "web-2"}},
+		{Metric: map[string]string{"instance": "10.0.0.1:9100"}},
+		{Metric: map[string]string{"hostname": "web-3"}},
+		// 名称或 IP 只是前缀相同的其它机器不应归到 web-1/web-4
+		{Metric: map[string]string{"hostname": "web-10"}},
+		{Metric: map[string]string{"instance": "10.0.0.11:9100"}},
+		{Metric: map[string]string{"hostname": "web-40.example.com"}},
+	}
+
+	got := attributeHosts(results, deployment, defaultAlertHostLabel)
+	want := []string{"web-1", "web-2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("attributeHosts() = %v, want %v", got, want)
+	}
+}
+
+func TestCompareAlertValue(t *testing.T) {
+	tests := []struct {
+		operator  string
+		value     float64
+		threshold float64
+		want      bool
+	}{
+		{operator: ">", value: 0.2, threshold: 0.1, want: true},
+		{operator: ">", value: 0.1, threshold: 0.1, want: false},
+		{operator: ">=", value: 0.1, threshold: 0.1, want: true},
+		{operator: "<", value: 5, threshold: 10, want: true},
+		{operator: "<=", value: 11, threshold: 10, want: false},
+		{operator: "==", value: 0, threshold: 0, want: true},
+		{operator: "!=", value: 1, threshold: 0, want: true},
+		{operator: "~", value: 1, threshold: 0, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := compareAlertValue(tt.operator, tt.value, tt.threshold); got != tt.want {
+			t.Errorf("compareAlertValue(%s, %v, %v) = %v, want %v", tt.operator, tt.value, tt.threshold, got, tt.want)
+		}
+	}
+}
+
+func TestDeploymentAlertAdvance(t *testing.T) {
+	start := time.Now()
+	forDuration := time.Minute
+	keepFiringFor := 2 * time.Minute
+	alert := &DeploymentAlert{}
+
+	alert.advance(true, start, forDuration, keepFiringFor)
+	if state := alert.state(start, forDuration); state != model.AlertEvaluationStatePending {
+		t.Fatalf("state = %s, want pending", state)
+	}
+
+	// 未达到 for 时恢复，不进入 keep_firing_for
+	if kept := alert.advance(false, start.Add(30*time.Second), forDuration, keepFiringFor); kept {
+		t.Fatalf("pending alert should not keep firing")
+	}
+	if alert.IsFiring {
+		t.Fatalf("pending alert should be reset")
+	}
+
+	alert.advance(true, start, forDuration, keepFiringFor)
+	alert.advance(true, start.Add(time.Minute), forDuration, keepFiringFor)
+	if state := alert.state(start.Add(time.Minute), forDuration); state != model.AlertEvaluationStateFiring {
+		t.Fatalf("state = %s, want firing", state)
+	}
+
+	// 告警中恢复后在 keep_firing_for 内保持告警
+	if kept := alert.advance(false, start.Add(2*time.Minute), forDuration, keepFiringFor); !kept {
+		t.Fatalf("firing alert should keep firing")
+	}
+	if state := alert.state(start.Add(2*time.Minute), forDuration); state != model.AlertEvaluationStateFiring {
+		t.Fatalf("state = %s, want firing", state)
+	}
+
+	if kept := alert.advance(false, start.Add(4*time.Minute), forDuration, keepFiringFor); kept || alert.IsFiring {
+		t.Fatalf("alert should be resolved after keep_firing_for")
+	}
+}
@@ -0,0 +1,99 @@
+package deployments
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+	"github.com/Z3Labs/Hackathon/backend/internal/types"
+)
+
+// newApproval 根据应用审批策略生成发布单的审批信息，未启用审批时返回 nil
+func newApproval(policy *model.ApprovalPolicy, now time.Time) *model.Approval {
+	if policy == nil || !policy.Enabled {
+		return nil
+	}
+
+	required := policy.RequiredApprovals
+	if required <= 0 {
+		required = 1
+	}
+	var expireTime int64
+	if policy.ExpireHours > 0 {
+		expireTime = now.Add(time.Duration(policy.ExpireHours) * time.Hour).Unix()
+	}
+
+	return &model.Approval{
+		Status:            model.ApprovalStatusPending,
+		Approvers:         policy.Approvers,
+		RequiredApprovals: required,
+		ExpireTime:        expireTime,
+		Records:           []model.ApprovalRecord{},
+	}
+}
+
+// approvalExpired 审批是否已过有效期
+func approvalExpired(approval *model.Approval, now time.Time) bool {
+	return approval.ExpireTime > 0 && now.Unix() >= approval.ExpireTime
+}
+
+// checkApprover 校验审批人在审批人组内且未审批过。记录由 DeploymentModel.AddApprovalRecord 按条件追加，
+// 并发审批时以追加结果为准
+func checkApprover(approval *model.Approval, approver string) error {
+	if approver == "" {
+		return errors.New("审批人不能为空")
+	}
+	if len(approval.Approvers) > 0 && !containsString(approval.Approvers, approver) {
+		return errors.New("不在审批人组内，无审批权限")
+	}
+	for _, record := range approval.Records {
+		if record.Approver == approver {
+			return errors.New("已审批过该发布单")
+		}
+	}
+	return nil
+}
+
+// approvedCount 审批通过的人数
+func approvedCount(approval *model.Approval) int {
+	count := 0
+	for _, record := range approval.Records {
+		if record.Action == model.ApprovalActionApprove {
+			count++
+		}
+	}
+	return count
+}
+
+func containsString(list []string, target string) bool {
+	for _, item := range list {
+		if item == target {
+			return true
+		}
+	}
+	return false
+}
+
+func convertApproval(approval *model.Approval) *types.Approval {
+	if approval == nil {
+		return nil
+	}
+
+	records := make([]types.ApprovalRecord, 0, len(approval.Records))
+	for _, record := range approval.Records {
+		records = append(records, types.ApprovalRecord{
+			Approver:    record.Approver,
+			Action:      string(record.Action),
+			Comment:     record.Comment,
+			CreatedTime: record.CreatedTime,
+		})
+	}
+
+	return &types.Approval{
+		Status:            string(approval.Status),
+		Approvers:         approval.Approvers,
+		RequiredApprovals: approval.RequiredApprovals,
+		ExpireTime:        approval.ExpireTime,
+		Records:           records,
+	}
+}
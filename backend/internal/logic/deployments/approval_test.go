@@ -0,0 +1,48 @@
+package deployments
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+)
+
+func TestCheckApprover(t *testing.T) {
+	now := time.Now()
+	approval := newApproval(&model.ApprovalPolicy{
+		Enabled:           true,
+		Approvers:         []string{"alice", "bob", "carol"},
+		RequiredApprovals: 2,
+		ExpireHours:       1,
+	}, now)
+
+	if err := checkApprover(approval, "mallory"); err == nil {
+		t.Error("approver outside the group should be refused")
+	}
+
+	if err := checkApprover(approval, "alice"); err != nil {
+		t.Fatalf("checkApprover() error = %v", err)
+	}
+	approval.Records = append(approval.Records, model.ApprovalRecord{Approver: "alice", Action: model.ApprovalActionApprove})
+	if count := approvedCount(approval); count != 1 {
+		t.Fatalf("approvedCount() = %d, want 1", count)
+	}
+
+	if err := checkApprover(approval, "alice"); err == nil {
+		t.Error("duplicate approval should be refused")
+	}
+
+	approval.Records = append(approval.Records,
+		model.ApprovalRecord{Approver: "carol", Action: model.ApprovalActionReject},
+		model.ApprovalRecord{Approver: "bob", Action: model.ApprovalActionApprove})
+	if count := approvedCount(approval); count != approval.RequiredApprovals {
+		t.Fatalf("approvedCount() = %d, want %d", count, approval.RequiredApprovals)
+	}
+
+	if approvalExpired(approval, now) {
+		t.Error("approval should not be expired yet")
+	}
+	if !approvalExpired(approval, now.Add(2*time.Hour)) {
+		t.Error("approval should be expired after ExpireHours")
+	}
+}
@@ -0,0 +1,133 @@
+package deployments
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/clients/notify"
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+	"github.com/Z3Labs/Hackathon/backend/internal/svc"
+	"github.com/Z3Labs/Hackathon/backend/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/core/stores/mon"
+)
+
+type ApproveDeploymentLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewApproveDeploymentLogic(ctx context.Context, svcCtx *svc.ServiceContext) ApproveDeploymentLogic {
+	return ApproveDeploymentLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *ApproveDeploymentLogic) ApproveDeployment(req *types.ApproveDeploymentReq) (resp *types.ApproveDeploymentResp, err error) {
+	deployment, err := l.svcCtx.DeploymentModel.FindById(l.ctx, req.Id)
+	if err != nil {
+		l.Errorf("[ApproveDeployment] DeploymentModel.FindById error:%v", err)
+		return nil, errors.New("发布记录不存在")
+	}
+
+	if deployment.Status != model.DeploymentStatusAwaitingApproval || deployment.Approval == nil {
+		l.Errorf("[ApproveDeployment] Invalid status for approve: %s", deployment.Status)
+		return nil, errors.New("只能审批待审批的发布单")
+	}
+
+	now := time.Now()
+	if approvalExpired(deployment.Approval, now) {
+		if _, err := l.svcCtx.DeploymentModel.FinishApproval(l.ctx, deployment.Id, model.ApprovalStatusExpired, model.DeploymentStatusRejected); err != nil {
+			l.Errorf("[ApproveDeployment] DeploymentModel.FinishApproval error:%v", err)
+		}
+		return nil, errors.New("审批已过期")
+	}
+
+	if err := checkApprover(deployment.Approval, req.Approver); err != nil {
+		l.Errorf("[ApproveDeployment] checkApprover error:%v", err)
+		return nil, err
+	}
+
+	// 按条件追加审批记录，并发审批时根据追加后的记录判断是否审批通过
+	deployment, err = l.svcCtx.DeploymentModel.AddApprovalRecord(l.ctx, req.Id, &model.ApprovalRecord{
+		Approver:    req.Approver,
+		Action:      model.ApprovalActionApprove,
+		Comment:     req.Comment,
+		CreatedTime: now.Unix(),
+	})
+	if err == mon.ErrNotFound {
+		l.Errorf("[ApproveDeployment] Deployment %s is no longer pending or already approved by %s", req.Id, req.Approver)
+		return nil, errors.New("发布单已不在待审批状态或已审批过该发布单")
+	}
+	if err != nil {
+		l.Errorf("[ApproveDeployment] DeploymentModel.AddApprovalRecord error:%v", err)
+		return nil, errors.New("审批发布失败")
+	}
+
+	count := approvedCount(deployment.Approval)
+	l.Infof("[ApproveDeployment] %s approved deployment %s (%d/%d)", req.Approver, req.Id,
+		count, deployment.Approval.RequiredApprovals)
+	if count < deployment.Approval.RequiredApprovals {
+		return &types.ApproveDeploymentResp{
+			Success: true,
+		}, nil
+	}
+
+	// 达到审批人数时结束审批，多个审批人同时达到时只有一个结束审批并开始发布
+	finished, err := l.svcCtx.DeploymentModel.FinishApproval(l.ctx, deployment.Id, model.ApprovalStatusApproved, model.DeploymentStatusPending)
+	if err != nil {
+		l.Errorf("[ApproveDeployment] DeploymentModel.FinishApproval error:%v", err)
+		return nil, errors.New("审批发布失败")
+	}
+	if finished && l.startGrayAfterApproval(deployment, now) {
+		l.svcCtx.Notifier.Notify(notify.DeploymentEvent(model.NotifyEventDeploymentStarted, deployment, "审批通过，开始发布灰度机器"))
+	}
+
+	return &types.ApproveDeploymentResp{
+		Success: true,
+	}, nil
+}
+
+// startGrayAfterApproval 审批通过后，非定时的灰度发布立即发布灰度设备；封版期或发布窗口外则保持待发布。
+// 返回是否开始发布
+func (l *ApproveDeploymentLogic) startGrayAfterApproval(deployment *model.Deployment, now time.Time) bool {
+	if deployment.GrayMachineId == "" || deployment.ScheduledTime > 0 {
+		return false
+	}
+
+	app, err := l.svcCtx.ApplicationModel.FindById(l.ctx, deployment.AppId)
+	if err != nil {
+		l.Errorf("[ApproveDeployment] ApplicationModel.FindById error:%v", err)
+		return false
+	}
+	if err := checkDeployAllowed(l.ctx, l.svcCtx.FreezePeriodModel, app, deployment.FreezeOverride, now); err != nil {
+		l.Infof("[ApproveDeployment] Gray release of deployment %s not started: %v", deployment.Id, err)
+		return false
+	}
+
+	for i := range deployment.NodeDeployments {
+		node := &deployment.NodeDeployments[i]
+		if node.Id != deployment.GrayMachineId {
+			continue
+		}
+		node.NodeDeployStatus = model.NodeDeploymentStatusDeploying
+		if err := l.svcCtx.DeploymentModel.UpdateNode(l.ctx, deployment.Id, node); err != nil {
+			l.Errorf("[ApproveDeployment] DeploymentModel.UpdateNode error:%v", err)
+			return false
+		}
+		started, err := l.svcCtx.DeploymentModel.TransitionStatus(l.ctx, deployment.Id,
+			model.DeploymentStatusPending, model.DeploymentStatusDeploying)
+		if err != nil {
+			l.Errorf("[ApproveDeployment] DeploymentModel.TransitionStatus error:%v", err)
+			return false
+		}
+		deployment.Status = model.DeploymentStatusDeploying
+		return started
+	}
+	return false
+}
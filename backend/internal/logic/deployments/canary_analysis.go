@@ -0,0 +1,281 @@
+package deployments
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/clients/prom"
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+	"github.com/Z3Labs/Hackathon/backend/internal/types"
+)
+
+const (
+	defaultCanaryPassScore     = 80
+	defaultCanaryMarginalScore = 50
+
+	// canarySignificance 单侧检验的显著性水平
+	canarySignificance = 0.05
+	// canaryMinSamples 每组至少需要的样本数，不足时该指标视为无数据
+	canaryMinSamples = 3
+)
+
+// CanaryAnalyzer 对比灰度机器和仍在旧版本的基线机器的 RED 指标，给出灰度结论
+type CanaryAnalyzer struct {
+	promClient prom.VMClient
+}
+
+func NewCanaryAnalyzer(promClient prom.VMClient) *CanaryAnalyzer {
+	return &CanaryAnalyzer{
+		promClient: promClient,
+	}
+}
+
+// canaryMetric 参与分析的指标及其变差方向：higherIsWorse 为 true 时灰度显著偏高判定不通过
+type canaryMetric struct {
+	name          string
+	definition    *model.MetricDefinition
+	higherIsWorse bool
+}
+
+// Analyze 查询观察窗口内灰度机器和基线机器的样本，逐个指标做 Mann-Whitney U 检验并打分
+func (ca *CanaryAnalyzer) Analyze(red *model.REDMetrics, policy *model.CanaryPolicy, canary string, baseline []string,
+	start, end time.Time) *model.CanaryAnalysis {
+	analysis := &model.CanaryAnalysis{
+		Verdict:   model.CanaryVerdictInconclusive,
+		Metrics:   []model.CanaryMetricResult{},
+		StartTime: start.Unix(),
+		EndTime:   end.Unix(),
+	}
+	if len(baseline) == 0 {
+		analysis.Message = "没有仍在旧版本的基线机器"
+		return analysis
+	}
+
+	step := end.Sub(start) / 30
+	if step < 5*time.Second {
+		step = 5 * time.Second
+	}
+
+	metrics := []canaryMetric{
+		{name: "rate", definition: red.RateMetric, higherIsWorse: false},
+		{name: "error", definition: red.ErrorMetric, higherIsWorse: true},
+		{name: "duration", definition: red.DurationMetric, higherIsWorse: true},
+	}
+	for _, metric := range metrics {
+		if metric.definition == nil || metric.definition.PromQL == "" {
+			continue
+		}
+
+		canarySamples, err := ca.samples(metric.definition.PromQL, canary, start, end, step)
+		if err != nil {
+			analysis.Message = fmt.Sprintf("查询灰度机器 %s 指标失败: %v", metric.name, err)
+			return analysis
+		}
+		// 每台基线机器使用与灰度机器相同的查询单独取样，PromQL 含聚合时两边的序列口径一致
+		baselineSamples := make([][]float64, 0, len(baseline))
+		for _, host := range baseline {
+			samples, err := ca.samples(metric.definition.PromQL, host, start, end, step)
+			if err != nil {
+				analysis.Message = fmt.Sprintf("查询基线机器 %s 的 %s 指标失败: %v", host, metric.name, err)
+				return analysis
+			}
+			baselineSamples = append(baselineSamples, samples)
+		}
+		analysis.Metrics = append(analysis.Metrics, compareCanaryMetric(metric, canarySamples, baselineSamples))
+	}
+
+	analysis.Score, analysis.Verdict = scoreCanary(analysis.Metrics, policy)
+	analysis.Message = fmt.Sprintf("灰度分析得分 %.1f", analysis.Score)
+	return analysis
+}
+
+// samples 查询一台机器在观察窗口内的样本
+func (ca *CanaryAnalyzer) samples(promql, hostname string, start, end time.Time, step time.Duration) ([]float64, error) {
+	results, err := ca.promClient.QueryRange(renderHostname(promql, []string{hostname}), start, end, step)
+	if err != nil {
+		return nil, err
+	}
+
+	samples := []float64{}
+	for _, result := range results {
+		for _, sample := range result.Values {
+			if !math.IsNaN(sample.Value) && !math.IsInf(sample.Value, 0) {
+				samples = append(samples, sample.Value)
+			}
+		}
+	}
+	return samples, nil
+}
+
+// compareCanaryMetric 单个指标的检验：灰度机器分别与每台基线机器做检验，
+// 在变差方向上显著偏离半数以上基线机器时不通过。样本不足的基线机器不参与比较
+func compareCanaryMetric(metric canaryMetric, canary []float64, baselines [][]float64) model.CanaryMetricResult {
+	result := model.CanaryMetricResult{
+		Name:    metric.name,
+		PValue:  1,
+		Verdict: model.CanaryVerdictNoData,
+	}
+	if len(canary) < canaryMinSamples {
+		return result
+	}
+
+	pValues, medians := []float64{}, []float64{}
+	failed := 0
+	for _, baseline := range baselines {
+		if len(baseline) < canaryMinSamples {
+			continue
+		}
+		var p float64
+		if metric.higherIsWorse {
+			p = mannWhitneyGreater(canary, baseline)
+		} else {
+			p = mannWhitneyGreater(baseline, canary)
+		}
+		if p < canarySignificance {
+			failed++
+		}
+		pValues = append(pValues, p)
+		medians = append(medians, median(baseline))
+	}
+	if len(pValues) == 0 {
+		return result
+	}
+
+	result.CanaryMedian = median(canary)
+	result.BaselineMedian = median(medians)
+	result.PValue = median(pValues)
+	result.Verdict = model.CanaryVerdictPass
+	if failed*2 > len(pValues) {
+		result.Verdict = model.CanaryVerdictFail
+	}
+	return result
+}
+
+// scoreCanary 得分为通过的指标占有数据指标的百分比，按策略阈值给出结论
+func scoreCanary(metrics []model.CanaryMetricResult, policy *model.CanaryPolicy) (float64, model.CanaryVerdict) {
+	passScore, marginalScore := float64(defaultCanaryPassScore), float64(defaultCanaryMarginalScore)
+	if policy != nil && policy.PassScore > 0 {
+		passScore = policy.PassScore
+	}
+	if policy != nil && policy.MarginalScore > 0 {
+		marginalScore = policy.MarginalScore
+	}
+
+	total, passed := 0, 0
+	for _, metric := range metrics {
+		switch metric.Verdict {
+		case model.CanaryVerdictPass:
+			total++
+			passed++
+		case model.CanaryVerdictFail:
+			total++
+		}
+	}
+	if total == 0 {
+		return 0, model.CanaryVerdictInconclusive
+	}
+
+	score := float64(passed) * 100 / float64(total)
+	switch {
+	case score >= passScore:
+		return score, model.CanaryVerdictPass
+	case score < marginalScore:
+		return score, model.CanaryVerdictFail
+	default:
+		return score, model.CanaryVerdictInconclusive
+	}
+}
+
+// mannWhitneyGreater 单侧 Mann-Whitney U 检验，返回 x 显著大于 y 的 p 值。
+// 使用带结修正和连续性修正的正态近似
+func mannWhitneyGreater(x, y []float64) float64 {
+	n1, n2 := float64(len(x)), float64(len(y))
+	if n1 == 0 || n2 == 0 {
+		return 1
+	}
+
+	type rankedValue struct {
+		value float64
+		fromX bool
+	}
+	values := make([]rankedValue, 0, len(x)+len(y))
+	for _, v := range x {
+		values = append(values, rankedValue{value: v, fromX: true})
+	}
+	for _, v := range y {
+		values = append(values, rankedValue{value: v})
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i].value < values[j].value })
+
+	// 相同值取平均秩
+	var rankSumX, tieTerm float64
+	for i := 0; i < len(values); {
+		j := i
+		for j < len(values) && values[j].value == values[i].value {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if values[k].fromX {
+				rankSumX += rank
+			}
+		}
+		ties := float64(j - i)
+		tieTerm += ties*ties*ties - ties
+		i = j
+	}
+
+	n := n1 + n2
+	u := rankSumX - n1*(n1+1)/2
+	mean := n1 * n2 / 2
+	variance := n1 * n2 / 12 * ((n + 1) - tieTerm/(n*(n-1)))
+	if variance <= 0 {
+		return 1
+	}
+
+	z := (u - mean - 0.5) / math.Sqrt(variance)
+	return 0.5 * math.Erfc(z/math.Sqrt2)
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+func convertCanaryAnalysis(analysis *model.CanaryAnalysis) *types.CanaryAnalysis {
+	if analysis == nil {
+		return nil
+	}
+
+	metrics := make([]types.CanaryMetricResult, 0, len(analysis.Metrics))
+	for _, metric := range analysis.Metrics {
+		metrics = append(metrics, types.CanaryMetricResult{
+			Name:           metric.Name,
+			CanaryMedian:   metric.CanaryMedian,
+			BaselineMedian: metric.BaselineMedian,
+			PValue:         metric.PValue,
+			Verdict:        string(metric.Verdict),
+		})
+	}
+
+	return &types.CanaryAnalysis{
+		Verdict:   string(analysis.Verdict),
+		Score:     analysis.Score,
+		Metrics:   metrics,
+		Message:   analysis.Message,
+		Promoted:  analysis.Promoted,
+		StartTime: analysis.StartTime,
+		EndTime:   analysis.EndTime,
+	}
+}
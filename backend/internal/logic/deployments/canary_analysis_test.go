@@ -0,0 +1,85 @@
+package deployments
+
+import (
+	"testing"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+)
+
+func TestMannWhitneyGreater(t *testing.T) {
+	baseline := []float64{100, 102, 98, 101, 99, 103, 97, 100, 101, 99}
+
+	tests := []struct {
+		name        string
+		canary      []float64
+		significant bool
+	}{
+		{
+			name:        "灰度显著偏高",
+			canary:      []float64{150, 148, 152, 149, 151, 150, 153, 147},
+			significant: true,
+		},
+		{
+			name:        "与基线分布一致",
+			canary:      []float64{100, 101, 99, 102, 98, 100, 101, 99},
+			significant: false,
+		},
+		{
+			name:        "灰度显著偏低不算偏高",
+			canary:      []float64{50, 52, 48, 51, 49, 50, 53, 47},
+			significant: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := mannWhitneyGreater(tt.canary, baseline)
+			if (p < canarySignificance) != tt.significant {
+				t.Errorf("mannWhitneyGreater() p = %f, want significant = %v", p, tt.significant)
+			}
+		})
+	}
+}
+
+func TestScoreCanary(t *testing.T) {
+	metrics := []model.CanaryMetricResult{
+		{Name: "rate", Verdict: model.CanaryVerdictPass},
+		{Name: "error", Verdict: model.CanaryVerdictFail},
+		{Name: "duration", Verdict: model.CanaryVerdictPass},
+	}
+
+	score, verdict := scoreCanary(metrics, nil)
+	if verdict != model.CanaryVerdictInconclusive {
+		t.Errorf("scoreCanary() = %.1f %s, want inconclusive", score, verdict)
+	}
+	if _, verdict := scoreCanary(metrics, &model.CanaryPolicy{PassScore: 60}); verdict != model.CanaryVerdictPass {
+		t.Errorf("scoreCanary() with pass score 60 = %s, want pass", verdict)
+	}
+	if _, verdict := scoreCanary([]model.CanaryMetricResult{{Name: "rate", Verdict: model.CanaryVerdictNoData}}, nil); verdict != model.CanaryVerdictInconclusive {
+		t.Errorf("scoreCanary() without data = %s, want inconclusive", verdict)
+	}
+}
+
+func TestCompareCanaryMetric(t *testing.T) {
+	metric := canaryMetric{name: "duration", higherIsWorse: true}
+	canary := []float64{150, 148, 152, 149, 151, 150, 153, 147}
+	normal := []float64{100, 102, 98, 101, 99, 103, 97, 100}
+	slow := []float64{160, 158, 162, 159, 161, 160, 163, 157}
+
+	// 灰度机器慢于所有基线机器
+	if got := compareCanaryMetric(metric, canary, [][]float64{normal, normal}); got.Verdict != model.CanaryVerdictFail {
+		t.Errorf("compareCanaryMetric() = %+v, want fail", got)
+	}
+	// 只慢于半数基线机器时不判定不通过
+	if got := compareCanaryMetric(metric, canary, [][]float64{normal, slow}); got.Verdict != model.CanaryVerdictPass {
+		t.Errorf("compareCanaryMetric() = %+v, want pass", got)
+	}
+	// 样本不足的基线机器不参与比较
+	got := compareCanaryMetric(metric, canary, [][]float64{{100}, slow})
+	if got.Verdict != model.CanaryVerdictPass || got.BaselineMedian != 160 {
+		t.Errorf("compareCanaryMetric() = %+v, want pass against slow baseline", got)
+	}
+	if got := compareCanaryMetric(metric, canary, [][]float64{{100}}); got.Verdict != model.CanaryVerdictNoData {
+		t.Errorf("compareCanaryMetric() = %+v, want no data", got)
+	}
+}
@@ -33,9 +33,10 @@ func (l *CancelDeploymentLogic) CancelDeployment(req *types.CancelDeploymentReq)
 		return nil, errors.New("发布记录不存在")
 	}
 
-	if deployment.Status != model.DeploymentStatusPending && deployment.Status != model.DeploymentStatusDeploying {
+	if deployment.Status != model.DeploymentStatusPending && deployment.Status != model.DeploymentStatusDeploying &&
+		deployment.Status != model.DeploymentStatusAwaitingApproval {
 		l.Errorf("[CancelDeployment] Invalid status for cancel: %s", deployment.Status)
-		return nil, errors.New("只能取消待发布或发布中的发布单")
+		return nil, errors.New("只能取消待审批、待发布或发布中的发布单")
 	}
 
 	deployment.Status = model.DeploymentStatusCanceled
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/Z3Labs/Hackathon/backend/common/qiniu"
+	"github.com/Z3Labs/Hackathon/backend/internal/clients/notify"
 	"github.com/Z3Labs/Hackathon/backend/internal/model"
 	"github.com/Z3Labs/Hackathon/backend/internal/svc"
 	"github.com/Z3Labs/Hackathon/backend/internal/types"
@@ -46,6 +47,31 @@ func (l *CreateDeploymentLogic) CreateDeployment(req *types.CreateDeploymentReq)
 		return nil, errors.New("应用不存在")
 	}
 
+	if req.ScheduledTime > 0 && req.ScheduledTime <= time.Now().Unix() {
+		return nil, errors.New("计划发布时间必须晚于当前时间")
+	}
+
+	// 启用审批的应用需要审批通过后才能开始发布
+	approval := newApproval(application[0].ApprovalPolicy, time.Now())
+
+	// 立即开始灰度发布时需要检查封版期和发布窗口，定时发布在到点时由定时任务检查
+	freezeOverride, err := newFreezeOverride(req.FreezeOverride, l.svcCtx.Config.Deploy.Admins)
+	if err != nil {
+		l.Errorf("[CreateDeployment] newFreezeOverride error:%v", err)
+		return nil, err
+	}
+	if err := bindFreezeOverride(l.ctx, l.svcCtx.FreezePeriodModel, application[0], freezeOverride, time.Now()); err != nil {
+		l.Errorf("[CreateDeployment] bindFreezeOverride error:%v", err)
+		return nil, errors.New("查询封版期失败")
+	}
+	startNow := req.GrayMachineId != "" && req.ScheduledTime == 0 && approval == nil
+	if startNow {
+		if err := checkDeployAllowed(l.ctx, l.svcCtx.FreezePeriodModel, application[0], freezeOverride, time.Now()); err != nil {
+			l.Errorf("[CreateDeployment] checkDeployAllowed error:%v", err)
+			return nil, err
+		}
+	}
+
 	// 从应用信息中提取机器列表并转换为 DeploymentMachine 格式
 	platform := model.PlatformPhysical
 	var nodeDeployments []model.NodeDeployment
@@ -73,6 +99,9 @@ func (l *CreateDeploymentLogic) CreateDeployment(req *types.CreateDeploymentReq)
 		GrayMachineId:   req.GrayMachineId,
 		Platform:        platform,
 		NodeDeployments: nodeDeployments,
+		ScheduledTime:   req.ScheduledTime,
+		FreezeOverride:  freezeOverride,
+		Approval:        approval,
 		CreatedTime:     time.Now().Unix(),
 		UpdatedTime:     time.Now().Unix(),
 	}
@@ -82,6 +111,9 @@ func (l *CreateDeploymentLogic) CreateDeployment(req *types.CreateDeploymentReq)
 		return nil, errors.New("获取包信息失败")
 	}
 	deployment.Package = pkg
+	if approval != nil {
+		deployment.Status = model.DeploymentStatusAwaitingApproval
+	}
 	// 保存到数据库
 	err = l.svcCtx.DeploymentModel.Insert(l.ctx, deployment)
 	if err != nil {
@@ -90,9 +122,10 @@ func (l *CreateDeploymentLogic) CreateDeployment(req *types.CreateDeploymentReq)
 	}
 
 	l.Infof("[CreateDeployment] Successfully created deployment: %s, ID: %s, machines count: %d", req.AppName, deploymentId, len(nodeDeployments))
+	l.svcCtx.Notifier.Notify(notify.DeploymentEvent(model.NotifyEventDeploymentCreated, deployment, ""))
 
 	// 如果指定了灰度设备，立即发布到该设备
-	if req.GrayMachineId != "" {
+	if startNow {
 		// 验证灰度设备是否存在于 NodeDeployments 中
 		grayMachineFound := false
 		for i := range deployment.NodeDeployments {
@@ -115,6 +148,7 @@ func (l *CreateDeploymentLogic) CreateDeployment(req *types.CreateDeploymentReq)
 				// 不影响创建结果，继续返回
 			} else {
 				l.Infof("[CreateDeployment] Gray machine deployment started for machine: %s", req.GrayMachineId)
+				l.svcCtx.Notifier.Notify(notify.DeploymentEvent(model.NotifyEventDeploymentStarted, deployment, "开始发布灰度机器"))
 			}
 		} else {
 			l.Infof("[CreateDeployment] Gray machine ID %s not found in deployment machines", req.GrayMachineId)
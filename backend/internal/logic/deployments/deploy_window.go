@@ -0,0 +1,177 @@
+package deployments
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"time"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/config"
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+	"github.com/Z3Labs/Hackathon/backend/internal/types"
+)
+
+// checkDeployAllowed 检查应用在指定时间是否允许开始发布：
+// 处于全局或应用级封版期、或不在应用配置的发布窗口内时拒绝发布。
+// 强制发布只放行记录时已生效的封版期，以及记录时所在的发布窗口外时段，之后新的封版期和窗口外时段仍会拒绝
+func checkDeployAllowed(ctx context.Context, freezeModel model.FreezePeriodModel, app *model.Application,
+	override *model.FreezeOverride, now time.Time) error {
+	freezes, err := freezeModel.Search(ctx, &model.FreezePeriodCond{
+		AppId:         app.Id,
+		IncludeGlobal: true,
+		ActiveAt:      now,
+	})
+	if err != nil {
+		return fmt.Errorf("查询封版期失败: %w", err)
+	}
+	for _, freeze := range freezes {
+		if override != nil && containsString(override.FreezeIds, freeze.Id) {
+			continue
+		}
+		return fmt.Errorf("当前处于封版期[%s]，%s 至 %s 禁止发布", freeze.Name,
+			freeze.StartTime.Format(time.DateTime), freeze.EndTime.Format(time.DateTime))
+	}
+
+	if len(app.DeployWindows) > 0 && !inDeployWindows(app.DeployWindows, now) &&
+		(override == nil || now.Unix() >= override.WindowUntil) {
+		return fmt.Errorf("当前不在应用 %s 的发布窗口内", app.Name)
+	}
+
+	return nil
+}
+
+// bindFreezeOverride 将强制发布限定到记录时的封版期和发布窗口外时段：记录已生效的封版期ID，
+// 不在发布窗口内时记录下一个发布窗口的开始时间
+func bindFreezeOverride(ctx context.Context, freezeModel model.FreezePeriodModel, app *model.Application,
+	override *model.FreezeOverride, now time.Time) error {
+	if override == nil {
+		return nil
+	}
+
+	freezes, err := freezeModel.Search(ctx, &model.FreezePeriodCond{
+		AppId:         app.Id,
+		IncludeGlobal: true,
+		ActiveAt:      now,
+	})
+	if err != nil {
+		return fmt.Errorf("查询封版期失败: %w", err)
+	}
+	override.FreezeIds = make([]string, 0, len(freezes))
+	for _, freeze := range freezes {
+		override.FreezeIds = append(override.FreezeIds, freeze.Id)
+	}
+
+	override.WindowUntil = 0
+	if len(app.DeployWindows) > 0 && !inDeployWindows(app.DeployWindows, now) {
+		override.WindowUntil = nextDeployWindow(app.DeployWindows, now).Unix()
+	}
+	return nil
+}
+
+// nextDeployWindow 返回 now 之后最近一个发布窗口的开始时间（按分钟），一周内没有窗口时返回一周后
+func nextDeployWindow(windows []model.DeployWindow, now time.Time) time.Time {
+	t := now.Truncate(time.Minute)
+	limit := now.Add(7 * 24 * time.Hour)
+	for t.Before(limit) {
+		t = t.Add(time.Minute)
+		if inDeployWindows(windows, t) {
+			return t
+		}
+	}
+	return limit
+}
+
+// inDeployWindows 判断时间点是否落在任一发布窗口内
+func inDeployWindows(windows []model.DeployWindow, now time.Time) bool {
+	for _, window := range windows {
+		if inDeployWindow(window, now) {
+			return true
+		}
+	}
+	return false
+}
+
+func inDeployWindow(window model.DeployWindow, now time.Time) bool {
+	start, err := parseClock(window.StartTime)
+	if err != nil {
+		return false
+	}
+	end, err := parseClock(window.EndTime)
+	if err != nil {
+		return false
+	}
+
+	minute := now.Hour()*60 + now.Minute()
+	weekday := int(now.Weekday())
+	if start <= end {
+		return matchWeekday(window.Weekdays, weekday) && minute >= start && minute < end
+	}
+
+	// 跨天窗口，凌晨部分属于前一天的窗口
+	if minute >= start {
+		return matchWeekday(window.Weekdays, weekday)
+	}
+	if minute < end {
+		return matchWeekday(window.Weekdays, (weekday+6)%7)
+	}
+	return false
+}
+
+func matchWeekday(weekdays []int, weekday int) bool {
+	if len(weekdays) == 0 {
+		return true
+	}
+	for _, d := range weekdays {
+		if d == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// parseClock 解析 HH:MM 格式的时间，返回当天的分钟数
+func parseClock(clock string) (int, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, fmt.Errorf("invalid clock %s: %w", clock, err)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// newFreezeOverride 将请求中的强制发布信息转换为审计记录，未填写操作人时视为未强制发布，
+// 操作人不是配置的发布管理员或令牌不匹配时拒绝
+func newFreezeOverride(override *types.FreezeOverride, admins []config.DeployAdmin) (*model.FreezeOverride, error) {
+	if override == nil || override.Operator == "" {
+		return nil, nil
+	}
+	if !isDeployAdmin(admins, override.Operator, override.Token) {
+		return nil, fmt.Errorf("%s 不是发布管理员或令牌错误，无权强制发布", override.Operator)
+	}
+	return &model.FreezeOverride{
+		Operator:    override.Operator,
+		Reason:      override.Reason,
+		CreatedTime: time.Now().Unix(),
+	}, nil
+}
+
+// isDeployAdmin 校验操作人是配置的发布管理员且令牌匹配，未配置令牌的管理员不能强制发布
+func isDeployAdmin(admins []config.DeployAdmin, name, token string) bool {
+	for _, admin := range admins {
+		if admin.Name == name && admin.Token != "" &&
+			subtle.ConstantTimeCompare([]byte(admin.Token), []byte(token)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+func convertFreezeOverride(override *model.FreezeOverride) *types.FreezeOverride {
+	if override == nil {
+		return nil
+	}
+	return &types.FreezeOverride{
+		Operator:    override.Operator,
+		Reason:      override.Reason,
+		CreatedTime: override.CreatedTime,
+	}
+}
@@ -0,0 +1,149 @@
+package deployments
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/config"
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+	"github.com/Z3Labs/Hackathon/backend/internal/types"
+)
+
+func TestInDeployWindow(t *testing.T) {
+	// 2025-01-15 是周三
+	at := func(hour, minute int) time.Time {
+		return time.Date(2025, 1, 15, hour, minute, 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		name   string
+		window model.DeployWindow
+		now    time.Time
+		want   bool
+	}{
+		{
+			name:   "每天窗口内",
+			window: model.DeployWindow{StartTime: "10:00", EndTime: "18:00"},
+			now:    at(14, 30),
+			want:   true,
+		},
+		{
+			name:   "窗口结束时间不包含",
+			window: model.DeployWindow{StartTime: "10:00", EndTime: "18:00"},
+			now:    at(18, 0),
+			want:   false,
+		},
+		{
+			name:   "星期不匹配",
+			window: model.DeployWindow{Weekdays: []int{1, 2}, StartTime: "10:00", EndTime: "18:00"},
+			now:    at(14, 30),
+			want:   false,
+		},
+		{
+			name:   "跨天窗口当天部分",
+			window: model.DeployWindow{Weekdays: []int{3}, StartTime: "22:00", EndTime: "02:00"},
+			now:    at(23, 0),
+			want:   true,
+		},
+		{
+			name:   "跨天窗口次日凌晨属于前一天",
+			window: model.DeployWindow{Weekdays: []int{2}, StartTime: "22:00", EndTime: "02:00"},
+			now:    at(1, 0),
+			want:   true,
+		},
+		{
+			name:   "时间格式错误",
+			window: model.DeployWindow{StartTime: "10", EndTime: "18:00"},
+			now:    at(14, 30),
+			want:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inDeployWindow(tt.window, tt.now); got != tt.want {
+				t.Errorf("inDeployWindow() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewFreezeOverride(t *testing.T) {
+	admins := []config.DeployAdmin{{Name: "alice", Token: "t0ken"}, {Name: "carol"}}
+
+	override, err := newFreezeOverride(&types.FreezeOverride{Operator: "alice", Token: "t0ken", Reason: "紧急修复"}, admins)
+	if err != nil || override == nil || override.Operator != "alice" {
+		t.Fatalf("override = %+v, err = %v", override, err)
+	}
+
+	// 非管理员、令牌错误或管理员未配置令牌时不能强制发布
+	for _, req := range []*types.FreezeOverride{
+		{Operator: "bob", Token: "t0ken"},
+		{Operator: "alice", Token: "wrong"},
+		{Operator: "alice"},
+		{Operator: "carol"},
+	} {
+		if _, err := newFreezeOverride(req, admins); err == nil {
+			t.Errorf("override %+v should be rejected", req)
+		}
+	}
+	if _, err := newFreezeOverride(&types.FreezeOverride{Operator: "alice", Token: "t0ken"}, nil); err == nil {
+		t.Error("override should be rejected when no admins configured")
+	}
+
+	// 未填写操作人视为未强制发布
+	if override, err := newFreezeOverride(&types.FreezeOverride{Reason: "x"}, admins); override != nil || err != nil {
+		t.Errorf("override = %+v, err = %v, want nil", override, err)
+	}
+}
+
+type fakeFreezePeriodModel struct {
+	model.FreezePeriodModel
+	freezes []*model.FreezePeriod
+}
+
+func (m *fakeFreezePeriodModel) Search(ctx context.Context, cond *model.FreezePeriodCond) ([]*model.FreezePeriod, error) {
+	var result []*model.FreezePeriod
+	for _, freeze := range m.freezes {
+		if !cond.ActiveAt.Before(freeze.StartTime) && cond.ActiveAt.Before(freeze.EndTime) {
+			result = append(result, freeze)
+		}
+	}
+	return result, nil
+}
+
+func TestCheckDeployAllowedOverride(t *testing.T) {
+	// 2025-01-15 是周三，发布窗口为每天 10:00-18:00
+	at := func(day, hour int) time.Time {
+		return time.Date(2025, 1, day, hour, 0, 0, 0, time.Local)
+	}
+	freezes := &fakeFreezePeriodModel{freezes: []*model.FreezePeriod{
+		{Id: "f1", Name: "春节", StartTime: at(15, 0), EndTime: at(16, 0)},
+		{Id: "f2", Name: "年终", StartTime: at(17, 0), EndTime: at(18, 0)},
+	}}
+	app := &model.Application{Name: "web", DeployWindows: []model.DeployWindow{{StartTime: "10:00", EndTime: "18:00"}}}
+	ctx := context.Background()
+
+	// 封版期 f1 内、发布窗口外强制发布
+	override := &model.FreezeOverride{Operator: "alice"}
+	if err := bindFreezeOverride(ctx, freezes, app, override, at(15, 20)); err != nil {
+		t.Fatal(err)
+	}
+	if len(override.FreezeIds) != 1 || override.FreezeIds[0] != "f1" || override.WindowUntil != at(16, 10).Unix() {
+		t.Fatalf("override = %+v, want bound to f1 until next window", override)
+	}
+	if err := checkDeployAllowed(ctx, freezes, app, override, at(15, 22)); err != nil {
+		t.Errorf("checkDeployAllowed() error = %v, want allowed", err)
+	}
+	// 记录之后的窗口外时段和封版期仍然拒绝
+	if err := checkDeployAllowed(ctx, freezes, app, override, at(16, 20)); err == nil {
+		t.Error("checkDeployAllowed() should reject later time outside deploy window")
+	}
+	if err := checkDeployAllowed(ctx, freezes, app, override, at(17, 12)); err == nil {
+		t.Error("checkDeployAllowed() should reject later freeze period")
+	}
+	if err := checkDeployAllowed(ctx, freezes, app, nil, at(15, 22)); err == nil {
+		t.Error("checkDeployAllowed() should reject without override")
+	}
+}
@@ -26,6 +26,18 @@ func NewDeploymentCron(deploymentManager *DeploymentManager, rollbackManager *Ro
 func (dc *DeploymentCron) Start() error {
 	_, err := dc.cron.AddFunc("@every 30s", func() {
 		ctx := context.Background()
+		if err := dc.deploymentManager.ExpireApprovals(ctx); err != nil {
+			fmt.Printf("expire deployment approvals error: %v\n", err)
+		}
+
+		if err := dc.deploymentManager.StartScheduledDeployments(ctx); err != nil {
+			fmt.Printf("start scheduled deployments error: %v\n", err)
+		}
+
+		if err := dc.deploymentManager.AnalyzeCanaries(ctx); err != nil {
+			fmt.Printf("analyze canary deployments error: %v\n", err)
+		}
+
 		if err := dc.deploymentManager.ContinueDeployingDeployments(ctx); err != nil {
 			fmt.Printf("continue deploying deployments error: %v\n", err)
 		}
@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Z3Labs/Hackathon/backend/internal/clients/notify"
 	"github.com/Z3Labs/Hackathon/backend/internal/logic/deployments/executor"
 	"github.com/Z3Labs/Hackathon/backend/internal/model"
 	"github.com/Z3Labs/Hackathon/backend/internal/svc"
@@ -13,10 +14,18 @@ import (
 )
 
 type DeploymentManager struct {
-	deploymentModel  model.DeploymentModel
-	applicationModel model.ApplicationModel
-	executorFactory  executor.ExecutorFactoryInterface
-	alertMonitor     *AlertMonitor
+	deploymentModel   model.DeploymentModel
+	applicationModel  model.ApplicationModel
+	freezePeriodModel model.FreezePeriodModel
+	nodeVersionModel  model.NodeVersionModel
+	executorFactory   executor.ExecutorFactoryInterface
+	alertMonitor      *AlertMonitor
+	healthVerifier    *HealthVerifier
+	canaryAnalyzer    *CanaryAnalyzer
+	notifier          *notify.Notifier
+	// inflight 本实例正在执行的节点任务，键为 发布单ID:节点ID。节点的发布、就绪探测和健康验证可能持续多个定时周期，
+	// 执行期间节点保持发布中状态，定时任务跳过这些节点，避免同一节点被重复发布
+	inflight sync.Map
 }
 
 var (
@@ -30,9 +39,12 @@ func NewDeploymentManager(
 ) *DeploymentManager {
 	once.Do(func() {
 		instance = &DeploymentManager{
-			deploymentModel:  svc.DeploymentModel,
-			applicationModel: svc.ApplicationModel,
-			executorFactory:  executor.NewExecutorFactory(),
+			deploymentModel:   svc.DeploymentModel,
+			applicationModel:  svc.ApplicationModel,
+			freezePeriodModel: svc.FreezePeriodModel,
+			nodeVersionModel:  svc.NodeVersionModel,
+			executorFactory:   svc.ExecutorFactory,
+			notifier:          svc.Notifier,
 		}
 	})
 	return instance
@@ -42,6 +54,14 @@ func (dm *DeploymentManager) SetAlertMonitor(monitor *AlertMonitor) {
 	dm.alertMonitor = monitor
 }
 
+func (dm *DeploymentManager) SetHealthVerifier(verifier *HealthVerifier) {
+	dm.healthVerifier = verifier
+}
+
+func (dm *DeploymentManager) SetCanaryAnalyzer(analyzer *CanaryAnalyzer) {
+	dm.canaryAnalyzer = analyzer
+}
+
 func GetDeploymentManager() *DeploymentManager {
 	return instance
 }
@@ -56,10 +76,16 @@ func (dm *DeploymentManager) ExecuteDeployment(ctx context.Context, deploymentID
 		return fmt.Errorf("deployment status is not pending, current status: %s", deployment.Status)
 	}
 
-	deployment.Status = model.DeploymentStatusDeploying
-	if err := dm.deploymentModel.Update(ctx, deployment); err != nil {
+	// 只有状态切换成功的一方继续发布并发送开始通知，避免重复执行时重复通知
+	ok, err := dm.deploymentModel.TransitionStatus(ctx, deployment.Id, model.DeploymentStatusPending, model.DeploymentStatusDeploying)
+	if err != nil {
 		return fmt.Errorf("failed to update deployment status: %w", err)
 	}
+	if !ok {
+		return fmt.Errorf("deployment %s has already been started", deploymentID)
+	}
+	deployment.Status = model.DeploymentStatusDeploying
+	dm.notifier.Notify(notify.DeploymentEvent(model.NotifyEventDeploymentStarted, deployment, ""))
 
 	if dm.alertMonitor != nil {
 		app, err := dm.applicationModel.FindById(ctx, deployment.AppName)
@@ -70,7 +96,7 @@ func (dm *DeploymentManager) ExecuteDeployment(ctx context.Context, deploymentID
 		}
 	}
 
-	taskCtx, _ := context.WithCancel(context.Background())
+	taskCtx := context.Background()
 
 	go func() {
 		dm.executeNodes(taskCtx, deployment)
@@ -90,11 +116,28 @@ func (dm *DeploymentManager) executeNodes(ctx context.Context, deployment *model
 		if deployment.NodeDeployments[i].NodeDeployStatus != model.NodeDeploymentStatusDeploying {
 			continue
 		}
+		if !dm.claimNode(deployment.Id, deployment.NodeDeployments[i].Id) {
+			continue
+		}
 		batchNodes = append(batchNodes, deployment.NodeDeployments[i])
 	}
+	deploymentId := deployment.Id
+	defer func() {
+		for _, node := range batchNodes {
+			dm.releaseNode(deploymentId, node.Id)
+		}
+	}()
 
 	if err := dm.executeBatch(ctx, deployment, batchNodes); err != nil {
-		dm.deploymentModel.UpdateStatus(context.Background(), deployment.Id, model.DeploymentStatusFailed)
+		ok, updateErr := dm.deploymentModel.TransitionStatus(context.Background(), deployment.Id, model.DeploymentStatusDeploying, model.DeploymentStatusFailed)
+		if updateErr != nil {
+			logx.Errorf("failed to mark deployment %s failed: %v", deployment.Id, updateErr)
+			return
+		}
+		if ok {
+			deployment.Status = model.DeploymentStatusFailed
+			dm.notifier.Notify(notify.DeploymentEvent(model.NotifyEventDeploymentFailed, deployment, err.Error()))
+		}
 		return
 	}
 	// 全部节点发布完成，设置本次发布完成状态
@@ -102,6 +145,9 @@ func (dm *DeploymentManager) executeNodes(ctx context.Context, deployment *model
 	if deployment.Status != model.DeploymentStatusDeploying {
 		return
 	}
+	if len(batchNodes) > 0 {
+		dm.notifier.Notify(batchCompletedEvent(deployment, batchNodes))
+	}
 	succCount := 0
 	for _, node := range deployment.NodeDeployments {
 		if node.NodeDeployStatus == model.NodeDeploymentStatusSuccess {
@@ -109,7 +155,17 @@ func (dm *DeploymentManager) executeNodes(ctx context.Context, deployment *model
 		}
 	}
 	if succCount == len(deployment.NodeDeployments) {
-		dm.deploymentModel.UpdateStatus(ctx, deployment.Id, model.DeploymentStatusSuccess)
+		// 多个批次可能同时发现全部节点已成功，只有状态切换成功的一方发送通知并更新应用版本
+		ok, err := dm.deploymentModel.TransitionStatus(ctx, deployment.Id, model.DeploymentStatusDeploying, model.DeploymentStatusSuccess)
+		if err != nil {
+			logx.Errorf("failed to mark deployment %s success: %v", deployment.Id, err)
+			return
+		}
+		if !ok {
+			return
+		}
+		deployment.Status = model.DeploymentStatusSuccess
+		dm.notifier.Notify(notify.DeploymentEvent(model.NotifyEventDeploymentSucceeded, deployment, ""))
 		if app, err := dm.applicationModel.FindById(ctx, deployment.AppId); err == nil {
 			app.PrevVersion = app.CurrentVersion
 			app.CurrentVersion = deployment.PackageVersion
@@ -145,6 +201,35 @@ func (dm *DeploymentManager) executeBatch(ctx context.Context, deployment *model
 	return nil
 }
 
+// batchCompletedEvent 一批机器发布完成的通知事件，说明中包含整单的发布进度
+func batchCompletedEvent(deployment *model.Deployment, batchNodes []model.NodeDeployment) notify.Event {
+	nodes := make([]string, 0, len(batchNodes))
+	for _, node := range batchNodes {
+		nodes = append(nodes, node.Name)
+	}
+	done := 0
+	for _, node := range deployment.NodeDeployments {
+		if node.NodeDeployStatus == model.NodeDeploymentStatusSuccess || node.NodeDeployStatus == model.NodeDeploymentStatusSkipped {
+			done++
+		}
+	}
+	event := notify.DeploymentEvent(model.NotifyEventBatchCompleted, deployment,
+		fmt.Sprintf("本批 %d 台机器发布完成，整单进度 %d/%d", len(batchNodes), done, len(deployment.NodeDeployments)))
+	event.Nodes = nodes
+	return event
+}
+
+// claimNode 标记节点任务开始执行，节点已在执行中时返回 false
+func (dm *DeploymentManager) claimNode(deploymentId, nodeId string) bool {
+	_, running := dm.inflight.LoadOrStore(deploymentId+":"+nodeId, struct{}{})
+	return !running
+}
+
+// releaseNode 节点任务执行结束
+func (dm *DeploymentManager) releaseNode(deploymentId, nodeId string) {
+	dm.inflight.Delete(deploymentId + ":" + nodeId)
+}
+
 func findNodeIndex(nodes []model.NodeDeployment, nodeId string) int {
 	for i := range nodes {
 		if nodes[i].Id == nodeId {
@@ -166,12 +251,34 @@ func (dm *DeploymentManager) executeNode(ctx context.Context, deployment *model.
 	logx.Infof("start executing node(%d) %s, deployment %s", nodeIndex, node.Id, deployment.Id)
 	node.DeployingVersion = deployment.PackageVersion
 	node.Platform = deployment.Platform
+
+	// 发布前读取机器上实际安装的版本，以校正后的版本清单确定发布前版本，发布失败时回滚到该版本
+	inspectExecutor, err := dm.executorFactory.CreateExecutor(ctx, executor.ExecutorConfig{
+		Platform: string(deployment.Platform),
+		Host:     node.Id,
+		IP:       node.Ip,
+		Service:  deployment.AppName,
+	})
+	var nodeVersion *model.NodeVersion
+	if err != nil {
+		nodeVersion = loadNodeVersion(context.Background(), dm.nodeVersionModel, node, deployment.AppName)
+	} else {
+		nodeVersion = inspectNodeVersion(ctx, dm.nodeVersionModel, inspectExecutor, node, deployment.AppName)
+	}
+	preVersion := nodeVersion.CurrentVersion
+	if preVersion == deployment.PackageVersion {
+		preVersion = nodeVersion.PrevVersion
+	}
+	if preVersion == "" {
+		preVersion = node.CurrentVersion
+	}
+	node.CurrentVersion = preVersion
 	node.UpdatedAt = time.Now()
 	if node.CreatedAt.IsZero() {
 		node.CreatedAt = time.Now()
 	}
 
-	if err := dm.deploymentModel.Update(context.Background(), deployment); err != nil {
+	if err := dm.deploymentModel.UpdateNode(context.Background(), deployment.Id, node); err != nil {
 		return fmt.Errorf("failed to update node status: %w", err)
 	}
 
@@ -181,9 +288,11 @@ func (dm *DeploymentManager) executeNode(ctx context.Context, deployment *model.
 		IP:          node.Ip,
 		Service:     deployment.AppName,
 		Version:     deployment.PackageVersion,
-		PrevVersion: node.PrevVersion,
+		PrevVersion: preVersion,
 		PackageURL:  deployment.Package.URL,
 		MD5:         deployment.Package.MD5,
+
+		ReuseRelease: node.ReuseRelease,
 	})
 
 	if err != nil {
@@ -191,7 +300,7 @@ func (dm *DeploymentManager) executeNode(ctx context.Context, deployment *model.
 		node.NodeDeployStatus = model.NodeDeploymentStatusFailed
 		node.ReleaseLog = err.Error()
 		node.UpdatedAt = time.Now()
-		dm.deploymentModel.Update(context.Background(), deployment)
+		dm.deploymentModel.UpdateNode(context.Background(), deployment.Id, node)
 		return err
 	}
 	if err := executor.Deploy(ctx); err != nil {
@@ -200,34 +309,127 @@ func (dm *DeploymentManager) executeNode(ctx context.Context, deployment *model.
 			node.NodeDeployStatus = model.NodeDeploymentStatusFailed
 			node.ReleaseLog = "deployment canceled"
 			node.UpdatedAt = time.Now()
-			dm.deploymentModel.Update(context.Background(), deployment)
+			dm.deploymentModel.UpdateNode(context.Background(), deployment.Id, node)
 			return ctx.Err()
 		}
 		logx.Errorf("deployment failed: %w", err)
-		node.NodeDeployStatus = model.NodeDeploymentStatusFailed
-		node.ReleaseLog = err.Error()
-		node.UpdatedAt = time.Now()
+		return dm.rollbackFailedNode(ctx, deployment, node, executor, preVersion, err)
+	}
 
-		if rollbackErr := executor.Rollback(ctx); rollbackErr != nil {
-			logx.Errorf("rollback failed: %w", rollbackErr)
-			node.ReleaseLog = fmt.Sprintf("deploy failed: %s, rollback failed: %s", err.Error(), rollbackErr.Error())
-		} else {
-			node.NodeDeployStatus = model.NodeDeploymentStatusRolledBack
+	// 发布后依次进行就绪探测和 RED 指标健康验证，任一未通过则回滚该节点
+	if err := dm.verifyNode(ctx, deployment, node, executor); err != nil {
+		if ctx.Err() != nil {
+			node.NodeDeployStatus = model.NodeDeploymentStatusFailed
+			node.ReleaseLog = "deployment canceled"
+			node.UpdatedAt = time.Now()
+			dm.deploymentModel.UpdateNode(context.Background(), deployment.Id, node)
+			return ctx.Err()
 		}
-		node.UpdatedAt = time.Now()
-		dm.deploymentModel.Update(context.Background(), deployment)
-		return err
+		logx.Errorf("node verification failed: %v", err)
+		return dm.rollbackFailedNode(ctx, deployment, node, executor, preVersion, err)
 	}
 
+	nodeVersion = recordNodeVersion(context.Background(), dm.nodeVersionModel, executor, node, deployment.AppName, deployment.PackageVersion,
+		deployment.Type == model.DeploymentTypeRollback)
+
 	node.NodeDeployStatus = model.NodeDeploymentStatusSuccess
 	node.ReleaseLog = "deployment successful"
-	node.PrevVersion = node.CurrentVersion
+	node.PrevVersion = preVersion
 	node.CurrentVersion = deployment.PackageVersion
 	node.DeployingVersion = ""
 	node.UpdatedAt = time.Now()
+	dm.startCanaryAnalysis(deployment, node)
 	logx.Infof("deployment successful: %s, node: %s, version: %s, deploying version: %s", deployment.Id, node.Id, deployment.PackageVersion, node.DeployingVersion)
-	dm.deploymentModel.Update(context.Background(), deployment)
+	dm.deploymentModel.UpdateNode(context.Background(), deployment.Id, node)
+
+	dm.enforceRetention(context.Background(), deployment, executor, nodeVersion)
+	return nil
+}
+
+// rollbackFailedNode 节点发布或健康验证失败后回滚到发布前的版本
+func (dm *DeploymentManager) rollbackFailedNode(ctx context.Context, deployment *model.Deployment, node *model.NodeDeployment,
+	exec executor.Executor, preVersion string, cause error) error {
+	node.NodeDeployStatus = model.NodeDeploymentStatusFailed
+	node.ReleaseLog = cause.Error()
+	node.UpdatedAt = time.Now()
+
+	if rollbackErr := exec.Rollback(ctx); rollbackErr != nil {
+		logx.Errorf("rollback failed: %w", rollbackErr)
+		node.ReleaseLog = fmt.Sprintf("deploy failed: %s, rollback failed: %s", cause.Error(), rollbackErr.Error())
+	} else {
+		node.NodeDeployStatus = model.NodeDeploymentStatusRolledBack
+		recordNodeVersion(context.Background(), dm.nodeVersionModel, exec, node, deployment.AppName, preVersion, true)
+	}
+	node.UpdatedAt = time.Now()
+	dm.deploymentModel.UpdateNode(context.Background(), deployment.Id, node)
+	return cause
+}
+
+// verifyNode 节点发布后的验证：就绪探测和 RED 指标健康验证
+func (dm *DeploymentManager) verifyNode(ctx context.Context, deployment *model.Deployment, node *model.NodeDeployment, exec executor.Executor) error {
+	app, err := dm.applicationModel.FindById(context.Background(), deployment.AppId)
+	if err != nil {
+		logx.Errorf("find app %s failed, skip node verification: %v", deployment.AppId, err)
+		return nil
+	}
+
+	if err := dm.probeNodeReadiness(ctx, deployment, app, node, exec); err != nil {
+		return err
+	}
+	return dm.verifyNodeHealth(ctx, deployment, app, node)
+}
+
+// probeNodeReadiness 按应用配置的就绪探针探测节点，未配置探针时直接通过。
+// 初始等待加重试可能超过一个定时周期，探测期间节点由 inflight 标记，不会被定时任务重新发布
+func (dm *DeploymentManager) probeNodeReadiness(ctx context.Context, deployment *model.Deployment, app *model.Application,
+	node *model.NodeDeployment, exec executor.Executor) error {
+	if app.ReadinessProbe == nil {
+		return nil
+	}
+
+	node.ReleaseLog = fmt.Sprintf("probing readiness (%s)", app.ReadinessProbe.Type)
+	node.UpdatedAt = time.Now()
+	dm.deploymentModel.UpdateNode(context.Background(), deployment.Id, node)
+
+	node.ProbeResult = runReadinessProbe(ctx, app.ReadinessProbe, node.Ip, exec)
+	logx.Infof("readiness probe of %s@%s: passed=%v, attempts=%d, %s",
+		deployment.AppName, node.Id, node.ProbeResult.Passed, node.ProbeResult.Attempts, node.ProbeResult.Message)
+	if !node.ProbeResult.Passed {
+		return fmt.Errorf("readiness probe failed: %s", node.ProbeResult.Message)
+	}
+	return nil
+}
+
+// verifyNodeHealth 等待应用配置的观察窗口后验证节点的 RED 指标，
+// 未配置健康验证或未接入监控时直接通过
+func (dm *DeploymentManager) verifyNodeHealth(ctx context.Context, deployment *model.Deployment, app *model.Application,
+	node *model.NodeDeployment) error {
+	if dm.healthVerifier == nil {
+		return nil
+	}
+	red := app.REDMetricsConfig
+	if red == nil || !red.Enabled || red.HealthThreshold == nil || red.VerifySeconds <= 0 {
+		return nil
+	}
 
+	window := time.Duration(red.VerifySeconds) * time.Second
+	node.ReleaseLog = fmt.Sprintf("verifying health for %s", window)
+	node.UpdatedAt = time.Now()
+	dm.deploymentModel.UpdateNode(context.Background(), deployment.Id, node)
+
+	start := time.Now()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(window):
+	}
+
+	node.HealthCheck = dm.healthVerifier.Verify(red, node.Name, start, time.Now())
+	logx.Infof("health verification of %s@%s: passed=%v, %s",
+		deployment.AppName, node.Id, node.HealthCheck.Passed, node.HealthCheck.Message)
+	if !node.HealthCheck.Passed {
+		return fmt.Errorf("health verification failed: %s", node.HealthCheck.Message)
+	}
 	return nil
 }
 
@@ -241,7 +443,8 @@ func (dm *DeploymentManager) CancelDeployment(ctx context.Context, deploymentID
 		return fmt.Errorf("failed to find deployment: %w", err)
 	}
 
-	if deployment.Status != model.DeploymentStatusPending && deployment.Status != model.DeploymentStatusDeploying {
+	if deployment.Status != model.DeploymentStatusPending && deployment.Status != model.DeploymentStatusDeploying &&
+		deployment.Status != model.DeploymentStatusAwaitingApproval {
 		return fmt.Errorf("cannot cancel deployment with status: %s", deployment.Status)
 	}
 
@@ -267,11 +470,14 @@ func (dm *DeploymentManager) ContinueDeployingDeployments(ctx context.Context) e
 		}
 
 		for _, deployment := range deployments {
-			taskCtx, _ := context.WithCancel(context.Background())
+			taskCtx := context.Background()
 
 			go func(dep *model.Deployment) {
 				logx.Infof("continuing deployment: %s", dep.Id)
 				dm.executeNodes(taskCtx, dep)
+				if dm.alertMonitor == nil {
+					return
+				}
 				if app, err := dm.applicationModel.FindById(ctx, dep.AppId); err == nil {
 					dm.alertMonitor.StartMonitoring(ctx, dep, app)
 				}
@@ -281,3 +487,243 @@ func (dm *DeploymentManager) ContinueDeployingDeployments(ctx context.Context) e
 
 	return nil
 }
+
+// StartScheduledDeployments 启动已到计划发布时间的发布单，封版期或发布窗口外的发布单保持待发布，下个周期重试
+func (dm *DeploymentManager) StartScheduledDeployments(ctx context.Context) error {
+	now := time.Now()
+	deployments, err := dm.deploymentModel.Search(ctx, &model.DeploymentCond{
+		Status:          string(model.DeploymentStatusPending),
+		ScheduledBefore: now.Unix(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to search scheduled deployments: %w", err)
+	}
+
+	for _, deployment := range deployments {
+		app, err := dm.applicationModel.FindById(ctx, deployment.AppId)
+		if err != nil {
+			logx.Errorf("failed to find app %s for scheduled deployment %s: %v", deployment.AppId, deployment.Id, err)
+			continue
+		}
+		if err := checkDeployAllowed(ctx, dm.freezePeriodModel, app, deployment.FreezeOverride, now); err != nil {
+			logx.Infof("scheduled deployment %s is not allowed to start yet: %v", deployment.Id, err)
+			continue
+		}
+
+		// 指定了灰度设备时先发布灰度设备，否则按批次大小发布第一批设备
+		batchSize := deployment.Pacer.BatchSize
+		if batchSize <= 0 {
+			batchSize = 1
+		}
+		started := 0
+		for i := range deployment.NodeDeployments {
+			node := &deployment.NodeDeployments[i]
+			if node.NodeDeployStatus != model.NodeDeploymentStatusPending {
+				continue
+			}
+			if deployment.GrayMachineId != "" {
+				if node.Id != deployment.GrayMachineId {
+					continue
+				}
+			} else if started >= batchSize {
+				break
+			}
+			node.NodeDeployStatus = model.NodeDeploymentStatusDeploying
+			started++
+		}
+		if started == 0 {
+			logx.Errorf("scheduled deployment %s has no pending node to start", deployment.Id)
+			continue
+		}
+
+		// 先切换发布单状态，只有切换成功的一方写入节点状态并发送开始通知
+		ok, err := dm.deploymentModel.TransitionStatus(ctx, deployment.Id, model.DeploymentStatusPending, model.DeploymentStatusDeploying)
+		if err != nil {
+			logx.Errorf("failed to start scheduled deployment %s: %v", deployment.Id, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		deployment.Status = model.DeploymentStatusDeploying
+		for i := range deployment.NodeDeployments {
+			node := &deployment.NodeDeployments[i]
+			if node.NodeDeployStatus != model.NodeDeploymentStatusDeploying {
+				continue
+			}
+			if err := dm.deploymentModel.UpdateNode(ctx, deployment.Id, node); err != nil {
+				logx.Errorf("failed to start node %s of scheduled deployment %s: %v", node.Name, deployment.Id, err)
+			}
+		}
+		logx.Infof("scheduled deployment %s started with %d nodes", deployment.Id, started)
+		dm.notifier.Notify(notify.DeploymentEvent(model.NotifyEventDeploymentStarted, deployment, "到达计划发布时间"))
+	}
+
+	return nil
+}
+
+// startCanaryAnalysis 灰度机器发布成功后开始灰度分析的观察窗口，由定时任务在窗口结束后给出结论
+func (dm *DeploymentManager) startCanaryAnalysis(deployment *model.Deployment, node *model.NodeDeployment) {
+	if dm.canaryAnalyzer == nil || deployment.GrayMachineId == "" || node.Id != deployment.GrayMachineId {
+		return
+	}
+	app, err := dm.applicationModel.FindById(context.Background(), deployment.AppId)
+	if err != nil || app.CanaryPolicy == nil || !app.CanaryPolicy.Enabled || app.REDMetricsConfig == nil {
+		return
+	}
+
+	now := time.Now()
+	deployment.CanaryAnalysis = &model.CanaryAnalysis{
+		Verdict:   model.CanaryVerdictRunning,
+		Metrics:   []model.CanaryMetricResult{},
+		StartTime: now.Unix(),
+		EndTime:   now.Add(time.Duration(app.CanaryPolicy.WindowSeconds) * time.Second).Unix(),
+	}
+	if err := dm.deploymentModel.UpdateCanaryAnalysis(context.Background(), deployment.Id, deployment.CanaryAnalysis); err != nil {
+		logx.Errorf("failed to start canary analysis of deployment %s: %v", deployment.Id, err)
+	}
+}
+
+// AnalyzeCanaries 对观察窗口已结束的灰度发布单给出分析结论，通过且开启自动推进时发布剩余机器
+func (dm *DeploymentManager) AnalyzeCanaries(ctx context.Context) error {
+	if dm.canaryAnalyzer == nil {
+		return nil
+	}
+	deployments, err := dm.deploymentModel.Search(ctx, &model.DeploymentCond{
+		Status: string(model.DeploymentStatusDeploying),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to search deploying deployments: %w", err)
+	}
+
+	now := time.Now()
+	for _, deployment := range deployments {
+		analysis := deployment.CanaryAnalysis
+		if analysis == nil {
+			continue
+		}
+		pendingPromotion := analysis.Verdict == model.CanaryVerdictPass && !analysis.Promoted
+		if !pendingPromotion && (analysis.Verdict != model.CanaryVerdictRunning || now.Unix() < analysis.EndTime) {
+			continue
+		}
+
+		app, err := dm.applicationModel.FindById(ctx, deployment.AppId)
+		if err != nil || app.CanaryPolicy == nil || app.REDMetricsConfig == nil {
+			logx.Errorf("failed to load canary policy of deployment %s: %v", deployment.Id, err)
+			continue
+		}
+
+		// 只写入分析结果和状态，并要求发布单仍处于发布中，避免覆盖同时写入的节点状态和其他操作变更的状态
+		if analysis.Verdict == model.CanaryVerdictRunning {
+			canary, baseline := canaryHosts(deployment)
+			analysis = dm.canaryAnalyzer.Analyze(app.REDMetricsConfig, app.CanaryPolicy, canary, baseline,
+				time.Unix(analysis.StartTime, 0), time.Unix(analysis.EndTime, 0))
+			deployment.CanaryAnalysis = analysis
+			logx.Infof("canary analysis of deployment %s: %s, %s", deployment.Id, analysis.Verdict, analysis.Message)
+
+			var event *notify.Event
+			if analysis.Verdict == model.CanaryVerdictFail {
+				event = dm.handleCanaryFailure(deployment, app)
+			}
+			saved, err := dm.deploymentModel.SaveCanaryAnalysis(ctx, deployment.Id, model.DeploymentStatusDeploying,
+				deployment.Status, analysis)
+			if err != nil {
+				logx.Errorf("failed to update canary analysis of deployment %s: %v", deployment.Id, err)
+				continue
+			}
+			if !saved {
+				continue
+			}
+			if event != nil {
+				dm.notifier.Notify(*event)
+			}
+		}
+
+		if analysis.Verdict != model.CanaryVerdictPass || !app.CanaryPolicy.AutoPromote {
+			continue
+		}
+		if err := checkDeployAllowed(ctx, dm.freezePeriodModel, app, deployment.FreezeOverride, now); err != nil {
+			logx.Infof("canary of deployment %s passed but promotion is not allowed yet: %v", deployment.Id, err)
+			continue
+		}
+		analysis.Promoted = true
+		promoted, err := dm.deploymentModel.SaveCanaryAnalysis(ctx, deployment.Id, model.DeploymentStatusDeploying,
+			model.DeploymentStatusDeploying, analysis)
+		if err != nil {
+			logx.Errorf("failed to promote canary of deployment %s: %v", deployment.Id, err)
+			continue
+		}
+		if !promoted {
+			continue
+		}
+		if err := dm.deploymentModel.PromotePendingNodes(ctx, deployment.Id); err != nil {
+			logx.Errorf("failed to promote remaining nodes of deployment %s: %v", deployment.Id, err)
+			continue
+		}
+		logx.Infof("canary of deployment %s passed, promoting remaining nodes", deployment.Id)
+	}
+
+	return nil
+}
+
+// handleCanaryFailure 灰度分析不通过：应用开启自动回滚时与告警触发的回滚相同，将发布单置为回滚中，
+// 由定时任务回滚已发布的机器；否则暂停发布，剩余机器需要发布管理员强制发布。
+// 返回分析结果保存后需要发送的通知
+func (dm *DeploymentManager) handleCanaryFailure(deployment *model.Deployment, app *model.Application) *notify.Event {
+	if app.RollbackPolicy != nil && app.RollbackPolicy.AutoRollback {
+		deployment.Status = model.DeploymentStatusRollingBack
+		deployment.CanaryAnalysis.Message += "，已触发自动回滚"
+		logx.Infof("canary of deployment %s failed, auto rollback triggered", deployment.Id)
+		event := notify.DeploymentEvent(model.NotifyEventRollbackTriggered, deployment, "灰度分析不通过触发自动回滚")
+		return &event
+	}
+
+	deployment.CanaryAnalysis.Message += "，发布已暂停"
+	logx.Infof("canary of deployment %s failed, deployment paused", deployment.Id)
+	event := notify.DeploymentEvent(model.NotifyEventCanaryFailed, deployment, deployment.CanaryAnalysis.Message)
+	return &event
+}
+
+// canaryHosts 灰度机器和仍待发布（运行旧版本）的基线机器的名称
+func canaryHosts(deployment *model.Deployment) (string, []string) {
+	var canary string
+	baseline := []string{}
+	for _, node := range deployment.NodeDeployments {
+		if node.Id == deployment.GrayMachineId {
+			canary = node.Name
+			continue
+		}
+		if node.NodeDeployStatus == model.NodeDeploymentStatusPending {
+			baseline = append(baseline, node.Name)
+		}
+	}
+	return canary, baseline
+}
+
+// ExpireApprovals 将超过审批有效期仍未审批通过的发布单置为审批未通过
+func (dm *DeploymentManager) ExpireApprovals(ctx context.Context) error {
+	deployments, err := dm.deploymentModel.Search(ctx, &model.DeploymentCond{
+		Status: string(model.DeploymentStatusAwaitingApproval),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to search deployments awaiting approval: %w", err)
+	}
+
+	now := time.Now()
+	for _, deployment := range deployments {
+		if deployment.Approval == nil || !approvalExpired(deployment.Approval, now) {
+			continue
+		}
+		expired, err := dm.deploymentModel.FinishApproval(ctx, deployment.Id, model.ApprovalStatusExpired, model.DeploymentStatusRejected)
+		if err != nil {
+			logx.Errorf("failed to expire approval of deployment %s: %v", deployment.Id, err)
+			continue
+		}
+		if !expired {
+			continue
+		}
+		logx.Infof("approval of deployment %s expired", deployment.Id)
+	}
+
+	return nil
+}
@@ -0,0 +1,23 @@
+package deployments
+
+import "testing"
+
+func TestClaimNode(t *testing.T) {
+	dm := &DeploymentManager{}
+
+	if !dm.claimNode("d1", "n1") {
+		t.Fatal("first claim should succeed")
+	}
+	// 执行中的节点不能被定时任务再次领取
+	if dm.claimNode("d1", "n1") {
+		t.Error("node in flight should not be claimed again")
+	}
+	if !dm.claimNode("d1", "n2") || !dm.claimNode("d2", "n1") {
+		t.Error("other nodes should be claimable")
+	}
+
+	dm.releaseNode("d1", "n1")
+	if !dm.claimNode("d1", "n1") {
+		t.Error("released node should be claimable")
+	}
+}
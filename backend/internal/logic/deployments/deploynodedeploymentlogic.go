@@ -3,8 +3,10 @@ package deployments
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
+	"github.com/Z3Labs/Hackathon/backend/internal/clients/notify"
 	"github.com/Z3Labs/Hackathon/backend/internal/model"
 	"github.com/Z3Labs/Hackathon/backend/internal/svc"
 	"github.com/Z3Labs/Hackathon/backend/internal/types"
@@ -43,6 +45,46 @@ func (l *DeployNodeDeploymentLogic) DeployNodeDeployment(req *types.DeployNodeDe
 		return nil, errors.New("已回滚的发布单无法执行发布操作")
 	}
 
+	if deployment.Status == model.DeploymentStatusAwaitingApproval || deployment.Status == model.DeploymentStatusRejected {
+		l.Errorf("[DeployNodeDeployment] Deployment is not approved: %s", deployment.Status)
+		return nil, errors.New("发布单未审批通过，无法执行发布操作")
+	}
+
+	application, err := l.svcCtx.ApplicationModel.FindById(l.ctx, deployment.AppId)
+	if err != nil {
+		l.Errorf("[DeployNodeDeployment] ApplicationModel.FindById error:%v", err)
+		return nil, errors.New("应用不存在")
+	}
+
+	// 封版期、发布窗口外或未到计划发布时间时需要发布管理员强制发布才能继续
+	override, err := newFreezeOverride(req.FreezeOverride, l.svcCtx.Config.Deploy.Admins)
+	if err != nil {
+		l.Errorf("[DeployNodeDeployment] newFreezeOverride error:%v", err)
+		return nil, err
+	}
+	if override != nil {
+		if err := bindFreezeOverride(l.ctx, l.svcCtx.FreezePeriodModel, application, override, time.Now()); err != nil {
+			l.Errorf("[DeployNodeDeployment] bindFreezeOverride error:%v", err)
+			return nil, errors.New("查询封版期失败")
+		}
+		deployment.FreezeOverride = override
+	}
+	if deployment.Status == model.DeploymentStatusPending && deployment.ScheduledTime > time.Now().Unix() && override == nil {
+		l.Errorf("[DeployNodeDeployment] Deployment is scheduled at %d", deployment.ScheduledTime)
+		return nil, fmt.Errorf("发布单计划于 %s 开始，未到计划时间需要发布管理员强制发布",
+			time.Unix(deployment.ScheduledTime, 0).Format(time.DateTime))
+	}
+	if err := checkDeployAllowed(l.ctx, l.svcCtx.FreezePeriodModel, application, deployment.FreezeOverride, time.Now()); err != nil {
+		l.Errorf("[DeployNodeDeployment] checkDeployAllowed error:%v", err)
+		return nil, err
+	}
+
+	// 灰度分析不通过时发布暂停，继续发布需要发布管理员强制发布
+	if deployment.CanaryAnalysis != nil && deployment.CanaryAnalysis.Verdict == model.CanaryVerdictFail && override == nil {
+		l.Errorf("[DeployNodeDeployment] Canary analysis failed: %s", deployment.CanaryAnalysis.Message)
+		return nil, errors.New("灰度分析未通过，发布已暂停，继续发布需要发布管理员强制发布")
+	}
+
 	nodeDeploymentIdMap := make(map[string]bool)
 	for _, id := range req.NodeDeploymentIds {
 		nodeDeploymentIdMap[id] = true
@@ -77,7 +119,17 @@ func (l *DeployNodeDeploymentLogic) DeployNodeDeployment(req *types.DeployNodeDe
 		return nil, errors.New("没有机器被设置为发布中状态")
 	}
 
+	// 待发布的发布单先切换状态，只有切换成功的请求发送开始通知
+	started := false
 	if deployment.Status == model.DeploymentStatusPending {
+		started, err = l.svcCtx.DeploymentModel.TransitionStatus(l.ctx, deployment.Id, model.DeploymentStatusPending, model.DeploymentStatusDeploying)
+		if err != nil {
+			l.Errorf("[DeployNodeDeployment] DeploymentModel.TransitionStatus error:%v", err)
+			return nil, errors.New("发布指定机器失败")
+		}
+		if !started {
+			return nil, errors.New("发布单状态已变化，请刷新后重试")
+		}
 		deployment.Status = model.DeploymentStatusDeploying
 	}
 
@@ -90,6 +142,9 @@ func (l *DeployNodeDeploymentLogic) DeployNodeDeployment(req *types.DeployNodeDe
 	}
 
 	l.Infof("[DeployNodeDeployment] Successfully deployed %d machines: %v for deployment: %s", deployCount, validMachineIds, req.Id)
+	if started {
+		l.svcCtx.Notifier.Notify(notify.DeploymentEvent(model.NotifyEventDeploymentStarted, deployment, ""))
+	}
 
 	return &types.DeployNodeDeploymentResp{
 		Success: true,
@@ -6,6 +6,17 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 与 playbooks/deploy.yml 中的目录约定保持一致
+const (
+	releaseRoot = "/opt/releases"
+	currentRoot = "/opt/current"
+
+	inspectSeparator = "---"
 )
 
 var execCommand = exec.CommandContext
@@ -36,6 +47,10 @@ func (a *AnsibleExecutor) Deploy(ctx context.Context) error {
 		a.config.PrevVersion,
 	)
 
+	if a.config.ReuseRelease {
+		extraVars += " reuse_release=true"
+	}
+
 	args := []string{a.playbookPath}
 	if a.config.IP != "" {
 		args = append(args, "-i", a.config.IP+",")
@@ -86,6 +101,148 @@ func (a *AnsibleExecutor) Rollback(ctx context.Context) error {
 	return nil
 }
 
+// InspectVersions 通过 ansible ad-hoc 命令读取远端 current 软链接和版本目录
+func (a *AnsibleExecutor) InspectVersions(ctx context.Context) (*InstalledVersions, error) {
+	if a.config.IP == "" {
+		return nil, fmt.Errorf("no target ip to inspect")
+	}
+
+	if !validServiceName(a.config.Service) {
+		return nil, fmt.Errorf("invalid service name: %q", a.config.Service)
+	}
+
+	script := fmt.Sprintf("readlink '%s/%s'; echo %s; ls -1 '%s/%s'",
+		currentRoot, a.config.Service, inspectSeparator, releaseRoot, a.config.Service)
+	args := []string{"all", "-i", a.config.IP + ",", "-u", "root", "-m", "shell", "-a", script}
+
+	cmd := execCommand(ctx, "ansible", args...)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect versions: %w", err)
+	}
+
+	return parseInstalledVersions(string(output)), nil
+}
+
+// parseInstalledVersions 解析 InspectVersions 的命令输出，第一行为 ansible 的主机状态行
+func parseInstalledVersions(output string) *InstalledVersions {
+	versions := &InstalledVersions{Releases: []string{}}
+
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	afterSeparator := false
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.Contains(line, " | ") {
+			continue
+		}
+		if line == inspectSeparator {
+			afterSeparator = true
+			continue
+		}
+		if !afterSeparator {
+			versions.Current = filepath.Base(line)
+			continue
+		}
+		if strings.HasSuffix(line, ".tar.gz") {
+			continue
+		}
+		versions.Releases = append(versions.Releases, line)
+	}
+
+	return versions
+}
+
+// PruneReleases 删除远端指定版本的目录及安装包，并统计释放的空间
+func (a *AnsibleExecutor) PruneReleases(ctx context.Context, versions []string) (*PruneResult, error) {
+	if a.config.IP == "" {
+		return nil, fmt.Errorf("no target ip to prune")
+	}
+	// 服务名为空时会进入共享的版本根目录，删除其他应用的同名版本
+	if !validServiceName(a.config.Service) {
+		return nil, fmt.Errorf("invalid service name: %q", a.config.Service)
+	}
+	if len(versions) == 0 {
+		return &PruneResult{Removed: []string{}}, nil
+	}
+
+	quoted := make([]string, 0, len(versions))
+	for _, version := range versions {
+		if !validReleaseName(version) {
+			return nil, fmt.Errorf("invalid release name: %q", version)
+		}
+		quoted = append(quoted, "'"+version+"'")
+	}
+
+	script := fmt.Sprintf(`cd '%s/%s' && for v in %s; do `+
+		`s=$(du -sbc "$v" "$v.tar.gz" 2>/dev/null | tail -1 | cut -f1); `+
+		`rm -rf -- "$v" "$v.tar.gz"; echo "$v ${s:-0}"; done`,
+		releaseRoot, a.config.Service, strings.Join(quoted, " "))
+	args := []string{"all", "-i", a.config.IP + ",", "-u", "root", "-m", "shell", "-a", script}
+
+	cmd := execCommand(ctx, "ansible", args...)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to prune releases: %w", err)
+	}
+
+	return parsePruneResult(string(output)), nil
+}
+
+// RunCommand 通过 ansible ad-hoc 命令在远端执行 shell 命令，命令退出码非 0 时返回错误
+func (a *AnsibleExecutor) RunCommand(ctx context.Context, command string) (string, error) {
+	if a.config.IP == "" {
+		return "", fmt.Errorf("no target ip to run command")
+	}
+
+	args := []string{"all", "-i", a.config.IP + ",", "-u", "root", "-m", "shell", "-a", command}
+	cmd := execCommand(ctx, "ansible", args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return string(output), fmt.Errorf("failed to run command: %w", err)
+	}
+	return string(output), nil
+}
+
+// serviceNameRegex 服务名会拼接进以 root 执行的远端 shell 命令和目录路径，只允许安全字符
+var serviceNameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9@._-]*$`)
+
+// validServiceName 服务名是否可以安全地拼接进远端命令，不能为空、不能含路径分隔符和 shell 元字符
+func validServiceName(name string) bool {
+	return serviceNameRegex.MatchString(name)
+}
+
+// validReleaseName 版本名会拼接进远端 shell 命令，只允许不含路径和引号的名称
+func validReleaseName(name string) bool {
+	if name == "" || name == "." || name == ".." {
+		return false
+	}
+	return !strings.ContainsAny(name, "/'\"\\ \t\n$`;&|")
+}
+
+// parsePruneResult 解析 PruneReleases 的命令输出，每行为 "<版本> <释放字节数>"
+func parsePruneResult(output string) *PruneResult {
+	result := &PruneResult{Removed: []string{}}
+
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.Contains(line, " | ") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		result.Removed = append(result.Removed, fields[0])
+		result.ReclaimedBytes += size
+	}
+
+	return result
+}
+
 func (a *AnsibleExecutor) getPlaybookDir() string {
 	return filepath.Dir(a.playbookPath)
 }
@@ -12,6 +12,33 @@ type Executor interface {
 	Rollback(ctx context.Context) error
 }
 
+// VersionInspector 能够读取目标机器上实际安装版本的执行器
+type VersionInspector interface {
+	InspectVersions(ctx context.Context) (*InstalledVersions, error)
+}
+
+// InstalledVersions 目标机器上某个服务的版本安装情况
+type InstalledVersions struct {
+	Current  string   // 当前生效版本（current 软链接指向的版本）
+	Releases []string // 机器上保留的版本目录
+}
+
+// ReleasePruner 能够清理目标机器上旧版本目录的执行器
+type ReleasePruner interface {
+	PruneReleases(ctx context.Context, versions []string) (*PruneResult, error)
+}
+
+// PruneResult 旧版本目录清理结果
+type PruneResult struct {
+	Removed        []string // 已删除的版本
+	ReclaimedBytes int64    // 释放的磁盘空间(字节)
+}
+
+// CommandRunner 能够在目标机器上执行命令的执行器
+type CommandRunner interface {
+	RunCommand(ctx context.Context, command string) (string, error)
+}
+
 type ExecutorConfig struct {
 	Platform    string
 	Host        string
@@ -24,6 +51,8 @@ type ExecutorConfig struct {
 	Namespace   string
 	Deployment  string
 	ImageURL    string
+	// ReuseRelease 目标版本目录已存在于机器上时直接切换，不重新下载安装包
+	ReuseRelease bool
 }
 
 type ExecutorFactoryInterface interface {
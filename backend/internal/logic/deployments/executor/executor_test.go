@@ -2,6 +2,7 @@ package executor
 
 import (
 	"context"
+	"os/exec"
 	"testing"
 
 	"github.com/Z3Labs/Hackathon/backend/internal/model"
@@ -150,3 +151,52 @@ func getTypeName(i interface{}) string {
 		return "unknown"
 	}
 }
+
+func TestParseInstalledVersions(t *testing.T) {
+	output := `10.0.0.1 | CHANGED | rc=0 >>
+/opt/releases/node_exporter/v1.0.2
+---
+v1.0.1
+v1.0.1.tar.gz
+v1.0.2
+v1.0.2.tar.gz`
+
+	versions := parseInstalledVersions(output)
+	if versions.Current != "v1.0.2" {
+		t.Errorf("Current = %s, want v1.0.2", versions.Current)
+	}
+	if len(versions.Releases) != 2 || versions.Releases[0] != "v1.0.1" || versions.Releases[1] != "v1.0.2" {
+		t.Errorf("Releases = %v, want [v1.0.1 v1.0.2]", versions.Releases)
+	}
+}
+
+func TestValidServiceName(t *testing.T) {
+	for _, name := range []string{"node_exporter", "order-api", "web.v2", "app@1"} {
+		if !validServiceName(name) {
+			t.Errorf("validServiceName(%q) = false, want true", name)
+		}
+	}
+	for _, name := range []string{"", ".", "..", "a/b", "a b", "a;reboot", "$(id)", "`id`", "a'b", "-rf"} {
+		if validServiceName(name) {
+			t.Errorf("validServiceName(%q) = true, want false", name)
+		}
+	}
+}
+
+func TestAnsibleExecutorRejectsInvalidService(t *testing.T) {
+	defer func(orig func(ctx context.Context, name string, arg ...string) *exec.Cmd) { execCommand = orig }(execCommand)
+	execCommand = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		t.Fatalf("command should not run: %s %v", name, arg)
+		return nil
+	}
+
+	for _, service := range []string{"", "app; reboot"} {
+		a := &AnsibleExecutor{config: ExecutorConfig{IP: "10.0.0.1", Service: service}}
+		if _, err := a.InspectVersions(context.Background()); err == nil {
+			t.Errorf("InspectVersions() with service %q should fail", service)
+		}
+		if _, err := a.PruneReleases(context.Background(), []string{"v1.0.0"}); err == nil {
+			t.Errorf("PruneReleases() with service %q should fail", service)
+		}
+	}
+}
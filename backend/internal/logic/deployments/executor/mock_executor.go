@@ -47,6 +47,35 @@ func (m *MockExecutor) Rollback(ctx context.Context) error {
 	return m.rollbackError
 }
 
+// RunCommand 模拟在机器上执行命令，始终成功
+func (m *MockExecutor) RunCommand(ctx context.Context, command string) (string, error) {
+	return "", nil
+}
+
+// PruneReleases 模拟清理，不释放实际空间
+func (m *MockExecutor) PruneReleases(ctx context.Context, versions []string) (*PruneResult, error) {
+	return &PruneResult{Removed: versions}, nil
+}
+
+// InspectVersions 模拟远端版本：回滚成功后为 PrevVersion，否则发布成功后为 Version
+func (m *MockExecutor) InspectVersions(ctx context.Context) (*InstalledVersions, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current := m.config.PrevVersion
+	if m.deployCalled && m.deployError == nil && !(m.rollbackCalled && m.rollbackError == nil) {
+		current = m.config.Version
+	}
+
+	releases := []string{}
+	for _, v := range []string{m.config.PrevVersion, m.config.Version} {
+		if v != "" {
+			releases = append(releases, v)
+		}
+	}
+	return &InstalledVersions{Current: current, Releases: releases}, nil
+}
+
 func (m *MockExecutor) DeployCalled() bool {
 	m.mu.Lock()
 	defer m.mu.Unlock()
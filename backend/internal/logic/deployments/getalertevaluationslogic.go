@@ -0,0 +1,77 @@
+package deployments
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+	"github.com/Z3Labs/Hackathon/backend/internal/svc"
+	"github.com/Z3Labs/Hackathon/backend/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type GetAlertEvaluationsLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewGetAlertEvaluationsLogic(ctx context.Context, svcCtx *svc.ServiceContext) GetAlertEvaluationsLogic {
+	return GetAlertEvaluationsLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *GetAlertEvaluationsLogic) GetAlertEvaluations(req *types.GetAlertEvaluationsReq) (resp *types.GetAlertEvaluationsResp, err error) {
+	cond := &model.AlertEvaluationCond{
+		DeploymentId: req.Id,
+		RuleName:     req.RuleName,
+		State:        req.State,
+		Pagination:   model.NewPaginationWithDefaultSort(req.Page, req.PageSize),
+	}
+
+	total, err := l.svcCtx.AlertEvaluationModel.Count(l.ctx, cond)
+	if err != nil {
+		l.Errorf("[GetAlertEvaluations] AlertEvaluationModel.Count error:%v", err)
+		return nil, errors.New("获取告警评估记录失败")
+	}
+
+	evaluations, err := l.svcCtx.AlertEvaluationModel.Search(l.ctx, cond)
+	if err != nil {
+		l.Errorf("[GetAlertEvaluations] AlertEvaluationModel.Search error:%v", err)
+		return nil, errors.New("获取告警评估记录失败")
+	}
+
+	resp = &types.GetAlertEvaluationsResp{
+		Evaluations: make([]types.AlertEvaluation, 0, len(evaluations)),
+		Total:       total,
+	}
+	for _, evaluation := range evaluations {
+		samples := make([]types.AlertSample, 0, len(evaluation.Samples))
+		for _, sample := range evaluation.Samples {
+			samples = append(samples, types.AlertSample{
+				Labels:   sample.Labels,
+				Value:    sample.Value,
+				Breached: sample.Breached,
+			})
+		}
+
+		resp.Evaluations = append(resp.Evaluations, types.AlertEvaluation{
+			Id:          evaluation.Id,
+			RuleName:    evaluation.RuleName,
+			Query:       evaluation.Query,
+			Operator:    evaluation.Operator,
+			Threshold:   evaluation.Threshold,
+			State:       string(evaluation.State),
+			Samples:     samples,
+			FiringHosts: evaluation.FiringHosts,
+			Reason:      evaluation.Reason,
+			CreatedAt:   evaluation.CreatedTime.Unix(),
+		})
+	}
+
+	return resp, nil
+}
@@ -44,6 +44,10 @@ func (l *GetDeploymentDetailLogic) GetDeploymentDetail(req *types.GetDeploymentD
 			CurrentVersion:   machine.CurrentVersion,
 			DeployingVersion: machine.DeployingVersion,
 			PrevVersion:      machine.PrevVersion,
+			ReuseRelease:     machine.ReuseRelease,
+			ProbeResult:      convertProbeResult(machine.ProbeResult),
+			HealthCheck:      convertHealthCheck(machine.HealthCheck),
+			FiringAlerts:     machine.FiringAlerts,
 			Platform:         string(machine.Platform),
 			UpdatedAt:        machine.UpdatedAt.Unix(),
 			CreatedAt:        machine.CreatedAt.Unix(),
@@ -53,11 +57,17 @@ func (l *GetDeploymentDetailLogic) GetDeploymentDetail(req *types.GetDeploymentD
 	// 构建响应
 	deploymentDetail := types.Deployment{
 		Id:              deployment.Id,
+		Type:            string(deployment.Type),
+		Reason:          deployment.Reason,
 		AppName:         deployment.AppName,
 		Status:          string(deployment.Status),
 		PackageVersion:  deployment.PackageVersion,
 		GrayMachineId:   deployment.GrayMachineId,
 		NodeDeployments: nodeDeployments,
+		ScheduledTime:   deployment.ScheduledTime,
+		FreezeOverride:  convertFreezeOverride(deployment.FreezeOverride),
+		Approval:        convertApproval(deployment.Approval),
+		CanaryAnalysis:  convertCanaryAnalysis(deployment.CanaryAnalysis),
 		CreatedAt:       deployment.CreatedTime,
 		UpdatedAt:       deployment.UpdatedTime,
 	}
@@ -68,15 +78,7 @@ func (l *GetDeploymentDetailLogic) GetDeploymentDetail(req *types.GetDeploymentD
 	reports, err := l.svcCtx.ReportModel.FindByDeploymentId(l.ctx, deployment.Id)
 	if err == nil && len(reports) > 0 {
 		// 找到报告，取最新的一条（已按创建时间倒序排列）
-		report := reports[0]
-		reportResp = &types.Report{
-			Id:           report.Id,
-			DeploymentId: report.DeploymentId,
-			Content:      report.Content,
-			Status:       string(report.Status),
-			CreatedAt:    report.CreatedTime.Unix(),
-			UpdatedAt:    report.UpdatedTime.Unix(),
-		}
+		reportResp = convertReport(reports[0])
 	}
 
 	l.Infof("[GetDeploymentDetail] Successfully retrieved deployment detail: %s", req.Id)
@@ -63,6 +63,10 @@ func (l *GetDeploymentListLogic) GetDeploymentList(req *types.GetDeploymentListR
 				CurrentVersion:   machine.CurrentVersion,
 				DeployingVersion: machine.DeployingVersion,
 				PrevVersion:      machine.PrevVersion,
+				ReuseRelease:     machine.ReuseRelease,
+				ProbeResult:      convertProbeResult(machine.ProbeResult),
+				HealthCheck:      convertHealthCheck(machine.HealthCheck),
+				FiringAlerts:     machine.FiringAlerts,
 				Platform:         string(machine.Platform),
 				UpdatedAt:        machine.UpdatedAt.Unix(),
 				CreatedAt:        machine.CreatedAt.Unix(),
@@ -71,11 +75,17 @@ func (l *GetDeploymentListLogic) GetDeploymentList(req *types.GetDeploymentListR
 
 		deploymentList = append(deploymentList, types.Deployment{
 			Id:              deployment.Id,
+			Type:            string(deployment.Type),
+			Reason:          deployment.Reason,
 			AppName:         deployment.AppName,
 			Status:          string(deployment.Status),
 			PackageVersion:  deployment.PackageVersion,
 			GrayMachineId:   deployment.GrayMachineId,
 			NodeDeployments: nodeDeployments,
+			ScheduledTime:   deployment.ScheduledTime,
+			FreezeOverride:  convertFreezeOverride(deployment.FreezeOverride),
+			Approval:        convertApproval(deployment.Approval),
+			CanaryAnalysis:  convertCanaryAnalysis(deployment.CanaryAnalysis),
 			CreatedAt:       deployment.CreatedTime,
 			UpdatedAt:       deployment.UpdatedTime,
 		})
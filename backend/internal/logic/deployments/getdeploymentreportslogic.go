@@ -0,0 +1,42 @@
+package deployments
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/svc"
+	"github.com/Z3Labs/Hackathon/backend/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type GetDeploymentReportsLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewGetDeploymentReportsLogic(ctx context.Context, svcCtx *svc.ServiceContext) GetDeploymentReportsLogic {
+	return GetDeploymentReportsLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *GetDeploymentReportsLogic) GetDeploymentReports(req *types.GetDeploymentReportsReq) (resp *types.GetDeploymentReportsResp, err error) {
+	reports, err := l.svcCtx.ReportModel.FindByDeploymentId(l.ctx, req.Id)
+	if err != nil {
+		l.Errorf("[GetDeploymentReports] ReportModel.FindByDeploymentId error:%v", err)
+		return nil, errors.New("获取诊断报告失败")
+	}
+
+	resp = &types.GetDeploymentReportsResp{
+		Reports: make([]types.Report, 0, len(reports)),
+	}
+	for _, report := range reports {
+		resp.Reports = append(resp.Reports, *convertReport(report))
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,66 @@
+package deployments
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/clients/diagnosis"
+	"github.com/Z3Labs/Hackathon/backend/internal/svc"
+	"github.com/Z3Labs/Hackathon/backend/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type GetReportFeedbackStatsLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewGetReportFeedbackStatsLogic(ctx context.Context, svcCtx *svc.ServiceContext) GetReportFeedbackStatsLogic {
+	return GetReportFeedbackStatsLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *GetReportFeedbackStatsLogic) GetReportFeedbackStats(req *types.GetReportFeedbackStatsReq) (resp *types.GetReportFeedbackStatsResp, err error) {
+	stats, err := l.svcCtx.ReportModel.FeedbackStats(l.ctx)
+	if err != nil {
+		l.Errorf("[GetReportFeedbackStats] ReportModel.FeedbackStats error:%v", err)
+		return nil, errors.New("获取报告评价统计失败")
+	}
+
+	// 未记录提示词版本或使用旧版本标识的报告由第一版内置提示词生成
+	merged := make(map[string]*types.ReportFeedbackStat)
+	resp = &types.GetReportFeedbackStatsResp{
+		Stats: make([]types.ReportFeedbackStat, 0, len(stats)),
+	}
+	order := make([]string, 0, len(stats))
+	for _, stat := range stats {
+		version := stat.PromptVersion
+		if version == "" || version == "builtin-v1" {
+			version = diagnosis.LegacyPromptVersion
+		}
+		item, ok := merged[version]
+		if !ok {
+			item = &types.ReportFeedbackStat{PromptVersion: version}
+			merged[version] = item
+			order = append(order, version)
+		}
+		item.Total += stat.Total
+		item.Rated += stat.Rated
+		item.Accurate += stat.Accurate
+		item.Inaccurate += stat.Inaccurate
+	}
+	for _, version := range order {
+		item := merged[version]
+		if item.Rated > 0 {
+			item.Accuracy = float64(item.Accurate) / float64(item.Rated)
+		}
+		resp.Stats = append(resp.Stats, *item)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,162 @@
+package deployments
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/clients/prom"
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+	"github.com/Z3Labs/Hackathon/backend/internal/types"
+)
+
+// hostnamePlaceholder RED 指标 PromQL 中的机器名占位符，与前端监控面板的约定一致
+const hostnamePlaceholder = "{{hostname}}"
+
+// HealthVerifier 节点发布后按应用的 RED 指标和健康度阈值验证节点是否健康
+type HealthVerifier struct {
+	promClient prom.VMClient
+}
+
+func NewHealthVerifier(promClient prom.VMClient) *HealthVerifier {
+	return &HealthVerifier{
+		promClient: promClient,
+	}
+}
+
+// healthObservation 观察窗口内各 RED 指标的平均值，HasXxx 表示是否查询到数据
+type healthObservation struct {
+	Rate           float64
+	HasRate        bool
+	ErrorRate      float64
+	HasErrorRate   bool
+	DurationP95    float64
+	HasDurationP95 bool
+}
+
+// Verify 查询机器在 [start, end] 内的 RED 指标并与阈值比较。
+// 指标查询失败视为验证不通过，避免在无法确认健康时放行
+func (hv *HealthVerifier) Verify(red *model.REDMetrics, hostname string, start, end time.Time) *model.HealthCheck {
+	check := &model.HealthCheck{
+		StartTime: start.Unix(),
+		EndTime:   end.Unix(),
+	}
+
+	step := end.Sub(start) / 10
+	if step < 5*time.Second {
+		step = 5 * time.Second
+	}
+
+	var observation healthObservation
+	var err error
+	if observation.Rate, observation.HasRate, err = hv.average(red.RateMetric, hostname, start, end, step); err != nil {
+		check.Message = fmt.Sprintf("查询请求速率失败: %v", err)
+		return check
+	}
+	if observation.ErrorRate, observation.HasErrorRate, err = hv.average(red.ErrorMetric, hostname, start, end, step); err != nil {
+		check.Message = fmt.Sprintf("查询错误率失败: %v", err)
+		return check
+	}
+	if observation.DurationP95, observation.HasDurationP95, err = hv.average(red.DurationMetric, hostname, start, end, step); err != nil {
+		check.Message = fmt.Sprintf("查询响应时长失败: %v", err)
+		return check
+	}
+
+	check.Rate = observation.Rate
+	check.ErrorRate = observation.ErrorRate
+	check.DurationP95 = observation.DurationP95
+
+	violations := evaluateHealth(red.HealthThreshold, observation)
+	check.Passed = len(violations) == 0
+	if check.Passed {
+		check.Message = "健康验证通过"
+	} else {
+		check.Message = strings.Join(violations, "; ")
+	}
+	return check
+}
+
+// average 查询指标在时间范围内所有样本的平均值，未配置指标或没有数据时返回 false
+func (hv *HealthVerifier) average(metric *model.MetricDefinition, hostname string, start, end time.Time, step time.Duration) (float64, bool, error) {
+	if metric == nil || metric.PromQL == "" {
+		return 0, false, nil
+	}
+
+	results, err := hv.promClient.QueryRange(renderHostname(metric.PromQL, []string{hostname}), start, end, step)
+	if err != nil {
+		return 0, false, err
+	}
+
+	var sum float64
+	var count int
+	for _, result := range results {
+		for _, sample := range result.Values {
+			sum += sample.Value
+			count++
+		}
+	}
+	if count == 0 {
+		return 0, false, nil
+	}
+	return sum / float64(count), true, nil
+}
+
+// evaluateHealth 将观察值与阈值比较，返回超出阈值的说明。
+// 阈值为 0 表示不检查该项；没有请求速率数据视为速率为 0，没有错误率和响应时长数据视为正常
+func evaluateHealth(threshold *model.HealthThreshold, observation healthObservation) []string {
+	violations := []string{}
+	if threshold == nil {
+		return violations
+	}
+
+	if threshold.RateMin > 0 && observation.Rate < threshold.RateMin {
+		if observation.HasRate {
+			violations = append(violations, fmt.Sprintf("请求速率 %.2f 低于 %.2f", observation.Rate, threshold.RateMin))
+		} else {
+			violations = append(violations, "没有请求速率数据")
+		}
+	}
+	if threshold.ErrorRateMax > 0 && observation.HasErrorRate && observation.ErrorRate > threshold.ErrorRateMax {
+		violations = append(violations, fmt.Sprintf("错误率 %.2f 超过 %.2f", observation.ErrorRate, threshold.ErrorRateMax))
+	}
+	if threshold.DurationP95Max > 0 && observation.HasDurationP95 && observation.DurationP95 > threshold.DurationP95Max {
+		violations = append(violations, fmt.Sprintf("P95 响应时长 %.2f 超过 %.2f", observation.DurationP95, threshold.DurationP95Max))
+	}
+	return violations
+}
+
+// renderHostname 将 PromQL 中的 {{hostname}} 替换为精确匹配指定机器名的正则字符串，未指定机器时匹配全部。
+// 机器名按字面量匹配，web-1 不会匹配到 web-10
+func renderHostname(promql string, hostnames []string) string {
+	if !strings.Contains(promql, hostnamePlaceholder) {
+		return promql
+	}
+
+	pattern := ".*"
+	if len(hostnames) > 0 {
+		quoted := make([]string, 0, len(hostnames))
+		for _, hostname := range hostnames {
+			quoted = append(quoted, regexp.QuoteMeta(hostname))
+		}
+		pattern = "^(" + strings.Join(quoted, "|") + ")$"
+	}
+	return strings.ReplaceAll(promql, hostnamePlaceholder, strconv.Quote(pattern))
+}
+
+func convertHealthCheck(check *model.HealthCheck) *types.NodeHealthCheck {
+	if check == nil {
+		return nil
+	}
+
+	return &types.NodeHealthCheck{
+		Passed:      check.Passed,
+		Rate:        check.Rate,
+		ErrorRate:   check.ErrorRate,
+		DurationP95: check.DurationP95,
+		Message:     check.Message,
+		StartTime:   check.StartTime,
+		EndTime:     check.EndTime,
+	}
+}
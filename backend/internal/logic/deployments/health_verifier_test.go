@@ -0,0 +1,72 @@
+package deployments
+
+import (
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+)
+
+func TestEvaluateHealth(t *testing.T) {
+	threshold := &model.HealthThreshold{RateMin: 10, ErrorRateMax: 1, DurationP95Max: 200}
+
+	tests := []struct {
+		name        string
+		observation healthObservation
+		want        int
+	}{
+		{
+			name:        "全部指标正常",
+			observation: healthObservation{Rate: 50, HasRate: true, ErrorRate: 0.5, HasErrorRate: true, DurationP95: 120, HasDurationP95: true},
+			want:        0,
+		},
+		{
+			name:        "错误率和响应时长超过阈值",
+			observation: healthObservation{Rate: 50, HasRate: true, ErrorRate: 5, HasErrorRate: true, DurationP95: 300, HasDurationP95: true},
+			want:        2,
+		},
+		{
+			name:        "没有请求速率数据",
+			observation: healthObservation{},
+			want:        1,
+		},
+		{
+			name:        "没有错误率和响应时长数据视为正常",
+			observation: healthObservation{Rate: 50, HasRate: true},
+			want:        0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := evaluateHealth(threshold, tt.observation); len(got) != tt.want {
+				t.Errorf("evaluateHealth() = %v, want %d violations", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderHostname(t *testing.T) {
+	promql := `sum(rate(http_requests_total{hostname=~{{hostname}}}[1m]))`
+
+	if got := renderHostname(promql, []string{"web-1", "web-2"}); got != `sum(rate(http_requests_total{hostname=~"^(web-1|web-2)$"}[1m]))` {
+		t.Errorf("renderHostname() = %s", got)
+	}
+	if got := renderHostname(promql, nil); got != `sum(rate(http_requests_total{hostname=~".*"}[1m]))` {
+		t.Errorf("renderHostname() = %s", got)
+	}
+
+	// 机器名按字面量精确匹配
+	literal := renderHostname("{{hostname}}", []string{"web-1", "db.1"})
+	value, err := strconv.Unquote(literal)
+	if err != nil {
+		t.Fatalf("renderHostname() = %s is not a string literal: %v", literal, err)
+	}
+	pattern := regexp.MustCompile(value)
+	for host, want := range map[string]bool{"web-1": true, "db.1": true, "web-10": false, "xweb-1": false, "db-1": false} {
+		if got := pattern.MatchString(host); got != want {
+			t.Errorf("%s matches %s = %v, want %v", value, host, got, want)
+		}
+	}
+}
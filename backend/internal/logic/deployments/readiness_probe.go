@@ -0,0 +1,161 @@
+package deployments
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/logic/deployments/executor"
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+	"github.com/Z3Labs/Hackathon/backend/internal/types"
+)
+
+const (
+	defaultProbeTimeout  = 5 * time.Second
+	defaultProbeRetries  = 3
+	defaultProbeInterval = 5 * time.Second
+	defaultProbeStatus   = http.StatusOK
+
+	// maxProbeBodySize 检查 HTTP 响应体时最多读取的字节数
+	maxProbeBodySize = 64 * 1024
+)
+
+// runReadinessProbe 节点发布后按应用配置的就绪探针探测，失败时按间隔重试，直到通过或用完重试次数。
+// HTTP/TCP 探针从发布服务直接访问机器，命令探针通过执行器在机器上执行
+func runReadinessProbe(ctx context.Context, probe *model.ReadinessProbe, ip string, exec executor.Executor) *model.ProbeResult {
+	result := &model.ProbeResult{Type: probe.Type}
+
+	timeout := defaultProbeTimeout
+	if probe.TimeoutSeconds > 0 {
+		timeout = time.Duration(probe.TimeoutSeconds) * time.Second
+	}
+	// 重试次数 0 为未配置，使用默认值；-1 表示只探测一次
+	retries := defaultProbeRetries
+	if probe.Retries > 0 {
+		retries = probe.Retries
+	} else if probe.Retries < 0 {
+		retries = 0
+	}
+	interval := defaultProbeInterval
+	if probe.IntervalSeconds > 0 {
+		interval = time.Duration(probe.IntervalSeconds) * time.Second
+	}
+
+	wait := time.Duration(probe.InitialDelaySeconds) * time.Second
+	for attempt := 0; attempt <= retries; attempt++ {
+		if wait > 0 {
+			select {
+			case <-ctx.Done():
+				result.Message = ctx.Err().Error()
+				result.CheckedAt = time.Now().Unix()
+				return result
+			case <-time.After(wait):
+			}
+		}
+		wait = interval
+
+		result.Attempts++
+		probeCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := probeOnce(probeCtx, probe, ip, exec)
+		cancel()
+		if err == nil {
+			result.Passed = true
+			result.Message = "probe succeeded"
+			break
+		}
+		result.Message = err.Error()
+	}
+
+	result.CheckedAt = time.Now().Unix()
+	return result
+}
+
+func probeOnce(ctx context.Context, probe *model.ReadinessProbe, ip string, exec executor.Executor) error {
+	switch probe.Type {
+	case model.ProbeTypeHTTP:
+		return probeHTTP(ctx, probe, ip)
+	case model.ProbeTypeTCP:
+		return probeTCP(ctx, probe, ip)
+	case model.ProbeTypeCommand:
+		return probeCommand(ctx, probe, exec)
+	default:
+		return fmt.Errorf("unsupported probe type: %s", probe.Type)
+	}
+}
+
+func probeHTTP(ctx context.Context, probe *model.ReadinessProbe, ip string) error {
+	path := probe.HTTPPath
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	url := "http://" + net.JoinHostPort(ip, strconv.Itoa(probe.Port)) + path
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("GET %s failed: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	expectStatus := probe.ExpectStatus
+	if expectStatus == 0 {
+		expectStatus = defaultProbeStatus
+	}
+	if resp.StatusCode != expectStatus {
+		return fmt.Errorf("GET %s returned %d, expected %d", url, resp.StatusCode, expectStatus)
+	}
+
+	if probe.ExpectBody != "" {
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxProbeBodySize))
+		if err != nil {
+			return fmt.Errorf("read response of %s failed: %w", url, err)
+		}
+		if !strings.Contains(string(body), probe.ExpectBody) {
+			return fmt.Errorf("response of %s does not contain %q", url, probe.ExpectBody)
+		}
+	}
+	return nil
+}
+
+func probeTCP(ctx context.Context, probe *model.ReadinessProbe, ip string) error {
+	address := net.JoinHostPort(ip, strconv.Itoa(probe.Port))
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return fmt.Errorf("dial %s failed: %w", address, err)
+	}
+	return conn.Close()
+}
+
+func probeCommand(ctx context.Context, probe *model.ReadinessProbe, exec executor.Executor) error {
+	runner, ok := exec.(executor.CommandRunner)
+	if !ok {
+		return fmt.Errorf("executor does not support command probe")
+	}
+	if output, err := runner.RunCommand(ctx, probe.Command); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(output))
+	}
+	return nil
+}
+
+func convertProbeResult(result *model.ProbeResult) *types.ProbeResult {
+	if result == nil {
+		return nil
+	}
+
+	return &types.ProbeResult{
+		Passed:    result.Passed,
+		Type:      string(result.Type),
+		Attempts:  result.Attempts,
+		Message:   result.Message,
+		CheckedAt: result.CheckedAt,
+	}
+}
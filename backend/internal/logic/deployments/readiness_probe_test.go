@@ -0,0 +1,76 @@
+package deployments
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+)
+
+func TestRunReadinessProbe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer server.Close()
+
+	host, portStr, _ := net.SplitHostPort(server.Listener.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+
+	tests := []struct {
+		name         string
+		probe        *model.ReadinessProbe
+		wantPassed   bool
+		wantAttempts int
+	}{
+		{
+			name:         "HTTP 探测通过",
+			probe:        &model.ReadinessProbe{Type: model.ProbeTypeHTTP, Port: port, HTTPPath: "health", ExpectBody: "ok"},
+			wantPassed:   true,
+			wantAttempts: 1,
+		},
+		{
+			name:         "HTTP 状态码不符时重试后失败",
+			probe:        &model.ReadinessProbe{Type: model.ProbeTypeHTTP, Port: port, HTTPPath: "/missing", Retries: 2, IntervalSeconds: 1},
+			wantPassed:   false,
+			wantAttempts: 3,
+		},
+		{
+			name:         "HTTP 响应体不包含期望内容",
+			probe:        &model.ReadinessProbe{Type: model.ProbeTypeHTTP, Port: port, HTTPPath: "/health", ExpectBody: "ready", Retries: 1, IntervalSeconds: 1},
+			wantPassed:   false,
+			wantAttempts: 2,
+		},
+		{
+			name:         "重试次数为 -1 时不重试",
+			probe:        &model.ReadinessProbe{Type: model.ProbeTypeHTTP, Port: port, HTTPPath: "/missing", Retries: -1},
+			wantPassed:   false,
+			wantAttempts: 1,
+		},
+		{
+			name:         "TCP 探测通过",
+			probe:        &model.ReadinessProbe{Type: model.ProbeTypeTCP, Port: port},
+			wantPassed:   true,
+			wantAttempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := runReadinessProbe(context.Background(), tt.probe, host, nil)
+			if result.Passed != tt.wantPassed {
+				t.Errorf("Passed = %v, want %v (%s)", result.Passed, tt.wantPassed, result.Message)
+			}
+			if result.Attempts != tt.wantAttempts {
+				t.Errorf("Attempts = %d, want %d", result.Attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package deployments
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/clients/diagnosis"
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+	"github.com/Z3Labs/Hackathon/backend/internal/svc"
+	"github.com/Z3Labs/Hackathon/backend/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type RegenerateReportLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewRegenerateReportLogic(ctx context.Context, svcCtx *svc.ServiceContext) RegenerateReportLogic {
+	return RegenerateReportLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// RegenerateReport 复用已有报告的告警信息重新诊断，新报告由诊断队列异步生成
+func (l *RegenerateReportLogic) RegenerateReport(req *types.RegenerateReportReq) (resp *types.RegenerateReportResp, err error) {
+	promptVersion := strings.TrimSpace(req.PromptVersion)
+	// 未指定时由诊断队列按应用选择的模板生成
+	if promptVersion != "" {
+		if _, err := diagnosis.ResolvePromptTemplate(l.ctx, l.svcCtx.PromptTemplateModel, promptVersion, nil); err != nil {
+			if errors.Is(err, diagnosis.ErrPromptTemplateNotFound) {
+				return nil, errors.New("提示词模板不存在")
+			}
+			l.Errorf("[RegenerateReport] diagnosis.ResolvePromptTemplate error:%v", err)
+			return nil, errors.New("获取提示词模板失败")
+		}
+	}
+
+	reports, err := l.svcCtx.ReportModel.FindByDeploymentId(l.ctx, req.Id)
+	if err != nil {
+		l.Errorf("[RegenerateReport] ReportModel.FindByDeploymentId error:%v", err)
+		return nil, errors.New("获取诊断报告失败")
+	}
+
+	// 默认使用最近一次带告警信息的报告，早期同步生成的报告没有保存告警
+	var source *model.Report
+	for _, report := range reports {
+		if report.Alert == nil {
+			continue
+		}
+		if req.ReportId == "" || report.Id == req.ReportId {
+			source = report
+			break
+		}
+	}
+	if source == nil {
+		if req.ReportId != "" {
+			return nil, errors.New("报告不存在或没有告警信息")
+		}
+		return nil, errors.New("发布单没有可用于重新生成的告警信息")
+	}
+
+	reportId, created, err := diagnosis.Regenerate(l.ctx, l.svcCtx.ReportModel, source, strings.TrimSpace(req.Model), promptVersion)
+	if err != nil {
+		l.Errorf("[RegenerateReport] diagnosis.Regenerate error:%v", err)
+		return nil, errors.New("重新生成诊断报告失败")
+	}
+	if !created {
+		l.Infof("Diagnosis for deployment %s is already queued as report %s", req.Id, reportId)
+	}
+
+	return &types.RegenerateReportResp{
+		ReportId: reportId,
+		Created:  created,
+	}, nil
+}
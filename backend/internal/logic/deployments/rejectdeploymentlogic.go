@@ -0,0 +1,77 @@
+package deployments
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+	"github.com/Z3Labs/Hackathon/backend/internal/svc"
+	"github.com/Z3Labs/Hackathon/backend/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/core/stores/mon"
+)
+
+type RejectDeploymentLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewRejectDeploymentLogic(ctx context.Context, svcCtx *svc.ServiceContext) RejectDeploymentLogic {
+	return RejectDeploymentLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *RejectDeploymentLogic) RejectDeployment(req *types.RejectDeploymentReq) (resp *types.RejectDeploymentResp, err error) {
+	deployment, err := l.svcCtx.DeploymentModel.FindById(l.ctx, req.Id)
+	if err != nil {
+		l.Errorf("[RejectDeployment] DeploymentModel.FindById error:%v", err)
+		return nil, errors.New("发布记录不存在")
+	}
+
+	if deployment.Status != model.DeploymentStatusAwaitingApproval || deployment.Approval == nil {
+		l.Errorf("[RejectDeployment] Invalid status for reject: %s", deployment.Status)
+		return nil, errors.New("只能驳回待审批的发布单")
+	}
+
+	if err := checkApprover(deployment.Approval, req.Approver); err != nil {
+		l.Errorf("[RejectDeployment] checkApprover error:%v", err)
+		return nil, err
+	}
+
+	_, err = l.svcCtx.DeploymentModel.AddApprovalRecord(l.ctx, req.Id, &model.ApprovalRecord{
+		Approver:    req.Approver,
+		Action:      model.ApprovalActionReject,
+		Comment:     req.Comment,
+		CreatedTime: time.Now().Unix(),
+	})
+	if err == mon.ErrNotFound {
+		l.Errorf("[RejectDeployment] Deployment %s is no longer pending or already approved by %s", req.Id, req.Approver)
+		return nil, errors.New("发布单已不在待审批状态或已审批过该发布单")
+	}
+	if err != nil {
+		l.Errorf("[RejectDeployment] DeploymentModel.AddApprovalRecord error:%v", err)
+		return nil, errors.New("驳回发布失败")
+	}
+
+	// 任一审批人驳回即整体驳回，审批已被其他审批人结束时以先结束的结果为准
+	finished, err := l.svcCtx.DeploymentModel.FinishApproval(l.ctx, req.Id, model.ApprovalStatusRejected, model.DeploymentStatusRejected)
+	if err != nil {
+		l.Errorf("[RejectDeployment] DeploymentModel.FinishApproval error:%v", err)
+		return nil, errors.New("驳回发布失败")
+	}
+	if !finished {
+		return nil, errors.New("审批已结束，驳回未生效")
+	}
+
+	l.Infof("[RejectDeployment] %s rejected deployment %s", req.Approver, req.Id)
+
+	return &types.RejectDeploymentResp{
+		Success: true,
+	}, nil
+}
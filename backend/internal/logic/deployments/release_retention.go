@@ -0,0 +1,75 @@
+package deployments
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/logic/deployments/executor"
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// PruneNodeReleases 按保留数量清理机器上某个应用的旧版本目录，并更新机器版本清单。
+// 删除前重新读取远端版本目录，按实际存在的目录计算可清理的版本，远端 current 指向的版本始终保留
+func PruneNodeReleases(ctx context.Context, nodeVersionModel model.NodeVersionModel, exec executor.Executor,
+	nodeVersion *model.NodeVersion, keep int) (*executor.PruneResult, error) {
+	pruner, ok := exec.(executor.ReleasePruner)
+	if !ok {
+		return nil, fmt.Errorf("executor does not support pruning releases")
+	}
+
+	remoteCurrent := ""
+	if inspector, ok := exec.(executor.VersionInspector); ok {
+		installed, err := inspector.InspectVersions(ctx)
+		if err != nil {
+			return nil, err
+		}
+		nodeVersion.InstalledVersions = installed.Releases
+		remoteCurrent = installed.Current
+	}
+
+	prunable := []string{}
+	for _, version := range nodeVersion.PrunableVersions(keep) {
+		if version != remoteCurrent {
+			prunable = append(prunable, version)
+		}
+	}
+	if len(prunable) == 0 {
+		return &executor.PruneResult{Removed: []string{}}, nil
+	}
+
+	result, err := pruner.PruneReleases(ctx, prunable)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := []string{}
+	for _, version := range nodeVersion.InstalledVersions {
+		if !containsString(result.Removed, version) {
+			remaining = append(remaining, version)
+		}
+	}
+	nodeVersion.InstalledVersions = remaining
+	if err := nodeVersionModel.Upsert(ctx, nodeVersion); err != nil {
+		logx.Errorf("update node version of %s@%s failed: %v", nodeVersion.AppName, nodeVersion.MachineId, err)
+	}
+	return result, nil
+}
+
+// enforceRetention 节点发布成功后按应用的保留策略清理旧版本，清理失败不影响发布结果
+func (dm *DeploymentManager) enforceRetention(ctx context.Context, deployment *model.Deployment, exec executor.Executor, nodeVersion *model.NodeVersion) {
+	app, err := dm.applicationModel.FindById(ctx, deployment.AppId)
+	if err != nil || app.RetentionPolicy == nil || app.RetentionPolicy.KeepReleases <= 0 {
+		return
+	}
+
+	result, err := PruneNodeReleases(ctx, dm.nodeVersionModel, exec, nodeVersion, app.RetentionPolicy.KeepReleases)
+	if err != nil {
+		logx.Errorf("prune releases of %s@%s failed: %v", deployment.AppName, nodeVersion.MachineId, err)
+		return
+	}
+	if len(result.Removed) > 0 {
+		logx.Infof("pruned releases %v of %s@%s, reclaimed %d bytes",
+			result.Removed, deployment.AppName, nodeVersion.MachineId, result.ReclaimedBytes)
+	}
+}
@@ -0,0 +1,146 @@
+package deployments
+
+import (
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+	"github.com/Z3Labs/Hackathon/backend/internal/types"
+)
+
+func convertReport(report *model.Report) *types.Report {
+	return &types.Report{
+		Id:               report.Id,
+		DeploymentId:     report.DeploymentId,
+		Content:          report.Content,
+		Status:           string(report.Status),
+		Attempts:         report.Attempts,
+		LastError:        report.LastError,
+		Result:           convertDiagnosisResult(report.Result),
+		Contexts:         convertDiagnosisContexts(report.Contexts),
+		Model:            report.Model,
+		PromptVersion:    report.PromptVersion,
+		Feedback:         convertReportFeedback(report.Feedback),
+		Usage:            convertReportUsage(report.Usage),
+		SimilarIncidents: convertSimilarIncidents(report.Similar),
+		CreatedAt:        report.CreatedTime.Unix(),
+		UpdatedAt:        report.UpdatedTime.Unix(),
+	}
+}
+
+func convertReportFeedback(feedback *model.ReportFeedback) *types.ReportFeedback {
+	if feedback == nil {
+		return nil
+	}
+	return &types.ReportFeedback{
+		Accurate:  feedback.Accurate,
+		Notes:     feedback.Notes,
+		Operator:  feedback.Operator,
+		CreatedAt: feedback.CreatedTime.Unix(),
+	}
+}
+
+func convertReportUsage(usage *model.ReportUsage) *types.ReportUsage {
+	if usage == nil {
+		return nil
+	}
+	return &types.ReportUsage{
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		ToolCalls:        usage.ToolCalls,
+		Cost:             usage.Cost,
+	}
+}
+
+func convertSimilarIncidents(incidents []model.SimilarIncident) []types.SimilarIncident {
+	resp := make([]types.SimilarIncident, 0, len(incidents))
+	for _, incident := range incidents {
+		remediation := incident.Remediation
+		if remediation == nil {
+			remediation = []string{}
+		}
+		resp = append(resp, types.SimilarIncident{
+			ReportId:     incident.ReportId,
+			DeploymentId: incident.DeploymentId,
+			AppName:      incident.AppName,
+			Alertname:    incident.Alertname,
+			Summary:      incident.Summary,
+			RootCause:    incident.RootCause,
+			Remediation:  remediation,
+			Verified:     incident.Verified,
+			Score:        incident.Score,
+			CreatedAt:    incident.CreatedTime.Unix(),
+		})
+	}
+	return resp
+}
+
+func convertDiagnosisContexts(contexts []model.DiagnosisContext) []types.DiagnosisContext {
+	resp := make([]types.DiagnosisContext, 0, len(contexts))
+	for _, item := range contexts {
+		resp = append(resp, types.DiagnosisContext{
+			Type:    string(item.Type),
+			Content: item.Content,
+			Error:   item.Error,
+		})
+	}
+	return resp
+}
+
+func convertDiagnosisResult(result *model.DiagnosisResult) *types.DiagnosisResult {
+	if result == nil {
+		return nil
+	}
+	resp := &types.DiagnosisResult{
+		Summary:     result.Summary,
+		RootCause:   result.RootCause,
+		Impact:      result.Impact,
+		Remediation: result.Remediation,
+		Suspects:    make([]types.SuspectChange, 0, len(result.Suspects)),
+		Evidence:    make([]types.DiagnosisEvidence, 0, len(result.Evidence)),
+		Confidence:  result.Confidence,
+	}
+	if resp.Remediation == nil {
+		resp.Remediation = []string{}
+	}
+	for _, suspect := range result.Suspects {
+		resp.Suspects = append(resp.Suspects, types.SuspectChange{
+			PR:          suspect.PR,
+			File:        suspect.File,
+			Description: suspect.Description,
+			Suggestion:  suspect.Suggestion,
+		})
+	}
+	for _, evidence := range result.Evidence {
+		resp.Evidence = append(resp.Evidence, types.DiagnosisEvidence{
+			PromQL:      evidence.PromQL,
+			Observation: evidence.Observation,
+			Snapshot:    convertEvidenceSnapshot(evidence.Snapshot),
+		})
+	}
+	return resp
+}
+
+// convertEvidenceSnapshot 转换为监控查询接口相同的时序格式，前端可直接绘图
+func convertEvidenceSnapshot(snapshot *model.EvidenceSnapshot) *types.EvidenceSnapshot {
+	if snapshot == nil {
+		return nil
+	}
+	resp := &types.EvidenceSnapshot{
+		Start:  snapshot.Start.Unix(),
+		End:    snapshot.End.Unix(),
+		Step:   snapshot.Step,
+		Series: make([]types.MonitorSeries, 0, len(snapshot.Series)),
+		Error:  snapshot.Error,
+	}
+	for _, series := range snapshot.Series {
+		data := make([]types.DataPoint, 0, len(series.Points))
+		for _, point := range series.Points {
+			data = append(data, types.DataPoint{Timestamp: point.Timestamp, Value: point.Value})
+		}
+		resp.Series = append(resp.Series, types.MonitorSeries{
+			Instance: series.Instance,
+			Metric:   "custom",
+			Data:     data,
+			Labels:   series.Labels,
+		})
+	}
+	return resp
+}
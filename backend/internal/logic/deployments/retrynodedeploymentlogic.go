@@ -43,6 +43,11 @@ func (l *RetryNodeDeploymentLogic) RetryNodeDeployment(req *types.RetryNodeDeplo
 		return nil, errors.New("已回滚的发布单无法执行重试操作")
 	}
 
+	if deployment.Status == model.DeploymentStatusAwaitingApproval || deployment.Status == model.DeploymentStatusRejected {
+		l.Errorf("[RetryNodeDeployment] Deployment is not approved: %s", deployment.Status)
+		return nil, errors.New("发布单未审批通过，无法执行重试操作")
+	}
+
 	nodeDeploymentIdMap := make(map[string]bool)
 	for _, id := range req.NodeDeploymentIds {
 		nodeDeploymentIdMap[id] = true
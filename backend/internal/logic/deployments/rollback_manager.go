@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Z3Labs/Hackathon/backend/internal/clients/notify"
 	"github.com/Z3Labs/Hackathon/backend/internal/logic/deployments/executor"
 	"github.com/Z3Labs/Hackathon/backend/internal/model"
 	"github.com/Z3Labs/Hackathon/backend/internal/svc"
@@ -15,21 +16,25 @@ import (
 type RollbackManager struct {
 	deploymentModel  model.DeploymentModel
 	applicationModel model.ApplicationModel
+	nodeVersionModel model.NodeVersionModel
 	executorFactory  executor.ExecutorFactoryInterface
 	taskRegistry     map[string]context.CancelFunc
 	taskMutex        sync.RWMutex
+	notifier         *notify.Notifier
 }
 
 func NewRollbackManager(ctx context.Context, svcCtx *svc.ServiceContext) *RollbackManager {
 	return &RollbackManager{
 		deploymentModel:  svcCtx.DeploymentModel,
 		applicationModel: svcCtx.ApplicationModel,
-		executorFactory:  executor.NewExecutorFactory(),
+		nodeVersionModel: svcCtx.NodeVersionModel,
+		executorFactory:  svcCtx.ExecutorFactory,
 		taskRegistry:     make(map[string]context.CancelFunc),
+		notifier:         svcCtx.Notifier,
 	}
 }
 
-func (rm *RollbackManager) executeRollback(ctx context.Context, deployment *model.Deployment, nodes []string, prevVersion string) int {
+func (rm *RollbackManager) executeRollback(ctx context.Context, deployment *model.Deployment, nodes []string) int {
 	if len(nodes) == 0 {
 		return 0
 	}
@@ -41,7 +46,7 @@ func (rm *RollbackManager) executeRollback(ctx context.Context, deployment *mode
 		wg.Add(1)
 		go func(id string) {
 			defer wg.Done()
-			err := rm.rollbackNode(ctx, deployment, id, prevVersion)
+			err := rm.rollbackNode(ctx, deployment, id)
 			if err != nil {
 				logx.Errorf("rolling back deployment:%s, node:%s, failed, err = %s ", deployment.Id, id, err)
 			} else {
@@ -56,17 +61,18 @@ func (rm *RollbackManager) executeRollback(ctx context.Context, deployment *mode
 	return successCount
 }
 
-func (rm *RollbackManager) rollbackNode(ctx context.Context, deployment *model.Deployment, id string, preVersion string) error {
+func (rm *RollbackManager) rollbackNode(ctx context.Context, deployment *model.Deployment, id string) error {
 
 	nodeIndex := findNodeIndex(deployment.NodeDeployments, id)
 	if nodeIndex < 0 {
 		return fmt.Errorf("node index out of range")
 	}
+	node := &deployment.NodeDeployments[nodeIndex]
+	preVersion := rm.rollbackTarget(ctx, deployment, node)
 	if preVersion == "" {
-		logx.Info("bad version, version = %s", preVersion)
+		logx.Infof("bad version, version = %s", preVersion)
 		return fmt.Errorf("invalid prev version")
 	}
-	node := &deployment.NodeDeployments[nodeIndex]
 	executor, err := rm.executorFactory.CreateExecutor(ctx, executor.ExecutorConfig{
 		Platform:    string(node.Platform),
 		Host:        node.Id,
@@ -102,9 +108,11 @@ func (rm *RollbackManager) rollbackNode(ctx context.Context, deployment *model.D
 		return err
 	}
 
+	recordNodeVersion(context.Background(), rm.nodeVersionModel, executor, node, deployment.AppName, preVersion, true)
+
 	node.NodeDeployStatus = model.NodeDeploymentStatusRolledBack
 	node.ReleaseLog = "rollback successful"
-	node.CurrentVersion = node.PrevVersion
+	node.CurrentVersion = preVersion
 	node.DeployingVersion = ""
 	node.UpdatedAt = time.Now()
 
@@ -123,6 +131,24 @@ func (rm *RollbackManager) rollbackNode(ctx context.Context, deployment *model.D
 	return nil
 }
 
+// rollbackTarget 确定节点的回滚目标版本：
+// 本次发布已切换到新版本的节点回滚到发布前的版本，否则以机器版本清单为准
+func (rm *RollbackManager) rollbackTarget(ctx context.Context, deployment *model.Deployment, node *model.NodeDeployment) string {
+	if node.CurrentVersion == deployment.PackageVersion && node.PrevVersion != "" {
+		return node.PrevVersion
+	}
+
+	nodeVersion, err := rm.nodeVersionModel.FindOne(ctx, node.Id, deployment.AppName)
+	if err != nil {
+		return node.PrevVersion
+	}
+	if nodeVersion.CurrentVersion == deployment.PackageVersion {
+		return nodeVersion.PrevVersion
+	}
+	// 机器并未运行本次发布的版本，回滚到其当前实际版本
+	return nodeVersion.CurrentVersion
+}
+
 func (rm *RollbackManager) ContinueRollingBackDeployments(ctx context.Context) error {
 
 	// 发布中任务，单节点回滚
@@ -138,12 +164,8 @@ func (rm *RollbackManager) ContinueRollingBackDeployments(ctx context.Context) e
 				nodesToRollback = append(nodesToRollback, node.Id)
 			}
 		}
-		app, err := rm.applicationModel.FindById(ctx, deployment.AppId)
-		if err != nil {
-			logx.Errorf("find app failed, errr = %s", err)
-		}
 		if len(nodesToRollback) > 0 {
-			rm.executeRollback(ctx, deployment, nodesToRollback, app.CurrentVersion)
+			rm.executeRollback(ctx, deployment, nodesToRollback)
 		}
 	}
 	// 整个发布回滚
@@ -162,9 +184,10 @@ func (rm *RollbackManager) ContinueRollingBackDeployments(ctx context.Context) e
 		app, err := rm.applicationModel.FindById(ctx, deployment.AppId)
 		if err != nil {
 			logx.Errorf("find app failed, errr = %s", err)
+			continue
 		}
 		if len(nodesToRollback) > 0 {
-			succCount := rm.executeRollback(ctx, deployment, nodesToRollback, app.PrevVersion)
+			succCount := rm.executeRollback(ctx, deployment, nodesToRollback)
 			// 发布单级别回滚需要更新发布单整体状态
 			if succCount == len(nodesToRollback) {
 				deployment.Status = model.DeploymentStatusRolledBack
@@ -174,6 +197,13 @@ func (rm *RollbackManager) ContinueRollingBackDeployments(ctx context.Context) e
 				deployment.Status = model.DeploymentStatusFailed
 			}
 			rm.deploymentModel.UpdateStatus(context.Background(), deployment.Id, deployment.Status)
+			if deployment.Status == model.DeploymentStatusRolledBack {
+				rm.notifier.Notify(notify.DeploymentEvent(model.NotifyEventDeploymentRolledBack, deployment,
+					fmt.Sprintf("%d 台机器已回滚到上一版本", succCount)))
+			} else {
+				rm.notifier.Notify(notify.DeploymentEvent(model.NotifyEventDeploymentFailed, deployment,
+					fmt.Sprintf("回滚失败，%d/%d 台机器回滚成功", succCount, len(nodesToRollback))))
+			}
 		}
 
 	}
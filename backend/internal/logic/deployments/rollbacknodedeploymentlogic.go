@@ -5,6 +5,7 @@ import (
 	"errors"
 	"time"
 
+	"github.com/Z3Labs/Hackathon/backend/internal/clients/notify"
 	"github.com/Z3Labs/Hackathon/backend/internal/model"
 	"github.com/Z3Labs/Hackathon/backend/internal/svc"
 	"github.com/Z3Labs/Hackathon/backend/internal/types"
@@ -112,6 +113,9 @@ func (l *RollbackNodeDeploymentLogic) RollbackNodeDeployment(req *types.Rollback
 	}
 
 	l.Infof("[RollbackNodeDeployment] Successfully rolled back %d machines: %v for deployment: %s", rollbackCount, validMachineIds, req.Id)
+	if deployment.Status == model.DeploymentStatusRolledBack {
+		l.svcCtx.Notifier.Notify(notify.DeploymentEvent(model.NotifyEventDeploymentRolledBack, deployment, "手动回滚完成"))
+	}
 
 	return &types.RollbackNodeDeploymentResp{
 		Success: true,
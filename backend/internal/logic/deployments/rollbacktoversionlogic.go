@@ -0,0 +1,203 @@
+package deployments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/clients/notify"
+	"github.com/Z3Labs/Hackathon/backend/internal/logic/deployments/executor"
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+	"github.com/Z3Labs/Hackathon/backend/internal/svc"
+	"github.com/Z3Labs/Hackathon/backend/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RollbackToVersionLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewRollbackToVersionLogic(ctx context.Context, svcCtx *svc.ServiceContext) RollbackToVersionLogic {
+	return RollbackToVersionLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// RollbackToVersion 创建回滚到指定历史版本的发布单。
+// 根据机器版本清单判断目标版本目录是否仍保留在机器上：保留的直接切换，缺失的重新下载安装包。
+// 回滚属于故障止损操作，不受封版期、发布窗口和审批流程限制
+func (l *RollbackToVersionLogic) RollbackToVersion(req *types.RollbackToVersionReq) (resp *types.RollbackToVersionResp, err error) {
+	if req.TargetVersion == "" {
+		return nil, errors.New("回滚目标版本不能为空")
+	}
+
+	apps, err := l.svcCtx.ApplicationModel.Search(l.ctx, &model.ApplicationCond{
+		Name: req.AppName,
+	})
+	if err != nil {
+		l.Errorf("[RollbackToVersion] ApplicationModel.Search error:%v", err)
+		return nil, errors.New("查找应用信息失败")
+	}
+	if len(apps) == 0 {
+		l.Errorf("[RollbackToVersion] Application not found: %s", req.AppName)
+		return nil, errors.New("应用不存在")
+	}
+	app := apps[0]
+
+	// 同一应用同时只允许一个进行中的发布/回滚
+	for _, status := range []model.DeploymentStatus{model.DeploymentStatusDeploying, model.DeploymentStatusRollingBack} {
+		deployments, err := l.svcCtx.DeploymentModel.Search(l.ctx, &model.DeploymentCond{
+			AppName: req.AppName,
+			Status:  string(status),
+		})
+		if err != nil {
+			l.Errorf("[RollbackToVersion] DeploymentModel.Search error:%v", err)
+			return nil, errors.New("查询发布单失败")
+		}
+		for _, deployment := range deployments {
+			if deployment.AppId == app.Id {
+				return nil, errors.New("应用存在进行中的发布单，无法回滚")
+			}
+		}
+	}
+
+	machines := app.Machines
+	if len(req.MachineIds) > 0 {
+		machines = nil
+		for _, machine := range app.Machines {
+			if containsString(req.MachineIds, machine.Id) {
+				machines = append(machines, machine)
+			}
+		}
+		if len(machines) != len(req.MachineIds) {
+			return nil, errors.New("存在不属于该应用的机器")
+		}
+	}
+	if len(machines) == 0 {
+		return nil, errors.New("应用没有可回滚的机器")
+	}
+
+	resp = &types.RollbackToVersionResp{
+		ReuseMachines:    []string{},
+		DownloadMachines: []string{},
+		SkippedMachines:  []string{},
+	}
+
+	// 版本目录在机器上被清理时需要重新下载安装包；直接切换的机器也带上安装包地址，
+	// 以便版本清单过期、目录实际不存在时由 playbook 回退为下载
+	var pkg model.PackageInfo
+	if l.svcCtx.QiniuClient != nil {
+		pkg, err = pkgInfo(l.svcCtx.QiniuClient, req.AppName, req.TargetVersion)
+		if err != nil {
+			l.Errorf("[RollbackToVersion] pkgInfo error:%v", err)
+		}
+	}
+
+	now := time.Now()
+	platform := model.PlatformPhysical
+	nodeDeployments := make([]model.NodeDeployment, 0, len(machines))
+	for _, machine := range machines {
+		node := model.NodeDeployment{
+			Id:               machine.Id,
+			Name:             machine.Name,
+			Ip:               machine.Ip,
+			NodeDeployStatus: model.NodeDeploymentStatusDeploying,
+			Platform:         platform,
+			CreatedAt:        now,
+			UpdatedAt:        now,
+		}
+
+		nodeVersion, err := l.svcCtx.NodeVersionModel.FindOne(l.ctx, machine.Id, req.AppName)
+		if err == nil {
+			node.CurrentVersion = nodeVersion.CurrentVersion
+			node.ReuseRelease = containsString(nodeVersion.InstalledVersions, req.TargetVersion)
+		}
+
+		// 已运行目标版本的机器不纳入回滚发布单
+		if node.CurrentVersion == req.TargetVersion {
+			resp.SkippedMachines = append(resp.SkippedMachines, machine.Id)
+			continue
+		}
+		// 安装包不存在时只能直接切换，清单可能已过期，以机器上实际保留的版本目录为准
+		if pkg.URL == "" && node.ReuseRelease {
+			node.ReuseRelease = l.releaseInstalled(machine, req.AppName, req.TargetVersion)
+		}
+		if node.ReuseRelease {
+			resp.ReuseMachines = append(resp.ReuseMachines, machine.Id)
+		} else {
+			resp.DownloadMachines = append(resp.DownloadMachines, machine.Id)
+		}
+		nodeDeployments = append(nodeDeployments, node)
+	}
+	if len(nodeDeployments) == 0 {
+		return nil, errors.New("所有机器已运行目标版本，无需回滚")
+	}
+
+	if pkg.URL == "" && len(resp.DownloadMachines) > 0 {
+		l.Errorf("[RollbackToVersion] Version %s not found in package store, missing on machines: %v", req.TargetVersion, resp.DownloadMachines)
+		return nil, fmt.Errorf("目标版本 %s 的安装包不存在，且机器 %s 上没有该版本目录",
+			req.TargetVersion, strings.Join(resp.DownloadMachines, ","))
+	}
+
+	deployment := &model.Deployment{
+		Id:              primitive.NewObjectID().Hex(),
+		Type:            model.DeploymentTypeRollback,
+		Reason:          req.Reason,
+		AppName:         req.AppName,
+		AppId:           app.Id,
+		Status:          model.DeploymentStatusDeploying,
+		PackageVersion:  req.TargetVersion,
+		Platform:        platform,
+		Package:         pkg,
+		NodeDeployments: nodeDeployments,
+		CreatedTime:     now.Unix(),
+		UpdatedTime:     now.Unix(),
+	}
+	// 由发布定时任务执行所有发布中状态的节点
+	if err := l.svcCtx.DeploymentModel.Insert(l.ctx, deployment); err != nil {
+		l.Errorf("[RollbackToVersion] DeploymentModel.Insert error:%v", err)
+		return nil, errors.New("创建回滚发布单失败")
+	}
+
+	l.Infof("[RollbackToVersion] Created rollback deployment %s: %s -> %s, reuse:%d download:%d skipped:%d",
+		deployment.Id, req.AppName, req.TargetVersion,
+		len(resp.ReuseMachines), len(resp.DownloadMachines), len(resp.SkippedMachines))
+	// 回滚发布单创建后直接处于发布中状态
+	l.svcCtx.Notifier.Notify(notify.DeploymentEvent(model.NotifyEventDeploymentCreated, deployment, req.Reason))
+	l.svcCtx.Notifier.Notify(notify.DeploymentEvent(model.NotifyEventDeploymentStarted, deployment, "回滚到版本 "+req.TargetVersion))
+
+	resp.Id = deployment.Id
+	return resp, nil
+}
+
+// releaseInstalled 读取机器上实际保留的版本目录，判断目标版本是否仍然存在，读取失败时视为不存在
+func (l *RollbackToVersionLogic) releaseInstalled(machine model.Machine, appName, version string) bool {
+	exec, err := l.svcCtx.ExecutorFactory.CreateExecutor(l.ctx, executor.ExecutorConfig{
+		Platform: string(model.PlatformPhysical),
+		Host:     machine.Id,
+		IP:       machine.Ip,
+		Service:  appName,
+	})
+	if err != nil {
+		l.Errorf("[RollbackToVersion] CreateExecutor error:%v", err)
+		return false
+	}
+	inspector, ok := exec.(executor.VersionInspector)
+	if !ok {
+		return false
+	}
+	installed, err := inspector.InspectVersions(l.ctx)
+	if err != nil {
+		l.Errorf("[RollbackToVersion] InspectVersions %s@%s error:%v", appName, machine.Id, err)
+		return false
+	}
+	return containsString(installed.Releases, version)
+}
@@ -0,0 +1,58 @@
+package deployments
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/clients/diagnosis"
+	"github.com/Z3Labs/Hackathon/backend/internal/svc"
+	"github.com/Z3Labs/Hackathon/backend/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// maxIncidentSearchLimit 相似故障检索最多返回的数量
+const maxIncidentSearchLimit = 50
+
+type SearchIncidentsLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewSearchIncidentsLogic(ctx context.Context, svcCtx *svc.ServiceContext) SearchIncidentsLogic {
+	return SearchIncidentsLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *SearchIncidentsLogic) SearchIncidents(req *types.SearchIncidentsReq) (resp *types.SearchIncidentsResp, err error) {
+	query := diagnosis.IncidentQuery{
+		Text:      strings.TrimSpace(req.Query),
+		AppName:   strings.TrimSpace(req.AppName),
+		Alertname: strings.TrimSpace(req.Alertname),
+		Limit:     req.Limit,
+	}
+	if query.Text == "" && query.AppName == "" && query.Alertname == "" {
+		return nil, errors.New("检索文本、应用名称、告警名称至少填写一项")
+	}
+	if query.Limit <= 0 {
+		query.Limit = 10
+	}
+	if query.Limit > maxIncidentSearchLimit {
+		query.Limit = maxIncidentSearchLimit
+	}
+
+	incidents, err := diagnosis.SearchIncidents(l.ctx, l.svcCtx.ReportModel, query)
+	if err != nil {
+		l.Errorf("[SearchIncidents] ReportModel.FindIncidents error:%v", err)
+		return nil, errors.New("检索相似故障失败")
+	}
+
+	return &types.SearchIncidentsResp{
+		Incidents: convertSimilarIncidents(incidents),
+	}, nil
+}
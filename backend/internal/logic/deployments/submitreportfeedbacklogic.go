@@ -0,0 +1,54 @@
+package deployments
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+	"github.com/Z3Labs/Hackathon/backend/internal/svc"
+	"github.com/Z3Labs/Hackathon/backend/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type SubmitReportFeedbackLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewSubmitReportFeedbackLogic(ctx context.Context, svcCtx *svc.ServiceContext) SubmitReportFeedbackLogic {
+	return SubmitReportFeedbackLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// SubmitReportFeedback 评价已生成的报告，重复评价时覆盖之前的评价
+func (l *SubmitReportFeedbackLogic) SubmitReportFeedback(req *types.SubmitReportFeedbackReq) (resp *types.SubmitReportFeedbackResp, err error) {
+	report, err := l.svcCtx.ReportModel.FindById(l.ctx, req.Id)
+	if err != nil {
+		l.Errorf("[SubmitReportFeedback] ReportModel.FindById error:%v", err)
+		return nil, errors.New("报告不存在")
+	}
+	if report.Status != model.ReportStatusCompleted {
+		return nil, errors.New("只能评价已生成完成的报告")
+	}
+
+	report.Feedback = &model.ReportFeedback{
+		Accurate:    req.Accurate,
+		Notes:       req.Notes,
+		Operator:    req.Operator,
+		CreatedTime: time.Now(),
+	}
+	if err := l.svcCtx.ReportModel.Update(l.ctx, report); err != nil {
+		l.Errorf("[SubmitReportFeedback] ReportModel.Update error:%v", err)
+		return nil, errors.New("保存报告评价失败")
+	}
+
+	return &types.SubmitReportFeedbackResp{
+		Success: true,
+	}, nil
+}
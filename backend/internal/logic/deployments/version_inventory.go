@@ -0,0 +1,95 @@
+package deployments
+
+import (
+	"context"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/logic/deployments/executor"
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// loadNodeVersion 读取机器的版本清单，不存在时返回空清单
+func loadNodeVersion(ctx context.Context, nodeVersionModel model.NodeVersionModel, node *model.NodeDeployment, appName string) *model.NodeVersion {
+	nodeVersion, err := nodeVersionModel.FindOne(ctx, node.Id, appName)
+	if err != nil {
+		return &model.NodeVersion{
+			Id:        model.NewNodeVersionId(node.Id, appName),
+			MachineId: node.Id,
+			Ip:        node.Ip,
+			AppName:   appName,
+		}
+	}
+	return nodeVersion
+}
+
+// inspectNodeVersion 发布前读取机器上实际安装的版本校正版本清单，清单不存在时以远端版本建立清单，
+// 作为发布失败时的回滚目标。执行器不支持或读取失败时沿用已有清单
+func inspectNodeVersion(ctx context.Context, nodeVersionModel model.NodeVersionModel, exec executor.Executor,
+	node *model.NodeDeployment, appName string) *model.NodeVersion {
+	nodeVersion := loadNodeVersion(ctx, nodeVersionModel, node, appName)
+	inspector, ok := exec.(executor.VersionInspector)
+	if !ok {
+		return nodeVersion
+	}
+	installed, err := inspector.InspectVersions(ctx)
+	if err != nil {
+		logx.Errorf("inspect versions of %s@%s before deploy failed, use recorded inventory: %v", appName, node.Id, err)
+		return nodeVersion
+	}
+
+	nodeVersion.Ip = node.Ip
+	seedNodeVersion(nodeVersion, installed)
+	if err := nodeVersionModel.Upsert(ctx, nodeVersion); err != nil {
+		logx.Errorf("update node version of %s@%s failed: %v", appName, node.Id, err)
+	}
+	return nodeVersion
+}
+
+// seedNodeVersion 以远端读取的版本校正清单：远端生效版本与清单记录不一致时以远端为准
+func seedNodeVersion(nodeVersion *model.NodeVersion, installed *executor.InstalledVersions) {
+	nodeVersion.InstalledVersions = installed.Releases
+	if installed.Current == "" || installed.Current == nodeVersion.CurrentVersion {
+		return
+	}
+	if nodeVersion.CurrentVersion != "" {
+		logx.Errorf("%s@%s is running %s, inventory records %s",
+			nodeVersion.AppName, nodeVersion.MachineId, installed.Current, nodeVersion.CurrentVersion)
+	}
+	if !containsString(nodeVersion.InstalledVersions, installed.Current) {
+		nodeVersion.InstalledVersions = append(nodeVersion.InstalledVersions, installed.Current)
+	}
+	// 按回滚处理，远端版本在历史中出现过时回退到该位置，否则追加
+	nodeVersion.SwitchTo(installed.Current, true)
+}
+
+// recordNodeVersion 在发布/回滚后更新机器的版本清单。
+// 执行器支持读取远端版本时以远端 current 软链接为准，否则以本次操作的目标版本为准
+func recordNodeVersion(ctx context.Context, nodeVersionModel model.NodeVersionModel, exec executor.Executor,
+	node *model.NodeDeployment, appName, targetVersion string, rollback bool) *model.NodeVersion {
+	nodeVersion := loadNodeVersion(ctx, nodeVersionModel, node, appName)
+	nodeVersion.Ip = node.Ip
+
+	current := targetVersion
+	if inspector, ok := exec.(executor.VersionInspector); ok {
+		if installed, err := inspector.InspectVersions(ctx); err != nil {
+			logx.Errorf("inspect versions of %s@%s failed, fallback to target version %s: %v", appName, node.Id, targetVersion, err)
+		} else {
+			if installed.Current != "" {
+				current = installed.Current
+			}
+			nodeVersion.InstalledVersions = installed.Releases
+		}
+	}
+	if current != targetVersion {
+		logx.Errorf("%s@%s is running %s, expected %s", appName, node.Id, current, targetVersion)
+	}
+	if !containsString(nodeVersion.InstalledVersions, current) {
+		nodeVersion.InstalledVersions = append(nodeVersion.InstalledVersions, current)
+	}
+
+	nodeVersion.SwitchTo(current, rollback)
+	if err := nodeVersionModel.Upsert(ctx, nodeVersion); err != nil {
+		logx.Errorf("update node version of %s@%s failed: %v", appName, node.Id, err)
+	}
+	return nodeVersion
+}
@@ -0,0 +1,33 @@
+package deployments
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/logic/deployments/executor"
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+)
+
+func TestSeedNodeVersion(t *testing.T) {
+	// 没有清单的机器以远端 current 作为发布前版本
+	nodeVersion := &model.NodeVersion{MachineId: "m1", AppName: "order"}
+	seedNodeVersion(nodeVersion, &executor.InstalledVersions{Current: "v1.1", Releases: []string{"v1.0", "v1.1"}})
+	if nodeVersion.CurrentVersion != "v1.1" || !reflect.DeepEqual(nodeVersion.History, []string{"v1.1"}) ||
+		!reflect.DeepEqual(nodeVersion.InstalledVersions, []string{"v1.0", "v1.1"}) {
+		t.Errorf("nodeVersion = %+v", nodeVersion)
+	}
+
+	// 远端被手动回退到历史版本时，清单回退到该版本
+	nodeVersion = &model.NodeVersion{CurrentVersion: "v3", PrevVersion: "v2", History: []string{"v1", "v2", "v3"}}
+	seedNodeVersion(nodeVersion, &executor.InstalledVersions{Current: "v2", Releases: []string{"v2", "v3"}})
+	if nodeVersion.CurrentVersion != "v2" || nodeVersion.PrevVersion != "v1" {
+		t.Errorf("nodeVersion = %+v, want current v2, prev v1", nodeVersion)
+	}
+
+	// 远端未读到 current 时保留清单记录的版本
+	nodeVersion = &model.NodeVersion{CurrentVersion: "v3", History: []string{"v3"}}
+	seedNodeVersion(nodeVersion, &executor.InstalledVersions{Releases: []string{}})
+	if nodeVersion.CurrentVersion != "v3" {
+		t.Errorf("nodeVersion = %+v, want current v3", nodeVersion)
+	}
+}
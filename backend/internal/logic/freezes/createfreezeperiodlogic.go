@@ -0,0 +1,62 @@
+package freezes
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+	"github.com/Z3Labs/Hackathon/backend/internal/svc"
+	"github.com/Z3Labs/Hackathon/backend/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type CreateFreezePeriodLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewCreateFreezePeriodLogic(ctx context.Context, svcCtx *svc.ServiceContext) CreateFreezePeriodLogic {
+	return CreateFreezePeriodLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *CreateFreezePeriodLogic) CreateFreezePeriod(req *types.CreateFreezePeriodReq) (resp *types.CreateFreezePeriodResp, err error) {
+	if req.EndTime <= req.StartTime {
+		return nil, errors.New("封版结束时间必须晚于开始时间")
+	}
+
+	// 应用级封版需要校验应用是否存在
+	if req.AppId != "" {
+		if _, err := l.svcCtx.ApplicationModel.FindById(l.ctx, req.AppId); err != nil {
+			l.Errorf("[CreateFreezePeriod] ApplicationModel.FindById error:%v", err)
+			return nil, errors.New("应用不存在")
+		}
+	}
+
+	freeze := &model.FreezePeriod{
+		AppId:     req.AppId,
+		Name:      req.Name,
+		Reason:    req.Reason,
+		StartTime: time.Unix(req.StartTime, 0),
+		EndTime:   time.Unix(req.EndTime, 0),
+		CreatedBy: req.CreatedBy,
+	}
+
+	err = l.svcCtx.FreezePeriodModel.Insert(l.ctx, freeze)
+	if err != nil {
+		l.Errorf("[CreateFreezePeriod] FreezePeriodModel.Insert error:%v", err)
+		return nil, errors.New("创建封版期失败")
+	}
+
+	l.Infof("[CreateFreezePeriod] Successfully created freeze period: %s, ID: %s, app: %s", req.Name, freeze.Id, req.AppId)
+
+	return &types.CreateFreezePeriodResp{
+		Id: freeze.Id,
+	}, nil
+}
@@ -0,0 +1,43 @@
+package freezes
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/svc"
+	"github.com/Z3Labs/Hackathon/backend/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type DeleteFreezePeriodLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewDeleteFreezePeriodLogic(ctx context.Context, svcCtx *svc.ServiceContext) DeleteFreezePeriodLogic {
+	return DeleteFreezePeriodLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *DeleteFreezePeriodLogic) DeleteFreezePeriod(req *types.DeleteFreezePeriodReq) (resp *types.DeleteFreezePeriodResp, err error) {
+	if _, err := l.svcCtx.FreezePeriodModel.FindById(l.ctx, req.Id); err != nil {
+		l.Errorf("[DeleteFreezePeriod] FreezePeriodModel.FindById error:%v", err)
+		return nil, errors.New("封版期不存在")
+	}
+
+	if err := l.svcCtx.FreezePeriodModel.Delete(l.ctx, req.Id); err != nil {
+		l.Errorf("[DeleteFreezePeriod] FreezePeriodModel.Delete error:%v", err)
+		return nil, errors.New("删除封版期失败")
+	}
+
+	l.Infof("[DeleteFreezePeriod] Successfully deleted freeze period: %s", req.Id)
+
+	return &types.DeleteFreezePeriodResp{
+		Success: true,
+	}, nil
+}
@@ -0,0 +1,71 @@
+package freezes
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+	"github.com/Z3Labs/Hackathon/backend/internal/svc"
+	"github.com/Z3Labs/Hackathon/backend/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type GetFreezePeriodListLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewGetFreezePeriodListLogic(ctx context.Context, svcCtx *svc.ServiceContext) GetFreezePeriodListLogic {
+	return GetFreezePeriodListLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *GetFreezePeriodListLogic) GetFreezePeriodList(req *types.GetFreezePeriodListReq) (resp *types.GetFreezePeriodListResp, err error) {
+	cond := &model.FreezePeriodCond{
+		AppId:         req.AppId,
+		IncludeGlobal: true,
+		Pagination:    model.NewPaginationWithSort(req.Page, req.PageSize, "startTime", "desc"),
+	}
+	if req.ActiveOnly {
+		cond.ActiveAt = time.Now()
+	}
+
+	total, err := l.svcCtx.FreezePeriodModel.Count(l.ctx, cond)
+	if err != nil {
+		l.Errorf("[GetFreezePeriodList] FreezePeriodModel.Count error:%v", err)
+		return nil, errors.New("获取封版期列表失败")
+	}
+
+	freezes, err := l.svcCtx.FreezePeriodModel.Search(l.ctx, cond)
+	if err != nil {
+		l.Errorf("[GetFreezePeriodList] FreezePeriodModel.Search error:%v", err)
+		return nil, errors.New("获取封版期列表失败")
+	}
+
+	var freezeList []types.FreezePeriod
+	for _, freeze := range freezes {
+		freezeList = append(freezeList, types.FreezePeriod{
+			Id:        freeze.Id,
+			AppId:     freeze.AppId,
+			Name:      freeze.Name,
+			Reason:    freeze.Reason,
+			StartTime: freeze.StartTime.Unix(),
+			EndTime:   freeze.EndTime.Unix(),
+			CreatedBy: freeze.CreatedBy,
+			CreatedAt: freeze.CreatedTime.Unix(),
+		})
+	}
+
+	return &types.GetFreezePeriodListResp{
+		FreezePeriods: freezeList,
+		Total:         total,
+		Page:          req.Page,
+		PageSize:      req.PageSize,
+	}, nil
+}
@@ -0,0 +1,58 @@
+package machines
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+	"github.com/Z3Labs/Hackathon/backend/internal/svc"
+	"github.com/Z3Labs/Hackathon/backend/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type GetMachineVersionsLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewGetMachineVersionsLogic(ctx context.Context, svcCtx *svc.ServiceContext) GetMachineVersionsLogic {
+	return GetMachineVersionsLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *GetMachineVersionsLogic) GetMachineVersions(req *types.GetMachineVersionsReq) (resp *types.GetMachineVersionsResp, err error) {
+	if _, err := l.svcCtx.MachineModel.FindById(l.ctx, req.Id); err != nil {
+		l.Errorf("[GetMachineVersions] MachineModel.FindById error:%v", err)
+		return nil, fmt.Errorf("machine not found")
+	}
+
+	nodeVersions, err := l.svcCtx.NodeVersionModel.Search(l.ctx, &model.NodeVersionCond{
+		MachineId: req.Id,
+		AppName:   req.AppName,
+	})
+	if err != nil {
+		l.Errorf("[GetMachineVersions] NodeVersionModel.Search error:%v", err)
+		return nil, fmt.Errorf("查询版本清单失败")
+	}
+
+	versions := make([]types.NodeVersion, 0, len(nodeVersions))
+	for _, nodeVersion := range nodeVersions {
+		versions = append(versions, types.NodeVersion{
+			AppName:           nodeVersion.AppName,
+			CurrentVersion:    nodeVersion.CurrentVersion,
+			PrevVersion:       nodeVersion.PrevVersion,
+			History:           nodeVersion.History,
+			InstalledVersions: nodeVersion.InstalledVersions,
+			UpdatedAt:         nodeVersion.UpdatedTime.Unix(),
+		})
+	}
+
+	return &types.GetMachineVersionsResp{
+		Versions: versions,
+	}, nil
+}
@@ -0,0 +1,118 @@
+package machines
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/logic/deployments"
+	"github.com/Z3Labs/Hackathon/backend/internal/logic/deployments/executor"
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+	"github.com/Z3Labs/Hackathon/backend/internal/svc"
+	"github.com/Z3Labs/Hackathon/backend/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type PruneMachineReleasesLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewPruneMachineReleasesLogic(ctx context.Context, svcCtx *svc.ServiceContext) PruneMachineReleasesLogic {
+	return PruneMachineReleasesLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *PruneMachineReleasesLogic) PruneMachineReleases(req *types.PruneMachineReleasesReq) (resp *types.PruneMachineReleasesResp, err error) {
+	if req.KeepReleases < 0 {
+		return nil, fmt.Errorf("保留版本数不能为负数")
+	}
+
+	machine, err := l.svcCtx.MachineModel.FindById(l.ctx, req.Id)
+	if err != nil {
+		l.Errorf("[PruneMachineReleases] MachineModel.FindById error:%v", err)
+		return nil, fmt.Errorf("machine not found")
+	}
+
+	nodeVersions, err := l.svcCtx.NodeVersionModel.Search(l.ctx, &model.NodeVersionCond{
+		MachineId: req.Id,
+		AppName:   req.AppName,
+	})
+	if err != nil {
+		l.Errorf("[PruneMachineReleases] NodeVersionModel.Search error:%v", err)
+		return nil, fmt.Errorf("查询版本清单失败")
+	}
+
+	resp = &types.PruneMachineReleasesResp{
+		Results: make([]types.PruneReleasesResult, 0, len(nodeVersions)),
+	}
+	for _, nodeVersion := range nodeVersions {
+		result := types.PruneReleasesResult{
+			AppName:         nodeVersion.AppName,
+			RemovedVersions: []string{},
+		}
+
+		keep := req.KeepReleases
+		if keep == 0 {
+			keep = l.appKeepReleases(nodeVersion.AppName)
+		}
+		if keep == 0 {
+			result.Message = "应用未配置版本保留策略"
+			resp.Results = append(resp.Results, result)
+			continue
+		}
+
+		exec, err := l.svcCtx.ExecutorFactory.CreateExecutor(l.ctx, executor.ExecutorConfig{
+			Platform: string(model.PlatformPhysical),
+			Host:     machine.Id,
+			IP:       machine.Ip,
+			Service:  nodeVersion.AppName,
+		})
+		if err != nil {
+			l.Errorf("[PruneMachineReleases] CreateExecutor error:%v", err)
+			result.Message = err.Error()
+			resp.Results = append(resp.Results, result)
+			continue
+		}
+
+		pruned, err := deployments.PruneNodeReleases(l.ctx, l.svcCtx.NodeVersionModel, exec, nodeVersion, keep)
+		if err != nil {
+			l.Errorf("[PruneMachineReleases] PruneNodeReleases %s@%s error:%v", nodeVersion.AppName, req.Id, err)
+			result.Message = err.Error()
+			resp.Results = append(resp.Results, result)
+			continue
+		}
+
+		result.RemovedVersions = pruned.Removed
+		result.ReclaimedBytes = pruned.ReclaimedBytes
+		resp.ReclaimedBytes += pruned.ReclaimedBytes
+		resp.Results = append(resp.Results, result)
+	}
+
+	l.Infof("[PruneMachineReleases] Pruned releases on machine %s, reclaimed %d bytes", req.Id, resp.ReclaimedBytes)
+
+	return resp, nil
+}
+
+// appKeepReleases 应用配置的保留版本数，未配置时返回 0
+func (l *PruneMachineReleasesLogic) appKeepReleases(appName string) int {
+	apps, err := l.svcCtx.ApplicationModel.Search(l.ctx, &model.ApplicationCond{
+		Name: appName,
+	})
+	if err != nil {
+		l.Errorf("[PruneMachineReleases] ApplicationModel.Search error:%v", err)
+		return 0
+	}
+
+	// 名称条件为模糊匹配，需要精确比较
+	for _, app := range apps {
+		if app.Name == appName && app.RetentionPolicy != nil {
+			return app.RetentionPolicy.KeepReleases
+		}
+	}
+	return 0
+}
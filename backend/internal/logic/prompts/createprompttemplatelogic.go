@@ -0,0 +1,65 @@
+package prompts
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/clients/diagnosis"
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+	"github.com/Z3Labs/Hackathon/backend/internal/svc"
+	"github.com/Z3Labs/Hackathon/backend/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// templateNameRegex 模板名称只允许字母、数字、下划线、点和中划线，"@" 用于分隔版本号
+var templateNameRegex = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+type CreatePromptTemplateLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewCreatePromptTemplateLogic(ctx context.Context, svcCtx *svc.ServiceContext) CreatePromptTemplateLogic {
+	return CreatePromptTemplateLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// CreatePromptTemplate 保存提示词模板，已有版本不可修改，同名模板保存为新版本
+func (l *CreatePromptTemplateLogic) CreatePromptTemplate(req *types.CreatePromptTemplateReq) (resp *types.CreatePromptTemplateResp, err error) {
+	name := strings.TrimSpace(req.Name)
+	if !templateNameRegex.MatchString(name) {
+		return nil, errors.New("模板名称只能包含字母、数字、下划线、点和中划线")
+	}
+	if name == diagnosis.BuiltinPromptName {
+		return nil, errors.New("不能使用内置提示词的名称")
+	}
+	if err := diagnosis.ValidatePromptTemplate(req.Content); err != nil {
+		return nil, err
+	}
+
+	template := &model.PromptTemplate{
+		Name:        name,
+		Content:     req.Content,
+		Description: req.Description,
+		CreatedBy:   req.CreatedBy,
+	}
+	if err := l.svcCtx.PromptTemplateModel.Insert(l.ctx, template); err != nil {
+		l.Errorf("[CreatePromptTemplate] PromptTemplateModel.Insert error:%v", err)
+		return nil, errors.New("保存提示词模板失败")
+	}
+
+	l.Infof("[CreatePromptTemplate] Successfully created prompt template %s by %s", template.Id, req.CreatedBy)
+
+	return &types.CreatePromptTemplateResp{
+		Id:      template.Id,
+		Name:    template.Name,
+		Version: template.Version,
+	}, nil
+}
@@ -0,0 +1,62 @@
+package prompts
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/clients/diagnosis"
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+	"github.com/Z3Labs/Hackathon/backend/internal/svc"
+	"github.com/Z3Labs/Hackathon/backend/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type GetPromptTemplateListLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewGetPromptTemplateListLogic(ctx context.Context, svcCtx *svc.ServiceContext) GetPromptTemplateListLogic {
+	return GetPromptTemplateListLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *GetPromptTemplateListLogic) GetPromptTemplateList(req *types.GetPromptTemplateListReq) (resp *types.GetPromptTemplateListResp, err error) {
+	templates, err := l.svcCtx.PromptTemplateModel.Search(l.ctx, &model.PromptTemplateCond{Name: req.Name})
+	if err != nil {
+		l.Errorf("[GetPromptTemplateList] PromptTemplateModel.Search error:%v", err)
+		return nil, errors.New("获取提示词模板列表失败")
+	}
+
+	resp = &types.GetPromptTemplateListResp{
+		Templates: make([]types.PromptTemplate, 0, len(templates)+1),
+	}
+	if req.Name == "" || req.Name == diagnosis.BuiltinPromptName {
+		resp.Templates = append(resp.Templates, convertPromptTemplate(diagnosis.BuiltinPromptTemplate()))
+	}
+	for _, template := range templates {
+		resp.Templates = append(resp.Templates, convertPromptTemplate(template))
+	}
+
+	return resp, nil
+}
+
+func convertPromptTemplate(template *model.PromptTemplate) types.PromptTemplate {
+	item := types.PromptTemplate{
+		Id:          template.Id,
+		Name:        template.Name,
+		Version:     template.Version,
+		Content:     template.Content,
+		Description: template.Description,
+		CreatedBy:   template.CreatedBy,
+	}
+	if !template.CreatedTime.IsZero() {
+		item.CreatedAt = template.CreatedTime.Unix()
+	}
+	return item
+}
@@ -0,0 +1,79 @@
+package prompts
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/clients/diagnosis"
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+	"github.com/Z3Labs/Hackathon/backend/internal/svc"
+	"github.com/Z3Labs/Hackathon/backend/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type PreviewPromptTemplateLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewPreviewPromptTemplateLogic(ctx context.Context, svcCtx *svc.ServiceContext) PreviewPromptTemplateLogic {
+	return PreviewPromptTemplateLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// PreviewPromptTemplate 使用历史报告的告警和补充上下文渲染提示词，不调用模型
+func (l *PreviewPromptTemplateLogic) PreviewPromptTemplate(req *types.PreviewPromptTemplateReq) (resp *types.PreviewPromptTemplateResp, err error) {
+	report, err := l.svcCtx.ReportModel.FindById(l.ctx, req.ReportId)
+	if err != nil {
+		l.Errorf("[PreviewPromptTemplate] ReportModel.FindById error:%v, reportId:%s", err, req.ReportId)
+		return nil, errors.New("报告不存在")
+	}
+	if report.Alert == nil {
+		return nil, errors.New("报告没有告警信息")
+	}
+
+	// 发布单或应用已删除时按告警单独渲染
+	var (
+		deployment *model.Deployment
+		app        *model.Application
+	)
+	if report.DeploymentId != "" {
+		if deployment, err = l.svcCtx.DeploymentModel.FindById(l.ctx, report.DeploymentId); err != nil {
+			l.Infof("[PreviewPromptTemplate] DeploymentModel.FindById error:%v, deploymentId:%s", err, report.DeploymentId)
+			deployment = nil
+		} else if app, err = l.svcCtx.ApplicationModel.FindById(l.ctx, deployment.AppId); err != nil {
+			l.Infof("[PreviewPromptTemplate] ApplicationModel.FindById error:%v, appId:%s", err, deployment.AppId)
+			app = nil
+		}
+	}
+
+	content := req.Content
+	version := ""
+	if strings.TrimSpace(content) == "" {
+		template, err := diagnosis.ResolvePromptTemplate(l.ctx, l.svcCtx.PromptTemplateModel, strings.TrimSpace(req.PromptVersion), app)
+		if err != nil {
+			if errors.Is(err, diagnosis.ErrPromptTemplateNotFound) {
+				return nil, errors.New("提示词模板不存在")
+			}
+			l.Errorf("[PreviewPromptTemplate] diagnosis.ResolvePromptTemplate error:%v", err)
+			return nil, errors.New("获取提示词模板失败")
+		}
+		content, version = template.Content, template.Id
+	}
+
+	prompt, err := diagnosis.RenderPrompt(content, diagnosis.NewPromptData(report.Alert, deployment, app, report.Contexts))
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.PreviewPromptTemplateResp{
+		Prompt:        prompt,
+		PromptVersion: version,
+	}, nil
+}
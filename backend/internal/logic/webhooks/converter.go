@@ -0,0 +1,89 @@
+package webhooks
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/clients/notify"
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+	"github.com/Z3Labs/Hackathon/backend/internal/types"
+)
+
+// validateWebhook 校验订阅地址和事件类型，返回去重后的事件类型
+func validateWebhook(name, target string, events []string) ([]model.NotifyEventType, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, errors.New("订阅名称不能为空")
+	}
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.New("订阅地址必须是 http 或 https 地址")
+	}
+
+	eventTypes := make([]model.NotifyEventType, 0, len(events))
+	seen := make(map[model.NotifyEventType]bool)
+	for _, event := range events {
+		eventType := model.NotifyEventType(event)
+		if !notify.ValidEventType(eventType) {
+			return nil, fmt.Errorf("不支持的事件类型: %s", event)
+		}
+		if !seen[eventType] {
+			seen[eventType] = true
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+	return eventTypes, nil
+}
+
+func convertWebhook(webhook *model.Webhook) types.Webhook {
+	events := make([]string, 0, len(webhook.Events))
+	for _, event := range webhook.Events {
+		events = append(events, string(event))
+	}
+	appIds := webhook.AppIds
+	if appIds == nil {
+		appIds = []string{}
+	}
+	return types.Webhook{
+		Id:        webhook.Id,
+		Name:      webhook.Name,
+		URL:       webhook.URL,
+		Events:    events,
+		AppIds:    appIds,
+		Enabled:   webhook.Enabled,
+		CreatedBy: webhook.CreatedBy,
+		CreatedAt: webhook.CreatedTime.Unix(),
+		UpdatedAt: webhook.UpdatedTime.Unix(),
+	}
+}
+
+func convertWebhookDeliveries(deliveries []*model.WebhookDelivery) []types.WebhookDelivery {
+	result := make([]types.WebhookDelivery, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		item := types.WebhookDelivery{
+			Id:             delivery.Id,
+			WebhookId:      delivery.WebhookId,
+			EventId:        delivery.EventId,
+			EventType:      string(delivery.EventType),
+			AppId:          delivery.AppId,
+			DeploymentId:   delivery.DeploymentId,
+			Payload:        delivery.Payload,
+			Status:         string(delivery.Status),
+			Attempts:       delivery.Attempts,
+			ResponseStatus: delivery.ResponseStatus,
+			ResponseBody:   delivery.ResponseBody,
+			LastError:      delivery.LastError,
+			Duration:       delivery.Duration,
+			CreatedAt:      delivery.CreatedTime.Unix(),
+		}
+		if delivery.Status == model.WebhookDeliveryStatusPending {
+			item.NextRunAt = delivery.NextRunAt.Unix()
+		}
+		if !delivery.DeliveredTime.IsZero() {
+			item.DeliveredAt = delivery.DeliveredTime.Unix()
+		}
+		result = append(result, item)
+	}
+	return result
+}
@@ -0,0 +1,60 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/clients/webhook"
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+	"github.com/Z3Labs/Hackathon/backend/internal/svc"
+	"github.com/Z3Labs/Hackathon/backend/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type CreateWebhookLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewCreateWebhookLogic(ctx context.Context, svcCtx *svc.ServiceContext) CreateWebhookLogic {
+	return CreateWebhookLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *CreateWebhookLogic) CreateWebhook(req *types.CreateWebhookReq) (resp *types.CreateWebhookResp, err error) {
+	events, err := validateWebhook(req.Name, req.URL, req.Events)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := strings.TrimSpace(req.Secret)
+	if secret == "" {
+		secret = webhook.NewSecret()
+	}
+	subscription := &model.Webhook{
+		Name:      strings.TrimSpace(req.Name),
+		URL:       req.URL,
+		Secret:    secret,
+		Events:    events,
+		AppIds:    req.AppIds,
+		Enabled:   req.Enabled,
+		CreatedBy: req.CreatedBy,
+	}
+	if err := l.svcCtx.WebhookModel.Insert(l.ctx, subscription); err != nil {
+		l.Errorf("[CreateWebhook] WebhookModel.Insert error:%v", err)
+		return nil, errors.New("创建订阅失败")
+	}
+
+	l.Infof("[CreateWebhook] Successfully created webhook: %s, ID: %s, events: %v", subscription.Name, subscription.Id, events)
+
+	return &types.CreateWebhookResp{
+		Id:     subscription.Id,
+		Secret: secret,
+	}, nil
+}
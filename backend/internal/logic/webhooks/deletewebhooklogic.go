@@ -0,0 +1,47 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/svc"
+	"github.com/Z3Labs/Hackathon/backend/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type DeleteWebhookLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewDeleteWebhookLogic(ctx context.Context, svcCtx *svc.ServiceContext) DeleteWebhookLogic {
+	return DeleteWebhookLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *DeleteWebhookLogic) DeleteWebhook(req *types.DeleteWebhookReq) (resp *types.DeleteWebhookResp, err error) {
+	if _, err := l.svcCtx.WebhookModel.FindById(l.ctx, req.Id); err != nil {
+		l.Errorf("[DeleteWebhook] WebhookModel.FindById error:%v", err)
+		return nil, errors.New("订阅不存在")
+	}
+
+	if err := l.svcCtx.WebhookModel.Delete(l.ctx, req.Id); err != nil {
+		l.Errorf("[DeleteWebhook] WebhookModel.Delete error:%v", err)
+		return nil, errors.New("删除订阅失败")
+	}
+	// 投递记录删除失败不影响结果，worker 投递时发现订阅已删除会将其移入死信列表
+	if err := l.svcCtx.WebhookDeliveryModel.DeleteByWebhookId(l.ctx, req.Id); err != nil {
+		l.Errorf("[DeleteWebhook] WebhookDeliveryModel.DeleteByWebhookId error:%v", err)
+	}
+
+	l.Infof("[DeleteWebhook] Successfully deleted webhook: %s", req.Id)
+
+	return &types.DeleteWebhookResp{
+		Success: true,
+	}, nil
+}
@@ -0,0 +1,53 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+	"github.com/Z3Labs/Hackathon/backend/internal/svc"
+	"github.com/Z3Labs/Hackathon/backend/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type GetWebhookDeadLettersLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewGetWebhookDeadLettersLogic(ctx context.Context, svcCtx *svc.ServiceContext) GetWebhookDeadLettersLogic {
+	return GetWebhookDeadLettersLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *GetWebhookDeadLettersLogic) GetWebhookDeadLetters(req *types.GetWebhookDeadLettersReq) (resp *types.GetWebhookDeadLettersResp, err error) {
+	cond := &model.WebhookDeliveryCond{
+		WebhookId:  req.WebhookId,
+		Status:     model.WebhookDeliveryStatusDead,
+		Pagination: model.NewPaginationWithDefaultSort(req.Page, req.PageSize),
+	}
+
+	total, err := l.svcCtx.WebhookDeliveryModel.Count(l.ctx, cond)
+	if err != nil {
+		l.Errorf("[GetWebhookDeadLetters] WebhookDeliveryModel.Count error:%v", err)
+		return nil, errors.New("获取死信列表失败")
+	}
+
+	deliveries, err := l.svcCtx.WebhookDeliveryModel.Search(l.ctx, cond)
+	if err != nil {
+		l.Errorf("[GetWebhookDeadLetters] WebhookDeliveryModel.Search error:%v", err)
+		return nil, errors.New("获取死信列表失败")
+	}
+
+	return &types.GetWebhookDeadLettersResp{
+		Deliveries: convertWebhookDeliveries(deliveries),
+		Total:      total,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+	}, nil
+}
@@ -0,0 +1,59 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+	"github.com/Z3Labs/Hackathon/backend/internal/svc"
+	"github.com/Z3Labs/Hackathon/backend/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type GetWebhookDeliveriesLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewGetWebhookDeliveriesLogic(ctx context.Context, svcCtx *svc.ServiceContext) GetWebhookDeliveriesLogic {
+	return GetWebhookDeliveriesLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *GetWebhookDeliveriesLogic) GetWebhookDeliveries(req *types.GetWebhookDeliveriesReq) (resp *types.GetWebhookDeliveriesResp, err error) {
+	if _, err := l.svcCtx.WebhookModel.FindById(l.ctx, req.Id); err != nil {
+		l.Errorf("[GetWebhookDeliveries] WebhookModel.FindById error:%v", err)
+		return nil, errors.New("订阅不存在")
+	}
+
+	cond := &model.WebhookDeliveryCond{
+		WebhookId:  req.Id,
+		Status:     model.WebhookDeliveryStatus(req.Status),
+		EventType:  model.NotifyEventType(req.EventType),
+		Pagination: model.NewPaginationWithDefaultSort(req.Page, req.PageSize),
+	}
+
+	total, err := l.svcCtx.WebhookDeliveryModel.Count(l.ctx, cond)
+	if err != nil {
+		l.Errorf("[GetWebhookDeliveries] WebhookDeliveryModel.Count error:%v", err)
+		return nil, errors.New("获取投递记录失败")
+	}
+
+	deliveries, err := l.svcCtx.WebhookDeliveryModel.Search(l.ctx, cond)
+	if err != nil {
+		l.Errorf("[GetWebhookDeliveries] WebhookDeliveryModel.Search error:%v", err)
+		return nil, errors.New("获取投递记录失败")
+	}
+
+	return &types.GetWebhookDeliveriesResp{
+		Deliveries: convertWebhookDeliveries(deliveries),
+		Total:      total,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+	}, nil
+}
@@ -0,0 +1,37 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/svc"
+	"github.com/Z3Labs/Hackathon/backend/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type GetWebhookDetailLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewGetWebhookDetailLogic(ctx context.Context, svcCtx *svc.ServiceContext) GetWebhookDetailLogic {
+	return GetWebhookDetailLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *GetWebhookDetailLogic) GetWebhookDetail(req *types.GetWebhookDetailReq) (resp *types.GetWebhookDetailResp, err error) {
+	subscription, err := l.svcCtx.WebhookModel.FindById(l.ctx, req.Id)
+	if err != nil {
+		l.Errorf("[GetWebhookDetail] WebhookModel.FindById error:%v", err)
+		return nil, errors.New("订阅不存在")
+	}
+
+	return &types.GetWebhookDetailResp{
+		Webhook: convertWebhook(subscription),
+	}, nil
+}
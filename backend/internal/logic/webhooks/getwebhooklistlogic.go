@@ -0,0 +1,43 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+	"github.com/Z3Labs/Hackathon/backend/internal/svc"
+	"github.com/Z3Labs/Hackathon/backend/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type GetWebhookListLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewGetWebhookListLogic(ctx context.Context, svcCtx *svc.ServiceContext) GetWebhookListLogic {
+	return GetWebhookListLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *GetWebhookListLogic) GetWebhookList(req *types.GetWebhookListReq) (resp *types.GetWebhookListResp, err error) {
+	subscriptions, err := l.svcCtx.WebhookModel.Search(l.ctx, &model.WebhookCond{})
+	if err != nil {
+		l.Errorf("[GetWebhookList] WebhookModel.Search error:%v", err)
+		return nil, errors.New("获取订阅列表失败")
+	}
+
+	webhooks := make([]types.Webhook, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		webhooks = append(webhooks, convertWebhook(subscription))
+	}
+
+	return &types.GetWebhookListResp{
+		Webhooks: webhooks,
+	}, nil
+}
@@ -0,0 +1,63 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+	"github.com/Z3Labs/Hackathon/backend/internal/svc"
+	"github.com/Z3Labs/Hackathon/backend/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type RedeliverWebhookLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewRedeliverWebhookLogic(ctx context.Context, svcCtx *svc.ServiceContext) RedeliverWebhookLogic {
+	return RedeliverWebhookLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// RedeliverWebhook 将死信或已投递成功的记录重新加入投递队列，尝试次数清零，请求体和事件ID保持不变
+func (l *RedeliverWebhookLogic) RedeliverWebhook(req *types.RedeliverWebhookReq) (resp *types.RedeliverWebhookResp, err error) {
+	delivery, err := l.svcCtx.WebhookDeliveryModel.FindById(l.ctx, req.Id)
+	if err != nil {
+		l.Errorf("[RedeliverWebhook] WebhookDeliveryModel.FindById error:%v", err)
+		return nil, errors.New("投递记录不存在")
+	}
+	if delivery.Status != model.WebhookDeliveryStatusDead && delivery.Status != model.WebhookDeliveryStatusSucceeded {
+		return nil, errors.New("投递记录正在投递中，无需重新投递")
+	}
+
+	subscription, err := l.svcCtx.WebhookModel.FindById(l.ctx, delivery.WebhookId)
+	if err != nil {
+		l.Errorf("[RedeliverWebhook] WebhookModel.FindById error:%v", err)
+		return nil, errors.New("订阅不存在")
+	}
+	if !subscription.Enabled {
+		return nil, errors.New("订阅已停用，请先启用订阅")
+	}
+
+	delivery.Status = model.WebhookDeliveryStatusPending
+	delivery.Attempts = 0
+	delivery.NextRunAt = time.Now()
+	if err := l.svcCtx.WebhookDeliveryModel.Update(l.ctx, delivery); err != nil {
+		l.Errorf("[RedeliverWebhook] WebhookDeliveryModel.Update error:%v", err)
+		return nil, errors.New("重新投递失败")
+	}
+	l.svcCtx.Webhooks.Wake()
+
+	l.Infof("[RedeliverWebhook] Delivery %s of webhook %s queued for redelivery", delivery.Id, delivery.WebhookId)
+
+	return &types.RedeliverWebhookResp{
+		Success: true,
+	}, nil
+}
@@ -0,0 +1,64 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/clients/webhook"
+	"github.com/Z3Labs/Hackathon/backend/internal/svc"
+	"github.com/Z3Labs/Hackathon/backend/internal/types"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+type UpdateWebhookLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewUpdateWebhookLogic(ctx context.Context, svcCtx *svc.ServiceContext) UpdateWebhookLogic {
+	return UpdateWebhookLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *UpdateWebhookLogic) UpdateWebhook(req *types.UpdateWebhookReq) (resp *types.UpdateWebhookResp, err error) {
+	subscription, err := l.svcCtx.WebhookModel.FindById(l.ctx, req.Id)
+	if err != nil {
+		l.Errorf("[UpdateWebhook] WebhookModel.FindById error:%v", err)
+		return nil, errors.New("订阅不存在")
+	}
+
+	events, err := validateWebhook(req.Name, req.URL, req.Events)
+	if err != nil {
+		return nil, err
+	}
+
+	resp = &types.UpdateWebhookResp{Success: true}
+	switch secret := strings.TrimSpace(req.Secret); {
+	case req.RotateSecret:
+		subscription.Secret = webhook.NewSecret()
+		resp.Secret = subscription.Secret
+	case secret != "":
+		subscription.Secret = secret
+		resp.Secret = secret
+	}
+	subscription.Name = strings.TrimSpace(req.Name)
+	subscription.URL = req.URL
+	subscription.Events = events
+	subscription.AppIds = req.AppIds
+	subscription.Enabled = req.Enabled
+
+	if err := l.svcCtx.WebhookModel.Update(l.ctx, subscription); err != nil {
+		l.Errorf("[UpdateWebhook] WebhookModel.Update error:%v", err)
+		return nil, errors.New("更新订阅失败")
+	}
+
+	l.Infof("[UpdateWebhook] Successfully updated webhook: %s, enabled: %v", req.Id, req.Enabled)
+
+	return resp, nil
+}
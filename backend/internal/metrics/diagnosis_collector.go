@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Z3Labs/Hackathon/backend/internal/clients/diagnosis"
+	"github.com/Z3Labs/Hackathon/backend/internal/config"
+	"github.com/Z3Labs/Hackathon/backend/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// DiagnosisUsageCollector 导出本月诊断报告的用量和成本，数据从报告记录中汇总，多个实例导出的值一致
+type DiagnosisUsageCollector struct {
+	reportModel model.ReportModel
+	budget      config.BudgetConfig
+
+	reports     *prometheus.GaugeVec
+	tokens      *prometheus.GaugeVec
+	toolCalls   *prometheus.GaugeVec
+	cost        *prometheus.GaugeVec
+	budgetLimit *prometheus.GaugeVec
+
+	mu sync.Mutex
+}
+
+func NewDiagnosisUsageCollector(reportModel model.ReportModel, budget config.BudgetConfig) *DiagnosisUsageCollector {
+	return &DiagnosisUsageCollector{
+		reportModel: reportModel,
+		budget:      budget,
+		reports: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "diagnosis_month_reports",
+				Help: "Diagnosis reports with recorded usage in the current month",
+			},
+			[]string{"app", "model"},
+		),
+		tokens: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "diagnosis_month_tokens",
+				Help: "Tokens consumed by diagnosis in the current month",
+			},
+			[]string{"app", "model", "type"},
+		),
+		toolCalls: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "diagnosis_month_tool_calls",
+				Help: "Tool calls made by diagnosis in the current month",
+			},
+			[]string{"app", "model"},
+		),
+		cost: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "diagnosis_month_cost",
+				Help: "Diagnosis cost in the current month, in the unit of the configured price table",
+			},
+			[]string{"app", "model"},
+		),
+		budgetLimit: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "diagnosis_month_budget",
+				Help: "Configured monthly diagnosis budget, scope is total or per_app",
+			},
+			[]string{"scope"},
+		),
+	}
+}
+
+func (c *DiagnosisUsageCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.reports.Describe(ch)
+	c.tokens.Describe(ch)
+	c.toolCalls.Describe(ch)
+	c.cost.Describe(ch)
+	c.budgetLimit.Describe(ch)
+}
+
+func (c *DiagnosisUsageCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.reports.Reset()
+	c.tokens.Reset()
+	c.toolCalls.Reset()
+	c.cost.Reset()
+	c.budgetLimit.Reset()
+
+	stats, err := c.reportModel.UsageStats(context.Background(), diagnosis.MonthStart(time.Now()))
+	if err != nil {
+		logx.Errorf("Failed to fetch diagnosis usage: %v", err)
+	} else {
+		for _, stat := range stats {
+			// 未关联应用的告警诊断归到空应用名下
+			c.reports.WithLabelValues(stat.AppName, stat.Model).Add(float64(stat.Reports))
+			c.tokens.WithLabelValues(stat.AppName, stat.Model, "prompt").Add(float64(stat.PromptTokens))
+			c.tokens.WithLabelValues(stat.AppName, stat.Model, "completion").Add(float64(stat.CompletionTokens))
+			c.toolCalls.WithLabelValues(stat.AppName, stat.Model).Add(float64(stat.ToolCalls))
+			c.cost.WithLabelValues(stat.AppName, stat.Model).Add(stat.Cost)
+		}
+	}
+	if c.budget.MonthlyLimit > 0 {
+		c.budgetLimit.WithLabelValues("total").Set(c.budget.MonthlyLimit)
+	}
+	if c.budget.AppMonthlyLimit > 0 {
+		c.budgetLimit.WithLabelValues("per_app").Set(c.budget.AppMonthlyLimit)
+	}
+
+	c.reports.Collect(ch)
+	c.tokens.Collect(ch)
+	c.toolCalls.Collect(ch)
+	c.cost.Collect(ch)
+	c.budgetLimit.Collect(ch)
+}
@@ -0,0 +1,132 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/core/stores/mon"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// alertEvaluationRetention 评估记录的保留时长。发布监控每个周期为每条规则写入一条记录，
+// 由 createdTime 上的 TTL 索引自动清理过期记录，避免集合无限增长
+const alertEvaluationRetention = 30 * 24 * time.Hour
+
+type (
+	// AlertEvaluation 发布监控中一次告警规则评估的记录，用于展示告警触发的原因
+	AlertEvaluation struct {
+		Id           string               `bson:"_id,omitempty" json:"id,omitempty"`
+		DeploymentId string               `bson:"deploymentId"  json:"deployment_id"` // 发布单ID
+		AppName      string               `bson:"appName"       json:"app_name"`      // 应用名称
+		RuleName     string               `bson:"ruleName"      json:"rule_name"`     // 告警规则名称
+		Query        string               `bson:"query"         json:"query"`         // 实际执行的查询语句
+		Operator     string               `bson:"operator"      json:"operator"`      // 比较运算符
+		Threshold    float64              `bson:"threshold"     json:"threshold"`     // 比较阈值
+		State        AlertEvaluationState `bson:"state"         json:"state"`         // 评估后的规则状态
+		Samples      []AlertSample        `bson:"samples"       json:"samples"`       // 查询到的序列及是否越过阈值
+		FiringHosts  []string             `bson:"firingHosts"   json:"firing_hosts"`  // 越过阈值的机器
+		Reason       string               `bson:"reason"        json:"reason"`        // 评估说明
+		CreatedTime  time.Time            `bson:"createdTime"   json:"createdTime"`
+	}
+
+	// AlertSample 评估时查询到的单个序列
+	AlertSample struct {
+		Labels   map[string]string `bson:"labels"   json:"labels"`   // 序列标签
+		Value    float64           `bson:"value"    json:"value"`    // 序列值
+		Breached bool              `bson:"breached" json:"breached"` // 是否满足告警条件
+	}
+
+	AlertEvaluationModel interface {
+		Insert(ctx context.Context, evaluation *AlertEvaluation) error
+		Search(ctx context.Context, cond *AlertEvaluationCond) ([]*AlertEvaluation, error)
+		Count(ctx context.Context, cond *AlertEvaluationCond) (int64, error)
+	}
+
+	defaultAlertEvaluationModel struct {
+		model *mon.Model
+	}
+
+	AlertEvaluationCond struct {
+		DeploymentId string
+		RuleName     string
+		State        string
+		Pagination   *Pagination
+	}
+)
+
+func NewAlertEvaluationModel(url, db string) AlertEvaluationModel {
+	m := mon.MustNewModel(url, db, CollectionAlertEvaluation)
+	ensureAlertEvaluationTTL(m)
+	return &defaultAlertEvaluationModel{
+		model: m,
+	}
+}
+
+// ensureAlertEvaluationTTL 创建 createdTime 上的 TTL 索引，索引已存在时不做任何事。
+// 创建失败不影响服务启动，只记录日志
+func ensureAlertEvaluationTTL(m *mon.Model) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := m.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "createdTime", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(int32(alertEvaluationRetention.Seconds())),
+	})
+	if err != nil {
+		logx.Errorf("[AlertEvaluationModel] create ttl index error:%v", err)
+	}
+}
+
+func (c *AlertEvaluationCond) genCond() bson.M {
+	filter := bson.M{}
+
+	if c.DeploymentId != "" {
+		filter["deploymentId"] = c.DeploymentId
+	}
+
+	if c.RuleName != "" {
+		filter["ruleName"] = c.RuleName
+	}
+
+	if c.State != "" {
+		filter["state"] = c.State
+	}
+
+	return filter
+}
+
+func (m *defaultAlertEvaluationModel) Insert(ctx context.Context, evaluation *AlertEvaluation) error {
+	if evaluation.Id == "" {
+		evaluation.Id = primitive.NewObjectID().Hex()
+	}
+	evaluation.CreatedTime = time.Now()
+
+	_, err := m.model.InsertOne(ctx, evaluation)
+	return err
+}
+
+func (m *defaultAlertEvaluationModel) Search(ctx context.Context, cond *AlertEvaluationCond) ([]*AlertEvaluation, error) {
+	var result []*AlertEvaluation
+	filter := cond.genCond()
+
+	var err error
+	if cond.Pagination.IsEmpty() {
+		err = m.model.Find(ctx, &result, filter)
+	} else {
+		err = m.model.Find(ctx, &result, filter, cond.Pagination.ToFindOptions())
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (m *defaultAlertEvaluationModel) Count(ctx context.Context, cond *AlertEvaluationCond) (int64, error) {
+	count, err := m.model.CountDocuments(ctx, cond.genCond())
+	return count, err
+}
@@ -0,0 +1,84 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/mon"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func NewAlertStateId(deploymentId, ruleName string) string {
+	return deploymentId + ":" + ruleName
+}
+
+type (
+	// AlertState 发布监控中单条告警规则的评估状态，服务重启后据此恢复监控和告警持续时长计时
+	AlertState struct {
+		Id            string          `bson:"_id"           json:"id"`
+		DeploymentId  string          `bson:"deploymentId"  json:"deployment_id"`   // 发布单ID
+		AppName       string          `bson:"appName"       json:"app_name"`        // 应用名称
+		Rule          PrometheusAlert `bson:"rule"          json:"rule"`            // 开始监控时的告警规则
+		StartTime     time.Time       `bson:"startTime"     json:"start_time"`      // 开始监控时间
+		LastCheckTime time.Time       `bson:"lastCheckTime" json:"last_check_time"` // 最近一次评估时间
+		FiringStart   *time.Time      `bson:"firingStart"   json:"firing_start"`    // 开始满足告警条件的时间
+		IsFiring      bool            `bson:"isFiring"      json:"is_firing"`       // 是否满足告警条件
+		LastActiveAt  *time.Time      `bson:"lastActiveAt"  json:"last_active_at"`  // 最近一次满足告警条件的时间
+		FiringHosts   []string        `bson:"firingHosts"   json:"firing_hosts"`    // 满足告警条件的机器
+		LastReason    string          `bson:"lastReason"    json:"last_reason"`     // 最近一次评估的说明
+		UpdatedTime   time.Time       `bson:"updatedTime"   json:"updatedTime"`
+	}
+
+	AlertStateModel interface {
+		Upsert(ctx context.Context, state *AlertState) error
+		Search(ctx context.Context, cond *AlertStateCond) ([]*AlertState, error)
+		DeleteByDeployment(ctx context.Context, deploymentId string) error
+	}
+
+	defaultAlertStateModel struct {
+		model *mon.Model
+	}
+
+	AlertStateCond struct {
+		DeploymentId string
+	}
+)
+
+func NewAlertStateModel(url, db string) AlertStateModel {
+	return &defaultAlertStateModel{
+		model: mon.MustNewModel(url, db, CollectionAlertState),
+	}
+}
+
+func (c *AlertStateCond) genCond() bson.M {
+	filter := bson.M{}
+
+	if c.DeploymentId != "" {
+		filter["deploymentId"] = c.DeploymentId
+	}
+
+	return filter
+}
+
+func (m *defaultAlertStateModel) Upsert(ctx context.Context, state *AlertState) error {
+	state.Id = NewAlertStateId(state.DeploymentId, state.Rule.Name)
+	state.UpdatedTime = time.Now()
+
+	_, err := m.model.ReplaceOne(ctx, bson.M{"_id": state.Id}, state, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (m *defaultAlertStateModel) Search(ctx context.Context, cond *AlertStateCond) ([]*AlertState, error) {
+	var result []*AlertState
+	err := m.model.Find(ctx, &result, cond.genCond())
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (m *defaultAlertStateModel) DeleteByDeployment(ctx context.Context, deploymentId string) error {
+	_, err := m.model.DeleteMany(ctx, bson.M{"deploymentId": deploymentId})
+	return err
+}
@@ -10,44 +10,133 @@ import (
 
 type (
 	Application struct {
-		Id                 string          `bson:"_id"                json:"id,omitempty"`        // mongo id
-		Name               string          `bson:"name"               json:"name"`                // 应用名称
-		Repo               string          `bson:"repo"               json:"repo"`                // 仓库地址
-		DeploymentPlatform PlatformType    `bson:"deploymentPlatform" json:"deployment_platform"` // 部署平台
-		DeployPath         string          `bson:"deployPath"         json:"deploy_path"`         // 部署路径
-		ConfigPath         string          `bson:"configPath"         json:"config_path"`         // 配置文件路径
-		StartCmd           string          `bson:"startCmd"           json:"start_cmd"`           // 启动命令
-		StopCmd            string          `bson:"stopCmd"            json:"stop_cmd"`            // 停止命令
-		CurrentVersion     string          `bson:"currentVersion"     json:"currentVersion"`      // 当前版本
-		PrevVersion        string          `bson:"prevVersion"        json:"prev_version"`        // 上一个稳定版本
-		MachineCount       int             `bson:"machineCount"       json:"machine_count"`       // 机器总数量
-		HealthCount        int             `bson:"healthCount"        json:"health_count"`        // 健康机器数量
-		ErrorCount         int             `bson:"errorCount"         json:"error_count"`         // 异常机器数量
-		AlertCount         int             `bson:"alertCount"         json:"alert_count"`         // 告警机器数量
-		Machines           []Machine       `bson:"machines"           json:"machines"`            // 机器列表
-		UpStreamAppIds     []string        `bson:"upStreamAppIds"     json:"up_stream_ids"`       // 上游应用
-		DownstreamAppIds   []string        `bson:"downStreamAppIds"   json:"down_stream_ids"`     // 下游服务
-		RollbackPolicy     *RollbackPolicy `bson:"rollbackPolicy"     json:"rollback_policy"`     // 回滚策略配置
-		REDMetricsConfig   *REDMetrics     `bson:"redMetricsConfig"   json:"red_metrics_config"`  // RED指标配置,在做基于 AI 的异常分析时可以使用这些指标
+		Id                 string                  `bson:"_id"                json:"id,omitempty"`        // mongo id
+		Name               string                  `bson:"name"               json:"name"`                // 应用名称
+		Repo               string                  `bson:"repo"               json:"repo"`                // 仓库地址
+		DeploymentPlatform PlatformType            `bson:"deploymentPlatform" json:"deployment_platform"` // 部署平台
+		DeployPath         string                  `bson:"deployPath"         json:"deploy_path"`         // 部署路径
+		ConfigPath         string                  `bson:"configPath"         json:"config_path"`         // 配置文件路径
+		StartCmd           string                  `bson:"startCmd"           json:"start_cmd"`           // 启动命令
+		StopCmd            string                  `bson:"stopCmd"            json:"stop_cmd"`            // 停止命令
+		CurrentVersion     string                  `bson:"currentVersion"     json:"currentVersion"`      // 当前版本
+		PrevVersion        string                  `bson:"prevVersion"        json:"prev_version"`        // 上一个稳定版本
+		MachineCount       int                     `bson:"machineCount"       json:"machine_count"`       // 机器总数量
+		HealthCount        int                     `bson:"healthCount"        json:"health_count"`        // 健康机器数量
+		ErrorCount         int                     `bson:"errorCount"         json:"error_count"`         // 异常机器数量
+		AlertCount         int                     `bson:"alertCount"         json:"alert_count"`         // 告警机器数量
+		Machines           []Machine               `bson:"machines"           json:"machines"`            // 机器列表
+		UpStreamAppIds     []string                `bson:"upStreamAppIds"     json:"up_stream_ids"`       // 上游应用
+		DownstreamAppIds   []string                `bson:"downStreamAppIds"   json:"down_stream_ids"`     // 下游服务
+		RollbackPolicy     *RollbackPolicy         `bson:"rollbackPolicy"     json:"rollback_policy"`     // 回滚策略配置
+		REDMetricsConfig   *REDMetrics             `bson:"redMetricsConfig"   json:"red_metrics_config"`  // RED指标配置,在做基于 AI 的异常分析时可以使用这些指标
+		DeployWindows      []DeployWindow          `bson:"deployWindows"      json:"deploy_windows"`      // 允许发布的时间窗口，为空表示不限制
+		ApprovalPolicy     *ApprovalPolicy         `bson:"approvalPolicy"     json:"approval_policy"`     // 发布审批策略
+		RetentionPolicy    *RetentionPolicy        `bson:"retentionPolicy"    json:"retention_policy"`    // 机器上版本目录保留策略
+		CanaryPolicy       *CanaryPolicy           `bson:"canaryPolicy"       json:"canary_policy"`       // 灰度自动分析策略
+		ReadinessProbe     *ReadinessProbe         `bson:"readinessProbe"     json:"readiness_probe"`     // 就绪探针，节点发布后探测通过才算发布成功
+		AlertRuleSync      []AlertRuleSyncStatus   `bson:"alertRuleSync"      json:"alert_rule_sync"`     // 告警规则同步到 vmalert 的状态
+		DiagnosisContext   *DiagnosisContextPolicy `bson:"diagnosisContext"   json:"diagnosis_context"`   // 诊断时额外收集的上下文
+		PromptTemplate     *PromptTemplateRef      `bson:"promptTemplate"     json:"prompt_template"`     // 诊断使用的提示词模板，为空时使用内置提示词
+		Notification       *NotificationPolicy     `bson:"notification"       json:"notification"`        // 发布事件通知路由
 
 		CreatedTime time.Time `bson:"createdTime" json:"createdTime"` // 创建时间
 		UpdatedTime time.Time `bson:"updatedTime" json:"updatedTime"` // 更新时间
 	}
 
+	// AlertRuleSyncStatus 单条告警规则同步到 vmalert 规则文件的结果
+	AlertRuleSyncStatus struct {
+		Name     string    `bson:"name"     json:"name"`      // 告警规则名称
+		Synced   bool      `bson:"synced"   json:"synced"`    // 是否已写入规则文件
+		Message  string    `bson:"message"  json:"message"`   // 同步说明，失败时为原因
+		SyncedAt time.Time `bson:"syncedAt" json:"synced_at"` // 同步时间
+	}
+
 	RollbackPolicy struct {
 		Enabled       bool              `bson:"enabled"       json:"enabled"`        // 是否启用自动回滚
 		AlertRules    []PrometheusAlert `bson:"alertRules"    json:"alert_rules"`    // Prometheus 告警规则列表
 		AutoRollback  bool              `bson:"autoRollback"  json:"auto_rollback"`  // 是否自动执行回滚
-		NotifyChannel string            `bson:"notifyChannel" json:"notify_channel"` // 通知渠道(如webhook、钉钉、企业微信等)
+		NotifyChannel string            `bson:"notifyChannel" json:"notify_channel"` // 默认通知渠道名称，接收应用的所有通知事件
 	}
 
 	PrometheusAlert struct {
-		Name        string            `bson:"name"        json:"name"`        // 告警名称
-		AlertExpr   string            `bson:"alertExpr"   json:"alert_expr"`  // Prometheus PromQL 表达式
-		Duration    string            `bson:"duration"    json:"duration"`    // 持续时长(如 "1m", "5m")
-		Severity    string            `bson:"severity"    json:"severity"`    // 告警级别(critical, warning, info)
-		Labels      map[string]string `bson:"labels"      json:"labels"`      // 自定义标签
-		Annotations map[string]string `bson:"annotations" json:"annotations"` // 告警注解(描述信息)
+		Name          string            `bson:"name"          json:"name"`            // 告警名称
+		AlertExpr     string            `bson:"alertExpr"     json:"alert_expr"`      // Prometheus PromQL 表达式
+		Duration      string            `bson:"duration"      json:"duration"`        // 持续时长(如 "1m", "5m")
+		Severity      string            `bson:"severity"      json:"severity"`        // 告警级别(critical, warning, info)
+		Labels        map[string]string `bson:"labels"        json:"labels"`          // 自定义标签
+		Annotations   map[string]string `bson:"annotations"   json:"annotations"`     // 告警注解(描述信息)
+		HostLabel     string            `bson:"hostLabel"     json:"host_label"`      // 标识机器的标签名，用于将表达式限定到发布中的机器，默认 hostname
+		Operator      string            `bson:"operator"      json:"operator"`        // 比较运算符(>, >=, <, <=, ==, !=)，为空时值大于 0 即告警
+		Threshold     float64           `bson:"threshold"     json:"threshold"`       // 比较阈值
+		KeepFiringFor string            `bson:"keepFiringFor" json:"keep_firing_for"` // 条件恢复后继续保持告警的时长(如 "5m")
+		AbsentPolicy  AlertAbsentPolicy `bson:"absentPolicy"  json:"absent_policy"`   // 查询无数据时的处理策略，默认视为正常
+	}
+
+	// ApprovalPolicy 发布审批策略，启用后发布单需审批通过才能开始发布
+	ApprovalPolicy struct {
+		Enabled           bool     `bson:"enabled"           json:"enabled"`            // 是否启用审批
+		Approvers         []string `bson:"approvers"         json:"approvers"`          // 审批人组，为空表示任何人都可审批
+		RequiredApprovals int      `bson:"requiredApprovals" json:"required_approvals"` // 需要的审批通过人数，至少为 1
+		ExpireHours       int      `bson:"expireHours"       json:"expire_hours"`       // 审批有效期(小时)，0 表示不过期
+	}
+
+	// RetentionPolicy 机器上版本目录的保留策略，节点发布成功后按策略清理旧版本
+	RetentionPolicy struct {
+		KeepReleases int `bson:"keepReleases" json:"keep_releases"` // 保留最近生效过的版本数，0 表示不清理；当前和上一个版本始终保留
+	}
+
+	// CanaryPolicy 灰度自动分析策略：灰度机器发布成功后，在观察窗口内对比灰度机器与仍在旧版本的机器的 RED 指标
+	CanaryPolicy struct {
+		Enabled       bool    `bson:"enabled"       json:"enabled"`        // 是否启用灰度自动分析
+		WindowSeconds int     `bson:"windowSeconds" json:"window_seconds"` // 观察窗口(秒)
+		PassScore     float64 `bson:"passScore"     json:"pass_score"`     // 得分不低于该值判定通过，默认 80
+		MarginalScore float64 `bson:"marginalScore" json:"marginal_score"` // 得分低于该值判定不通过，介于两者之间为无法判断，默认 50
+		AutoPromote   bool    `bson:"autoPromote"   json:"auto_promote"`   // 分析通过后是否自动发布剩余机器
+	}
+
+	// DiagnosisContextPolicy 生成诊断报告前收集的额外上下文，随提示词一起发给模型
+	DiagnosisContextPolicy struct {
+		Providers   []DiagnosisContextType `bson:"providers"   json:"providers"`    // 启用的上下文类型
+		LogLines    int                    `bson:"logLines"    json:"log_lines"`    // 发布日志和 journalctl 读取的行数，默认 100
+		SystemdUnit string                 `bson:"systemdUnit" json:"systemd_unit"` // systemd 单元名，默认 <应用名>.service
+	}
+
+	// PromptTemplateRef 应用选择的提示词模板
+	PromptTemplateRef struct {
+		Name    string `bson:"name"    json:"name"`    // 模板名称
+		Version int    `bson:"version" json:"version"` // 固定使用的版本，0 表示始终使用最新版本
+	}
+
+	// NotificationPolicy 按事件类型把应用的通知发送到配置的渠道
+	NotificationPolicy struct {
+		Routes []NotifyRoute `bson:"routes" json:"routes"` // 通知路由，同一渠道匹配多条路由时只发送一次
+	}
+
+	// NotifyRoute 一条通知路由
+	NotifyRoute struct {
+		Channel string            `bson:"channel" json:"channel"` // 渠道名称，对应配置中的 Notify.Channels
+		Events  []NotifyEventType `bson:"events"  json:"events"`  // 接收的事件类型，为空表示所有事件
+	}
+
+	// ReadinessProbe 节点发布后的就绪探针
+	ReadinessProbe struct {
+		Type                ProbeType `bson:"type"                json:"type"`                  // 探针类型
+		Port                int       `bson:"port"                json:"port"`                  // HTTP/TCP 探测端口
+		HTTPPath            string    `bson:"httpPath"            json:"http_path"`             // HTTP 探测路径
+		ExpectStatus        int       `bson:"expectStatus"        json:"expect_status"`         // HTTP 期望状态码，默认 200
+		ExpectBody          string    `bson:"expectBody"          json:"expect_body"`           // HTTP 响应体需包含的内容，为空不检查
+		Command             string    `bson:"command"             json:"command"`               // 在机器上执行的探测命令，退出码为 0 表示就绪
+		TimeoutSeconds      int       `bson:"timeoutSeconds"      json:"timeout_seconds"`       // 单次探测超时(秒)，默认 5
+		Retries             int       `bson:"retries"             json:"retries"`               // 失败重试次数，0 表示默认 3 次，-1 表示不重试
+		IntervalSeconds     int       `bson:"intervalSeconds"     json:"interval_seconds"`      // 重试间隔(秒)，默认 5
+		InitialDelaySeconds int       `bson:"initialDelaySeconds" json:"initial_delay_seconds"` // 发布后首次探测前的等待时间(秒)
+	}
+
+	// DeployWindow 允许发布的时间窗口，按服务端本地时间计算
+	DeployWindow struct {
+		Weekdays  []int  `bson:"weekdays"  json:"weekdays"`   // 生效的星期(0=周日 ... 6=周六)，为空表示每天
+		StartTime string `bson:"startTime" json:"start_time"` // 开始时间(HH:MM)
+		EndTime   string `bson:"endTime"   json:"end_time"`   // 结束时间(HH:MM)，小于开始时间表示跨天
 	}
 
 	REDMetrics struct {
@@ -56,6 +145,7 @@ type (
 		ErrorMetric     *MetricDefinition `bson:"errorMetric"     json:"error_metric"`     // Error - 错误率
 		DurationMetric  *MetricDefinition `bson:"durationMetric"  json:"duration_metric"`  // Duration - 响应时长
 		HealthThreshold *HealthThreshold  `bson:"healthThreshold" json:"health_threshold"` // 健康度阈值
+		VerifySeconds   int               `bson:"verifySeconds"   json:"verify_seconds"`   // 节点发布后健康验证的观察窗口(秒)，0 表示不验证
 	}
 
 	MetricDefinition struct {
@@ -74,6 +164,7 @@ type (
 	ApplicationModel interface {
 		Insert(ctx context.Context, application *Application) error
 		Update(ctx context.Context, application *Application) error
+		UpdateAlertRuleSync(ctx context.Context, id string, statuses []AlertRuleSyncStatus) error
 		Delete(ctx context.Context, id string) error
 		FindById(ctx context.Context, id string) (*Application, error)
 		Search(ctx context.Context, cond *ApplicationCond) ([]*Application, error)
@@ -134,6 +225,16 @@ func (m *defaultApplicationModel) Update(ctx context.Context, application *Appli
 	return err
 }
 
+// UpdateAlertRuleSync 只更新告警规则同步状态，不覆盖应用的其他字段
+func (m *defaultApplicationModel) UpdateAlertRuleSync(ctx context.Context, id string, statuses []AlertRuleSyncStatus) error {
+	_, err := m.model.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"alertRuleSync": statuses}},
+	)
+	return err
+}
+
 func (m *defaultApplicationModel) Delete(ctx context.Context, id string) error {
 	_, err := m.model.DeleteOne(ctx, bson.M{"_id": id})
 	return err
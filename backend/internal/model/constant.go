@@ -2,25 +2,45 @@ package model
 
 const (
 	// 集合名称
-	CollectionApplication = "application"  // 应用
-	CollectionDeployment  = "deployment"   // 发布
-	CollectionMachine     = "machine"      // 机器
-	CollectionReleasePlan = "release_plan" // 发布计划
-	CollectionReport      = "report"       // 发布错误分析报告
+	CollectionApplication     = "application"      // 应用
+	CollectionDeployment      = "deployment"       // 发布
+	CollectionMachine         = "machine"          // 机器
+	CollectionReleasePlan     = "release_plan"     // 发布计划
+	CollectionReport          = "report"           // 发布错误分析报告
+	CollectionFreezePeriod    = "freeze_period"    // 封版期
+	CollectionNodeVersion     = "node_version"     // 机器版本清单
+	CollectionAlertEvaluation = "alert_evaluation" // 告警规则评估记录
+	CollectionAlertState      = "alert_state"      // 发布监控告警状态
+	CollectionReceivedAlert   = "received_alert"   // 告警回调接收记录
+	CollectionPromptTemplate  = "prompt_template"  // 诊断提示词模板
+	CollectionWebhook         = "webhook"          // 外部系统的 webhook 订阅
+	CollectionWebhookDelivery = "webhook_delivery" // webhook 投递记录
 )
 
 type (
-	HealthStatus         string // 健康状态
-	ErrorStatus          string // 异常状态
-	AlertStatus          string // 告警状态
-	DeploymentStatus     string // 发布单状态
-	NodeDeploymentStatus string // 发布状态
-	GrayStrategy         string // 灰度策略
-	PlanStatus           string // 发布计划状态
-	StageStatus          string // 阶段状态
-	NodeStatus           string // 节点状态
-	PlatformType         string // 平台类型
-	ReportStatus         string // 报告生成状态
+	HealthStatus          string // 健康状态
+	ErrorStatus           string // 异常状态
+	AlertStatus           string // 告警状态
+	DeploymentStatus      string // 发布单状态
+	NodeDeploymentStatus  string // 发布状态
+	GrayStrategy          string // 灰度策略
+	PlanStatus            string // 发布计划状态
+	StageStatus           string // 阶段状态
+	NodeStatus            string // 节点状态
+	PlatformType          string // 平台类型
+	ReportStatus          string // 报告生成状态
+	ApprovalStatus        string // 审批状态
+	ApprovalAction        string // 审批动作
+	DeploymentType        string // 发布单类型
+	CanaryVerdict         string // 灰度分析结论
+	ProbeType             string // 就绪探针类型
+	AlertAbsentPolicy     string // 告警无数据处理策略
+	AlertEvaluationState  string // 告警规则评估状态
+	ReceivedAlertStatus   string // 告警回调中的告警状态
+	AlertHandleAction     string // 告警回调的处理结果
+	DiagnosisContextType  string // 诊断上下文类型
+	NotifyEventType       string // 通知事件类型
+	WebhookDeliveryStatus string // webhook 投递状态
 )
 
 const (
@@ -41,13 +61,15 @@ const (
 	NodeDeploymentStatusRolledBack  NodeDeploymentStatus = "rolled_back"  // 已回滚
 	NodeDeploymentStatusFailed      NodeDeploymentStatus = "failed"       // 失败
 
-	DeploymentStatusPending     DeploymentStatus = "pending"      // 待发布
-	DeploymentStatusDeploying   DeploymentStatus = "deploying"    // 发布中
-	DeploymentStatusSuccess     DeploymentStatus = "success"      // 成功
-	DeploymentStatusFailed      DeploymentStatus = "failed"       // 失败
-	DeploymentStatusRollingBack DeploymentStatus = "rolling_back" // 回滚中
-	DeploymentStatusRolledBack  DeploymentStatus = "rolled_back"  // 已回滚
-	DeploymentStatusCanceled    DeploymentStatus = "canceled"     // 已取消
+	DeploymentStatusPending          DeploymentStatus = "pending"           // 待发布
+	DeploymentStatusDeploying        DeploymentStatus = "deploying"         // 发布中
+	DeploymentStatusSuccess          DeploymentStatus = "success"           // 成功
+	DeploymentStatusFailed           DeploymentStatus = "failed"            // 失败
+	DeploymentStatusRollingBack      DeploymentStatus = "rolling_back"      // 回滚中
+	DeploymentStatusRolledBack       DeploymentStatus = "rolled_back"       // 已回滚
+	DeploymentStatusCanceled         DeploymentStatus = "canceled"          // 已取消
+	DeploymentStatusAwaitingApproval DeploymentStatus = "awaiting_approval" // 待审批
+	DeploymentStatusRejected         DeploymentStatus = "rejected"          // 审批未通过
 
 	GrayStrategyCanary    GrayStrategy = "canary"     // 金丝雀发布
 	GrayStrategyBlueGreen GrayStrategy = "blue-green" // 蓝绿发布
@@ -77,7 +99,69 @@ const (
 	PlatformPhysical PlatformType = "physical" // 物理机
 	PlatformK8s      PlatformType = "k8s"      // K8s
 
+	ReportStatusQueued     ReportStatus = "queued"     // 排队中，包括失败后等待重试
 	ReportStatusGenerating ReportStatus = "generating" // 生成中
 	ReportStatusCompleted  ReportStatus = "completed"  // 生成完成
 	ReportStatusFailed     ReportStatus = "failed"     // 生成失败
+	ReportStatusSkipped    ReportStatus = "skipped"    // 诊断预算已用完，未生成
+
+	ApprovalStatusPending  ApprovalStatus = "pending"  // 审批中
+	ApprovalStatusApproved ApprovalStatus = "approved" // 已通过
+	ApprovalStatusRejected ApprovalStatus = "rejected" // 已驳回
+	ApprovalStatusExpired  ApprovalStatus = "expired"  // 已过期
+
+	ApprovalActionApprove ApprovalAction = "approve" // 通过
+	ApprovalActionReject  ApprovalAction = "reject"  // 驳回
+
+	DeploymentTypeDeploy   DeploymentType = "deploy"   // 发布新版本
+	DeploymentTypeRollback DeploymentType = "rollback" // 回滚到历史版本
+
+	CanaryVerdictRunning      CanaryVerdict = "running"      // 分析中
+	CanaryVerdictPass         CanaryVerdict = "pass"         // 通过
+	CanaryVerdictFail         CanaryVerdict = "fail"         // 未通过
+	CanaryVerdictInconclusive CanaryVerdict = "inconclusive" // 无法判断
+	CanaryVerdictNoData       CanaryVerdict = "no_data"      // 指标无数据，仅用于单个指标
+
+	ProbeTypeHTTP    ProbeType = "http"    // HTTP 接口探测
+	ProbeTypeTCP     ProbeType = "tcp"     // TCP 端口探测
+	ProbeTypeCommand ProbeType = "command" // 在机器上执行命令探测
+
+	AlertAbsentPolicyOK     AlertAbsentPolicy = "ok"     // 无数据视为正常
+	AlertAbsentPolicyFire   AlertAbsentPolicy = "fire"   // 无数据视为告警
+	AlertAbsentPolicyIgnore AlertAbsentPolicy = "ignore" // 忽略本次评估，保持之前的状态
+
+	AlertEvaluationStateInactive AlertEvaluationState = "inactive" // 未满足告警条件
+	AlertEvaluationStatePending  AlertEvaluationState = "pending"  // 满足条件但未达到持续时长
+	AlertEvaluationStateFiring   AlertEvaluationState = "firing"   // 告警中
+
+	ReceivedAlertStatusFiring   ReceivedAlertStatus = "firing"   // 告警中
+	ReceivedAlertStatusResolved ReceivedAlertStatus = "resolved" // 已恢复
+
+	AlertHandleActionRollback  AlertHandleAction = "rollback"  // 触发自动回滚并生成诊断报告
+	AlertHandleActionDiagnose  AlertHandleAction = "diagnose"  // 生成诊断报告
+	AlertHandleActionResolved  AlertHandleAction = "resolved"  // 告警恢复，仅记录
+	AlertHandleActionDuplicate AlertHandleAction = "duplicate" // 重复推送，已忽略
+	AlertHandleActionUnmatched AlertHandleAction = "unmatched" // 未关联到发布中的发布单
+
+	DiagnosisContextDeployLog          DiagnosisContextType = "deploy_log"          // 告警机器的发布日志
+	DiagnosisContextJournal            DiagnosisContextType = "journal"             // 通过 SSH 读取 systemd 单元的 journalctl 日志
+	DiagnosisContextVersionDiff        DiagnosisContextType = "version_diff"        // 上一个版本与发布版本之间的代码差异
+	DiagnosisContextMachine            DiagnosisContextType = "machine"             // 告警机器的基本信息和资源概况
+	DiagnosisContextRelatedDeployments DiagnosisContextType = "related_deployments" // 上下游应用近期的发布记录
+	DiagnosisContextSimilarIncidents   DiagnosisContextType = "similar_incidents"   // 历史相似故障，所有诊断默认附带，不需要在应用中启用
+
+	NotifyEventDeploymentCreated    NotifyEventType = "deployment_created"     // 创建发布单
+	NotifyEventDeploymentStarted    NotifyEventType = "deployment_started"     // 发布单开始发布
+	NotifyEventBatchCompleted       NotifyEventType = "batch_completed"        // 一批机器发布完成
+	NotifyEventDeploymentSucceeded  NotifyEventType = "deployment_succeeded"   // 发布成功
+	NotifyEventDeploymentFailed     NotifyEventType = "deployment_failed"      // 发布失败
+	NotifyEventRollbackTriggered    NotifyEventType = "rollback_triggered"     // 告警或灰度分析不通过触发自动回滚
+	NotifyEventCanaryFailed         NotifyEventType = "canary_failed"          // 灰度分析不通过，发布暂停
+	NotifyEventDeploymentRolledBack NotifyEventType = "deployment_rolled_back" // 整单回滚完成
+	NotifyEventReportCompleted      NotifyEventType = "report_completed"       // 诊断报告生成完成
+
+	WebhookDeliveryStatusPending    WebhookDeliveryStatus = "pending"    // 待投递，包括失败后等待重试
+	WebhookDeliveryStatusDelivering WebhookDeliveryStatus = "delivering" // 投递中
+	WebhookDeliveryStatusSucceeded  WebhookDeliveryStatus = "succeeded"  // 投递成功
+	WebhookDeliveryStatusDead       WebhookDeliveryStatus = "dead"       // 超过最大尝试次数，进入死信列表
 )
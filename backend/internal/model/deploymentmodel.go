@@ -6,11 +6,14 @@ import (
 
 	"github.com/zeromicro/go-zero/core/stores/mon"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type (
 	Deployment struct {
 		Id              string           `bson:"_id,omitempty"   json:"id,omitempty"`
+		Type            DeploymentType   `bson:"type"            json:"type"`     // 发布单类型，为空时等同 deploy
+		Reason          string           `bson:"reason"          json:"reason"`   // 发布说明/回滚原因
 		AppName         string           `bson:"appName"         json:"app_name"` // 应用名称
 		AppId           string           `bson:"appId"           json:"app_id"`
 		Status          DeploymentStatus `bson:"status"          json:"status"`           // 发布状态
@@ -20,6 +23,10 @@ type (
 		Package         PackageInfo      `bson:"package"         json:"package"`          // 包信息
 		Pacer           PacerConfig      `bson:"pacer"           json:"pacer"`            // 批量部署控制
 		NodeDeployments []NodeDeployment `bson:"nodeDeployments" json:"node_deployments"` // 发布机器列表
+		ScheduledTime   int64            `bson:"scheduledTime"   json:"scheduled_time"`   // 计划发布时间戳，0 表示不定时
+		FreezeOverride  *FreezeOverride  `bson:"freezeOverride"  json:"freeze_override"`  // 封版期/发布窗口外强制发布记录
+		Approval        *Approval        `bson:"approval"        json:"approval"`         // 发布审批信息，未启用审批时为空
+		CanaryAnalysis  *CanaryAnalysis  `bson:"canaryAnalysis"  json:"canary_analysis"`  // 灰度自动分析结果，未启用时为空
 		CreatedTime     int64            `bson:"createdTime"     json:"createdTime"`      // 创建时间戳
 		UpdatedTime     int64            `bson:"updatedTime"     json:"updatedTime"`      // 更新时间戳
 	}
@@ -31,6 +38,70 @@ type (
 		CreatedAt time.Time `bson:"createdAt" json:"created_at"`
 	}
 
+	// FreezeOverride 封版期内强制发布的审计记录，只对记录时已生效的封版期和所在的发布窗口外时段有效
+	FreezeOverride struct {
+		Operator    string   `bson:"operator"    json:"operator"`     // 操作人
+		Reason      string   `bson:"reason"      json:"reason"`       // 强制发布原因
+		FreezeIds   []string `bson:"freezeIds"   json:"freeze_ids"`   // 记录时已生效的封版期ID
+		WindowUntil int64    `bson:"windowUntil" json:"window_until"` // 记录时不在发布窗口内时，下一个发布窗口的开始时间戳
+		CreatedTime int64    `bson:"createdTime" json:"created_time"` // 记录时间戳
+	}
+
+	// Approval 发布单的审批状态及审批记录
+	Approval struct {
+		Status            ApprovalStatus   `bson:"status"            json:"status"`             // 审批状态
+		Approvers         []string         `bson:"approvers"         json:"approvers"`          // 审批人组
+		RequiredApprovals int              `bson:"requiredApprovals" json:"required_approvals"` // 需要的审批通过人数
+		ExpireTime        int64            `bson:"expireTime"        json:"expire_time"`        // 审批过期时间戳，0 表示不过期
+		Records           []ApprovalRecord `bson:"records"           json:"records"`            // 审批记录
+	}
+
+	ApprovalRecord struct {
+		Approver    string         `bson:"approver"    json:"approver"`     // 审批人
+		Action      ApprovalAction `bson:"action"      json:"action"`       // 审批动作
+		Comment     string         `bson:"comment"     json:"comment"`      // 审批意见
+		CreatedTime int64          `bson:"createdTime" json:"created_time"` // 审批时间戳
+	}
+
+	// CanaryAnalysis 灰度机器与基线机器的 RED 指标对比分析
+	CanaryAnalysis struct {
+		Verdict   CanaryVerdict        `bson:"verdict"   json:"verdict"`    // 分析结论
+		Score     float64              `bson:"score"     json:"score"`      // 得分(0-100)，通过的指标占有数据指标的比例
+		Metrics   []CanaryMetricResult `bson:"metrics"   json:"metrics"`    // 各指标的检验结果
+		Message   string               `bson:"message"   json:"message"`    // 分析说明
+		Promoted  bool                 `bson:"promoted"  json:"promoted"`   // 是否已自动发布剩余机器
+		StartTime int64                `bson:"startTime" json:"start_time"` // 观察开始时间戳
+		EndTime   int64                `bson:"endTime"   json:"end_time"`   // 观察结束时间戳
+	}
+
+	CanaryMetricResult struct {
+		Name           string        `bson:"name"           json:"name"`            // 指标名称(rate/error/duration)
+		CanaryMedian   float64       `bson:"canaryMedian"   json:"canary_median"`   // 灰度机器样本中位数
+		BaselineMedian float64       `bson:"baselineMedian" json:"baseline_median"` // 各基线机器样本中位数的中位数
+		PValue         float64       `bson:"pValue"         json:"p_value"`         // 与各基线机器 Mann-Whitney U 检验单侧 p 值的中位数
+		Verdict        CanaryVerdict `bson:"verdict"        json:"verdict"`         // 指标结论
+	}
+
+	// ProbeResult 节点发布后的就绪探测结果
+	ProbeResult struct {
+		Passed    bool      `bson:"passed"    json:"passed"`     // 是否通过
+		Type      ProbeType `bson:"type"      json:"type"`       // 探针类型
+		Attempts  int       `bson:"attempts"  json:"attempts"`   // 探测次数
+		Message   string    `bson:"message"   json:"message"`    // 最后一次探测的结果说明
+		CheckedAt int64     `bson:"checkedAt" json:"checked_at"` // 探测完成时间戳
+	}
+
+	// HealthCheck 节点发布后在观察窗口内的 RED 指标健康验证结果
+	HealthCheck struct {
+		Passed      bool    `bson:"passed"      json:"passed"`       // 是否通过
+		Rate        float64 `bson:"rate"        json:"rate"`         // 观察窗口内的平均请求速率
+		ErrorRate   float64 `bson:"errorRate"   json:"error_rate"`   // 观察窗口内的平均错误率
+		DurationP95 float64 `bson:"durationP95" json:"duration_p95"` // 观察窗口内的平均 P95 响应时长
+		Message     string  `bson:"message"     json:"message"`      // 验证说明，未通过时为超出阈值的指标
+		StartTime   int64   `bson:"startTime"   json:"start_time"`   // 观察开始时间戳
+		EndTime     int64   `bson:"endTime"     json:"end_time"`     // 观察结束时间戳
+	}
+
 	PacerConfig struct {
 		BatchSize       int `bson:"batchSize"       json:"batch_size"`
 		IntervalSeconds int `bson:"intervalSeconds" json:"interval_seconds"`
@@ -46,6 +117,10 @@ type (
 		CurrentVersion   string               `bson:"currentVersion"   json:"current_version"`   // 当前版本
 		DeployingVersion string               `bson:"deployingVersion" json:"deploying_version"` // 正在部署的版本
 		PrevVersion      string               `bson:"prevVersion"      json:"prev_version"`      // 之前版本
+		ReuseRelease     bool                 `bson:"reuseRelease"     json:"reuse_release"`     // 机器上已保留目标版本目录，无需重新下载
+		HealthCheck      *HealthCheck         `bson:"healthCheck"      json:"health_check"`      // 发布后健康验证结果
+		ProbeResult      *ProbeResult         `bson:"probeResult"      json:"probe_result"`      // 发布后就绪探测结果
+		FiringAlerts     []string             `bson:"firingAlerts"     json:"firing_alerts"`     // 在该机器上触发的告警规则名称
 		Platform         PlatformType         `bson:"platform"         json:"platform"`          // 平台类型
 		UpdatedAt        time.Time            `bson:"updatedAt"        json:"updated_at"`        // 更新时间
 		CreatedAt        time.Time            `bson:"createdAt"        json:"created_at"`        // 创建时间
@@ -55,6 +130,14 @@ type (
 		Insert(ctx context.Context, deployment *Deployment) error
 		Update(ctx context.Context, deployment *Deployment) error
 		UpdateStatus(ctx context.Context, id string, status DeploymentStatus) error
+		UpdateNode(ctx context.Context, id string, node *NodeDeployment) error
+		UpdateCanaryAnalysis(ctx context.Context, id string, analysis *CanaryAnalysis) error
+		TransitionStatus(ctx context.Context, id string, from, to DeploymentStatus) (bool, error)
+		SaveCanaryAnalysis(ctx context.Context, id string, from, to DeploymentStatus, analysis *CanaryAnalysis) (bool, error)
+		PromotePendingNodes(ctx context.Context, id string) error
+		AddFiringAlert(ctx context.Context, id string, nodeNames []string, alertName string) error
+		AddApprovalRecord(ctx context.Context, id string, record *ApprovalRecord) (*Deployment, error)
+		FinishApproval(ctx context.Context, id string, approvalStatus ApprovalStatus, status DeploymentStatus) (bool, error)
 		Delete(ctx context.Context, id string) error
 		FindById(ctx context.Context, id string) (*Deployment, error)
 		Search(ctx context.Context, cond *DeploymentCond) ([]*Deployment, error)
@@ -70,7 +153,11 @@ type (
 		Id      string
 		Ids     []string
 		AppName string
+		AppIds  []string
 		Status  string
+
+		ScheduledBefore int64 // 计划发布时间不晚于该时间戳（仅匹配定时发布单）
+		CreatedAfter    int64 // 创建时间不早于该时间戳
 	}
 )
 
@@ -93,10 +180,22 @@ func (c *DeploymentCond) genCond() bson.M {
 		filter["appName"] = bson.M{"$regex": c.AppName, "$options": "i"}
 	}
 
+	if len(c.AppIds) > 0 {
+		filter["appId"] = bson.M{"$in": c.AppIds}
+	}
+
 	if c.Status != "" {
 		filter["status"] = c.Status
 	}
 
+	if c.ScheduledBefore > 0 {
+		filter["scheduledTime"] = bson.M{"$gt": 0, "$lte": c.ScheduledBefore}
+	}
+
+	if c.CreatedAfter > 0 {
+		filter["createdTime"] = bson.M{"$gte": c.CreatedAfter}
+	}
+
 	return filter
 }
 
@@ -127,6 +226,121 @@ func (m *defaultDeploymentModel) UpdateStatus(ctx context.Context, id string, st
 	return err
 }
 
+// UpdateNode 只更新发布单中的一个节点，长时间执行的节点任务不会覆盖期间对发布单其他字段的修改
+func (m *defaultDeploymentModel) UpdateNode(ctx context.Context, id string, node *NodeDeployment) error {
+	_, err := m.model.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "nodeDeployments.id": node.Id},
+		bson.M{"$set": bson.M{"nodeDeployments.$": node, "updatedTime": time.Now().Unix()}},
+	)
+	return err
+}
+
+// UpdateCanaryAnalysis 只更新发布单的灰度分析结果
+func (m *defaultDeploymentModel) UpdateCanaryAnalysis(ctx context.Context, id string, analysis *CanaryAnalysis) error {
+	_, err := m.model.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"canaryAnalysis": analysis, "updatedTime": time.Now().Unix()}},
+	)
+	return err
+}
+
+// TransitionStatus 仅当发布单当前状态为 from 时更新为 to，返回是否更新。并发的状态变更只有一个生效
+func (m *defaultDeploymentModel) TransitionStatus(ctx context.Context, id string, from, to DeploymentStatus) (bool, error) {
+	res, err := m.model.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "status": from},
+		bson.M{"$set": bson.M{"status": to, "updatedTime": time.Now().Unix()}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+// SaveCanaryAnalysis 仅当发布单当前状态为 from 时保存灰度分析结果并将状态更新为 to，返回是否更新。
+// 只写入分析结果和状态，不覆盖同时由发布任务写入的节点状态
+func (m *defaultDeploymentModel) SaveCanaryAnalysis(ctx context.Context, id string, from, to DeploymentStatus, analysis *CanaryAnalysis) (bool, error) {
+	res, err := m.model.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "status": from},
+		bson.M{"$set": bson.M{"canaryAnalysis": analysis, "status": to, "updatedTime": time.Now().Unix()}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+// PromotePendingNodes 将发布中的发布单里仍待发布的节点置为发布中，由发布定时任务继续发布
+func (m *defaultDeploymentModel) PromotePendingNodes(ctx context.Context, id string) error {
+	_, err := m.model.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "status": DeploymentStatusDeploying},
+		bson.M{"$set": bson.M{
+			"nodeDeployments.$[node].releaseStatus": NodeDeploymentStatusDeploying,
+			"updatedTime":                           time.Now().Unix(),
+		}},
+		options.Update().SetArrayFilters(options.ArrayFilters{
+			Filters: []interface{}{bson.M{"node.releaseStatus": NodeDeploymentStatusPending}},
+		}),
+	)
+	return err
+}
+
+// AddFiringAlert 在发布单的指定机器上记录触发的告警名称，只更新这些机器的 firingAlerts，不覆盖发布单的其它字段
+func (m *defaultDeploymentModel) AddFiringAlert(ctx context.Context, id string, nodeNames []string, alertName string) error {
+	_, err := m.model.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{
+			"$addToSet": bson.M{"nodeDeployments.$[node].firingAlerts": alertName},
+			"$set":      bson.M{"updatedTime": time.Now().Unix()},
+		},
+		options.Update().SetArrayFilters(options.ArrayFilters{
+			Filters: []interface{}{bson.M{"node.name": bson.M{"$in": nodeNames}}},
+		}),
+	)
+	return err
+}
+
+// AddApprovalRecord 向待审批的发布单追加审批记录，返回追加后的发布单。
+// 发布单不在待审批状态或该审批人已审批过时返回 mon.ErrNotFound
+func (m *defaultDeploymentModel) AddApprovalRecord(ctx context.Context, id string, record *ApprovalRecord) (*Deployment, error) {
+	filter := bson.M{
+		"_id":                       id,
+		"status":                    DeploymentStatusAwaitingApproval,
+		"approval.status":           ApprovalStatusPending,
+		"approval.records.approver": bson.M{"$ne": record.Approver},
+	}
+	update := bson.M{
+		"$push": bson.M{"approval.records": record},
+		"$set":  bson.M{"updatedTime": time.Now().Unix()},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var deployment Deployment
+	if err := m.model.FindOneAndUpdate(ctx, &deployment, filter, update, opts); err != nil {
+		return nil, err
+	}
+	return &deployment, nil
+}
+
+// FinishApproval 结束待审批发布单的审批，同时更新审批状态和发布单状态，返回是否更新。
+// 多个审批人同时审批时只有一个结束审批
+func (m *defaultDeploymentModel) FinishApproval(ctx context.Context, id string, approvalStatus ApprovalStatus, status DeploymentStatus) (bool, error) {
+	res, err := m.model.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "status": DeploymentStatusAwaitingApproval, "approval.status": ApprovalStatusPending},
+		bson.M{"$set": bson.M{"approval.status": approvalStatus, "status": status, "updatedTime": time.Now().Unix()}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
 func (m *defaultDeploymentModel) Delete(ctx context.Context, id string) error {
 	_, err := m.model.DeleteOne(ctx, bson.M{"_id": id})
 	return err
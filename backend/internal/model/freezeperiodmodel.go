@@ -0,0 +1,116 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/mon"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type (
+	// FreezePeriod 封版期，封版期内禁止发布
+	FreezePeriod struct {
+		Id          string    `bson:"_id,omitempty" json:"id,omitempty"`
+		AppId       string    `bson:"appId"         json:"app_id"`     // 应用ID，为空表示全局封版
+		Name        string    `bson:"name"          json:"name"`       // 封版名称
+		Reason      string    `bson:"reason"        json:"reason"`     // 封版原因
+		StartTime   time.Time `bson:"startTime"     json:"start_time"` // 开始时间
+		EndTime     time.Time `bson:"endTime"       json:"end_time"`   // 结束时间
+		CreatedBy   string    `bson:"createdBy"     json:"created_by"` // 创建人
+		CreatedTime time.Time `bson:"createdTime"   json:"createdTime"`
+		UpdatedTime time.Time `bson:"updatedTime"   json:"updatedTime"`
+	}
+
+	FreezePeriodModel interface {
+		Insert(ctx context.Context, freeze *FreezePeriod) error
+		Delete(ctx context.Context, id string) error
+		FindById(ctx context.Context, id string) (*FreezePeriod, error)
+		Search(ctx context.Context, cond *FreezePeriodCond) ([]*FreezePeriod, error)
+		Count(ctx context.Context, cond *FreezePeriodCond) (int64, error)
+	}
+
+	defaultFreezePeriodModel struct {
+		model *mon.Model
+	}
+
+	FreezePeriodCond struct {
+		AppId         string
+		IncludeGlobal bool      // 按应用查询时是否同时返回全局封版
+		ActiveAt      time.Time // 只返回在该时间点生效的封版期
+		Pagination    *Pagination
+	}
+)
+
+func NewFreezePeriodModel(url, db string) FreezePeriodModel {
+	return &defaultFreezePeriodModel{
+		model: mon.MustNewModel(url, db, CollectionFreezePeriod),
+	}
+}
+
+func (c *FreezePeriodCond) genCond() bson.M {
+	filter := bson.M{}
+
+	if c.AppId != "" {
+		if c.IncludeGlobal {
+			filter["appId"] = bson.M{"$in": []string{c.AppId, ""}}
+		} else {
+			filter["appId"] = c.AppId
+		}
+	}
+
+	if !c.ActiveAt.IsZero() {
+		filter["startTime"] = bson.M{"$lte": c.ActiveAt}
+		filter["endTime"] = bson.M{"$gt": c.ActiveAt}
+	}
+
+	return filter
+}
+
+func (m *defaultFreezePeriodModel) Insert(ctx context.Context, freeze *FreezePeriod) error {
+	if freeze.Id == "" {
+		freeze.Id = primitive.NewObjectID().Hex()
+	}
+	freeze.CreatedTime = time.Now()
+	freeze.UpdatedTime = time.Now()
+
+	_, err := m.model.InsertOne(ctx, freeze)
+	return err
+}
+
+func (m *defaultFreezePeriodModel) Delete(ctx context.Context, id string) error {
+	_, err := m.model.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (m *defaultFreezePeriodModel) FindById(ctx context.Context, id string) (*FreezePeriod, error) {
+	var freeze FreezePeriod
+	err := m.model.FindOne(ctx, &freeze, bson.M{"_id": id})
+	if err != nil {
+		return nil, err
+	}
+	return &freeze, nil
+}
+
+func (m *defaultFreezePeriodModel) Search(ctx context.Context, cond *FreezePeriodCond) ([]*FreezePeriod, error) {
+	var result []*FreezePeriod
+	filter := cond.genCond()
+
+	var err error
+	if cond.Pagination.IsEmpty() {
+		err = m.model.Find(ctx, &result, filter)
+	} else {
+		err = m.model.Find(ctx, &result, filter, cond.Pagination.ToFindOptions())
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (m *defaultFreezePeriodModel) Count(ctx context.Context, cond *FreezePeriodCond) (int64, error) {
+	count, err := m.model.CountDocuments(ctx, cond.genCond())
+	return count, err
+}
@@ -0,0 +1,236 @@
+package model
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/mon"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxVersionHistory 每台机器每个应用保留的版本历史条数
+const maxVersionHistory = 20
+
+func NewNodeVersionId(machineId, appName string) string {
+	return machineId + ":" + appName
+}
+
+type (
+	// NodeVersion 机器上某个应用的版本清单，是回滚时确定目标版本的依据
+	NodeVersion struct {
+		Id                string    `bson:"_id"               json:"id"`
+		MachineId         string    `bson:"machineId"         json:"machine_id"`         // 机器ID
+		Ip                string    `bson:"ip"                json:"ip"`                 // IP地址
+		AppName           string    `bson:"appName"           json:"app_name"`           // 应用名称
+		CurrentVersion    string    `bson:"currentVersion"    json:"current_version"`    // 当前生效版本
+		PrevVersion       string    `bson:"prevVersion"       json:"prev_version"`       // 上一个生效版本
+		History           []string  `bson:"history"           json:"history"`            // 生效版本历史，最后一个为当前版本
+		InstalledVersions []string  `bson:"installedVersions" json:"installed_versions"` // 机器上保留的版本目录
+		UpdatedTime       time.Time `bson:"updatedTime"       json:"updatedTime"`
+	}
+
+	NodeVersionModel interface {
+		Upsert(ctx context.Context, nodeVersion *NodeVersion) error
+		FindOne(ctx context.Context, machineId, appName string) (*NodeVersion, error)
+		Search(ctx context.Context, cond *NodeVersionCond) ([]*NodeVersion, error)
+	}
+
+	defaultNodeVersionModel struct {
+		model *mon.Model
+	}
+
+	NodeVersionCond struct {
+		MachineId  string
+		MachineIds []string
+		AppName    string
+	}
+)
+
+func NewNodeVersionModel(url, db string) NodeVersionModel {
+	return &defaultNodeVersionModel{
+		model: mon.MustNewModel(url, db, CollectionNodeVersion),
+	}
+}
+
+func (c *NodeVersionCond) genCond() bson.M {
+	filter := bson.M{}
+
+	if c.MachineId != "" {
+		filter["machineId"] = c.MachineId
+	} else if len(c.MachineIds) > 0 {
+		filter["machineId"] = bson.M{"$in": c.MachineIds}
+	}
+
+	if c.AppName != "" {
+		filter["appName"] = c.AppName
+	}
+
+	return filter
+}
+
+// SwitchTo 记录版本切换：发布时追加到历史，回滚时回退到历史中该版本最后一次出现的位置
+func (v *NodeVersion) SwitchTo(version string, rollback bool) {
+	if version == "" {
+		return
+	}
+
+	if rollback {
+		for i := len(v.History) - 1; i >= 0; i-- {
+			if v.History[i] == version {
+				v.History = v.History[:i+1]
+				break
+			}
+		}
+	}
+	if len(v.History) == 0 || v.History[len(v.History)-1] != version {
+		v.History = append(v.History, version)
+	}
+	if len(v.History) > maxVersionHistory {
+		v.History = v.History[len(v.History)-maxVersionHistory:]
+	}
+
+	v.CurrentVersion = version
+	v.PrevVersion = ""
+	if len(v.History) > 1 {
+		v.PrevVersion = v.History[len(v.History)-2]
+	}
+}
+
+// RetainedVersions 按保留数量计算机器上需要保留的版本目录：当前和上一个版本始终保留，
+// 其余按新旧依次保留到 keep 个。生效过的版本按版本历史从新到旧排列，
+// 不在历史中的目录（超出历史条数、开始记录前已安装或回滚时被回退的版本）排在其后，按版本号从大到小排列
+func (v *NodeVersion) RetainedVersions(keep int) []string {
+	retained := []string{}
+	add := func(version string) {
+		if version == "" || containsVersion(retained, version) {
+			return
+		}
+		retained = append(retained, version)
+	}
+
+	add(v.CurrentVersion)
+	add(v.PrevVersion)
+	for i := len(v.History) - 1; i >= 0 && len(retained) < keep; i-- {
+		if containsVersion(v.InstalledVersions, v.History[i]) {
+			add(v.History[i])
+		}
+	}
+
+	untracked := []string{}
+	for _, installed := range v.InstalledVersions {
+		if !containsVersion(v.History, installed) {
+			untracked = append(untracked, installed)
+		}
+	}
+	sort.Slice(untracked, func(i, j int) bool {
+		return compareVersions(untracked[i], untracked[j]) > 0
+	})
+	for _, version := range untracked {
+		if len(retained) >= keep {
+			break
+		}
+		add(version)
+	}
+	return retained
+}
+
+// PrunableVersions 机器上实际存在的版本目录中超出保留数量、可以清理的版本。
+// 不知道当前版本时无法确定保留哪些版本，不清理任何版本
+func (v *NodeVersion) PrunableVersions(keep int) []string {
+	if v.CurrentVersion == "" {
+		return nil
+	}
+
+	retained := v.RetainedVersions(keep)
+	prunable := []string{}
+	for _, installed := range v.InstalledVersions {
+		if !containsVersion(retained, installed) {
+			prunable = append(prunable, installed)
+		}
+	}
+	return prunable
+}
+
+func containsVersion(versions []string, version string) bool {
+	for _, item := range versions {
+		if item == version {
+			return true
+		}
+	}
+	return false
+}
+
+// compareVersions 按数字段的大小比较版本号，如 v1.10.0 大于 v1.9.2，返回 -1、0 或 1
+func compareVersions(a, b string) int {
+	for a != "" && b != "" {
+		var x, y string
+		x, a = splitVersionSegment(a)
+		y, b = splitVersionSegment(b)
+		if x == y {
+			continue
+		}
+		xn, xerr := strconv.Atoi(x)
+		yn, yerr := strconv.Atoi(y)
+		switch {
+		case xerr == nil && yerr == nil && xn != yn:
+			if xn < yn {
+				return -1
+			}
+			return 1
+		case xerr == nil && yerr == nil:
+			continue
+		case x < y:
+			return -1
+		default:
+			return 1
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+// splitVersionSegment 取出版本号开头连续的数字或非数字部分
+func splitVersionSegment(s string) (string, string) {
+	digit := s[0] >= '0' && s[0] <= '9'
+	i := 1
+	for i < len(s) && (s[i] >= '0' && s[i] <= '9') == digit {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+func (m *defaultNodeVersionModel) Upsert(ctx context.Context, nodeVersion *NodeVersion) error {
+	if nodeVersion.Id == "" {
+		nodeVersion.Id = NewNodeVersionId(nodeVersion.MachineId, nodeVersion.AppName)
+	}
+	nodeVersion.UpdatedTime = time.Now()
+
+	_, err := m.model.UpdateOne(
+		ctx,
+		bson.M{"_id": nodeVersion.Id},
+		bson.M{"$set": nodeVersion},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func (m *defaultNodeVersionModel) FindOne(ctx context.Context, machineId, appName string) (*NodeVersion, error) {
+	var nodeVersion NodeVersion
+	err := m.model.FindOne(ctx, &nodeVersion, bson.M{"_id": NewNodeVersionId(machineId, appName)})
+	if err != nil {
+		return nil, err
+	}
+	return &nodeVersion, nil
+}
+
+func (m *defaultNodeVersionModel) Search(ctx context.Context, cond *NodeVersionCond) ([]*NodeVersion, error) {
+	var result []*NodeVersion
+	err := m.model.Find(ctx, &result, cond.genCond())
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,104 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNodeVersion_PrunableVersions(t *testing.T) {
+	tests := []struct {
+		name        string
+		nodeVersion NodeVersion
+		keep        int
+		want        []string
+	}{
+		{
+			name: "保留最近的版本",
+			nodeVersion: NodeVersion{
+				CurrentVersion:    "v4",
+				PrevVersion:       "v3",
+				History:           []string{"v1", "v2", "v3", "v4"},
+				InstalledVersions: []string{"v1", "v2", "v3", "v4"},
+			},
+			keep: 3,
+			want: []string{"v1"},
+		},
+		{
+			name: "保留数小于2时仍保留当前和上一个版本",
+			nodeVersion: NodeVersion{
+				CurrentVersion:    "v4",
+				PrevVersion:       "v3",
+				History:           []string{"v1", "v2", "v3", "v4"},
+				InstalledVersions: []string{"v1", "v2", "v3", "v4"},
+			},
+			keep: 1,
+			want: []string{"v1", "v2"},
+		},
+		{
+			name: "回滚后被回退的版本不在历史中，按版本号参与保留",
+			nodeVersion: NodeVersion{
+				CurrentVersion:    "v2",
+				PrevVersion:       "v1",
+				History:           []string{"v0", "v1", "v2"},
+				InstalledVersions: []string{"v0", "v1", "v2", "v3"},
+			},
+			keep: 2,
+			want: []string{"v0", "v3"},
+		},
+		{
+			name: "首次发布时已存在的版本目录保留最新的",
+			nodeVersion: NodeVersion{
+				CurrentVersion:    "v1.10.0",
+				History:           []string{"v1.10.0"},
+				InstalledVersions: []string{"v1.2.0", "v1.9.0", "v1.10.0", "v1.8.1"},
+			},
+			keep: 2,
+			want: []string{"v1.2.0", "v1.8.1"},
+		},
+		{
+			name: "超出版本历史条数的旧版本可以清理",
+			nodeVersion: NodeVersion{
+				CurrentVersion:    "v4",
+				PrevVersion:       "v3",
+				History:           []string{"v2", "v3", "v4"},
+				InstalledVersions: []string{"v1", "v2", "v3", "v4"},
+			},
+			keep: 3,
+			want: []string{"v1"},
+		},
+		{
+			name: "不知道当前版本时不清理",
+			nodeVersion: NodeVersion{
+				InstalledVersions: []string{"v1", "v2"},
+			},
+			keep: 1,
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.nodeVersion.PrunableVersions(tt.keep); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PrunableVersions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v1.10.0", "v1.9.2", 1},
+		{"v1.2.0", "v1.2.0", 0},
+		{"v1.2", "v1.2.1", -1},
+		{"release-2", "release-10", -1},
+		{"v1.0.0-rc1", "v1.0.0-rc2", -1},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
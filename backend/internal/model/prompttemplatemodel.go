@@ -0,0 +1,118 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/mon"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxPromptTemplateInsertRetries 并发保存同名模板时分配版本号的重试次数
+const maxPromptTemplateInsertRetries = 3
+
+type (
+	// PromptTemplate 诊断提示词模板，每次保存生成一个不可修改的新版本
+	PromptTemplate struct {
+		Id          string    `bson:"_id"         json:"id"`          // 模板版本标识，格式为 <name>@v<version>
+		Name        string    `bson:"name"        json:"name"`        // 模板名称
+		Version     int       `bson:"version"     json:"version"`     // 版本号，从 1 开始递增
+		Content     string    `bson:"content"     json:"content"`     // text/template 模板内容
+		Description string    `bson:"description" json:"description"` // 版本说明
+		CreatedBy   string    `bson:"createdBy"   json:"created_by"`  // 创建人
+		CreatedTime time.Time `bson:"createdTime" json:"createdTime"`
+	}
+
+	PromptTemplateModel interface {
+		Insert(ctx context.Context, template *PromptTemplate) error
+		FindById(ctx context.Context, id string) (*PromptTemplate, error)
+		FindLatest(ctx context.Context, name string) (*PromptTemplate, error)
+		Search(ctx context.Context, cond *PromptTemplateCond) ([]*PromptTemplate, error)
+	}
+
+	defaultPromptTemplateModel struct {
+		model *mon.Model
+	}
+
+	PromptTemplateCond struct {
+		Name string
+	}
+)
+
+func NewPromptTemplateModel(url, db string) PromptTemplateModel {
+	return &defaultPromptTemplateModel{
+		model: mon.MustNewModel(url, db, CollectionPromptTemplate),
+	}
+}
+
+// PromptTemplateId 模板版本标识
+func PromptTemplateId(name string, version int) string {
+	return fmt.Sprintf("%s@v%d", name, version)
+}
+
+func (c *PromptTemplateCond) genCond() bson.M {
+	filter := bson.M{}
+
+	if c.Name != "" {
+		filter["name"] = c.Name
+	}
+
+	return filter
+}
+
+// Insert 以同名模板的最新版本号加一保存新版本，版本标识作为主键，并发保存时重新分配版本号
+func (m *defaultPromptTemplateModel) Insert(ctx context.Context, template *PromptTemplate) error {
+	var err error
+	for i := 0; i < maxPromptTemplateInsertRetries; i++ {
+		template.Version = 1
+		latest, findErr := m.FindLatest(ctx, template.Name)
+		if findErr != nil && findErr != mon.ErrNotFound {
+			return findErr
+		}
+		if latest != nil {
+			template.Version = latest.Version + 1
+		}
+		template.Id = PromptTemplateId(template.Name, template.Version)
+		template.CreatedTime = time.Now()
+
+		_, err = m.model.InsertOne(ctx, template)
+		if !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+	}
+	return err
+}
+
+func (m *defaultPromptTemplateModel) FindById(ctx context.Context, id string) (*PromptTemplate, error) {
+	var template PromptTemplate
+	err := m.model.FindOne(ctx, &template, bson.M{"_id": id})
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// FindLatest 模板的最新版本
+func (m *defaultPromptTemplateModel) FindLatest(ctx context.Context, name string) (*PromptTemplate, error) {
+	var template PromptTemplate
+	opts := options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}})
+	err := m.model.FindOne(ctx, &template, bson.M{"name": name}, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// Search 按名称升序、版本号倒序返回模板
+func (m *defaultPromptTemplateModel) Search(ctx context.Context, cond *PromptTemplateCond) ([]*PromptTemplate, error) {
+	var result []*PromptTemplate
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}, {Key: "version", Value: -1}})
+	err := m.model.Find(ctx, &result, cond.genCond(), opts)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
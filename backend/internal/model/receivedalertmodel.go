@@ -0,0 +1,105 @@
+package model
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/mon"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// NewReceivedAlertId 同一指纹的告警每次重新触发的开始时间不同，按指纹和开始时间区分
+func NewReceivedAlertId(fingerprint string, startsAt time.Time) string {
+	return fingerprint + ":" + strconv.FormatInt(startsAt.Unix(), 10)
+}
+
+type (
+	// ReceivedAlert 告警回调接收到的告警，用于按指纹去重和追溯处理结果
+	ReceivedAlert struct {
+		Id           string              `bson:"_id"          json:"id"`
+		Fingerprint  string              `bson:"fingerprint"  json:"fingerprint"`   // 告警指纹
+		Alertname    string              `bson:"alertname"    json:"alertname"`     // 告警名称
+		Status       ReceivedAlertStatus `bson:"status"       json:"status"`        // 告警状态
+		Labels       map[string]string   `bson:"labels"       json:"labels"`        // 告警标签
+		Annotations  map[string]string   `bson:"annotations"  json:"annotations"`   // 告警注解
+		StartsAt     time.Time           `bson:"startsAt"     json:"starts_at"`     // 告警开始时间
+		EndsAt       time.Time           `bson:"endsAt"       json:"ends_at"`       // 告警结束时间
+		GeneratorURL string              `bson:"generatorUrl" json:"generator_url"` // 告警来源地址
+		DeploymentId string              `bson:"deploymentId" json:"deployment_id"` // 关联的发布单ID，未关联时为空
+		AppName      string              `bson:"appName"      json:"app_name"`      // 关联的应用名称
+		Hosts        []string            `bson:"hosts"        json:"hosts"`         // 关联到的发布机器
+		Action       AlertHandleAction   `bson:"action"       json:"action"`        // 处理结果
+		CreatedTime  time.Time           `bson:"createdTime"  json:"createdTime"`
+		UpdatedTime  time.Time           `bson:"updatedTime"  json:"updatedTime"`
+	}
+
+	ReceivedAlertModel interface {
+		Record(ctx context.Context, alert *ReceivedAlert) (bool, error)
+		FindById(ctx context.Context, id string) (*ReceivedAlert, error)
+	}
+
+	defaultReceivedAlertModel struct {
+		model *mon.Model
+	}
+)
+
+func NewReceivedAlertModel(url, db string) ReceivedAlertModel {
+	return &defaultReceivedAlertModel{
+		model: mon.MustNewModel(url, db, CollectionReceivedAlert),
+	}
+}
+
+// Record 记录告警，同一告警以相同状态重复推送时不写入并返回 false。
+// 只更新状态不同的记录，状态相同时 upsert 因主键冲突失败，并发推送也只有一次返回 true。
+// 之前未关联到发布单的记录在本次关联上时也会更新，使发布单创建前已推送过的告警仍能被处理
+func (m *defaultReceivedAlertModel) Record(ctx context.Context, alert *ReceivedAlert) (bool, error) {
+	now := time.Now()
+	alert.Id = NewReceivedAlertId(alert.Fingerprint, alert.StartsAt)
+	alert.UpdatedTime = now
+
+	filter := bson.M{"_id": alert.Id, "status": bson.M{"$ne": alert.Status}}
+	if alert.Action != AlertHandleActionUnmatched {
+		filter = bson.M{"_id": alert.Id, "$or": []bson.M{
+			{"status": bson.M{"$ne": alert.Status}},
+			{"action": AlertHandleActionUnmatched},
+		}}
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"fingerprint":  alert.Fingerprint,
+			"alertname":    alert.Alertname,
+			"status":       alert.Status,
+			"labels":       alert.Labels,
+			"annotations":  alert.Annotations,
+			"startsAt":     alert.StartsAt,
+			"endsAt":       alert.EndsAt,
+			"generatorUrl": alert.GeneratorURL,
+			"deploymentId": alert.DeploymentId,
+			"appName":      alert.AppName,
+			"hosts":        alert.Hosts,
+			"action":       alert.Action,
+			"updatedTime":  now,
+		},
+		"$setOnInsert": bson.M{"createdTime": now},
+	}
+	_, err := m.model.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (m *defaultReceivedAlertModel) FindById(ctx context.Context, id string) (*ReceivedAlert, error) {
+	var alert ReceivedAlert
+	err := m.model.FindOne(ctx, &alert, bson.M{"_id": id})
+	if err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
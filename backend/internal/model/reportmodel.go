@@ -4,9 +4,11 @@ import (
 	"context"
 	"time"
 
+	"github.com/zeromicro/go-zero/core/logx"
 	"github.com/zeromicro/go-zero/core/stores/mon"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
@@ -17,12 +19,149 @@ func NewReportId() string {
 type (
 	// Report 存储 AI 生成的诊断报告
 	Report struct {
-		Id           string       `bson:"_id,omitempty" json:"id,omitempty"`
-		DeploymentId string       `bson:"deploymentId"  json:"deploymentId"` // 关联的部署ID
-		Content      string       `bson:"content"       json:"content"`      // AI 生成的报告
-		Status       ReportStatus `bson:"status"        json:"status"`       // 报告生成状态
-		CreatedTime  time.Time    `bson:"createdTime"   json:"createdTime"`
-		UpdatedTime  time.Time    `bson:"updatedTime"   json:"updatedTime"`
+		Id            string             `bson:"_id,omitempty" json:"id,omitempty"`
+		DeploymentId  string             `bson:"deploymentId"  json:"deploymentId"`  // 关联的部署ID
+		AppId         string             `bson:"appId"         json:"appId"`         // 发布单所属应用，用于按应用统计用量
+		AppName       string             `bson:"appName"       json:"appName"`       // 应用名称
+		Content       string             `bson:"content"       json:"content"`       // AI 生成的报告原文
+		Result        *DiagnosisResult   `bson:"result"        json:"result"`        // 校验通过的结构化报告，旧版报告为空
+		Status        ReportStatus       `bson:"status"        json:"status"`        // 报告生成状态
+		Alert         *DiagnosisAlert    `bson:"alert"         json:"alert"`         // 触发诊断的告警
+		Contexts      []DiagnosisContext `bson:"contexts"      json:"contexts"`      // 生成报告前收集的额外上下文，为空表示尚未收集
+		Model         string             `bson:"model"         json:"model"`         // 生成报告使用的模型，为空时使用配置的默认模型
+		PromptVersion string             `bson:"promptVersion" json:"promptVersion"` // 生成报告使用的提示词版本
+		Feedback      *ReportFeedback    `bson:"feedback"      json:"feedback"`      // 运维人员对报告准确性的评价，未评价时为空
+		Usage         *ReportUsage       `bson:"usage"         json:"usage"`         // 模型用量和成本，累计所有尝试，未调用模型时为空
+		Similar       []SimilarIncident  `bson:"similar"       json:"similar"`       // 诊断前检索到的历史相似故障，与补充上下文一起在首次执行时保存
+		Attempts      int                `bson:"attempts"      json:"attempts"`      // 已尝试生成的次数
+		NextRunAt     time.Time          `bson:"nextRunAt"     json:"nextRunAt"`     // 排队中的任务最早执行时间
+		LeaseUntil    time.Time          `bson:"leaseUntil"    json:"leaseUntil"`    // 生成中的任务租约到期时间，到期未完成视为中断，可被重新领取
+		LastError     string             `bson:"lastError"     json:"lastError"`     // 最近一次生成失败的原因
+		ActiveKey     string             `bson:"activeKey,omitempty" json:"-"`       // 排队中或生成中时为发布单ID，其它状态时为空，唯一索引保证同一发布单只有一个进行中的任务
+		CreatedTime   time.Time          `bson:"createdTime"   json:"createdTime"`
+		UpdatedTime   time.Time          `bson:"updatedTime"   json:"updatedTime"`
+	}
+
+	// DiagnosisResult 结构化诊断报告，json 标签即要求模型输出的字段名
+	DiagnosisResult struct {
+		Summary     string              `bson:"summary"     json:"summary"`     // 问题概述
+		RootCause   string              `bson:"rootCause"   json:"rootCause"`   // 根因分析
+		Impact      string              `bson:"impact"      json:"impact"`      // 影响范围
+		Remediation []string            `bson:"remediation" json:"remediation"` // 解决步骤，按执行顺序
+		Suspects    []SuspectChange     `bson:"suspects"    json:"suspects"`    // 可疑的代码变更
+		Evidence    []DiagnosisEvidence `bson:"evidence"    json:"evidence"`    // 支撑结论的指标
+		Confidence  float64             `bson:"confidence"  json:"confidence"`  // 结论置信度，0~1
+	}
+
+	// SuspectChange 可能导致问题的 PR 或文件
+	SuspectChange struct {
+		PR          int    `bson:"pr"          json:"pr"`          // PR 编号，未关联 PR 时为 0
+		File        string `bson:"file"        json:"file"`        // 文件路径
+		Description string `bson:"description" json:"description"` // 问题描述
+		Suggestion  string `bson:"suggestion"  json:"suggestion"`  // 修复建议
+	}
+
+	// DiagnosisEvidence 支撑诊断结论的指标查询
+	DiagnosisEvidence struct {
+		PromQL      string            `bson:"promQL"      json:"promQL"`      // 查询语句
+		Observation string            `bson:"observation" json:"observation"` // 模型查询时观察到的现象，如峰值、变化时间点
+		Snapshot    *EvidenceSnapshot `bson:"snapshot"    json:"-"`           // 报告生成后按告警时间窗口保存的指标数据，不由模型输出
+	}
+
+	// EvidenceSnapshot 指标快照，避免查看报告时数据已过期
+	EvidenceSnapshot struct {
+		Start  time.Time        `bson:"start"  json:"start"`  // 查询开始时间
+		End    time.Time        `bson:"end"    json:"end"`    // 查询结束时间
+		Step   int              `bson:"step"   json:"step"`   // 采样间隔（秒）
+		Series []EvidenceSeries `bson:"series" json:"series"` // 时序数据
+		Error  string           `bson:"error"  json:"error"`  // 查询失败的原因
+	}
+
+	EvidenceSeries struct {
+		Instance string            `bson:"instance" json:"instance"` // 实例标识（机器名称）
+		Labels   map[string]string `bson:"labels"   json:"labels"`   // 原始标签
+		Points   []EvidencePoint   `bson:"points"   json:"points"`   // 数据点
+	}
+
+	EvidencePoint struct {
+		Timestamp int64   `bson:"timestamp" json:"timestamp"` // 时间戳（秒）
+		Value     float64 `bson:"value"     json:"value"`     // 数值
+	}
+
+	// DiagnosisContext 一类上下文的收集结果
+	DiagnosisContext struct {
+		Type    DiagnosisContextType `bson:"type"    json:"type"`    // 上下文类型
+		Content string               `bson:"content" json:"content"` // 收集到的内容
+		Error   string               `bson:"error"   json:"error"`   // 收集失败的原因
+	}
+
+	// ReportFeedback 报告准确性评价，重复评价时覆盖
+	ReportFeedback struct {
+		Accurate    bool      `bson:"accurate"    json:"accurate"`    // 报告结论是否准确
+		Notes       string    `bson:"notes"       json:"notes"`       // 评价说明
+		Operator    string    `bson:"operator"    json:"operator"`    // 评价人
+		CreatedTime time.Time `bson:"createdTime" json:"createdTime"` // 评价时间
+	}
+
+	// ReportUsage 生成报告消耗的 token、工具调用次数和按价格表计算的成本
+	ReportUsage struct {
+		PromptTokens     int     `bson:"promptTokens"     json:"promptTokens"`     // 输入 token 数
+		CompletionTokens int     `bson:"completionTokens" json:"completionTokens"` // 输出 token 数
+		ToolCalls        int     `bson:"toolCalls"        json:"toolCalls"`        // 工具调用次数
+		Cost             float64 `bson:"cost"             json:"cost"`             // 成本，单位与价格表一致，模型不在价格表中时为 0
+	}
+
+	// SimilarIncident 与告警相似的历史故障及其处理方式
+	SimilarIncident struct {
+		ReportId     string    `bson:"reportId"     json:"reportId"`     // 历史报告ID
+		DeploymentId string    `bson:"deploymentId" json:"deploymentId"` // 历史报告关联的部署ID
+		AppName      string    `bson:"appName"      json:"appName"`      // 应用名称
+		Alertname    string    `bson:"alertname"    json:"alertname"`    // 告警名称
+		Summary      string    `bson:"summary"      json:"summary"`      // 问题概述
+		RootCause    string    `bson:"rootCause"    json:"rootCause"`    // 根因分析
+		Remediation  []string  `bson:"remediation"  json:"remediation"`  // 解决步骤
+		Verified     bool      `bson:"verified"     json:"verified"`     // 报告是否被评价为准确
+		Score        float64   `bson:"score"        json:"score"`        // 相似度得分，仅用于排序
+		CreatedTime  time.Time `bson:"createdTime"  json:"createdTime"`  // 历史报告创建时间
+	}
+
+	// ReportUsageStat 按应用和模型汇总的用量
+	ReportUsageStat struct {
+		AppId            string  `bson:"appId"            json:"appId"`            // 应用ID
+		AppName          string  `bson:"appName"          json:"appName"`          // 应用名称
+		Model            string  `bson:"model"            json:"model"`            // 模型名称
+		Reports          int64   `bson:"reports"          json:"reports"`          // 有用量记录的报告数
+		PromptTokens     int64   `bson:"promptTokens"     json:"promptTokens"`     // 输入 token 数
+		CompletionTokens int64   `bson:"completionTokens" json:"completionTokens"` // 输出 token 数
+		ToolCalls        int64   `bson:"toolCalls"        json:"toolCalls"`        // 工具调用次数
+		Cost             float64 `bson:"cost"             json:"cost"`             // 成本
+	}
+
+	// ReportFeedbackStat 一个提示词版本的报告评价统计
+	ReportFeedbackStat struct {
+		PromptVersion string `bson:"_id"        json:"promptVersion"` // 提示词版本，旧版报告为空
+		Total         int64  `bson:"total"      json:"total"`         // 生成完成的报告数
+		Rated         int64  `bson:"-"          json:"rated"`         // 已评价的报告数
+		Accurate      int64  `bson:"accurate"   json:"accurate"`      // 评价为准确的报告数
+		Inaccurate    int64  `bson:"inaccurate" json:"inaccurate"`    // 评价为不准确的报告数
+	}
+
+	// DiagnosisAlert 诊断任务的告警信息，任务排队期间持久化，服务重启后仍可执行
+	DiagnosisAlert struct {
+		Key          string            `bson:"key"          json:"key"`
+		Status       string            `bson:"status"       json:"status"`
+		Desc         string            `bson:"desc"         json:"desc"`
+		StartsAt     string            `bson:"startsAt"     json:"startsAt"`
+		ReceiveAt    string            `bson:"receiveAt"    json:"receiveAt"`
+		EndsAt       string            `bson:"endsAt"       json:"endsAt"`
+		Severity     string            `bson:"severity"     json:"severity"`
+		Alertname    string            `bson:"alertname"    json:"alertname"`
+		GeneratorURL string            `bson:"generatorUrl" json:"generatorUrl"`
+		RepoAddress  string            `bson:"repoAddress"  json:"repoAddress"` // github仓库地址
+		Tag          string            `bson:"tag"          json:"tag"`         // 发布的tag版本
+		Labels       map[string]string `bson:"labels"       json:"labels"`
+		Annotations  map[string]string `bson:"annotations"  json:"annotations"`
+		Values       float64           `bson:"values"       json:"values"`
 	}
 
 	ReportModel interface {
@@ -30,7 +169,12 @@ type (
 		FindById(ctx context.Context, id string) (*Report, error)
 		FindByDeploymentId(ctx context.Context, deploymentId string) ([]*Report, error)
 		Update(ctx context.Context, report *Report) error
+		Enqueue(ctx context.Context, report *Report) (bool, error)
+		Claim(ctx context.Context, now time.Time, lease time.Duration) (*Report, error)
 		DeleteByDeploymentId(ctx context.Context, deploymentId string) error
+		FeedbackStats(ctx context.Context) ([]*ReportFeedbackStat, error)
+		UsageStats(ctx context.Context, since time.Time) ([]*ReportUsageStat, error)
+		FindIncidents(ctx context.Context, limit int64) ([]*Report, error)
 	}
 
 	defaultReportModel struct {
@@ -39,17 +183,43 @@ type (
 )
 
 func NewReportModel(url, db string) ReportModel {
+	m := mon.MustNewModel(url, db, CollectionReport)
+	ensureReportActiveKeyIndex(m)
 	return &defaultReportModel{
-		model: mon.MustNewModel(url, db, CollectionReport),
+		model: m,
+	}
+}
+
+// ensureReportActiveKeyIndex 创建 activeKey 上的唯一稀疏索引，索引已存在时不做任何事。
+// 创建失败不影响服务启动，只记录日志
+func ensureReportActiveKeyIndex(m *mon.Model) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := m.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "activeKey", Value: 1}},
+		Options: options.Index().SetUnique(true).SetSparse(true),
+	})
+	if err != nil {
+		logx.Errorf("[ReportModel] create activeKey index error:%v", err)
 	}
 }
 
+// reportActiveKey 排队中或生成中的报告返回发布单ID，其它状态返回空
+func reportActiveKey(report *Report) string {
+	if report.Status == ReportStatusQueued || report.Status == ReportStatusGenerating {
+		return report.DeploymentId
+	}
+	return ""
+}
+
 func (m *defaultReportModel) Insert(ctx context.Context, report *Report) error {
 	if report.Id == "" {
 		report.Id = NewReportId()
 	}
 	report.CreatedTime = time.Now()
 	report.UpdatedTime = time.Now()
+	report.ActiveKey = reportActiveKey(report)
 
 	_, err := m.model.InsertOne(ctx, report)
 	return err
@@ -66,30 +236,173 @@ func (m *defaultReportModel) FindById(ctx context.Context, id string) (*Report,
 
 func (m *defaultReportModel) FindByDeploymentId(ctx context.Context, deploymentId string) ([]*Report, error) {
 	var reports []*Report
-	
+
 	// 使用数据库排序，按创建时间倒序（最新的在前）
 	opts := options.Find().SetSort(bson.D{{Key: "createdTime", Value: -1}})
-	
+
 	err := m.model.Find(ctx, &reports, bson.M{"deploymentId": deploymentId}, opts)
 	if err != nil {
 		return nil, err
 	}
-	
+
 	return reports, nil
 }
 
 func (m *defaultReportModel) Update(ctx context.Context, report *Report) error {
 	report.UpdatedTime = time.Now()
+	report.ActiveKey = reportActiveKey(report)
 
-	_, err := m.model.UpdateOne(
-		ctx,
-		bson.M{"_id": report.Id},
-		bson.M{"$set": report},
-	)
+	update := bson.M{"$set": report}
+	if report.ActiveKey == "" {
+		update["$unset"] = bson.M{"activeKey": ""}
+	}
+	_, err := m.model.UpdateOne(ctx, bson.M{"_id": report.Id}, update)
 	return err
 }
 
+// Enqueue 插入排队中的诊断任务。同一发布单已有排队中或生成中的任务时不再插入，
+// 将 report.Id 设为已有任务的ID并返回 false。activeKey 的唯一索引保证并发入队也只插入一个任务
+func (m *defaultReportModel) Enqueue(ctx context.Context, report *Report) (bool, error) {
+	now := time.Now()
+	if report.Id == "" {
+		report.Id = NewReportId()
+	}
+	report.Status = ReportStatusQueued
+	report.CreatedTime = now
+	report.UpdatedTime = now
+	if report.NextRunAt.IsZero() {
+		report.NextRunAt = now
+	}
+	report.ActiveKey = report.DeploymentId
+
+	filter := bson.M{
+		"deploymentId": report.DeploymentId,
+		"status":       bson.M{"$in": []ReportStatus{ReportStatusQueued, ReportStatusGenerating}},
+	}
+	result, err := m.model.UpdateOne(ctx, filter, bson.M{"$setOnInsert": report}, options.Update().SetUpsert(true))
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return false, err
+	}
+	if err == nil && result.UpsertedCount > 0 {
+		return true, nil
+	}
+
+	var existing Report
+	if err := m.model.FindOne(ctx, &existing, filter); err != nil {
+		return false, err
+	}
+	report.Id = existing.Id
+	return false, nil
+}
+
+// Claim 领取一个到期的排队任务或租约已过期的生成中任务，置为生成中并累加尝试次数，没有可领取的任务时返回 nil
+func (m *defaultReportModel) Claim(ctx context.Context, now time.Time, lease time.Duration) (*Report, error) {
+	filter := bson.M{"$or": []bson.M{
+		{"status": ReportStatusQueued, "nextRunAt": bson.M{"$lte": now}},
+		{"status": ReportStatusGenerating, "leaseUntil": bson.M{"$lt": now}},
+	}}
+	update := bson.M{
+		"$set": bson.M{
+			"status":      ReportStatusGenerating,
+			"leaseUntil":  now.Add(lease),
+			"updatedTime": now,
+		},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "nextRunAt", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var report Report
+	err := m.model.FindOneAndUpdate(ctx, &report, filter, update, opts)
+	if err == mon.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// DeleteByDeploymentId 删除发布单的所有报告
 func (m *defaultReportModel) DeleteByDeploymentId(ctx context.Context, deploymentId string) error {
-	_, err := m.model.DeleteOne(ctx, bson.M{"deploymentId": deploymentId})
+	_, err := m.model.DeleteMany(ctx, bson.M{"deploymentId": deploymentId})
 	return err
 }
+
+// FeedbackStats 按提示词版本统计生成完成的报告数和评价结果
+func (m *defaultReportModel) FeedbackStats(ctx context.Context) ([]*ReportFeedbackStat, error) {
+	pipeline := []bson.M{
+		{"$match": bson.M{"status": ReportStatusCompleted}},
+		{"$group": bson.M{
+			"_id":   bson.M{"$ifNull": []interface{}{"$promptVersion", ""}},
+			"total": bson.M{"$sum": 1},
+			"accurate": bson.M{"$sum": bson.M{"$cond": []interface{}{
+				bson.M{"$eq": []interface{}{"$feedback.accurate", true}}, 1, 0}}},
+			"inaccurate": bson.M{"$sum": bson.M{"$cond": []interface{}{
+				bson.M{"$eq": []interface{}{"$feedback.accurate", false}}, 1, 0}}},
+		}},
+		{"$sort": bson.M{"_id": 1}},
+	}
+
+	stats := make([]*ReportFeedbackStat, 0)
+	if err := m.model.Aggregate(ctx, &stats, pipeline); err != nil {
+		return nil, err
+	}
+	for _, stat := range stats {
+		stat.Rated = stat.Accurate + stat.Inaccurate
+	}
+	return stats, nil
+}
+
+// UsageStats 按应用和模型汇总 since 之后创建的报告的用量
+func (m *defaultReportModel) UsageStats(ctx context.Context, since time.Time) ([]*ReportUsageStat, error) {
+	pipeline := []bson.M{
+		{"$match": bson.M{"createdTime": bson.M{"$gte": since}, "usage": bson.M{"$ne": nil}}},
+		{"$group": bson.M{
+			"_id":              bson.M{"appId": "$appId", "appName": "$appName", "model": "$model"},
+			"reports":          bson.M{"$sum": 1},
+			"promptTokens":     bson.M{"$sum": "$usage.promptTokens"},
+			"completionTokens": bson.M{"$sum": "$usage.completionTokens"},
+			"toolCalls":        bson.M{"$sum": "$usage.toolCalls"},
+			"cost":             bson.M{"$sum": "$usage.cost"},
+		}},
+		{"$project": bson.M{
+			"_id":              0,
+			"appId":            "$_id.appId",
+			"appName":          "$_id.appName",
+			"model":            "$_id.model",
+			"reports":          1,
+			"promptTokens":     1,
+			"completionTokens": 1,
+			"toolCalls":        1,
+			"cost":             1,
+		}},
+		{"$sort": bson.D{{Key: "appName", Value: 1}, {Key: "model", Value: 1}}},
+	}
+
+	stats := make([]*ReportUsageStat, 0)
+	if err := m.model.Aggregate(ctx, &stats, pipeline); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// FindIncidents 最近生成完成且有结构化结果的报告，用于检索相似故障，排除评价为不准确的报告
+func (m *defaultReportModel) FindIncidents(ctx context.Context, limit int64) ([]*Report, error) {
+	filter := bson.M{
+		"status":            ReportStatusCompleted,
+		"result":            bson.M{"$ne": nil},
+		"feedback.accurate": bson.M{"$ne": false},
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdTime", Value: -1}}).
+		SetLimit(limit).
+		SetProjection(bson.M{"contexts": 0, "content": 0, "result.evidence": 0, "similar": 0})
+
+	reports := make([]*Report, 0)
+	if err := m.model.Find(ctx, &reports, filter, opts); err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
@@ -0,0 +1,175 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/mon"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type (
+	// WebhookDelivery 一个事件对一个 webhook 订阅的投递记录，投递任务持久化在记录中，失败后按退避重试，
+	// 超过最大尝试次数后进入死信列表，可手动重新投递
+	WebhookDelivery struct {
+		Id             string                `bson:"_id,omitempty"  json:"id,omitempty"`
+		WebhookId      string                `bson:"webhookId"      json:"webhook_id"`      // 订阅ID
+		EventId        string                `bson:"eventId"        json:"event_id"`        // 事件ID，同一事件投递到多个订阅时相同，接收方可据此去重
+		EventType      NotifyEventType       `bson:"eventType"      json:"event_type"`      // 事件类型
+		AppId          string                `bson:"appId"          json:"app_id"`          // 事件所属应用
+		DeploymentId   string                `bson:"deploymentId"   json:"deployment_id"`   // 事件关联的发布单
+		Payload        string                `bson:"payload"        json:"payload"`         // 请求体，重试时原样发送
+		Status         WebhookDeliveryStatus `bson:"status"         json:"status"`          // 投递状态
+		Attempts       int                   `bson:"attempts"       json:"attempts"`        // 已尝试投递的次数
+		NextRunAt      time.Time             `bson:"nextRunAt"      json:"nextRunAt"`       // 待投递的任务最早执行时间
+		LeaseUntil     time.Time             `bson:"leaseUntil"     json:"leaseUntil"`      // 投递中的任务租约到期时间，到期未完成视为中断，可被重新领取
+		ResponseStatus int                   `bson:"responseStatus" json:"response_status"` // 最近一次投递的 HTTP 状态码，请求未发出时为 0
+		ResponseBody   string                `bson:"responseBody"   json:"response_body"`   // 最近一次投递的响应体，截断保存
+		LastError      string                `bson:"lastError"      json:"last_error"`      // 最近一次投递失败的原因
+		Duration       int64                 `bson:"duration"       json:"duration"`        // 最近一次投递耗时（毫秒）
+		DeliveredTime  time.Time             `bson:"deliveredTime"  json:"deliveredTime"`   // 投递成功的时间
+		CreatedTime    time.Time             `bson:"createdTime"    json:"createdTime"`
+		UpdatedTime    time.Time             `bson:"updatedTime"    json:"updatedTime"`
+	}
+
+	WebhookDeliveryModel interface {
+		Insert(ctx context.Context, delivery *WebhookDelivery) error
+		Update(ctx context.Context, delivery *WebhookDelivery) error
+		FindById(ctx context.Context, id string) (*WebhookDelivery, error)
+		Claim(ctx context.Context, now time.Time, lease time.Duration) (*WebhookDelivery, error)
+		Search(ctx context.Context, cond *WebhookDeliveryCond) ([]*WebhookDelivery, error)
+		Count(ctx context.Context, cond *WebhookDeliveryCond) (int64, error)
+		DeleteByWebhookId(ctx context.Context, webhookId string) error
+	}
+
+	defaultWebhookDeliveryModel struct {
+		model *mon.Model
+	}
+
+	WebhookDeliveryCond struct {
+		WebhookId  string
+		Status     WebhookDeliveryStatus
+		EventType  NotifyEventType
+		Pagination *Pagination
+	}
+)
+
+func NewWebhookDeliveryModel(url, db string) WebhookDeliveryModel {
+	return &defaultWebhookDeliveryModel{
+		model: mon.MustNewModel(url, db, CollectionWebhookDelivery),
+	}
+}
+
+func (c *WebhookDeliveryCond) genCond() bson.M {
+	filter := bson.M{}
+
+	if c.WebhookId != "" {
+		filter["webhookId"] = c.WebhookId
+	}
+
+	if c.Status != "" {
+		filter["status"] = c.Status
+	}
+
+	if c.EventType != "" {
+		filter["eventType"] = c.EventType
+	}
+
+	return filter
+}
+
+// Insert 插入待投递的记录
+func (m *defaultWebhookDeliveryModel) Insert(ctx context.Context, delivery *WebhookDelivery) error {
+	now := time.Now()
+	if delivery.Id == "" {
+		delivery.Id = primitive.NewObjectID().Hex()
+	}
+	delivery.Status = WebhookDeliveryStatusPending
+	delivery.CreatedTime = now
+	delivery.UpdatedTime = now
+	if delivery.NextRunAt.IsZero() {
+		delivery.NextRunAt = now
+	}
+
+	_, err := m.model.InsertOne(ctx, delivery)
+	return err
+}
+
+func (m *defaultWebhookDeliveryModel) Update(ctx context.Context, delivery *WebhookDelivery) error {
+	delivery.UpdatedTime = time.Now()
+
+	_, err := m.model.UpdateOne(
+		ctx,
+		bson.M{"_id": delivery.Id},
+		bson.M{"$set": delivery},
+	)
+	return err
+}
+
+func (m *defaultWebhookDeliveryModel) FindById(ctx context.Context, id string) (*WebhookDelivery, error) {
+	var delivery WebhookDelivery
+	err := m.model.FindOne(ctx, &delivery, bson.M{"_id": id})
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// Claim 领取一个到期的待投递任务或租约已过期的投递中任务，置为投递中并累加尝试次数，没有可领取的任务时返回 nil
+func (m *defaultWebhookDeliveryModel) Claim(ctx context.Context, now time.Time, lease time.Duration) (*WebhookDelivery, error) {
+	filter := bson.M{"$or": []bson.M{
+		{"status": WebhookDeliveryStatusPending, "nextRunAt": bson.M{"$lte": now}},
+		{"status": WebhookDeliveryStatusDelivering, "leaseUntil": bson.M{"$lt": now}},
+	}}
+	update := bson.M{
+		"$set": bson.M{
+			"status":      WebhookDeliveryStatusDelivering,
+			"leaseUntil":  now.Add(lease),
+			"updatedTime": now,
+		},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "nextRunAt", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var delivery WebhookDelivery
+	err := m.model.FindOneAndUpdate(ctx, &delivery, filter, update, opts)
+	if err == mon.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (m *defaultWebhookDeliveryModel) Search(ctx context.Context, cond *WebhookDeliveryCond) ([]*WebhookDelivery, error) {
+	var result []*WebhookDelivery
+	filter := cond.genCond()
+
+	var err error
+	if cond.Pagination.IsEmpty() {
+		err = m.model.Find(ctx, &result, filter)
+	} else {
+		err = m.model.Find(ctx, &result, filter, cond.Pagination.ToFindOptions())
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (m *defaultWebhookDeliveryModel) Count(ctx context.Context, cond *WebhookDeliveryCond) (int64, error) {
+	count, err := m.model.CountDocuments(ctx, cond.genCond())
+	return count, err
+}
+
+// DeleteByWebhookId 删除订阅的所有投递记录
+func (m *defaultWebhookDeliveryModel) DeleteByWebhookId(ctx context.Context, webhookId string) error {
+	_, err := m.model.DeleteMany(ctx, bson.M{"webhookId": webhookId})
+	return err
+}
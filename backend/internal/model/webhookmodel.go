@@ -0,0 +1,122 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/mon"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type (
+	// Webhook 外部系统订阅的 webhook，发布事件以 HMAC 签名的 JSON 请求投递到订阅地址
+	Webhook struct {
+		Id          string            `bson:"_id,omitempty" json:"id,omitempty"`
+		Name        string            `bson:"name"          json:"name"`       // 订阅名称
+		URL         string            `bson:"url"           json:"url"`        // 接收事件的地址
+		Secret      string            `bson:"secret"        json:"secret"`     // HMAC-SHA256 签名密钥
+		Events      []NotifyEventType `bson:"events"        json:"events"`     // 订阅的事件类型，为空时订阅所有事件
+		AppIds      []string          `bson:"appIds"        json:"app_ids"`    // 只接收这些应用的事件，为空时接收所有应用
+		Enabled     bool              `bson:"enabled"       json:"enabled"`    // 是否启用，停用后不再产生新的投递
+		CreatedBy   string            `bson:"createdBy"     json:"created_by"` // 创建人
+		CreatedTime time.Time         `bson:"createdTime"   json:"createdTime"`
+		UpdatedTime time.Time         `bson:"updatedTime"   json:"updatedTime"`
+	}
+
+	WebhookModel interface {
+		Insert(ctx context.Context, webhook *Webhook) error
+		Update(ctx context.Context, webhook *Webhook) error
+		Delete(ctx context.Context, id string) error
+		FindById(ctx context.Context, id string) (*Webhook, error)
+		Search(ctx context.Context, cond *WebhookCond) ([]*Webhook, error)
+	}
+
+	defaultWebhookModel struct {
+		model *mon.Model
+	}
+
+	WebhookCond struct {
+		Enabled   *bool           // 按启用状态过滤
+		EventType NotifyEventType // 只返回订阅了该事件的 webhook
+		AppId     string          // 只返回接收该应用事件的 webhook
+	}
+)
+
+func NewWebhookModel(url, db string) WebhookModel {
+	return &defaultWebhookModel{
+		model: mon.MustNewModel(url, db, CollectionWebhook),
+	}
+}
+
+func (c *WebhookCond) genCond() bson.M {
+	filter := bson.M{}
+
+	if c.Enabled != nil {
+		filter["enabled"] = *c.Enabled
+	}
+
+	and := make([]bson.M, 0, 2)
+	if c.EventType != "" {
+		and = append(and, bson.M{"$or": []bson.M{
+			{"events": c.EventType},
+			{"events": bson.M{"$in": []interface{}{nil, []NotifyEventType{}}}},
+		}})
+	}
+	if c.AppId != "" {
+		and = append(and, bson.M{"$or": []bson.M{
+			{"appIds": c.AppId},
+			{"appIds": bson.M{"$in": []interface{}{nil, []string{}}}},
+		}})
+	}
+	if len(and) > 0 {
+		filter["$and"] = and
+	}
+
+	return filter
+}
+
+func (m *defaultWebhookModel) Insert(ctx context.Context, webhook *Webhook) error {
+	if webhook.Id == "" {
+		webhook.Id = primitive.NewObjectID().Hex()
+	}
+	webhook.CreatedTime = time.Now()
+	webhook.UpdatedTime = time.Now()
+
+	_, err := m.model.InsertOne(ctx, webhook)
+	return err
+}
+
+func (m *defaultWebhookModel) Update(ctx context.Context, webhook *Webhook) error {
+	webhook.UpdatedTime = time.Now()
+
+	_, err := m.model.UpdateOne(
+		ctx,
+		bson.M{"_id": webhook.Id},
+		bson.M{"$set": webhook},
+	)
+	return err
+}
+
+func (m *defaultWebhookModel) Delete(ctx context.Context, id string) error {
+	_, err := m.model.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (m *defaultWebhookModel) FindById(ctx context.Context, id string) (*Webhook, error) {
+	var webhook Webhook
+	err := m.model.FindOne(ctx, &webhook, bson.M{"_id": id})
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (m *defaultWebhookModel) Search(ctx context.Context, cond *WebhookCond) ([]*Webhook, error) {
+	var result []*Webhook
+	err := m.model.Find(ctx, &result, cond.genCond())
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -6,19 +6,35 @@ import (
 	"time"
 
 	"github.com/Z3Labs/Hackathon/backend/common/qiniu"
+	"github.com/Z3Labs/Hackathon/backend/internal/clients/notify"
+	"github.com/Z3Labs/Hackathon/backend/internal/clients/prom"
+	"github.com/Z3Labs/Hackathon/backend/internal/clients/webhook"
 	"github.com/Z3Labs/Hackathon/backend/internal/config"
+	"github.com/Z3Labs/Hackathon/backend/internal/logic/deployments/executor"
 	"github.com/Z3Labs/Hackathon/backend/internal/model"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type ServiceContext struct {
-	Config           config.Config
-	ApplicationModel model.ApplicationModel
-	DeploymentModel  model.DeploymentModel
-	MachineModel     model.MachineModel
-	ReportModel      model.ReportModel
-	QiniuClient      *qiniu.Client
+	Config               config.Config
+	ApplicationModel     model.ApplicationModel
+	DeploymentModel      model.DeploymentModel
+	MachineModel         model.MachineModel
+	ReportModel          model.ReportModel
+	FreezePeriodModel    model.FreezePeriodModel
+	NodeVersionModel     model.NodeVersionModel
+	AlertEvaluationModel model.AlertEvaluationModel
+	AlertStateModel      model.AlertStateModel
+	ReceivedAlertModel   model.ReceivedAlertModel
+	PromptTemplateModel  model.PromptTemplateModel
+	WebhookModel         model.WebhookModel
+	WebhookDeliveryModel model.WebhookDeliveryModel
+	QiniuClient          *qiniu.Client
+	PromClient           prom.VMClient                     // 未配置 Prometheus 地址时为 nil
+	Notifier             *notify.Notifier                  // 发布事件通知，为 nil 时不发送
+	Webhooks             *webhook.Dispatcher               // webhook 订阅投递，订阅了 Notifier 的所有事件，为 nil 时不投递
+	ExecutorFactory      executor.ExecutorFactoryInterface // 创建机器上的发布执行器，测试时可替换
 }
 
 func NewServiceContext(c config.Config) *ServiceContext {
@@ -27,15 +43,40 @@ func NewServiceContext(c config.Config) *ServiceContext {
 		qiniuClient = qiniu.NewClient(c.Qiniu.AccessKey, c.Qiniu.SecretKey, c.Qiniu.Bucket, c.Qiniu.DownloadHost)
 	}
 
+	applicationModel := model.NewApplicationModel(c.Mongo.URL, c.Mongo.Database)
+	webhookModel := model.NewWebhookModel(c.Mongo.URL, c.Mongo.Database)
+	webhookDeliveryModel := model.NewWebhookDeliveryModel(c.Mongo.URL, c.Mongo.Database)
+	notifier := notify.MustNewNotifier(c.Notify, applicationModel)
+	webhooks := webhook.NewDispatcher(c.Webhook, webhookModel, webhookDeliveryModel)
+	notifier.Subscribe(webhooks)
 	return &ServiceContext{
-		Config:           c,
-		ApplicationModel: model.NewApplicationModel(c.Mongo.URL, c.Mongo.Database),
-		DeploymentModel:  model.NewDeploymentModel(c.Mongo.URL, c.Mongo.Database),
-		MachineModel:     model.NewMachineModel(c.Mongo.URL, c.Mongo.Database),
-		ReportModel:      model.NewReportModel(c.Mongo.URL, c.Mongo.Database),
-		QiniuClient:      qiniuClient,
+		Config:               c,
+		ApplicationModel:     applicationModel,
+		DeploymentModel:      model.NewDeploymentModel(c.Mongo.URL, c.Mongo.Database),
+		MachineModel:         model.NewMachineModel(c.Mongo.URL, c.Mongo.Database),
+		ReportModel:          model.NewReportModel(c.Mongo.URL, c.Mongo.Database),
+		FreezePeriodModel:    model.NewFreezePeriodModel(c.Mongo.URL, c.Mongo.Database),
+		NodeVersionModel:     model.NewNodeVersionModel(c.Mongo.URL, c.Mongo.Database),
+		AlertEvaluationModel: model.NewAlertEvaluationModel(c.Mongo.URL, c.Mongo.Database),
+		AlertStateModel:      model.NewAlertStateModel(c.Mongo.URL, c.Mongo.Database),
+		ReceivedAlertModel:   model.NewReceivedAlertModel(c.Mongo.URL, c.Mongo.Database),
+		PromptTemplateModel:  model.NewPromptTemplateModel(c.Mongo.URL, c.Mongo.Database),
+		WebhookModel:         webhookModel,
+		WebhookDeliveryModel: webhookDeliveryModel,
+		QiniuClient:          qiniuClient,
+		PromClient:           newPromClient(c),
+		Notifier:             notifier,
+		Webhooks:             webhooks,
+		ExecutorFactory:      executor.NewExecutorFactory(),
 	}
 }
+
+func newPromClient(c config.Config) prom.VMClient {
+	if c.AI.PrometheusURL == "" {
+		return nil
+	}
+	return prom.NewVMClient(prom.NewDefaultConfig(c.AI.PrometheusURL).WithAlertRules(c.VM.AlertRulesPath, c.VM.VMAlertURL))
+}
 func NewUTServiceContext(c config.Config) *ServiceContext {
 	var qiniuClient *qiniu.Client
 	if c.Qiniu.AccessKey != "" && c.Qiniu.SecretKey != "" && c.Qiniu.Bucket != "" {
@@ -43,12 +84,21 @@ func NewUTServiceContext(c config.Config) *ServiceContext {
 	}
 
 	svc := &ServiceContext{
-		Config:           c,
-		ApplicationModel: model.NewApplicationModel(c.Mongo.URL, c.Mongo.Database),
-		DeploymentModel:  model.NewDeploymentModel(c.Mongo.URL, c.Mongo.Database),
-		MachineModel:     model.NewMachineModel(c.Mongo.URL, c.Mongo.Database),
-		ReportModel:      model.NewReportModel(c.Mongo.URL, c.Mongo.Database),
-		QiniuClient:      qiniuClient,
+		Config:               c,
+		ApplicationModel:     model.NewApplicationModel(c.Mongo.URL, c.Mongo.Database),
+		DeploymentModel:      model.NewDeploymentModel(c.Mongo.URL, c.Mongo.Database),
+		MachineModel:         model.NewMachineModel(c.Mongo.URL, c.Mongo.Database),
+		ReportModel:          model.NewReportModel(c.Mongo.URL, c.Mongo.Database),
+		FreezePeriodModel:    model.NewFreezePeriodModel(c.Mongo.URL, c.Mongo.Database),
+		NodeVersionModel:     model.NewNodeVersionModel(c.Mongo.URL, c.Mongo.Database),
+		AlertEvaluationModel: model.NewAlertEvaluationModel(c.Mongo.URL, c.Mongo.Database),
+		AlertStateModel:      model.NewAlertStateModel(c.Mongo.URL, c.Mongo.Database),
+		ReceivedAlertModel:   model.NewReceivedAlertModel(c.Mongo.URL, c.Mongo.Database),
+		PromptTemplateModel:  model.NewPromptTemplateModel(c.Mongo.URL, c.Mongo.Database),
+		WebhookModel:         model.NewWebhookModel(c.Mongo.URL, c.Mongo.Database),
+		WebhookDeliveryModel: model.NewWebhookDeliveryModel(c.Mongo.URL, c.Mongo.Database),
+		QiniuClient:          qiniuClient,
+		ExecutorFactory:      executor.NewExecutorFactory(),
 	}
 
 	// 清空测试数据库中的所有集合
@@ -77,6 +127,13 @@ func cleanTestDatabase(url, database string) {
 		model.CollectionDeployment,
 		model.CollectionMachine,
 		model.CollectionReport,
+		model.CollectionFreezePeriod,
+		model.CollectionNodeVersion,
+		model.CollectionAlertEvaluation,
+		model.CollectionAlertState,
+		model.CollectionPromptTemplate,
+		model.CollectionWebhook,
+		model.CollectionWebhookDelivery,
 	}
 
 	for _, collection := range collections {
@@ -24,47 +24,127 @@ type Machine struct {
 }
 
 type Application struct {
-	Id               string          `json:"id"`                 // 应用唯一标识
-	Name             string          `json:"name"`               // 应用名称
-	Repo             string          `json:"repo"`               // 仓库地址
-	DeployPath       string          `json:"deploy_path"`        // 部署路径
-	ConfigPath       string          `json:"config_path"`        // 配置文件路径
-	StartCmd         string          `json:"start_cmd"`          // 启动命令
-	StopCmd          string          `json:"stop_cmd"`           // 停止命令
-	CurrentVersion   string          `json:"currentVersion"`     // 当前版本
-	MachineCount     int             `json:"machine_count"`      // 机器总数量
-	HealthCount      int             `json:"health_count"`       // 健康机器数量
-	ErrorCount       int             `json:"error_count"`        // 异常机器数量
-	AlertCount       int             `json:"alert_count"`        // 告警机器数量
-	Machines         []Machine       `json:"machines"`           // 机器列表
-	RollbackPolicy   *RollbackPolicy `json:"rollback_policy"`    // 回滚策略配置
-	REDMetricsConfig *REDMetrics     `json:"red_metrics_config"` // RED指标配置
-	CreatedAt        int64           `json:"created_at"`         // 创建时间戳
-	UpdatedAt        int64           `json:"updated_at"`         // 更新时间戳
+	Id               string                  `json:"id"`                 // 应用唯一标识
+	Name             string                  `json:"name"`               // 应用名称
+	Repo             string                  `json:"repo"`               // 仓库地址
+	DeployPath       string                  `json:"deploy_path"`        // 部署路径
+	ConfigPath       string                  `json:"config_path"`        // 配置文件路径
+	StartCmd         string                  `json:"start_cmd"`          // 启动命令
+	StopCmd          string                  `json:"stop_cmd"`           // 停止命令
+	CurrentVersion   string                  `json:"currentVersion"`     // 当前版本
+	MachineCount     int                     `json:"machine_count"`      // 机器总数量
+	HealthCount      int                     `json:"health_count"`       // 健康机器数量
+	ErrorCount       int                     `json:"error_count"`        // 异常机器数量
+	AlertCount       int                     `json:"alert_count"`        // 告警机器数量
+	Machines         []Machine               `json:"machines"`           // 机器列表
+	RollbackPolicy   *RollbackPolicy         `json:"rollback_policy"`    // 回滚策略配置
+	REDMetricsConfig *REDMetrics             `json:"red_metrics_config"` // RED指标配置
+	DeployWindows    []DeployWindow          `json:"deploy_windows"`     // 允许发布的时间窗口
+	ApprovalPolicy   *ApprovalPolicy         `json:"approval_policy"`    // 发布审批策略
+	RetentionPolicy  *RetentionPolicy        `json:"retention_policy"`   // 版本目录保留策略
+	CanaryPolicy     *CanaryPolicy           `json:"canary_policy"`      // 灰度自动分析策略
+	ReadinessProbe   *ReadinessProbe         `json:"readiness_probe"`    // 发布后就绪探针
+	DiagnosisContext *DiagnosisContextPolicy `json:"diagnosis_context"`  // 诊断前收集的补充上下文
+	PromptTemplate   *PromptTemplateRef      `json:"prompt_template"`    // 诊断使用的提示词模板，为空时使用内置提示词
+	Notification     *NotificationPolicy     `json:"notification"`       // 发布事件通知路由
+	AlertRuleSync    []AlertRuleSyncStatus   `json:"alert_rule_sync"`    // 告警规则同步到 vmalert 的状态
+	CreatedAt        int64                   `json:"created_at"`         // 创建时间戳
+	UpdatedAt        int64                   `json:"updated_at"`         // 更新时间戳
+}
+
+type AlertRuleSyncStatus struct {
+	Name     string `json:"name"`      // 告警规则名称
+	Synced   bool   `json:"synced"`    // 是否已写入规则文件
+	Message  string `json:"message"`   // 同步说明，失败时为原因
+	SyncedAt int64  `json:"synced_at"` // 同步时间戳
+}
+
+type RetentionPolicy struct {
+	KeepReleases int `json:"keep_releases"` // 保留最近生效过的版本数，0 表示不清理；当前和上一个版本始终保留
+}
+
+type CanaryPolicy struct {
+	Enabled       bool    `json:"enabled"`                 // 是否启用灰度自动分析
+	WindowSeconds int     `json:"window_seconds"`          // 观察窗口(秒)
+	PassScore     float64 `json:"pass_score,optional"`     // 得分不低于该值判定通过，默认 80
+	MarginalScore float64 `json:"marginal_score,optional"` // 得分低于该值判定不通过，默认 50
+	AutoPromote   bool    `json:"auto_promote,optional"`   // 分析通过后是否自动发布剩余机器
+}
+
+type ReadinessProbe struct {
+	Type                string `json:"type"`                           // 探针类型: http-HTTP 请求, tcp-TCP 连接, command-在机器上执行命令
+	Port                int    `json:"port,optional"`                  // HTTP/TCP 探测端口
+	HTTPPath            string `json:"http_path,optional"`             // HTTP 探测路径
+	ExpectStatus        int    `json:"expect_status,optional"`         // HTTP 期望状态码，默认 200
+	ExpectBody          string `json:"expect_body,optional"`           // HTTP 响应体需包含的内容
+	Command             string `json:"command,optional"`               // 命令探针执行的命令，退出码为 0 表示就绪
+	TimeoutSeconds      int    `json:"timeout_seconds,optional"`       // 单次探测超时(秒)，默认 5
+	Retries             int    `json:"retries,optional"`               // 失败后的重试次数，0 表示默认 3 次，-1 表示不重试
+	IntervalSeconds     int    `json:"interval_seconds,optional"`      // 重试间隔(秒)，默认 5
+	InitialDelaySeconds int    `json:"initial_delay_seconds,optional"` // 首次探测前的等待时间(秒)
+}
+
+type DiagnosisContextPolicy struct {
+	Providers   []string `json:"providers"`             // 启用的上下文: deploy_log-发布日志, journal-systemd 日志, version_diff-版本代码差异, machine-机器信息, related_deployments-上下游应用近期发布
+	LogLines    int      `json:"log_lines,optional"`    // 日志类上下文读取的行数，默认 100
+	SystemdUnit string   `json:"systemd_unit,optional"` // systemd 单元名，默认 <应用名>.service
+}
+
+type PromptTemplateRef struct {
+	Name    string `json:"name"`             // 模板名称
+	Version int    `json:"version,optional"` // 模板版本，0 表示始终使用最新版本
+}
+
+type NotificationPolicy struct {
+	Routes []NotifyRoute `json:"routes"` // 通知路由，同一渠道匹配多条路由时只发送一次
+}
+
+type NotifyRoute struct {
+	Channel string   `json:"channel"`         // 渠道名称，对应服务配置 Notify.Channels 中的渠道
+	Events  []string `json:"events,optional"` // 接收的事件: deployment_succeeded-发布成功, deployment_failed-发布失败, rollback_triggered-告警触发自动回滚, deployment_rolled_back-回滚完成, report_completed-诊断报告生成完成；为空表示所有事件
+}
+
+type ApprovalPolicy struct {
+	Enabled           bool     `json:"enabled"`                     // 是否启用审批
+	Approvers         []string `json:"approvers,optional"`          // 审批人组，为空表示任何人都可审批
+	RequiredApprovals int      `json:"required_approvals,optional"` // 需要的审批通过人数
+	ExpireHours       int      `json:"expire_hours,optional"`       // 审批有效期(小时)，0 表示不过期
+}
+
+type DeployWindow struct {
+	Weekdays  []int  `json:"weekdays,optional"` // 生效的星期(0=周日 ... 6=周六)，为空表示每天
+	StartTime string `json:"start_time"`        // 开始时间(HH:MM)
+	EndTime   string `json:"end_time"`          // 结束时间(HH:MM)，小于开始时间表示跨天
 }
 
 type RollbackPolicy struct {
 	Enabled       bool              `json:"enabled"`              // 是否启用自动回滚
 	AlertRules    []PrometheusAlert `json:"alert_rules,optional"` // Prometheus告警规则列表
 	AutoRollback  bool              `json:"auto_rollback"`        // 是否自动执行回滚
-	NotifyChannel string            `json:"notify_channel"`       // 通知渠道
+	NotifyChannel string            `json:"notify_channel"`       // 默认通知渠道名称，接收应用的所有通知事件
 }
 
 type PrometheusAlert struct {
-	Name        string            `json:"name"`        // 告警名称
-	AlertExpr   string            `json:"alert_expr"`  // Prometheus PromQL表达式
-	Duration    string            `json:"duration"`    // 持续时长
-	Severity    string            `json:"severity"`    // 告警级别
-	Labels      map[string]string `json:"labels"`      // 自定义标签
-	Annotations map[string]string `json:"annotations"` // 告警注解
+	Name          string            `json:"name"`                     // 告警名称
+	AlertExpr     string            `json:"alert_expr"`               // Prometheus PromQL表达式
+	Duration      string            `json:"duration"`                 // 持续时长
+	Severity      string            `json:"severity"`                 // 告警级别
+	Labels        map[string]string `json:"labels"`                   // 自定义标签
+	Annotations   map[string]string `json:"annotations"`              // 告警注解
+	HostLabel     string            `json:"host_label,optional"`      // 标识机器的标签名，用于将表达式限定到发布中的机器，默认 hostname
+	Operator      string            `json:"operator,optional"`        // 比较运算符(>, >=, <, <=, ==, !=)，为空时值大于 0 即告警
+	Threshold     float64           `json:"threshold,optional"`       // 比较阈值
+	KeepFiringFor string            `json:"keep_firing_for,optional"` // 条件恢复后继续保持告警的时长
+	AbsentPolicy  string            `json:"absent_policy,optional"`   // 查询无数据时的处理策略: ok-视为正常, fire-视为告警, ignore-忽略本次评估
 }
 
 type REDMetrics struct {
-	Enabled         bool              `json:"enabled"`          // 是否启用RED监控
-	RateMetric      *MetricDefinition `json:"rate_metric"`      // Rate-请求速率
-	ErrorMetric     *MetricDefinition `json:"error_metric"`     // Error-错误率
-	DurationMetric  *MetricDefinition `json:"duration_metric"`  // Duration-响应时长
-	HealthThreshold *HealthThreshold  `json:"health_threshold"` // 健康度阈值
+	Enabled         bool              `json:"enabled"`                 // 是否启用RED监控
+	RateMetric      *MetricDefinition `json:"rate_metric"`             // Rate-请求速率
+	ErrorMetric     *MetricDefinition `json:"error_metric"`            // Error-错误率
+	DurationMetric  *MetricDefinition `json:"duration_metric"`         // Duration-响应时长
+	HealthThreshold *HealthThreshold  `json:"health_threshold"`        // 健康度阈值
+	VerifySeconds   int               `json:"verify_seconds,optional"` // 节点发布后健康验证的观察窗口(秒)，0 表示不验证
 }
 
 type MetricDefinition struct {
@@ -81,28 +161,107 @@ type HealthThreshold struct {
 }
 
 type NodeDeployment struct {
-	Id               string `json:"id"`                 // 机器唯一标识
-	Name             string `json:"name"`               // 机器名称
-	Ip               string `json:"ip"`                 // IP地址
-	NodeDeployStatus string `json:"node_deploy_status"` // 发布状态: pending-待发布, deploying-发布中, success-成功, failed-失败
-	ReleaseLog       string `json:"release_log"`        // 发布日志
-	CurrentVersion   string `json:"current_version"`    // 当前版本
-	DeployingVersion string `json:"deploying_version"`  // 正在部署的版本
-	PrevVersion      string `json:"prev_version"`       // 之前版本
-	Platform         string `json:"platform"`           // 平台类型
-	UpdatedAt        int64  `json:"updated_at"`         // 更新时间戳
-	CreatedAt        int64  `json:"created_at"`         // 创建时间戳
+	Id               string           `json:"id"`                      // 机器唯一标识
+	Name             string           `json:"name"`                    // 机器名称
+	Ip               string           `json:"ip"`                      // IP地址
+	NodeDeployStatus string           `json:"node_deploy_status"`      // 发布状态: pending-待发布, deploying-发布中, success-成功, failed-失败
+	ReleaseLog       string           `json:"release_log"`             // 发布日志
+	CurrentVersion   string           `json:"current_version"`         // 当前版本
+	DeployingVersion string           `json:"deploying_version"`       // 正在部署的版本
+	PrevVersion      string           `json:"prev_version"`            // 之前版本
+	ReuseRelease     bool             `json:"reuse_release"`           // 是否复用机器上保留的版本目录
+	ProbeResult      *ProbeResult     `json:"probe_result,omitempty"`  // 发布后就绪探测结果
+	HealthCheck      *NodeHealthCheck `json:"health_check,omitempty"`  // 发布后健康验证结果
+	FiringAlerts     []string         `json:"firing_alerts,omitempty"` // 在该机器上触发的告警规则名称
+	Platform         string           `json:"platform"`                // 平台类型
+	UpdatedAt        int64            `json:"updated_at"`              // 更新时间戳
+	CreatedAt        int64            `json:"created_at"`              // 创建时间戳
+}
+
+type ProbeResult struct {
+	Passed    bool   `json:"passed"`     // 是否通过
+	Type      string `json:"type"`       // 探针类型
+	Attempts  int    `json:"attempts"`   // 探测次数
+	Message   string `json:"message"`    // 探测说明，未通过时为最后一次失败原因
+	CheckedAt int64  `json:"checked_at"` // 探测完成时间戳
+}
+
+type NodeHealthCheck struct {
+	Passed      bool    `json:"passed"`       // 是否通过
+	Rate        float64 `json:"rate"`         // 观察窗口内的平均请求速率
+	ErrorRate   float64 `json:"error_rate"`   // 观察窗口内的平均错误率
+	DurationP95 float64 `json:"duration_p95"` // 观察窗口内的平均 P95 响应时长
+	Message     string  `json:"message"`      // 验证说明，未通过时为超出阈值的指标
+	StartTime   int64   `json:"start_time"`   // 观察开始时间戳
+	EndTime     int64   `json:"end_time"`     // 观察结束时间戳
 }
 
 type Deployment struct {
-	Id              string           `json:"id"`               // 发布记录唯一标识
-	AppName         string           `json:"app_name"`         // 应用名称
-	Status          string           `json:"status"`           // 发布状态: pending-待发布, deploying-发布中, success-成功, failed-失败, rolled_back-已回滚
-	PackageVersion  string           `json:"package_version"`  // 包版本
-	GrayMachineId   string           `json:"gray_machine_id"`  // 灰度设备ID
-	NodeDeployments []NodeDeployment `json:"node_deployments"` // 发布机器列表
-	CreatedAt       int64            `json:"created_at"`       // 创建时间戳
-	UpdatedAt       int64            `json:"updated_at"`       // 更新时间戳
+	Id              string           `json:"id"`                        // 发布记录唯一标识
+	Type            string           `json:"type"`                      // 发布单类型: deploy-发布新版本, rollback-回滚到历史版本
+	Reason          string           `json:"reason"`                    // 发布说明/回滚原因
+	AppName         string           `json:"app_name"`                  // 应用名称
+	Status          string           `json:"status"`                    // 发布状态: pending-待发布, deploying-发布中, success-成功, failed-失败, rolled_back-已回滚
+	PackageVersion  string           `json:"package_version"`           // 包版本
+	GrayMachineId   string           `json:"gray_machine_id"`           // 灰度设备ID
+	NodeDeployments []NodeDeployment `json:"node_deployments"`          // 发布机器列表
+	ScheduledTime   int64            `json:"scheduled_time"`            // 计划发布时间戳，0 表示不定时
+	FreezeOverride  *FreezeOverride  `json:"freeze_override,omitempty"` // 封版期强制发布记录
+	Approval        *Approval        `json:"approval,omitempty"`        // 发布审批信息
+	CanaryAnalysis  *CanaryAnalysis  `json:"canary_analysis,omitempty"` // 灰度自动分析结果
+	CreatedAt       int64            `json:"created_at"`                // 创建时间戳
+	UpdatedAt       int64            `json:"updated_at"`                // 更新时间戳
+}
+
+type CanaryAnalysis struct {
+	Verdict   string               `json:"verdict"`    // 分析结论: running-分析中, pass-通过, fail-未通过, inconclusive-无法判断
+	Score     float64              `json:"score"`      // 得分(0-100)
+	Metrics   []CanaryMetricResult `json:"metrics"`    // 各指标的检验结果
+	Message   string               `json:"message"`    // 分析说明
+	Promoted  bool                 `json:"promoted"`   // 是否已自动发布剩余机器
+	StartTime int64                `json:"start_time"` // 观察开始时间戳
+	EndTime   int64                `json:"end_time"`   // 观察结束时间戳
+}
+
+type CanaryMetricResult struct {
+	Name           string  `json:"name"`            // 指标名称: rate, error, duration
+	CanaryMedian   float64 `json:"canary_median"`   // 灰度机器样本中位数
+	BaselineMedian float64 `json:"baseline_median"` // 各基线机器样本中位数的中位数
+	PValue         float64 `json:"p_value"`         // 与各基线机器 Mann-Whitney U 检验单侧 p 值的中位数
+	Verdict        string  `json:"verdict"`         // 指标结论: pass, fail, no_data
+}
+
+type Approval struct {
+	Status            string           `json:"status"`             // 审批状态: pending-审批中, approved-已通过, rejected-已驳回, expired-已过期
+	Approvers         []string         `json:"approvers"`          // 审批人组
+	RequiredApprovals int              `json:"required_approvals"` // 需要的审批通过人数
+	ExpireTime        int64            `json:"expire_time"`        // 审批过期时间戳，0 表示不过期
+	Records           []ApprovalRecord `json:"records"`            // 审批记录
+}
+
+type ApprovalRecord struct {
+	Approver    string `json:"approver"`     // 审批人
+	Action      string `json:"action"`       // 审批动作: approve-通过, reject-驳回
+	Comment     string `json:"comment"`      // 审批意见
+	CreatedTime int64  `json:"created_time"` // 审批时间戳
+}
+
+type FreezeOverride struct {
+	Operator    string `json:"operator"`              // 操作人
+	Token       string `json:"token,optional"`        // 发布管理员令牌，用于校验操作人身份，不保存
+	Reason      string `json:"reason"`                // 强制发布原因
+	CreatedTime int64  `json:"created_time,optional"` // 记录时间戳
+}
+
+type FreezePeriod struct {
+	Id        string `json:"id"`         // 封版期唯一标识
+	AppId     string `json:"app_id"`     // 应用ID，为空表示全局封版
+	Name      string `json:"name"`       // 封版名称
+	Reason    string `json:"reason"`     // 封版原因
+	StartTime int64  `json:"start_time"` // 开始时间戳
+	EndTime   int64  `json:"end_time"`   // 结束时间戳
+	CreatedBy string `json:"created_by"` // 创建人
+	CreatedAt int64  `json:"created_at"` // 创建时间戳
 }
 
 type CreateAppReq struct {
@@ -119,20 +278,42 @@ type CreateAppResp struct {
 }
 
 type UpdateAppReq struct {
-	Id               string          `json:"id"`                          // 应用ID
-	Name             string          `json:"name"`                        // 应用名称
-	Repo             string          `json:"repo,optional"`               // 仓库地址
-	DeployPath       string          `json:"deploy_path"`                 // 部署路径
-	ConfigPath       string          `json:"config_path,optional"`        // 配置文件路径
-	StartCmd         string          `json:"start_cmd"`                   // 启动命令
-	StopCmd          string          `json:"stop_cmd"`                    // 停止命令
-	MachineIds       []string        `json:"machine_ids,optional"`        // 关联的机器ID列表
-	RollbackPolicy   *RollbackPolicy `json:"rollback_policy,optional"`    // 回滚策略配置
-	REDMetricsConfig *REDMetrics     `json:"red_metrics_config,optional"` // RED指标配置
+	Id               string                  `json:"id"`                          // 应用ID
+	Name             string                  `json:"name"`                        // 应用名称
+	Repo             string                  `json:"repo,optional"`               // 仓库地址
+	DeployPath       string                  `json:"deploy_path"`                 // 部署路径
+	ConfigPath       string                  `json:"config_path,optional"`        // 配置文件路径
+	StartCmd         string                  `json:"start_cmd"`                   // 启动命令
+	StopCmd          string                  `json:"stop_cmd"`                    // 停止命令
+	MachineIds       []string                `json:"machine_ids,optional"`        // 关联的机器ID列表
+	RollbackPolicy   *RollbackPolicy         `json:"rollback_policy,optional"`    // 回滚策略配置
+	REDMetricsConfig *REDMetrics             `json:"red_metrics_config,optional"` // RED指标配置
+	DeployWindows    []DeployWindow          `json:"deploy_windows,optional"`     // 允许发布的时间窗口
+	ApprovalPolicy   *ApprovalPolicy         `json:"approval_policy,optional"`    // 发布审批策略
+	RetentionPolicy  *RetentionPolicy        `json:"retention_policy,optional"`   // 版本目录保留策略
+	CanaryPolicy     *CanaryPolicy           `json:"canary_policy,optional"`      // 灰度自动分析策略
+	ReadinessProbe   *ReadinessProbe         `json:"readiness_probe,optional"`    // 发布后就绪探针，类型为空表示删除探针
+	DiagnosisContext *DiagnosisContextPolicy `json:"diagnosis_context,optional"`  // 诊断前收集的补充上下文
+	PromptTemplate   *PromptTemplateRef      `json:"prompt_template,optional"`    // 诊断使用的提示词模板，name 为空表示恢复内置提示词
+	Notification     *NotificationPolicy     `json:"notification,optional"`       // 发布事件通知路由，传空路由表示取消
 }
 
 type UpdateAppResp struct {
-	Success bool `json:"success"` // 更新是否成功
+	Success       bool                  `json:"success"`         // 更新是否成功
+	AlertRuleSync []AlertRuleSyncStatus `json:"alert_rule_sync"` // 告警规则同步到 vmalert 的状态，未同步时为空
+}
+
+type PreviewAlertRulesReq struct {
+	Id             string          `path:"id"`                       // 应用ID
+	RollbackPolicy *RollbackPolicy `json:"rollback_policy,optional"` // 待保存的回滚策略，不传时使用应用当前配置
+	MachineIds     []string        `json:"machine_ids,optional"`     // 待保存的机器ID列表，不传时使用应用当前机器
+}
+
+type PreviewAlertRulesResp struct {
+	Group         string                `json:"group"`           // vmalert 规则分组名称
+	Changed       bool                  `json:"changed"`         // 规则文件是否会变化
+	Diff          string                `json:"diff"`            // 规则文件按行比较的差异，"+ " 为新增行，"- " 为删除行
+	AlertRuleSync []AlertRuleSyncStatus `json:"alert_rule_sync"` // 每条规则能否写入规则文件
 }
 
 type GetAppListReq struct {
@@ -170,9 +351,11 @@ type AppVersion struct {
 }
 
 type CreateDeploymentReq struct {
-	AppName        string `json:"app_name"`        // 应用名称
-	PackageVersion string `json:"package_version"` // 包版本
-	GrayMachineId  string `json:"gray_machine_id"` // 灰度设备ID（可选，用于灰度发布）
+	AppName        string          `json:"app_name"`                 // 应用名称
+	PackageVersion string          `json:"package_version"`          // 包版本
+	GrayMachineId  string          `json:"gray_machine_id"`          // 灰度设备ID（可选，用于灰度发布）
+	ScheduledTime  int64           `json:"scheduled_time,optional"`  // 计划发布时间戳（可选，到点后自动开始发布）
+	FreezeOverride *FreezeOverride `json:"freeze_override,optional"` // 封版期强制发布（可选）
 }
 
 type CreateDeploymentResp struct {
@@ -238,12 +421,127 @@ type DataPoint struct {
 }
 
 type Report struct {
-	Id           string `json:"id"`            // 报告唯一标识
-	DeploymentId string `json:"deployment_id"` // 关联的部署ID
-	Content      string `json:"content"`       // AI 生成的报告内容
-	Status       string `json:"status"`        // 报告生成状态: generating-生成中, completed-生成完成, failed-生成失败
-	CreatedAt    int64  `json:"created_at"`    // 创建时间戳
-	UpdatedAt    int64  `json:"updated_at"`    // 更新时间戳
+	Id               string             `json:"id"`                 // 报告唯一标识
+	DeploymentId     string             `json:"deployment_id"`      // 关联的部署ID
+	Content          string             `json:"content"`            // AI 生成的报告内容
+	Status           string             `json:"status"`             // 报告生成状态: queued-排队中(含等待重试), generating-生成中, completed-生成完成, failed-生成失败, skipped-诊断预算已用完未生成
+	Attempts         int                `json:"attempts"`           // 已尝试生成的次数
+	LastError        string             `json:"last_error"`         // 最近一次生成失败的原因
+	Result           *DiagnosisResult   `json:"result,omitempty"`   // 结构化报告，旧版报告为空
+	Contexts         []DiagnosisContext `json:"contexts"`           // 诊断前收集的补充上下文
+	Model            string             `json:"model"`              // 生成报告使用的模型
+	PromptVersion    string             `json:"prompt_version"`     // 生成报告使用的提示词版本
+	Feedback         *ReportFeedback    `json:"feedback,omitempty"` // 报告准确性评价，未评价时为空
+	Usage            *ReportUsage       `json:"usage,omitempty"`    // 模型用量和成本，累计所有尝试，未调用模型时为空
+	SimilarIncidents []SimilarIncident  `json:"similar_incidents"`  // 诊断前检索到的历史相似故障
+	CreatedAt        int64              `json:"created_at"`         // 创建时间戳
+	UpdatedAt        int64              `json:"updated_at"`         // 更新时间戳
+}
+
+type DiagnosisResult struct {
+	Summary     string              `json:"summary"`     // 问题概述
+	RootCause   string              `json:"root_cause"`  // 根因分析
+	Impact      string              `json:"impact"`      // 影响范围
+	Remediation []string            `json:"remediation"` // 解决步骤
+	Suspects    []SuspectChange     `json:"suspects"`    // 可疑的代码变更
+	Evidence    []DiagnosisEvidence `json:"evidence"`    // 支撑结论的指标
+	Confidence  float64             `json:"confidence"`  // 结论置信度，0~1
+}
+
+type SuspectChange struct {
+	PR          int    `json:"pr"`          // PR 编号，未关联 PR 时为 0
+	File        string `json:"file"`        // 文件路径
+	Description string `json:"description"` // 问题描述
+	Suggestion  string `json:"suggestion"`  // 修复建议
+}
+
+type DiagnosisEvidence struct {
+	PromQL      string            `json:"promql"`             // 查询语句
+	Observation string            `json:"observation"`        // 生成报告时观察到的现象
+	Snapshot    *EvidenceSnapshot `json:"snapshot,omitempty"` // 告警时间窗口内的指标快照，可直接用于绘图
+}
+
+type EvidenceSnapshot struct {
+	Start  int64           `json:"start"`  // 开始时间戳（秒）
+	End    int64           `json:"end"`    // 结束时间戳（秒）
+	Step   int             `json:"step"`   // 采样间隔（秒）
+	Series []MonitorSeries `json:"series"` // 时序数据
+	Error  string          `json:"error"`  // 查询失败的原因
+}
+
+type ReportUsage struct {
+	PromptTokens     int     `json:"prompt_tokens"`     // 输入 token 数
+	CompletionTokens int     `json:"completion_tokens"` // 输出 token 数
+	ToolCalls        int     `json:"tool_calls"`        // 工具调用次数
+	Cost             float64 `json:"cost"`              // 成本，单位与配置的价格表一致
+}
+
+type SimilarIncident struct {
+	ReportId     string   `json:"report_id"`     // 历史报告ID
+	DeploymentId string   `json:"deployment_id"` // 历史报告关联的部署ID
+	AppName      string   `json:"app_name"`      // 应用名称
+	Alertname    string   `json:"alertname"`     // 告警名称
+	Summary      string   `json:"summary"`       // 问题概述
+	RootCause    string   `json:"root_cause"`    // 根因分析
+	Remediation  []string `json:"remediation"`   // 解决步骤
+	Verified     bool     `json:"verified"`      // 报告是否被评价为准确
+	Score        float64  `json:"score"`         // 相似度得分，仅用于排序
+	CreatedAt    int64    `json:"created_at"`    // 历史报告创建时间戳
+}
+
+type ReportFeedback struct {
+	Accurate  bool   `json:"accurate"`   // 报告结论是否准确
+	Notes     string `json:"notes"`      // 评价说明
+	Operator  string `json:"operator"`   // 评价人
+	CreatedAt int64  `json:"created_at"` // 评价时间戳
+}
+
+type DiagnosisContext struct {
+	Type    string `json:"type"`    // 上下文类型
+	Content string `json:"content"` // 上下文内容
+	Error   string `json:"error"`   // 收集失败的原因
+}
+
+type PromptTemplate struct {
+	Id          string `json:"id"`          // 模板版本标识，格式为 <name>@v<version>
+	Name        string `json:"name"`        // 模板名称
+	Version     int    `json:"version"`     // 版本号
+	Content     string `json:"content"`     // text/template 模板内容
+	Description string `json:"description"` // 版本说明
+	CreatedBy   string `json:"created_by"`  // 创建人
+	CreatedAt   int64  `json:"created_at"`  // 创建时间戳
+}
+
+type CreatePromptTemplateReq struct {
+	Name        string `json:"name"`                 // 模板名称，已存在时保存为新版本
+	Content     string `json:"content"`              // text/template 模板内容，输出格式说明由系统追加
+	Description string `json:"description,optional"` // 版本说明
+	CreatedBy   string `json:"created_by,optional"`  // 创建人
+}
+
+type CreatePromptTemplateResp struct {
+	Id      string `json:"id"`      // 模板版本标识
+	Name    string `json:"name"`    // 模板名称
+	Version int    `json:"version"` // 版本号
+}
+
+type GetPromptTemplateListReq struct {
+	Name string `form:"name,optional"` // 模板名称筛选，可选
+}
+
+type GetPromptTemplateListResp struct {
+	Templates []PromptTemplate `json:"templates"` // 模板列表，内置提示词在最前，其余按名称升序、版本倒序
+}
+
+type PreviewPromptTemplateReq struct {
+	ReportId      string `json:"report_id"`               // 用于渲染的历史诊断报告ID，使用其告警和补充上下文
+	PromptVersion string `json:"prompt_version,optional"` // 模板版本或名称，为空时使用应用选择的模板
+	Content       string `json:"content,optional"`        // 未保存的模板内容，传入时忽略 prompt_version
+}
+
+type PreviewPromptTemplateResp struct {
+	Prompt        string `json:"prompt"`         // 渲染后的完整提示词
+	PromptVersion string `json:"prompt_version"` // 使用的模板版本，预览未保存的内容时为空
 }
 
 type CancelDeploymentReq struct {
@@ -262,6 +560,40 @@ type RollbackDeploymentResp struct {
 	Success bool `json:"success"` // 回滚是否成功
 }
 
+type RollbackToVersionReq struct {
+	AppName       string   `json:"app_name"`             // 应用名称
+	TargetVersion string   `json:"target_version"`       // 回滚目标版本
+	MachineIds    []string `json:"machine_ids,optional"` // 回滚的机器ID列表，为空时回滚应用全部机器
+	Reason        string   `json:"reason,optional"`      // 回滚原因
+}
+
+type RollbackToVersionResp struct {
+	Id               string   `json:"id"`                // 创建的回滚发布单ID
+	ReuseMachines    []string `json:"reuse_machines"`    // 保留了目标版本目录、直接切换的机器
+	DownloadMachines []string `json:"download_machines"` // 缺少目标版本目录、需要重新下载的机器
+	SkippedMachines  []string `json:"skipped_machines"`  // 已运行目标版本、无需回滚的机器
+}
+
+type ApproveDeploymentReq struct {
+	Id       string `path:"id"`               // 发布记录ID
+	Approver string `json:"approver"`         // 审批人
+	Comment  string `json:"comment,optional"` // 审批意见
+}
+
+type ApproveDeploymentResp struct {
+	Success bool `json:"success"` // 审批是否成功
+}
+
+type RejectDeploymentReq struct {
+	Id       string `path:"id"`               // 发布记录ID
+	Approver string `json:"approver"`         // 审批人
+	Comment  string `json:"comment,optional"` // 驳回意见
+}
+
+type RejectDeploymentResp struct {
+	Success bool `json:"success"` // 驳回是否成功
+}
+
 type CreateMachineReq struct {
 	Name        string `json:"name"`        // 机器名称
 	Ip          string `json:"ip"`          // IP地址
@@ -344,6 +676,42 @@ type GetMachineHostnameResp struct {
 	Message  string `json:"message"`  // 结果消息
 }
 
+type NodeVersion struct {
+	AppName           string   `json:"app_name"`           // 应用名称
+	CurrentVersion    string   `json:"current_version"`    // 当前生效版本
+	PrevVersion       string   `json:"prev_version"`       // 上一个生效版本
+	History           []string `json:"history"`            // 生效版本历史，最后一个为当前版本
+	InstalledVersions []string `json:"installed_versions"` // 机器上保留的版本目录
+	UpdatedAt         int64    `json:"updated_at"`         // 更新时间
+}
+
+type GetMachineVersionsReq struct {
+	Id      string `path:"id"`                // 机器ID
+	AppName string `form:"app_name,optional"` // 应用名称
+}
+
+type GetMachineVersionsResp struct {
+	Versions []NodeVersion `json:"versions"` // 各应用版本清单
+}
+
+type PruneMachineReleasesReq struct {
+	Id           string `path:"id"`                     // 机器ID
+	AppName      string `json:"app_name,optional"`      // 应用名称，为空时清理机器上所有应用
+	KeepReleases int    `json:"keep_releases,optional"` // 保留的版本数，为空时使用应用的保留策略
+}
+
+type PruneReleasesResult struct {
+	AppName         string   `json:"app_name"`         // 应用名称
+	RemovedVersions []string `json:"removed_versions"` // 已删除的版本
+	ReclaimedBytes  int64    `json:"reclaimed_bytes"`  // 释放的磁盘空间(字节)
+	Message         string   `json:"message"`          // 未清理的原因或错误信息
+}
+
+type PruneMachineReleasesResp struct {
+	Results        []PruneReleasesResult `json:"results"`         // 各应用清理结果
+	ReclaimedBytes int64                 `json:"reclaimed_bytes"` // 共释放的磁盘空间(字节)
+}
+
 type PostAlertCallbackReq struct {
 	Key          string            `json:"key"`
 	Status       string            `json:"status"`
@@ -364,6 +732,45 @@ type PostAlertCallbackReq struct {
 	Values       float64           `json:"values"`
 }
 
+type AlertmanagerAlert struct {
+	Status       string            `json:"status"`                // firing 或 resolved
+	Labels       map[string]string `json:"labels"`                // 告警标签，按 deploymentId、appName/appId 和 hostname/instance 关联发布单
+	Annotations  map[string]string `json:"annotations,optional"`  // 告警注解
+	StartsAt     string            `json:"startsAt"`              // 开始时间，RFC3339
+	EndsAt       string            `json:"endsAt,optional"`       // 结束时间，RFC3339
+	GeneratorURL string            `json:"generatorURL,optional"` // 告警来源地址
+	Fingerprint  string            `json:"fingerprint,optional"`  // 告警指纹，未提供时按标签计算
+}
+
+type AlertmanagerWebhookReq struct {
+	Version           string              `json:"version,optional"`           // 协议版本
+	GroupKey          string              `json:"groupKey,optional"`          // 告警分组键
+	TruncatedAlerts   int                 `json:"truncatedAlerts,optional"`   // 因数量限制被截断的告警数
+	Status            string              `json:"status"`                     // 分组状态，firing 或 resolved
+	Receiver          string              `json:"receiver,optional"`          // 接收器名称
+	GroupLabels       map[string]string   `json:"groupLabels,optional"`       // 分组标签
+	CommonLabels      map[string]string   `json:"commonLabels,optional"`      // 所有告警共有的标签
+	CommonAnnotations map[string]string   `json:"commonAnnotations,optional"` // 所有告警共有的注解
+	ExternalURL       string              `json:"externalURL,optional"`       // Alertmanager 地址
+	Alerts            []AlertmanagerAlert `json:"alerts"`                     // 分组内的告警
+}
+
+type AlertmanagerWebhookResp struct {
+	Received   int                 `json:"received"`   // 收到的告警数
+	Matched    int                 `json:"matched"`    // 关联到发布中发布单的告警数
+	Duplicated int                 `json:"duplicated"` // 重复推送被忽略的告警数
+	Results    []AlertHandleResult `json:"results"`    // 每条告警的处理结果
+}
+
+type AlertHandleResult struct {
+	Fingerprint  string   `json:"fingerprint"`   // 告警指纹
+	Alertname    string   `json:"alertname"`     // 告警名称
+	Status       string   `json:"status"`        // 告警状态
+	DeploymentId string   `json:"deployment_id"` // 关联的发布单ID，未关联时为空
+	Hosts        []string `json:"hosts"`         // 关联到的发布机器
+	Action       string   `json:"action"`        // 处理结果 rollback/diagnose/resolved/duplicate/unmatched
+}
+
 type RollbackNodeDeploymentReq struct {
 	Id                string   `path:"id"`                  // 发布记录ID
 	NodeDeploymentIds []string `json:"node_deployment_ids"` // 发布机器ID列表
@@ -374,8 +781,9 @@ type RollbackNodeDeploymentResp struct {
 }
 
 type DeployNodeDeploymentReq struct {
-	Id                string   `path:"id"`                  // 发布记录ID
-	NodeDeploymentIds []string `json:"node_deployment_ids"` // 发布机器ID列表
+	Id                string          `path:"id"`                       // 发布记录ID
+	NodeDeploymentIds []string        `json:"node_deployment_ids"`      // 发布机器ID列表
+	FreezeOverride    *FreezeOverride `json:"freeze_override,optional"` // 封版期强制发布（可选）
 }
 
 type DeployNodeDeploymentResp struct {
@@ -408,3 +816,249 @@ type CancelNodeDeploymentReq struct {
 type CancelNodeDeploymentResp struct {
 	Success bool `json:"success"` // 取消是否成功
 }
+
+type AlertSample struct {
+	Labels   map[string]string `json:"labels"`   // 序列标签
+	Value    float64           `json:"value"`    // 序列值
+	Breached bool              `json:"breached"` // 是否满足告警条件
+}
+
+type AlertEvaluation struct {
+	Id          string        `json:"id"`           // 评估记录ID
+	RuleName    string        `json:"rule_name"`    // 告警规则名称
+	Query       string        `json:"query"`        // 实际执行的查询语句
+	Operator    string        `json:"operator"`     // 比较运算符
+	Threshold   float64       `json:"threshold"`    // 比较阈值
+	State       string        `json:"state"`        // 评估后的规则状态: inactive-未满足条件, pending-等待持续时长, firing-告警中
+	Samples     []AlertSample `json:"samples"`      // 查询到的序列
+	FiringHosts []string      `json:"firing_hosts"` // 满足告警条件的机器
+	Reason      string        `json:"reason"`       // 评估说明
+	CreatedAt   int64         `json:"created_at"`   // 评估时间戳
+}
+
+type GetAlertEvaluationsReq struct {
+	Id       string `path:"id"`                   // 发布记录ID
+	RuleName string `form:"rule_name,optional"`   // 告警规则名称筛选，可选
+	State    string `form:"state,optional"`       // 状态筛选，可选
+	Page     int    `form:"page,default=1"`       // 页码，默认第1页
+	PageSize int    `form:"page_size,default=20"` // 每页数量，默认20条
+}
+
+type GetAlertEvaluationsResp struct {
+	Evaluations []AlertEvaluation `json:"evaluations"` // 评估记录列表，按时间倒序
+	Total       int64             `json:"total"`       // 总数量
+}
+
+type GetDeploymentReportsReq struct {
+	Id string `path:"id"` // 发布记录ID
+}
+
+type GetDeploymentReportsResp struct {
+	Reports []Report `json:"reports"` // 报告列表，按创建时间倒序
+}
+
+type RegenerateReportReq struct {
+	Id            string `path:"id"`                      // 发布记录ID
+	ReportId      string `json:"report_id,optional"`      // 作为告警来源的报告ID，默认为最近一次带告警信息的报告
+	Model         string `json:"model,optional"`          // 使用的模型，默认为配置的模型
+	PromptVersion string `json:"prompt_version,optional"` // 使用的提示词版本，默认为内置提示词
+}
+
+type RegenerateReportResp struct {
+	ReportId string `json:"report_id"` // 新报告ID，已有排队中或生成中的报告时为该报告ID
+	Created  bool   `json:"created"`   // 是否新建了诊断任务
+}
+
+type SubmitReportFeedbackReq struct {
+	Id       string `path:"id"`                // 报告ID
+	Accurate bool   `json:"accurate"`          // 报告结论是否准确
+	Notes    string `json:"notes,optional"`    // 评价说明
+	Operator string `json:"operator,optional"` // 评价人
+}
+
+type SubmitReportFeedbackResp struct {
+	Success bool `json:"success"` // 评价是否成功
+}
+
+type GetReportFeedbackStatsReq struct {
+}
+
+type GetReportFeedbackStatsResp struct {
+	Stats []ReportFeedbackStat `json:"stats"` // 按提示词版本统计的评价结果
+}
+
+type ReportFeedbackStat struct {
+	PromptVersion string  `json:"prompt_version"` // 提示词版本
+	Total         int64   `json:"total"`          // 生成完成的报告数
+	Rated         int64   `json:"rated"`          // 已评价的报告数
+	Accurate      int64   `json:"accurate"`       // 评价为准确的报告数
+	Inaccurate    int64   `json:"inaccurate"`     // 评价为不准确的报告数
+	Accuracy      float64 `json:"accuracy"`       // 准确率（准确数/已评价数），未评价时为 0
+}
+
+type SearchIncidentsReq struct {
+	Query     string `form:"query,optional"`     // 检索文本，如告警描述、根因关键词
+	AppName   string `form:"app_name,optional"`  // 应用名称
+	Alertname string `form:"alertname,optional"` // 告警名称
+	Limit     int    `form:"limit,default=10"`   // 返回数量，最多50条
+}
+
+type SearchIncidentsResp struct {
+	Incidents []SimilarIncident `json:"incidents"` // 按相似度从高到低排列的历史故障
+}
+
+type CreateFreezePeriodReq struct {
+	AppId     string `json:"app_id,optional"`     // 应用ID，为空表示全局封版
+	Name      string `json:"name"`                // 封版名称
+	Reason    string `json:"reason,optional"`     // 封版原因
+	StartTime int64  `json:"start_time"`          // 开始时间戳
+	EndTime   int64  `json:"end_time"`            // 结束时间戳
+	CreatedBy string `json:"created_by,optional"` // 创建人
+}
+
+type CreateFreezePeriodResp struct {
+	Id string `json:"id"` // 创建的封版期ID
+}
+
+type GetFreezePeriodListReq struct {
+	Page       int    `form:"page,default=1"`       // 页码，默认第1页
+	PageSize   int    `form:"page_size,default=10"` // 每页数量，默认10条
+	AppId      string `form:"app_id,optional"`      // 应用ID筛选（同时返回全局封版），可选
+	ActiveOnly bool   `form:"active_only,optional"` // 只返回当前生效的封版期，可选
+}
+
+type GetFreezePeriodListResp struct {
+	FreezePeriods []FreezePeriod `json:"freeze_periods"` // 封版期列表
+	Total         int64          `json:"total"`          // 总数量
+	Page          int            `json:"page"`           // 当前页码
+	PageSize      int            `json:"page_size"`      // 每页数量
+}
+
+type DeleteFreezePeriodReq struct {
+	Id string `path:"id"` // 封版期ID
+}
+
+type DeleteFreezePeriodResp struct {
+	Success bool `json:"success"` // 删除是否成功
+}
+
+type Webhook struct {
+	Id        string   `json:"id"`         // 订阅ID
+	Name      string   `json:"name"`       // 订阅名称
+	URL       string   `json:"url"`        // 接收事件的地址
+	Events    []string `json:"events"`     // 订阅的事件类型，为空表示所有事件
+	AppIds    []string `json:"app_ids"`    // 只接收这些应用的事件，为空表示所有应用
+	Enabled   bool     `json:"enabled"`    // 是否启用
+	CreatedBy string   `json:"created_by"` // 创建人
+	CreatedAt int64    `json:"created_at"` // 创建时间戳
+	UpdatedAt int64    `json:"updated_at"` // 更新时间戳
+}
+
+type WebhookDelivery struct {
+	Id             string `json:"id"`              // 投递记录ID
+	WebhookId      string `json:"webhook_id"`      // 订阅ID
+	EventId        string `json:"event_id"`        // 事件ID，同一事件投递到多个订阅时相同
+	EventType      string `json:"event_type"`      // 事件类型
+	AppId          string `json:"app_id"`          // 事件所属应用
+	DeploymentId   string `json:"deployment_id"`   // 事件关联的发布单
+	Payload        string `json:"payload"`         // 请求体
+	Status         string `json:"status"`          // 投递状态：pending-待投递，delivering-投递中，succeeded-投递成功，dead-死信
+	Attempts       int    `json:"attempts"`        // 已尝试投递的次数
+	NextRunAt      int64  `json:"next_run_at"`     // 下次投递时间戳，待投递时有效
+	ResponseStatus int    `json:"response_status"` // 最近一次投递的 HTTP 状态码，请求未发出时为 0
+	ResponseBody   string `json:"response_body"`   // 最近一次投递的响应体（截断）
+	LastError      string `json:"last_error"`      // 最近一次投递失败的原因
+	Duration       int64  `json:"duration"`        // 最近一次投递耗时（毫秒）
+	DeliveredAt    int64  `json:"delivered_at"`    // 投递成功时间戳，未成功时为 0
+	CreatedAt      int64  `json:"created_at"`      // 创建时间戳
+}
+
+type CreateWebhookReq struct {
+	Name      string   `json:"name"`                 // 订阅名称
+	URL       string   `json:"url"`                  // 接收事件的地址，http 或 https
+	Secret    string   `json:"secret,optional"`      // 签名密钥，为空时自动生成
+	Events    []string `json:"events,optional"`      // 订阅的事件类型，为空表示所有事件
+	AppIds    []string `json:"app_ids,optional"`     // 只接收这些应用的事件，为空表示所有应用
+	Enabled   bool     `json:"enabled,default=true"` // 是否启用，默认启用
+	CreatedBy string   `json:"created_by,optional"`  // 创建人
+}
+
+type CreateWebhookResp struct {
+	Id     string `json:"id"`     // 创建的订阅ID
+	Secret string `json:"secret"` // 签名密钥，只在创建时返回
+}
+
+type GetWebhookListReq struct {
+}
+
+type GetWebhookListResp struct {
+	Webhooks []Webhook `json:"webhooks"` // 订阅列表
+}
+
+type GetWebhookDetailReq struct {
+	Id string `path:"id"` // 订阅ID
+}
+
+type GetWebhookDetailResp struct {
+	Webhook Webhook `json:"webhook"` // 订阅详情
+}
+
+type UpdateWebhookReq struct {
+	Id           string   `path:"id"`                     // 订阅ID
+	Name         string   `json:"name"`                   // 订阅名称
+	URL          string   `json:"url"`                    // 接收事件的地址
+	Secret       string   `json:"secret,optional"`        // 新的签名密钥，为空时保持不变
+	RotateSecret bool     `json:"rotate_secret,optional"` // 是否重新生成签名密钥，优先于 secret
+	Events       []string `json:"events,optional"`        // 订阅的事件类型，为空表示所有事件
+	AppIds       []string `json:"app_ids,optional"`       // 只接收这些应用的事件，为空表示所有应用
+	Enabled      bool     `json:"enabled"`                // 是否启用
+}
+
+type UpdateWebhookResp struct {
+	Success bool   `json:"success"`          // 更新是否成功
+	Secret  string `json:"secret,omitempty"` // 更新后的签名密钥，只在修改密钥时返回
+}
+
+type DeleteWebhookReq struct {
+	Id string `path:"id"` // 订阅ID
+}
+
+type DeleteWebhookResp struct {
+	Success bool `json:"success"` // 删除是否成功
+}
+
+type GetWebhookDeliveriesReq struct {
+	Id        string `path:"id"`                   // 订阅ID
+	Status    string `form:"status,optional"`      // 投递状态筛选，可选
+	EventType string `form:"event_type,optional"`  // 事件类型筛选，可选
+	Page      int    `form:"page,default=1"`       // 页码，默认第1页
+	PageSize  int    `form:"page_size,default=20"` // 每页数量，默认20条
+}
+
+type GetWebhookDeliveriesResp struct {
+	Deliveries []WebhookDelivery `json:"deliveries"` // 投递记录，按创建时间倒序
+	Total      int64             `json:"total"`      // 总数量
+	Page       int               `json:"page"`       // 当前页码
+	PageSize   int               `json:"page_size"`  // 每页数量
+}
+
+type GetWebhookDeadLettersReq struct {
+	WebhookId string `form:"webhook_id,optional"`  // 订阅ID筛选，可选
+	Page      int    `form:"page,default=1"`       // 页码，默认第1页
+	PageSize  int    `form:"page_size,default=20"` // 每页数量，默认20条
+}
+
+type GetWebhookDeadLettersResp struct {
+	Deliveries []WebhookDelivery `json:"deliveries"` // 超过最大尝试次数的投递记录，按创建时间倒序
+	Total      int64             `json:"total"`      // 总数量
+	Page       int               `json:"page"`       // 当前页码
+	PageSize   int               `json:"page_size"`  // 每页数量
+}
+
+type RedeliverWebhookReq struct {
+	Id string `path:"id"` // 投递记录ID
+}
+
+type RedeliverWebhookResp struct {
+	Success bool `json:"success"` // 是否已重新加入投递队列
+}
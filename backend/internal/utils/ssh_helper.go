@@ -59,3 +59,47 @@ func TestSSHConnectionAndGetHostname(ip string, port int, username, password str
 
 	return true, hostname, fmt.Sprintf("成功连接并获取hostname: %s", hostname), nil
 }
+
+// RunSSHCommand 通过 SSH 在远程机器上执行命令并返回合并后的输出，超时或命令退出码非 0 时返回错误
+func RunSSHCommand(ip string, port int, username, password, command string, timeout time.Duration) (string, error) {
+	config := &ssh.ClientConfig{
+		User: username,
+		Auth: []ssh.AuthMethod{
+			ssh.Password(password),
+		},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Timeout:         timeout,
+	}
+
+	client, err := ssh.Dial("tcp", fmt.Sprintf("%s:%d", ip, port), config)
+	if err != nil {
+		return "", fmt.Errorf("SSH 连接 %s:%d 失败: %w", ip, port, err)
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return "", fmt.Errorf("创建 SSH 会话失败: %w", err)
+	}
+	defer session.Close()
+
+	type result struct {
+		output []byte
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		output, err := session.CombinedOutput(command)
+		done <- result{output, err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			return string(r.output), fmt.Errorf("命令执行失败: %w", r.err)
+		}
+		return string(r.output), nil
+	case <-time.After(timeout):
+		return "", fmt.Errorf("命令执行超时(%s)", timeout)
+	}
+}
@@ -340,7 +340,50 @@ const DeploymentDetail: React.FC<DeploymentDetailProps> = ({ deploymentId, onClo
 
 
   // 解析诊断报告内容
+  const contextTitles: Record<string, string> = {
+    deploy_log: '发布日志',
+    journal: 'systemd 日志',
+    version_diff: '版本代码差异',
+    machine: '机器信息',
+    related_deployments: '上下游应用近期发布',
+    similar_incidents: '历史相似故障',
+  };
+
   const parseReportContent = (report: Report): ReportData => {
+    // 结构化报告按段落展示
+    if (report.result) {
+      const result = report.result;
+      const sections = [
+        `【问题概述】\n${result.summary}`,
+        `【根因分析】\n${result.root_cause}`,
+        `【影响范围】\n${result.impact}`,
+        `【解决方案】\n${result.remediation.map((step, index) => `${index + 1}. ${step}`).join('\n')}`,
+      ];
+      if (result.suspects.length > 0) {
+        sections.push(`【可疑变更】\n${result.suspects.map((suspect) => {
+          const location = [suspect.pr > 0 ? `PR #${suspect.pr}` : '', suspect.file].filter(Boolean).join(' ');
+          return `- ${location}：${suspect.description}${suspect.suggestion ? `\n  建议：${suspect.suggestion}` : ''}`;
+        }).join('\n')}`);
+      }
+      if (result.evidence.length > 0) {
+        sections.push(`【指标依据】\n${result.evidence.map((evidence) => `- ${evidence.promql}${evidence.observation ? `：${evidence.observation}` : ''}`).join('\n')}`);
+      }
+      sections.push(`【置信度】${Math.round(result.confidence * 100)}%`);
+      if (report.usage) {
+        sections.push(`【模型用量】输入 ${report.usage.prompt_tokens} / 输出 ${report.usage.completion_tokens} tokens，工具调用 ${report.usage.tool_calls} 次，成本 ${report.usage.cost.toFixed(4)}`);
+      }
+      if (report.similar_incidents && report.similar_incidents.length > 0) {
+        sections.push(`【历史相似故障】\n${report.similar_incidents.map((incident, index) => `${index + 1}. ${new Date(incident.created_at * 1000).toLocaleDateString()} ${incident.app_name} ${incident.alertname}${incident.verified ? '（已确认准确）' : ''}：${incident.summary}${incident.remediation.length > 0 ? `\n   处理：${incident.remediation.join('；')}` : ''}`).join('\n')}`);
+      }
+      if (report.contexts && report.contexts.length > 0) {
+        sections.push(`【参考上下文】${report.contexts.map((item) => `${contextTitles[item.type] || item.type}${item.error ? '（收集失败）' : ''}`).join('、')}`);
+      }
+      return {
+        promQL: result.evidence.map((evidence) => evidence.promql),
+        content: sections.join('\n\n'),
+      };
+    }
+
     try {
       // 尝试解析 content 为 JSON
       const parsed = JSON.parse(report.content);
@@ -411,6 +454,16 @@ const DeploymentDetail: React.FC<DeploymentDetailProps> = ({ deploymentId, onClo
   // 当报告加载时，如果有 promQL，自动查询
   useEffect(() => {
     if (report && report.status === 'completed') {
+      // 结构化报告已保存告警时间窗口的指标快照，直接展示
+      const snapshots = report.result?.evidence.filter((evidence) => evidence.snapshot) ?? [];
+      if (snapshots.length > 0) {
+        const results: Record<string, any[]> = {};
+        snapshots.forEach((evidence) => {
+          results[evidence.promql] = evidence.snapshot?.series ?? [];
+        });
+        setReportPromQLResults(results);
+        return;
+      }
       const reportData = parseReportContent(report);
       if (reportData.promQL && reportData.promQL.length > 0) {
         fetchReportPromQLResults(reportData.promQL);
@@ -497,14 +550,18 @@ const DeploymentDetail: React.FC<DeploymentDetailProps> = ({ deploymentId, onClo
     }
 
     const statusColor: Record<Report['status'], string> = {
+      queued: '#faad14',
       generating: '#1890ff',
       completed: '#52c41a',
       failed: '#f5222d',
+      skipped: '#8c8c8c',
     };
     const statusText: Record<Report['status'], string> = {
+      queued: '报告排队中',
       generating: '报告生成中...',
       completed: '报告生成完成',
       failed: '报告生成失败',
+      skipped: '已跳过诊断',
     };
 
     const reportData = parseReportContent(report);
@@ -527,6 +584,14 @@ const DeploymentDetail: React.FC<DeploymentDetailProps> = ({ deploymentId, onClo
         </div>
 
         <div style={{ background: '#fff', border: '1px solid #f0f0f0', borderRadius: 6, padding: 12 }}>
+          {report.status === 'queued' && (
+            <div style={{ color: '#8c8c8c', fontSize: '13px' }}>
+              {report.attempts > 0
+                ? `第 ${report.attempts} 次生成失败，等待重试：${report.last_error}`
+                : '报告排队中，请稍候...'}
+            </div>
+          )}
+
           {report.status === 'generating' && (
             <div style={{ color: '#8c8c8c', display: 'flex', alignItems: 'center', gap: 6, fontSize: '13px' }}>
               <span className="spin" style={{ width: 14, height: 14, border: '2px solid #1890ff', borderTopColor: 'transparent', borderRadius: '50%', display: 'inline-block', animation: 'spin 1s linear infinite' }} />
@@ -540,6 +605,12 @@ const DeploymentDetail: React.FC<DeploymentDetailProps> = ({ deploymentId, onClo
             </div>
           )}
 
+          {report.status === 'skipped' && (
+            <div style={{ color: '#8c8c8c', fontSize: '13px' }}>
+              {report.content}
+            </div>
+          )}
+
           {report.status === 'completed' && (
             <>
               {/* 如果有 promQL，显示查询结果 */}
@@ -557,7 +628,11 @@ const DeploymentDetail: React.FC<DeploymentDetailProps> = ({ deploymentId, onClo
                           <div style={{ fontSize: '13px', fontFamily: 'monospace', color: '#1890ff', wordBreak: 'break-all' }}>{query}</div>
                         </div>
                         <div style={{ padding: 12 }}>
-                          {results && results.length > 0 ? (
+                          {results && results.length === 0 && report.result?.evidence[index]?.snapshot ? (
+                            <div style={{ padding: '20px', textAlign: 'center', color: '#8c8c8c', fontSize: '13px' }}>
+                              {report.result.evidence[index].snapshot?.error || '告警时间窗口内无数据'}
+                            </div>
+                          ) : results && results.length > 0 ? (
                             <MonitorChart 
                               series={results} 
                               height={300} 
@@ -1068,7 +1068,7 @@ const Apps: React.FC = () => {
                               notify_channel: e.target.value
                             }
                           }))}
-                          placeholder="例如: webhook_url 或 email"
+                          placeholder="服务配置 Notify.Channels 中的渠道名称，如 ops-dingtalk"
                         />
                       </div>
 
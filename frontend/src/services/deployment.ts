@@ -7,6 +7,18 @@ import type {
   GetDeploymentListRequest,
   GetDeploymentListResponse,
   GetDeploymentDetailResponse,
+  Report,
+  RegenerateReportRequest,
+  RegenerateReportResponse,
+  SubmitReportFeedbackRequest,
+  ReportFeedbackStat,
+  SimilarIncident,
+  SearchIncidentsRequest,
+  PromptTemplate,
+  CreatePromptTemplateRequest,
+  CreatePromptTemplateResponse,
+  PreviewPromptTemplateRequest,
+  PreviewPromptTemplateResponse,
 } from '../types/deployment';
 
 export const deploymentService = {
@@ -54,4 +66,36 @@ export const deploymentService = {
   async cancelNodeDeployment(id: string, nodeDeploymentIds: string[]): Promise<{ success: boolean }> {
     return api.post(`/deployments/${id}/node-deployments/cancel`, { node_deployment_ids: nodeDeploymentIds });
   },
+
+  async getDeploymentReports(id: string): Promise<{ reports: Report[] }> {
+    return api.get(`/deployments/${id}/reports`);
+  },
+
+  async regenerateReport(id: string, data: RegenerateReportRequest): Promise<RegenerateReportResponse> {
+    return api.post(`/deployments/${id}/reports/regenerate`, data);
+  },
+
+  async submitReportFeedback(reportId: string, data: SubmitReportFeedbackRequest): Promise<{ success: boolean }> {
+    return api.post(`/reports/${reportId}/feedback`, data);
+  },
+
+  async getReportFeedbackStats(): Promise<{ stats: ReportFeedbackStat[] }> {
+    return api.get('/reports/feedback-stats');
+  },
+
+  async searchIncidents(params: SearchIncidentsRequest): Promise<{ incidents: SimilarIncident[] }> {
+    return api.get('/incidents', { params });
+  },
+
+  async createPromptTemplate(data: CreatePromptTemplateRequest): Promise<CreatePromptTemplateResponse> {
+    return api.post('/prompt-templates', data);
+  },
+
+  async getPromptTemplates(name?: string): Promise<{ templates: PromptTemplate[] }> {
+    return api.get('/prompt-templates', { params: { name } });
+  },
+
+  async previewPromptTemplate(data: PreviewPromptTemplateRequest): Promise<PreviewPromptTemplateResponse> {
+    return api.post('/prompt-templates/preview', data);
+  },
 };
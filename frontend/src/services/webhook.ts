@@ -0,0 +1,51 @@
+import api from './api';
+import type {
+  Webhook,
+  CreateWebhookReq,
+  UpdateWebhookReq,
+  WebhookDeliveryListResp,
+  WebhookDeliveryStatus,
+  NotifyEventType,
+} from '../types';
+
+export const webhookService = {
+  async createWebhook(data: CreateWebhookReq): Promise<{ id: string; secret: string }> {
+    return api.post('/webhooks', data);
+  },
+
+  async getWebhooks(): Promise<{ webhooks: Webhook[] }> {
+    return api.get('/webhooks');
+  },
+
+  async getWebhookDetail(id: string): Promise<{ webhook: Webhook }> {
+    return api.get(`/webhooks/${id}`);
+  },
+
+  async updateWebhook(data: UpdateWebhookReq): Promise<{ success: boolean; secret?: string }> {
+    const { id, ...updateData } = data;
+    return api.put(`/webhooks/${id}`, updateData);
+  },
+
+  async deleteWebhook(id: string): Promise<{ success: boolean }> {
+    return api.delete(`/webhooks/${id}`);
+  },
+
+  // 订阅的投递记录，按创建时间倒序
+  async getDeliveries(id: string, params?: {
+    status?: WebhookDeliveryStatus;
+    event_type?: NotifyEventType;
+    page?: number;
+    page_size?: number;
+  }): Promise<WebhookDeliveryListResp> {
+    return api.get(`/webhooks/${id}/deliveries`, { params });
+  },
+
+  // 超过最大尝试次数的投递记录
+  async getDeadLetters(params?: { webhook_id?: string; page?: number; page_size?: number }): Promise<WebhookDeliveryListResp> {
+    return api.get('/webhooks/dead-letters', { params });
+  },
+
+  async redeliver(deliveryId: string): Promise<{ success: boolean }> {
+    return api.post(`/webhooks/deliveries/${deliveryId}/redeliver`);
+  },
+};
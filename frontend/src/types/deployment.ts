@@ -52,12 +52,152 @@ export interface Report {
   id: string;
   deployment_id: string;
   content: string;
-  status: 'generating' | 'completed' | 'failed';
+  status: 'queued' | 'generating' | 'completed' | 'failed' | 'skipped';  // skipped 为诊断预算已用完
+  attempts: number;
+  last_error: string;
+  result?: DiagnosisResult;  // 结构化报告，旧版报告为空
+  contexts?: DiagnosisContext[];  // 诊断前收集的补充上下文
+  model: string;  // 生成报告使用的模型
+  prompt_version: string;  // 生成报告使用的提示词版本
+  feedback?: ReportFeedback;  // 报告准确性评价，未评价时为空
+  usage?: ReportUsage;  // 模型用量和成本，累计所有尝试
+  similar_incidents?: SimilarIncident[];  // 诊断前检索到的历史相似故障
   created_at: number;
   updated_at: number;
   promQL?: string[];  // PromQL 查询列表（可选）
 }
 
+export interface ReportUsage {
+  prompt_tokens: number;
+  completion_tokens: number;
+  tool_calls: number;
+  cost: number;  // 单位与后端配置的价格表一致
+}
+
+export interface ReportFeedback {
+  accurate: boolean;
+  notes: string;
+  operator: string;
+  created_at: number;
+}
+
+export interface RegenerateReportRequest {
+  report_id?: string;  // 作为告警来源的报告ID，默认为最近一次带告警信息的报告
+  model?: string;
+  prompt_version?: string;
+}
+
+export interface RegenerateReportResponse {
+  report_id: string;
+  created: boolean;  // 已有排队中或生成中的报告时为 false
+}
+
+export interface SubmitReportFeedbackRequest {
+  accurate: boolean;
+  notes?: string;
+  operator?: string;
+}
+
+export interface SimilarIncident {
+  report_id: string;
+  deployment_id: string;
+  app_name: string;
+  alertname: string;
+  summary: string;
+  root_cause: string;
+  remediation: string[];
+  verified: boolean;  // 报告是否被评价为准确
+  score: number;  // 相似度得分，仅用于排序
+  created_at: number;
+}
+
+export interface SearchIncidentsRequest {
+  query?: string;
+  app_name?: string;
+  alertname?: string;
+  limit?: number;
+}
+
+export interface ReportFeedbackStat {
+  prompt_version: string;
+  total: number;
+  rated: number;
+  accurate: number;
+  inaccurate: number;
+  accuracy: number;  // 准确数/已评价数
+}
+
+export interface PromptTemplate {
+  id: string;  // 模板版本标识，格式为 <name>@v<version>
+  name: string;
+  version: number;
+  content: string;  // text/template 模板内容
+  description: string;
+  created_by: string;
+  created_at: number;
+}
+
+export interface CreatePromptTemplateRequest {
+  name: string;  // 已存在时保存为新版本
+  content: string;
+  description?: string;
+  created_by?: string;
+}
+
+export interface CreatePromptTemplateResponse {
+  id: string;
+  name: string;
+  version: number;
+}
+
+export interface PreviewPromptTemplateRequest {
+  report_id: string;  // 用于渲染的历史诊断报告
+  prompt_version?: string;  // 为空时使用应用选择的模板
+  content?: string;  // 未保存的模板内容，传入时忽略 prompt_version
+}
+
+export interface PreviewPromptTemplateResponse {
+  prompt: string;
+  prompt_version: string;
+}
+
+export interface DiagnosisContext {
+  type: 'deploy_log' | 'journal' | 'version_diff' | 'machine' | 'related_deployments';
+  content: string;
+  error: string;
+}
+
+export interface SuspectChange {
+  pr: number;
+  file: string;
+  description: string;
+  suggestion: string;
+}
+
+export interface EvidenceSnapshot {
+  start: number;
+  end: number;
+  step: number;
+  series: any[];  // 与监控查询接口相同的时序格式
+  error: string;
+}
+
+export interface DiagnosisEvidence {
+  promql: string;
+  observation: string;
+  snapshot?: EvidenceSnapshot;  // 告警时间窗口内的指标快照
+}
+
+export interface DiagnosisResult {
+  summary: string;
+  root_cause: string;
+  impact: string;
+  remediation: string[];
+  suspects: SuspectChange[];
+  evidence: DiagnosisEvidence[];
+  confidence: number;
+}
+
 export interface ReportData {
   promQL?: string[];
   content: string;
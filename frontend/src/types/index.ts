@@ -53,7 +53,7 @@ export interface RollbackPolicy {
   enabled: boolean
   alert_rules: PrometheusAlert[]
   auto_rollback: boolean
-  notify_channel: string
+  notify_channel: string  // 默认通知渠道名称，接收应用的所有通知事件
 }
 
 // 应用信息
@@ -73,10 +73,103 @@ export interface Application {
   machines: Machine[]
   rollback_policy?: RollbackPolicy
   red_metrics_config?: REDMetrics
+  prompt_template?: PromptTemplateRef  // 诊断使用的提示词模板，为空时使用内置提示词
+  notification?: NotificationPolicy  // 发布事件通知路由
   created_at: number
   updated_at: number
 }
 
+// 应用选择的提示词模板
+export interface PromptTemplateRef {
+  name: string
+  version?: number  // 0 或不传表示始终使用最新版本
+}
+
+// 通知事件类型
+export type NotifyEventType =
+  | 'deployment_created'
+  | 'deployment_started'
+  | 'batch_completed'
+  | 'deployment_succeeded'
+  | 'deployment_failed'
+  | 'rollback_triggered'
+  | 'canary_failed'
+  | 'deployment_rolled_back'
+  | 'report_completed'
+
+// 发布事件通知路由
+export interface NotificationPolicy {
+  routes: NotifyRoute[]
+}
+
+export interface NotifyRoute {
+  channel: string  // 服务配置中的渠道名称
+  events?: NotifyEventType[]  // 为空表示所有事件
+}
+
+// 外部系统的 webhook 订阅，签名密钥只在创建和更新密钥时返回
+export interface Webhook {
+  id: string
+  name: string
+  url: string
+  events: NotifyEventType[]  // 为空表示所有事件
+  app_ids: string[]  // 为空表示所有应用
+  enabled: boolean
+  created_by: string
+  created_at: number
+  updated_at: number
+}
+
+export type WebhookDeliveryStatus = 'pending' | 'delivering' | 'succeeded' | 'dead'
+
+// webhook 投递记录
+export interface WebhookDelivery {
+  id: string
+  webhook_id: string
+  event_id: string  // 同一事件投递到多个订阅时相同
+  event_type: NotifyEventType
+  app_id: string
+  deployment_id: string
+  payload: string
+  status: WebhookDeliveryStatus
+  attempts: number
+  next_run_at: number
+  response_status: number
+  response_body: string
+  last_error: string
+  duration: number  // 毫秒
+  delivered_at: number
+  created_at: number
+}
+
+export interface CreateWebhookReq {
+  name: string
+  url: string
+  secret?: string  // 为空时自动生成
+  events?: NotifyEventType[]
+  app_ids?: string[]
+  enabled?: boolean
+  created_by?: string
+}
+
+export interface UpdateWebhookReq {
+  id: string
+  name: string
+  url: string
+  secret?: string
+  rotate_secret?: boolean
+  events?: NotifyEventType[]
+  app_ids?: string[]
+  enabled: boolean
+}
+
+export interface WebhookDeliveryListResp {
+  deliveries: WebhookDelivery[]
+  total: number
+  page: number
+  page_size: number
+}
+
 // 发布机器信息
 export interface DeploymentMachine {
   id: string
@@ -129,6 +222,8 @@ export interface UpdateAppReq {
   machine_ids?: string[]
   rollback_policy?: RollbackPolicy
   red_metrics_config?: REDMetrics
+  prompt_template?: PromptTemplateRef  // name 为空表示恢复内置提示词
+  notification?: NotificationPolicy  // 传空路由表示取消
 }
 
 export interface UpdateAppResp {